@@ -47,6 +47,17 @@ func ConnectionDB() {
 
 // SetupDatabase สร้าง table และข้อมูลเริ่มต้น
 func SetupDatabase() {
+	// PackageMember เก็บประวัติการสมัครหลายรอบต่อผู้ใช้ จึงต้องลบ unique index เดิมของ user_id
+	// ก่อน AutoMigrate สร้าง index ชื่อเดิมแบบไม่ unique
+	if db.Migrator().HasTable(&entity.PackageMember{}) {
+		var unique int
+		db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ? AND sql LIKE 'CREATE UNIQUE%'",
+			"idx_package_members_user_id").Scan(&unique)
+		if unique > 0 {
+			db.Migrator().DropIndex(&entity.PackageMember{}, "idx_package_members_user_id")
+		}
+	}
+
 	db.AutoMigrate(
 		&entity.Genders{},
 		&entity.ClassActivity{},
@@ -56,24 +67,71 @@ func SetupDatabase() {
 		&entity.Trainer{},
 		&entity.Admin{},
 		&entity.Health{},
+		&entity.BmiCutoff{},
+		&entity.ActivityType{},
 		&entity.Activity{},
+		&entity.ActivityTrack{},
+		&entity.WorkoutLog{},
+		&entity.WorkoutExercise{},
+		&entity.WorkoutSet{},
+		&entity.PersonalRecord{},
+		&entity.Goal{},
+		&entity.GoalMilestone{},
 		&entity.Nutrition{},
 		&entity.Meal{},
+		&entity.Food{},
+		&entity.MealEntry{},
+		&entity.BarcodeLookup{},
+		&entity.FoodCorrection{},
+		&entity.Recipe{},
+		&entity.RecipeIngredient{},
+		&entity.MealPlan{},
+		&entity.MealPlanItem{},
+		&entity.WellnessCheckIn{},
+		&entity.BodyMeasurement{},
+		&entity.ProgressPhoto{},
+		&entity.PackageEntitlement{},
+		&entity.EntitlementUsage{},
+		&entity.FacilityEntry{},
+		&entity.PaymentOrder{},
+		&entity.PaymentEvent{},
+		&entity.Branch{},
+		&entity.BillingProfile{},
+		&entity.InvoiceSequence{},
+		&entity.Invoice{},
 		&entity.TrainerSchedule{},
+		&entity.TrainerAvailability{},
+		&entity.TrainerLeave{},
 		&entity.TrainBooking{},
+		&entity.TrainBookingHistory{},
+		&entity.BookingPolicy{},
 		&entity.ClassBooking{},
 
 
 		&entity.PersonalTrain{},
+		&entity.Exercise{},
+		&entity.TrainingProgram{},
+		&entity.ProgramPhase{},
+		&entity.ProgramSession{},
+		&entity.ExercisePrescription{},
 
 		&entity.Review{},
 		&entity.WorkoutGroup{},
 		&entity.Package{},
 		&entity.Services{},
 		&entity.PackageMember{},
+		&entity.Notification{},
+		&entity.CalendarToken{},
 
 	)
 
+	// บาร์โค้ดในแคตตาล็อกของระบบต้องไม่ซ้ำ (อาหารที่ผู้ใช้สร้างเองซ้ำได้)
+	// ข้อมูลเดิมที่ซ้ำกันให้แถวแรกเก็บบาร์โค้ดไว้ แถวอื่นล้างบาร์โค้ดออกก่อนสร้าง index
+	db.Exec(`UPDATE foods SET barcode = '' WHERE barcode <> '' AND is_custom = ? AND deleted_at IS NULL AND id NOT IN (
+		SELECT MIN(id) FROM foods WHERE barcode <> '' AND is_custom = ? AND deleted_at IS NULL GROUP BY barcode)`, false, false)
+	db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_foods_catalog_barcode ON foods(barcode)
+		WHERE barcode <> '' AND is_custom = 0 AND deleted_at IS NULL`)
+
 	// Seed genders (idempotent)
 	var male entity.Genders
 	db.Where(entity.Genders{Gender: "ชาย"}).FirstOrInit(&male)
@@ -98,6 +156,61 @@ func SetupDatabase() {
 	BirthDay, _ := time.Parse("2006-01-02", "1988-11-12")
 	formattedBirthDay := BirthDay.Format("2006-01-02")
 
+	// Seed BmiCutoff (WHO Asian cut-offs) if empty
+	var bmiCutoff entity.BmiCutoff
+	if err := db.First(&bmiCutoff).Error; err != nil && err == gorm.ErrRecordNotFound {
+		db.Create(&entity.BmiCutoff{UnderweightBelow: 18.5, NormalBelow: 23, OverweightBelow: 25, Obese1Below: 30})
+	}
+
+	// Seed BookingPolicy (default) if empty
+	var policy entity.BookingPolicy
+	if err := db.First(&policy).Error; err != nil && err == gorm.ErrRecordNotFound {
+		db.Create(&entity.BookingPolicy{FreeCancelHours: 24, RescheduleNoticeHours: 12, MaxReschedules: 2, NoShowGraceMinutes: 15})
+	}
+
+	// Seed ActivityType from the Compendium of Physical Activities (2011) if empty
+	var existingActivityType entity.ActivityType
+	if err := db.First(&existingActivityType).Error; err != nil && err == gorm.ErrRecordNotFound {
+		activityTypes := []entity.ActivityType{
+			{Name: "วิ่ง", NameEN: "Running", Code: "12050", Category: "วิ่ง", Intensity: "vigorous", MET: 9.8, PaceModel: "running"},
+			{Name: "วิ่งเหยาะ", NameEN: "Jogging", Code: "12020", Category: "วิ่ง", Intensity: "vigorous", MET: 7.0, PaceModel: "running"},
+			{Name: "วิ่งบนลู่", NameEN: "Treadmill running", Code: "12050", Category: "วิ่ง", Intensity: "vigorous", MET: 9.8, PaceModel: "running"},
+			{Name: "เดิน", NameEN: "Walking", Code: "17200", Category: "เดิน", Intensity: "moderate", MET: 3.5, PaceModel: "walking"},
+			{Name: "เดินเร็ว", NameEN: "Brisk walking", Code: "17220", Category: "เดิน", Intensity: "moderate", MET: 4.3, PaceModel: "walking"},
+			{Name: "เดินขึ้นเขา", NameEN: "Hiking", Code: "17080", Category: "เดิน", Intensity: "vigorous", MET: 6.0},
+			{Name: "ปั่นจักรยาน", NameEN: "Cycling", Code: "01015", Category: "ปั่นจักรยาน", Intensity: "vigorous", MET: 7.5, PaceModel: "cycling"},
+			{Name: "ปั่นจักรยานอยู่กับที่", NameEN: "Stationary cycling", Code: "02014", Category: "ปั่นจักรยาน", Intensity: "moderate", MET: 6.8},
+			{Name: "ว่ายน้ำ", NameEN: "Swimming", Code: "18310", Category: "กีฬาทางน้ำ", Intensity: "vigorous", MET: 6.0},
+			{Name: "เวทเทรนนิ่ง", NameEN: "Weight training", Code: "02054", Category: "ออกกำลังกาย", Intensity: "moderate", MET: 5.0},
+			{Name: "HIIT", NameEN: "Circuit training", Code: "02040", Category: "ออกกำลังกาย", Intensity: "vigorous", MET: 8.0},
+			{Name: "โยคะ", NameEN: "Yoga", Code: "02150", Category: "ออกกำลังกาย", Intensity: "light", MET: 2.5},
+			{Name: "พิลาทิส", NameEN: "Pilates", Code: "02105", Category: "ออกกำลังกาย", Intensity: "moderate", MET: 3.0},
+			{Name: "แอโรบิก", NameEN: "Aerobic dance", Code: "03015", Category: "เต้น", Intensity: "moderate", MET: 7.3},
+			{Name: "เครื่องเดินวงรี", NameEN: "Elliptical trainer", Code: "02048", Category: "ออกกำลังกาย", Intensity: "moderate", MET: 5.0},
+			{Name: "กรรเชียงบก", NameEN: "Rowing machine", Code: "02071", Category: "ออกกำลังกาย", Intensity: "vigorous", MET: 7.0},
+			{Name: "กระโดดเชือก", NameEN: "Rope jumping", Code: "15551", Category: "ออกกำลังกาย", Intensity: "vigorous", MET: 11.8},
+			{Name: "มวยไทย", NameEN: "Muay Thai / kickboxing", Code: "15110", Category: "กีฬา", Intensity: "vigorous", MET: 7.8},
+			{Name: "แบดมินตัน", NameEN: "Badminton", Code: "15030", Category: "กีฬา", Intensity: "moderate", MET: 5.5},
+			{Name: "ฟุตบอล", NameEN: "Soccer", Code: "15610", Category: "กีฬา", Intensity: "vigorous", MET: 7.0},
+			{Name: "บาสเกตบอล", NameEN: "Basketball", Code: "15055", Category: "กีฬา", Intensity: "vigorous", MET: 6.5},
+			{Name: "เทนนิส", NameEN: "Tennis", Code: "15675", Category: "กีฬา", Intensity: "vigorous", MET: 7.3},
+			{Name: "ยืดเหยียด", NameEN: "Stretching", Code: "02101", Category: "ออกกำลังกาย", Intensity: "light", MET: 2.3},
+		}
+		active := true
+		for _, t := range activityTypes {
+			t.IsActive = &active
+			db.Create(&t)
+		}
+	}
+
+	// Seed Food catalogue (Thai dishes, per 100 g) if empty
+	var existingFood entity.Food
+	if err := db.First(&existingFood).Error; err != nil && err == gorm.ErrRecordNotFound {
+		for _, f := range SeedFoods() {
+			db.Create(&f)
+		}
+	}
+
 	// Seed ClassActivity if empty
 	var existingClass entity.ClassActivity
 	if err := db.First(&existingClass).Error; err != nil && err == gorm.ErrRecordNotFound {
@@ -133,6 +246,20 @@ func SetupDatabase() {
 			db.Create(&f)
 		}
 	}
+
+	// Seed Branch (ผู้ขายในใบกำกับภาษี) if empty
+	var existingBranch entity.Branch
+	if err := db.First(&existingBranch).Error; err != nil && err == gorm.ErrRecordNotFound {
+		db.Create(&entity.Branch{
+			BranchNo:  "00000",
+			Name:      "บริษัท ฟิตเนส จำกัด",
+			NameEN:    "Fitness Co., Ltd.",
+			Address:   "111 ถนนมหาวิทยาลัย ตำบลสุรนารี อำเภอเมืองนครราชสีมา จังหวัดนครราชสีมา 30000",
+			AddressEN: "111 University Avenue, Suranari, Mueang Nakhon Ratchasima, Nakhon Ratchasima 30000",
+			TaxID:     "0105561234560",
+			IsDefault: true,
+		})
+	}
 	// --- สร้างข้อมูลเริ่มต้นใหม่ ---
 
 	// Admin
@@ -280,3 +407,38 @@ func SetupDatabase() {
 	}
 
 }
+
+// SeedFoods แคตตาล็อกอาหารไทยเริ่มต้น (ต่อ 100 กรัม) ใช้ทั้งตอนสร้างฐานข้อมูลใหม่และเติมแท็กให้ข้อมูลเดิม
+func SeedFoods() []entity.Food {
+	plate := func(g float64) []entity.FoodServing { return []entity.FoodServing{{Name: "จาน", Grams: g}} }
+	bowl := func(g float64) []entity.FoodServing { return []entity.FoodServing{{Name: "ถ้วย", Grams: g}} }
+	return []entity.Food{
+		{Name: "ข้าวสวย", NameEN: "Steamed jasmine rice", Category: "ข้าว/แป้ง", EnergyKcal: 130, ProteinG: 2.7, FatG: 0.3, CarbG: 28.2, FiberG: 0.4, Servings: []entity.FoodServing{{Name: "ทัพพี", Grams: 60}, {Name: "จาน", Grams: 160}}, DietaryTags: []string{"vegetarian", "halal"}},
+		{Name: "ข้าวกล้อง", NameEN: "Brown rice", Category: "ข้าว/แป้ง", EnergyKcal: 123, ProteinG: 2.7, FatG: 1.0, CarbG: 25.6, FiberG: 1.6, Servings: []entity.FoodServing{{Name: "ทัพพี", Grams: 60}, {Name: "จาน", Grams: 160}}, DietaryTags: []string{"vegetarian", "halal"}},
+		{Name: "ข้าวเหนียว", NameEN: "Sticky rice", Category: "ข้าว/แป้ง", EnergyKcal: 169, ProteinG: 3.5, FatG: 0.3, CarbG: 36.9, FiberG: 0.9, Servings: []entity.FoodServing{{Name: "กระติ๊บเล็ก", Grams: 80}, {Name: "ห่อ", Grams: 120}}, DietaryTags: []string{"vegetarian", "halal"}},
+		{Name: "ข้าวโอ๊ต", NameEN: "Rolled oats (dry)", Category: "ข้าว/แป้ง", EnergyKcal: 379, ProteinG: 13.2, FatG: 6.5, CarbG: 67.7, FiberG: 10.1, SugarG: 1.0, Servings: []entity.FoodServing{{Name: "ถ้วยตวงครึ่ง", Grams: 40}}, DietaryTags: []string{"vegetarian", "halal"}, Allergens: []string{"gluten"}},
+		{Name: "ขนมปังโฮลวีต", NameEN: "Whole wheat bread", Category: "ข้าว/แป้ง", EnergyKcal: 252, ProteinG: 12.4, FatG: 3.5, CarbG: 42.7, FiberG: 6.0, SugarG: 4.4, SodiumMg: 450, Servings: []entity.FoodServing{{Name: "แผ่น", Grams: 30}}, DietaryTags: []string{"vegetarian", "halal"}, Allergens: []string{"gluten"}},
+		{Name: "ผัดกะเพราไก่ราดข้าว", NameEN: "Stir-fried chicken with holy basil on rice", Category: "อาหารจานเดียว", EnergyKcal: 163, ProteinG: 8.1, FatG: 6.4, CarbG: 18.3, SodiumMg: 380, Servings: plate(350), DietaryTags: []string{"halal"}, Allergens: []string{"soy"}},
+		{Name: "ข้าวผัดหมู", NameEN: "Pork fried rice", Category: "อาหารจานเดียว", EnergyKcal: 186, ProteinG: 6.6, FatG: 6.9, CarbG: 24.5, SodiumMg: 350, Servings: plate(300), Allergens: []string{"egg", "soy"}},
+		{Name: "ผัดไทย", NameEN: "Pad Thai", Category: "อาหารจานเดียว", EnergyKcal: 188, ProteinG: 6.9, FatG: 7.3, CarbG: 24.0, SugarG: 5.8, SodiumMg: 390, Servings: plate(290), Allergens: []string{"egg", "peanut", "shellfish", "soy"}},
+		{Name: "ข้าวมันไก่", NameEN: "Hainanese chicken rice", Category: "อาหารจานเดียว", EnergyKcal: 197, ProteinG: 8.3, FatG: 7.1, CarbG: 24.9, SodiumMg: 320, Servings: plate(300), DietaryTags: []string{"halal"}, Allergens: []string{"soy"}},
+		{Name: "ก๋วยเตี๋ยวเส้นเล็กน้ำใส", NameEN: "Rice noodle soup", Category: "อาหารจานเดียว", EnergyKcal: 65, ProteinG: 3.6, FatG: 1.6, CarbG: 9.0, SodiumMg: 330, Servings: bowl(450), Allergens: []string{"soy"}},
+		{Name: "โจ๊กหมู", NameEN: "Rice porridge with pork", Category: "อาหารจานเดียว", EnergyKcal: 62, ProteinG: 3.0, FatG: 1.7, CarbG: 8.6, SodiumMg: 210, Servings: bowl(350)},
+		{Name: "ต้มยำกุ้ง", NameEN: "Tom yum goong", Category: "กับข้าว", EnergyKcal: 38, ProteinG: 4.6, FatG: 1.2, CarbG: 2.3, SodiumMg: 420, Servings: bowl(250), DietaryTags: []string{"halal"}, Allergens: []string{"shellfish"}},
+		{Name: "ต้มข่าไก่", NameEN: "Chicken in coconut soup", Category: "กับข้าว", EnergyKcal: 112, ProteinG: 5.6, FatG: 9.1, CarbG: 2.6, SodiumMg: 310, Servings: bowl(250), DietaryTags: []string{"halal"}},
+		{Name: "แกงเขียวหวานไก่", NameEN: "Green curry with chicken", Category: "กับข้าว", EnergyKcal: 117, ProteinG: 6.5, FatG: 8.8, CarbG: 3.2, SodiumMg: 360, Servings: bowl(250), DietaryTags: []string{"halal"}, Allergens: []string{"shellfish"}},
+		{Name: "ส้มตำไทย", NameEN: "Green papaya salad", Category: "กับข้าว", EnergyKcal: 62, ProteinG: 1.9, FatG: 1.6, CarbG: 10.8, FiberG: 2.0, SugarG: 7.0, SodiumMg: 500, Servings: plate(200), Allergens: []string{"peanut", "shellfish", "fish"}},
+		{Name: "ลาบหมู", NameEN: "Spicy minced pork salad", Category: "กับข้าว", EnergyKcal: 139, ProteinG: 15.1, FatG: 7.4, CarbG: 2.8, SodiumMg: 420, Servings: plate(150)},
+		{Name: "ไก่ย่าง", NameEN: "Grilled chicken", Category: "กับข้าว", EnergyKcal: 190, ProteinG: 25.0, FatG: 9.7, CarbG: 1.5, SodiumMg: 380, Servings: []entity.FoodServing{{Name: "น่อง", Grams: 100}, {Name: "ครึ่งตัว", Grams: 350}}, DietaryTags: []string{"halal"}},
+		{Name: "อกไก่ต้ม", NameEN: "Boiled chicken breast", Category: "เนื้อสัตว์", EnergyKcal: 151, ProteinG: 30.5, FatG: 3.2, CarbG: 0, SodiumMg: 65, Servings: []entity.FoodServing{{Name: "ชิ้น", Grams: 120}}, DietaryTags: []string{"halal"}},
+		{Name: "ไข่ต้ม", NameEN: "Boiled egg", Category: "เนื้อสัตว์", EnergyKcal: 155, ProteinG: 12.6, FatG: 10.6, CarbG: 1.1, SodiumMg: 124, Servings: []entity.FoodServing{{Name: "ฟอง", Grams: 50}}, DietaryTags: []string{"vegetarian", "halal"}, Allergens: []string{"egg"}},
+		{Name: "ไข่เจียว", NameEN: "Thai omelette", Category: "เนื้อสัตว์", EnergyKcal: 283, ProteinG: 11.1, FatG: 25.9, CarbG: 0.9, SodiumMg: 330, Servings: []entity.FoodServing{{Name: "ฟอง", Grams: 70}}, DietaryTags: []string{"vegetarian", "halal"}, Allergens: []string{"egg"}},
+		{Name: "เต้าหู้แข็ง", NameEN: "Firm tofu", Category: "เนื้อสัตว์", EnergyKcal: 144, ProteinG: 15.8, FatG: 8.7, CarbG: 2.8, FiberG: 2.3, Servings: []entity.FoodServing{{Name: "ก้อน", Grams: 150}}, DietaryTags: []string{"vegetarian", "halal"}, Allergens: []string{"soy"}},
+		{Name: "ปาท่องโก๋", NameEN: "Chinese fried dough", Category: "ขนม", EnergyKcal: 412, ProteinG: 7.0, FatG: 24.0, CarbG: 42.0, SodiumMg: 520, Servings: []entity.FoodServing{{Name: "คู่", Grams: 30}}, DietaryTags: []string{"vegetarian", "halal"}, Allergens: []string{"gluten"}},
+		{Name: "ข้าวเหนียวมะม่วง", NameEN: "Mango sticky rice", Category: "ขนม", EnergyKcal: 213, ProteinG: 2.7, FatG: 6.2, CarbG: 37.1, SugarG: 18.0, Servings: plate(250), DietaryTags: []string{"vegetarian", "halal"}},
+		{Name: "มะม่วงสุก", NameEN: "Ripe mango", Category: "ผลไม้", EnergyKcal: 60, ProteinG: 0.8, FatG: 0.4, CarbG: 15.0, FiberG: 1.6, SugarG: 13.7, Servings: []entity.FoodServing{{Name: "ลูก", Grams: 200}}, DietaryTags: []string{"vegetarian", "halal"}},
+		{Name: "กล้วยหอม", NameEN: "Banana", Category: "ผลไม้", EnergyKcal: 89, ProteinG: 1.1, FatG: 0.3, CarbG: 22.8, FiberG: 2.6, SugarG: 12.2, Servings: []entity.FoodServing{{Name: "ผล", Grams: 120}}, DietaryTags: []string{"vegetarian", "halal"}},
+		{Name: "นมจืด", NameEN: "Whole milk", Category: "เครื่องดื่ม", EnergyKcal: 61, ProteinG: 3.2, FatG: 3.3, CarbG: 4.8, SugarG: 4.8, SodiumMg: 43, Servings: []entity.FoodServing{{Name: "กล่อง", Grams: 200}, {Name: "แก้ว", Grams: 250}}, DietaryTags: []string{"vegetarian", "halal"}, Allergens: []string{"dairy"}},
+		{Name: "ชาไทยเย็น", NameEN: "Thai iced tea", Category: "เครื่องดื่ม", EnergyKcal: 70, ProteinG: 0.9, FatG: 2.2, CarbG: 11.8, SugarG: 11.5, Servings: []entity.FoodServing{{Name: "แก้ว", Grams: 350}}, DietaryTags: []string{"vegetarian", "halal"}, Allergens: []string{"dairy"}},
+	}
+}
@@ -0,0 +1,14 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Env อ่านค่าจากตัวแปรสภาพแวดล้อม ถ้าไม่ได้ตั้งไว้ใช้ fallback
+func Env(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
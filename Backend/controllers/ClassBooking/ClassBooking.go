@@ -1,6 +1,7 @@
 package ClassBooking
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -17,7 +18,23 @@ func Create(c *gin.Context) {
 		return
 	}
 
-	booking, err := services.CreateClassBooking(req)
+	// ลูกค้าจองให้ตนเองเท่านั้น (ผู้ใช้จาก token) ผู้ดูแลระบบจองแทนลูกค้าได้ สถานะกำหนดโดยระบบ
+	actor, actorID := currentActor(c)
+	switch actor {
+	case "customer":
+		req.UserID = actorID
+	case "admin":
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะสมาชิกหรือผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+
+	booking, err := services.CreateClassBooking(entity.ClassBooking{UserID: req.UserID, ClassActivityID: req.ClassActivityID})
+	var entitlementErr *services.EntitlementError
+	if errors.As(err, &entitlementErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "upgrade_required", "entitlement": entitlementErr})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -83,4 +100,10 @@ func GetUserBookings(c *gin.Context) {
 	c.JSON(http.StatusOK, bookings)
 }
 
-
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userID, _ := c.Get("user_id")
+	actorStr, _ := actor.(string)
+	id, _ := userID.(uint)
+	return actorStr, id
+}
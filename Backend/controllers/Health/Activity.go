@@ -6,6 +6,7 @@ import (
 
 	"example.com/fitness-backend/config"
 	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -27,35 +28,26 @@ func CreateActivity(c *gin.Context) {
 
 	db := config.DB()
 
-	// ✅ หา Health ล่าสุดของ user
-	var latestHealth entity.Health
-	if err := db.Where("user_id = ?", userID).Order("date desc").First(&latestHealth).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No health record found for user"})
+	// ✅ คำนวณ Calories จาก MET ในแคตตาล็อกและน้ำหนักจากบันทึกสุขภาพที่ใกล้วันทำกิจกรรมที่สุด
+	activity.UserID = userID
+	if activity.Date.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Activity date cannot be in the future"})
 		return
 	}
-
-	// ✅ คำนวณ Calories จาก MET
-	MET := 5.0
-	switch activity.Type {
-	case "วิ่ง":
-		MET = 9.8
-	case "เดิน":
-		MET = 3.5
-	case "ปั่นจักรยาน":
-		MET = 7.5
+	if err := services.EstimateActivityCalories(&activity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.ApplyHeartRateMetrics(&activity, nil); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	activity.UserID = userID
-	activity.HealthID = latestHealth.ID
-	// แปลงนาทีเป็นชั่วโมงสำหรับการคำนวณแคลอรี่
-	durationInHours := activity.Duration / 60.0
-	activity.Calories = MET * latestHealth.Weight * durationInHours
-	activity.Date = time.Now()
 
 	if err := db.Create(&activity).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create activity"})
 		return
 	}
+	services.RefreshGoalsAfterChange(userID)
 
 	c.JSON(http.StatusOK, activity)
 }
@@ -112,6 +104,7 @@ func DeleteActivity(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete activity"})
 		return
 	}
+	services.RefreshGoalsAfterChange(userID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Activity deleted successfully"})
 }
@@ -144,9 +137,13 @@ func UpdateActivity(c *gin.Context) {
 
 	// รับข้อมูลที่ต้องการอัปเดต
 	var updateData struct {
-		Type     string  `json:"type"`
-		Distance float64 `json:"distance"`
-		Duration float64 `json:"duration"`
+		Type           string    `json:"type"`
+		ActivityTypeID *uint     `json:"activity_type_id"`
+		Distance       float64   `json:"distance"`
+		Duration       float64   `json:"duration"`
+		Date           time.Time `json:"date"`
+		AvgHeartRate   *int      `json:"avg_heart_rate"` // ไม่ส่งมา = คงค่าเดิม
+		MaxHeartRate   *int      `json:"max_heart_rate"`
 	}
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -156,36 +153,48 @@ func UpdateActivity(c *gin.Context) {
 
 	// อัปเดตข้อมูล
 	existingActivity.Type = updateData.Type
+	existingActivity.ActivityTypeID = updateData.ActivityTypeID
 	existingActivity.Distance = updateData.Distance
 	existingActivity.Duration = updateData.Duration
+	if !updateData.Date.IsZero() {
+		if updateData.Date.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Activity date cannot be in the future"})
+			return
+		}
+		existingActivity.Date = updateData.Date
+	}
 
 	// คำนวณแคลอรี่ใหม่
-	MET := 5.0
-	switch updateData.Type {
-	case "วิ่ง":
-		MET = 9.8
-	case "เดิน":
-		MET = 3.5
-	case "ปั่นจักรยาน":
-		MET = 7.5
+	if err := services.EstimateActivityCalories(&existingActivity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	// หา Health ล่าสุดของ user เพื่อคำนวณแคลอรี่
-	var latestHealth entity.Health
-	if err := db.Where("user_id = ?", userID).Order("date desc").First(&latestHealth).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No health record found for user"})
+	// กิจกรรมที่นำเข้าจากไฟล์ใช้ชีพจรจากเส้นทางเดิม ส่วนที่กรอกเองใช้ค่าที่ส่งมา
+	var points []entity.TrackPoint
+	if existingActivity.Source != "" && existingActivity.Source != "manual" {
+		if track, err := services.GetActivityTrack(existingActivity.ID); err == nil {
+			points = track.Points
+		}
+	} else {
+		if updateData.AvgHeartRate != nil {
+			existingActivity.AvgHeartRate = *updateData.AvgHeartRate
+		}
+		if updateData.MaxHeartRate != nil {
+			existingActivity.MaxHeartRate = *updateData.MaxHeartRate
+		}
+	}
+	if err := services.ApplyHeartRateMetrics(&existingActivity, points); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// แปลงนาทีเป็นชั่วโมงสำหรับการคำนวณแคลอรี่
-	durationInHours := updateData.Duration / 60.0
-	existingActivity.Calories = MET * latestHealth.Weight * durationInHours
-
 	// บันทึกการเปลี่ยนแปลง
 	if err := db.Save(&existingActivity).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update activity"})
 		return
 	}
+	services.RefreshGoalsAfterChange(userID)
 
 	c.JSON(http.StatusOK, existingActivity)
 }
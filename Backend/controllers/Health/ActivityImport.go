@@ -0,0 +1,95 @@
+package Health
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxTrackFileSize ขนาดไฟล์นำเข้าสูงสุด (20MB)
+const maxTrackFileSize = 20 << 20
+
+// POST /api/activity/import (multipart: file, type)
+// นำเข้ากิจกรรมจากไฟล์ GPX, TCX หรือ FIT ที่ส่งออกจากนาฬิกาหรือแอปออกกำลังกาย
+func ImportActivity(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	userID := userIDInterface.(uint)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาแนบไฟล์ GPX, TCX หรือ FIT"})
+		return
+	}
+	if fileHeader.Size > maxTrackFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไฟล์มีขนาดใหญ่เกิน 20MB"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถอ่านไฟล์ได้"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxTrackFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถอ่านไฟล์ได้"})
+		return
+	}
+
+	activity, err := services.ImportActivityFile(userID, filepath.Base(fileHeader.Filename), data, c.PostForm("type"))
+	if err != nil {
+		if errors.Is(err, services.ErrDuplicateActivity) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "activity": activity})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, activity)
+}
+
+// GET /api/activity/:id/track
+// เส้นทาง จุดข้อมูล และเวลาต่อกิโลเมตรสำหรับแสดงแผนที่/กราฟ
+func GetActivityTrack(c *gin.Context) {
+	userIDInterface, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+	userID := userIDInterface.(uint)
+
+	activityID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Activity ID is required"})
+		return
+	}
+
+	var activity entity.Activity
+	if err := config.DB().Where("id = ? AND user_id = ?", activityID, userID).First(&activity).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Activity not found or not authorized"})
+		return
+	}
+
+	track, err := services.GetActivityTrack(activity.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "กิจกรรมนี้ไม่มีข้อมูลเส้นทาง"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, track)
+}
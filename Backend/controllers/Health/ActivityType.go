@@ -0,0 +1,99 @@
+package Health
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /api/activity-types?all=true
+// ผู้ใช้ทั่วไปเห็นเฉพาะประเภทที่เปิดใช้ ผู้ดูแลระบบส่ง all=true เพื่อดูทั้งหมด
+func GetActivityTypes(c *gin.Context) {
+	actor, _ := c.Get("actor")
+	activeOnly := !(actor == "admin" && c.Query("all") == "true")
+	types, err := services.GetActivityTypes(activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, types)
+}
+
+// POST /api/activity-types
+func CreateActivityType(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	var payload entity.ActivityType
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	created, err := services.CreateActivityType(payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// PUT /api/activity-types/:id
+func UpdateActivityType(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var payload entity.ActivityType
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	updated, err := services.UpdateActivityType(uint(id), payload)
+	if err != nil {
+		respondActivityTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DELETE /api/activity-types/:id
+func DeleteActivityType(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if err := services.DeleteActivityType(uint(id)); err != nil {
+		respondActivityTypeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Activity type deleted successfully"})
+}
+
+func requireAdmin(c *gin.Context) bool {
+	if actor, _ := c.Get("actor"); actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้น"})
+		return false
+	}
+	return true
+}
+
+func respondActivityTypeError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Activity type not found"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,46 @@
+package Health
+
+import (
+	"net/http"
+
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/nutrition/energy-balance?user_id=&date=
+// พลังงานที่ได้รับเทียบกับที่ใช้ของวัน สะสม 7 วัน และข้อเสนอปรับแผนตามแนวโน้มน้ำหนัก
+func GetEnergyBalance(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	date, ok := queryDay(c)
+	if !ok {
+		return
+	}
+	report, err := services.GetEnergyBalance(userID, date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// POST /api/nutrition/energy-balance/adjust?user_id=
+// ปรับพลังงานเป้าหมายตามข้อเสนอใน trend ของ energy-balance (บันทึกเป็นแผนของวันนี้)
+func ApplyEnergyBalanceAdjustment(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	plan, adjustment, err := services.ApplyEnergyBalanceAdjustment(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "adjustment": adjustment})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "ปรับแผนโภชนาการสำเร็จ",
+		"data":       plan,
+		"adjustment": adjustment,
+	})
+}
@@ -0,0 +1,243 @@
+package Health
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /api/foods?q=&category=&custom=true
+// แสดงอาหารในแคตตาล็อกรวมกับอาหารที่ผู้ใช้สร้างเอง
+func GetFoods(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+	foods, err := services.SearchFoods(services.FoodFilter{
+		Query:      c.Query("q"),
+		Category:   c.Query("category"),
+		UserID:     uid,
+		CustomOnly: c.Query("custom") == "true",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรายการอาหารได้"})
+		return
+	}
+	c.JSON(http.StatusOK, foods)
+}
+
+// GET /api/foods/:id
+func GetFood(c *gin.Context) {
+	food, ok := loadFood(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, food)
+}
+
+// POST /api/foods
+// ผู้ดูแลเพิ่มอาหารเข้าแคตตาล็อก ผู้ใช้อื่นเพิ่มเป็นอาหารของตนเอง
+func CreateFood(c *gin.Context) {
+	var input entity.Food
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	var owner *uint
+	if actor, _ := c.Get("actor"); actor != "admin" {
+		userID, _ := c.Get("user_id")
+		uid, _ := userID.(uint)
+		if uid == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "ไม่พบข้อมูลผู้ใช้"})
+			return
+		}
+		owner = &uid
+	}
+	food, err := services.CreateFood(input, owner)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "เพิ่มอาหารสำเร็จ", "data": food})
+}
+
+// PUT /api/foods/:id
+func UpdateFood(c *gin.Context) {
+	existing, ok := loadFood(c, true)
+	if !ok {
+		return
+	}
+	var input entity.Food
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	food, err := services.UpdateFood(existing.ID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "อัปเดตอาหารสำเร็จ", "data": food})
+}
+
+// DELETE /api/foods/:id
+func DeleteFood(c *gin.Context) {
+	existing, ok := loadFood(c, true)
+	if !ok {
+		return
+	}
+	if err := services.DeleteFood(existing.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบอาหารได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบอาหารสำเร็จ"})
+}
+
+// POST /api/meal-entries
+// ระบุ serving_name + quantity หรือ grams โดยตรง ระบบคำนวณพลังงานและสารอาหารให้
+func CreateMealEntry(c *gin.Context) {
+	var input entity.MealEntry
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	userID, ok := resolveWorkoutUser(c, input.UserID)
+	if !ok {
+		return
+	}
+	input.UserID = userID
+	entry, err := services.CreateMealEntry(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "บันทึกอาหารสำเร็จ", "data": entry})
+}
+
+// GET /api/meal-entries?user_id=&date= (ค่าเริ่มต้นคือวันนี้)
+func GetMealEntries(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	date, ok := queryDay(c)
+	if !ok {
+		return
+	}
+	entries, err := services.GetMealEntries(userID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรายการอาหารที่บันทึกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// PUT /api/meal-entries/:id?user_id=
+func UpdateMealEntry(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสรายการไม่ถูกต้อง"})
+		return
+	}
+	var input entity.MealEntry
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	entry, err := services.UpdateMealEntry(uint(id), userID, input)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรายการอาหาร"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "อัปเดตรายการอาหารสำเร็จ", "data": entry})
+}
+
+// DELETE /api/meal-entries/:id?user_id=
+func DeleteMealEntry(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสรายการไม่ถูกต้อง"})
+		return
+	}
+	if err := services.DeleteMealEntry(uint(id), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรายการอาหาร"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบรายการอาหารได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบรายการอาหารสำเร็จ"})
+}
+
+// GET /api/nutrition/summary?user_id=&date=
+// ผลรวมพลังงานและสารอาหารของวัน แยกตามมื้อ เทียบกับเป้าหมายในแผนโภชนาการ
+func GetNutritionSummary(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	date, ok := queryDay(c)
+	if !ok {
+		return
+	}
+	summary, err := services.GetDailyNutritionSummary(userID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถสรุปข้อมูลโภชนาการได้"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// loadFood ดึงอาหารตาม :id อาหารที่ผู้ใช้สร้างเองเข้าถึงได้เฉพาะเจ้าของ
+// manage = true คือจะแก้ไข/ลบ อาหารในแคตตาล็อกกลางต้องเป็นผู้ดูแลเท่านั้น
+func loadFood(c *gin.Context, manage bool) (entity.Food, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสอาหารไม่ถูกต้อง"})
+		return entity.Food{}, false
+	}
+	actor, _ := c.Get("actor")
+	userID, _ := c.Get("user_id")
+	uid, _ := userID.(uint)
+	if actor == "admin" {
+		uid = 0
+	}
+	food, err := services.GetFood(uint(id), uid)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบอาหาร"})
+		return food, false
+	}
+	if manage && !food.IsCustom && !requireAdmin(c) {
+		return food, false
+	}
+	return food, true
+}
+
+func queryDay(c *gin.Context) (string, bool) {
+	date := c.Query("date")
+	if date == "" {
+		return time.Now().Format("2006-01-02"), true
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+		return "", false
+	}
+	return date, true
+}
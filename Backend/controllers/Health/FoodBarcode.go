@@ -0,0 +1,102 @@
+package Health
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /api/foods/barcode/:ean
+// รองรับ EAN-13 และ UPC-A ค้นหาในระบบก่อน แล้วจึงถามผู้ให้บริการภายนอก
+func GetFoodByBarcode(c *gin.Context) {
+	result, err := services.LookupBarcode(c.Request.Context(), c.Param("ean"))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, result)
+	case errors.Is(err, services.ErrInvalidBarcode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrBarcodeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
+
+// POST /api/foods/:id/corrections
+// ผู้ใช้เสนอแก้ไขข้อมูลอาหาร ส่งเฉพาะค่าที่ต้องการแก้
+func CreateFoodCorrection(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสอาหารไม่ถูกต้อง"})
+		return
+	}
+	var input entity.FoodCorrection
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	userID, _ := c.Get("user_id")
+	input.UserID, _ = userID.(uint)
+	input.FoodID = uint(id)
+	correction, err := services.CreateFoodCorrection(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "ส่งข้อเสนอแก้ไขแล้ว รอผู้ดูแลตรวจสอบ", "data": correction})
+}
+
+// GET /api/foods/corrections?status=pending
+func GetFoodCorrections(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+	corrections, err := services.GetFoodCorrections(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อเสนอแก้ไขได้"})
+		return
+	}
+	c.JSON(http.StatusOK, corrections)
+}
+
+// POST /api/foods/corrections/:id/approve
+func ApproveFoodCorrection(c *gin.Context) {
+	reviewFoodCorrection(c, true)
+}
+
+// POST /api/foods/corrections/:id/reject
+func RejectFoodCorrection(c *gin.Context) {
+	reviewFoodCorrection(c, false)
+}
+
+func reviewFoodCorrection(c *gin.Context, approve bool) {
+	if !requireAdmin(c) {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสข้อเสนอไม่ถูกต้อง"})
+		return
+	}
+	var body struct {
+		Note string `json:"note"`
+	}
+	_ = c.ShouldBindJSON(&body)
+	adminID, _ := c.Get("user_id")
+	reviewer, _ := adminID.(uint)
+	correction, err := services.ReviewFoodCorrection(uint(id), reviewer, approve, body.Note)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบข้อเสนอแก้ไข"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ตรวจสอบข้อเสนอแก้ไขแล้ว", "data": correction})
+}
@@ -1,13 +1,17 @@
 package Health
 
 import (
+	"errors"
 	"log"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
 	"example.com/fitness-backend/config"
 	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateHealth - POST /api/health/
@@ -33,23 +37,20 @@ func CreateHealth(c *gin.Context) {
 
 	health.UserID = userID
 
-	if health.Date == "" {
-		health.Date = time.Now().Format("2006-01-02")
-	}
-
 	log.Printf("Creating Health record: %+v\n", health)
 
-	db := config.DB()
-	if err := db.Create(&health).Error; err != nil {
+	// ✅ BMI, กลุ่ม BMI และกลุ่มความดันคำนวณที่เซิร์ฟเวอร์ ไม่ใช้ค่าที่ส่งมา
+	created, err := services.CreateHealth(health)
+	if err != nil {
 		log.Printf("Failed to create health: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"id":      health.ID,
-		"data":    health,
+		"id":      created.ID,
+		"data":    created,
 	})
 }
 
@@ -70,3 +71,144 @@ func GetAllHealth(c *gin.Context) {
 
 	c.JSON(http.StatusOK, healths)
 }
+
+// UpdateHealth - PUT /api/health/:id
+func UpdateHealth(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID, _ := userIDRaw.(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid health ID"})
+		return
+	}
+
+	var input entity.Health
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	updated, err := services.UpdateHealth(uint(id), userID, input)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Health record not found or not authorized"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": updated})
+}
+
+// DeleteHealth - DELETE /api/health/:id
+func DeleteHealth(c *gin.Context) {
+	userIDRaw, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	userID, _ := userIDRaw.(uint)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid health ID"})
+		return
+	}
+
+	if err := services.DeleteHealth(uint(id), userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Health record not found or not authorized"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Health record deleted successfully"})
+}
+
+// GetBmiCutoff - GET /api/health/bmi-cutoff
+func GetBmiCutoff(c *gin.Context) {
+	cutoff, err := services.GetBmiCutoff()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงเกณฑ์ BMI ได้"})
+		return
+	}
+	c.JSON(http.StatusOK, cutoff)
+}
+
+// UpdateBmiCutoff - PUT /api/health/bmi-cutoff (ผู้ดูแลระบบเท่านั้น)
+func UpdateBmiCutoff(c *gin.Context) {
+	if actor, _ := c.Get("actor"); actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้นที่สามารถแก้ไขเกณฑ์ BMI ได้"})
+		return
+	}
+
+	var cutoff entity.BmiCutoff
+	if err := c.ShouldBindJSON(&cutoff); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+
+	updated, err := services.UpdateBmiCutoff(cutoff)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// GetHealthAnalytics - GET /api/health/analytics?bucket=&from=&to=&window=&target_weight=&user_id=
+// แนวโน้มน้ำหนัก ไขมัน BMI และความดันโลหิต แบ่งเป็นรายวัน/สัปดาห์/เดือน พร้อมค่าเฉลี่ยเคลื่อนที่
+// และการคาดการณ์เป้าหมาย (target_<metric> เช่น target_weight=65, target_fat=20)
+func GetHealthAnalytics(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	filter := services.HealthTrendFilter{
+		UserID:  userID,
+		From:    from,
+		To:      to,
+		Bucket:  c.DefaultQuery("bucket", "day"),
+		Targets: map[string]float64{},
+	}
+	if window := c.Query("window"); window != "" {
+		value, err := strconv.Atoi(window)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window ต้องเป็นจำนวนเต็มบวก"})
+			return
+		}
+		filter.Window = value
+	}
+	for key, values := range c.Request.URL.Query() {
+		metric, found := strings.CutPrefix(key, "target_")
+		if !found || len(values) == 0 {
+			continue
+		}
+		target, err := strconv.ParseFloat(values[0], 64)
+		if err != nil || target <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ค่าเป้าหมายของ " + metric + " ไม่ถูกต้อง"})
+			return
+		}
+		filter.Targets[metric] = target
+	}
+
+	trends, err := services.GetHealthTrends(filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, trends)
+}
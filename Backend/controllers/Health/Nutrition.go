@@ -7,10 +7,14 @@ import (
 
 	"example.com/fitness-backend/config"
 	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/nutrition"
+	"example.com/fitness-backend/services"
 	"github.com/gin-gonic/gin"
 )
 
 // POST /api/nutrition
+// ไม่ส่งแคลอรี่ต่อวันมา ระบบคำนวณจากสูตร ระดับกิจกรรม และเป้าหมายที่เลือก
+// ไม่ส่งมาโครครบทั้งสามค่า ระบบแบ่งจากพลังงานต่อวันตาม macro_strategy
 func CreateOrUpdateNutrition(c *gin.Context) {
 	var body struct {
 		Goal                string  `json:"goal"`
@@ -20,6 +24,9 @@ func CreateOrUpdateNutrition(c *gin.Context) {
 		ProteinG            float64 `json:"protein_g"`
 		FatG                float64 `json:"fat_g"`
 		CarbG               float64 `json:"carb_g"`
+		Formula             string  `json:"formula"`        // mifflin_st_jeor, harris_benedict, katch_mcardle
+		ActivityLevel       string  `json:"activity_level"` // ว่าง = ประมาณจากกิจกรรม 14 วันล่าสุด
+		MacroStrategy       string  `json:"macro_strategy"` // balanced, high_protein, low_carb
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
@@ -37,162 +44,93 @@ func CreateOrUpdateNutrition(c *gin.Context) {
 		body.Date = time.Now().Format("2006-01-02")
 	}
 
-	db := config.DB()
-
-	// หากไม่ส่งแคลอรี่ต่อวันมา หรือส่งมาน้อยกว่าหรือเท่ากับ 0 ให้คำนวณให้อัตโนมัติจากข้อมูลสุขภาพล่าสุดและเพศ
-	if body.TotalCaloriesPerDay <= 0 {
-		var user entity.Users
-		db.First(&user, userID)
-
-		var latestHealth entity.Health
-		db.Where("user_id = ?", userID).Order("date desc").First(&latestHealth)
-
-		weight := float64(latestHealth.Weight)
-		height := float64(latestHealth.Height)
-		age := int(user.Age)
-		isMale := user.GenderID == 1 // ปรับตามค่าเพศในระบบของคุณ
-
-		// สูตร Mifflin-St Jeor
-		s := 5.0
-		if !isMale {
-			s = -161.0
-		}
-		bmr := 10*weight + 6.25*height - 5*float64(age) + s
-		if bmr < 800 {
-			bmr = 800
-		}
-		// สมมติระดับกิจกรรม Light
-		tdee := bmr * 1.375
-
-		switch body.Goal {
-		case "ลดน้ำหนัก", "cut", "loss":
-			tdee = tdee * 0.85
-		case "เพิ่มกล้ามเนื้อ", "bulk", "gain":
-			tdee = tdee * 1.10
-		}
-
-		body.TotalCaloriesPerDay = float64(int(tdee))
-	}
-
-	// ใช้ข้อมูลมาโครที่ส่งมา หรือคำนวณถ้าไม่ได้ส่งมา
-	var proteinG, fatG, carbG float64
-	if body.ProteinG > 0 && body.FatG > 0 && body.CarbG > 0 {
-		// ใช้ข้อมูลที่ส่งมา
-		proteinG = body.ProteinG
-		fatG = body.FatG
-		carbG = body.CarbG
-	} else {
-		// คำนวณมาโครอัตโนมัติ
-		var user entity.Users
-		db.First(&user, userID)
-		var latestHealth entity.Health
-		db.Where("user_id = ?", userID).Order("date desc").First(&latestHealth)
-
-		if latestHealth.ID != 0 {
-			isMale := user.GenderID == 1
-			proteinPerKg := 1.4
-			if isMale {
-				proteinPerKg = 1.6
-			}
-			proteinG = latestHealth.Weight * proteinPerKg
-			fatKcal := body.TotalCaloriesPerDay * 0.25
-			fatG = fatKcal / 9.0
-			carbKcal := body.TotalCaloriesPerDay - (proteinG * 4) - fatKcal
-			carbG = carbKcal / 4.0
-		}
+	opts := services.NutritionOptions{
+		Formula:       body.Formula,
+		ActivityLevel: body.ActivityLevel,
+		Goal:          body.Goal,
+		Strategy:      body.MacroStrategy,
 	}
+	macros := nutrition.Macros{ProteinG: body.ProteinG, FatG: body.FatG, CarbG: body.CarbG}
+	hasMacros := body.ProteinG > 0 && body.FatG > 0 && body.CarbG > 0
+	var calculation interface{}
 
-	// upsert nutrition by user/date
-	var nutrition entity.Nutrition
-	if err := db.Where("user_id = ? AND date = ?", userID, body.Date).First(&nutrition).Error; err == nil {
-		// Update existing nutrition
-		nutrition.Goal = body.Goal
-		nutrition.TotalCaloriesPerDay = body.TotalCaloriesPerDay
-		nutrition.Note = body.Note
-		db.Save(&nutrition)
-
-		// Update or create meal record
-		var meal entity.Meal
-		if err := db.Where("nutrition_id = ?", nutrition.ID).First(&meal).Error; err == nil {
-			// Update existing meal
-			meal.ProteinG = proteinG
-			meal.FatG = fatG
-			meal.CarbG = carbG
-			db.Save(&meal)
-		} else {
-			// Create new meal
-			meal = entity.Meal{
-				NutritionID: nutrition.ID,
-				UserID:      userID,
-				ProteinG:    proteinG,
-				FatG:        fatG,
-				CarbG:       carbG,
-			}
-			db.Create(&meal)
+	if body.TotalCaloriesPerDay <= 0 {
+		result, err := services.CalculateNutrition(userID, opts)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
-	} else {
-		// Create new nutrition
-		nutrition = entity.Nutrition{
-			UserID:              userID,
-			Date:                body.Date,
-			Goal:                body.Goal,
-			TotalCaloriesPerDay: body.TotalCaloriesPerDay,
-			Note:                body.Note,
+		body.TotalCaloriesPerDay = result.TargetCalories
+		if !hasMacros {
+			macros = result.Macros
 		}
-		if err := db.Create(&nutrition).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create"})
+		calculation = result
+	} else if !hasMacros {
+		calculated, steps, err := services.CalculateNutritionMacros(userID, body.TotalCaloriesPerDay, opts)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-
-		// Create meal record
-		meal := entity.Meal{
-			NutritionID: nutrition.ID,
-			UserID:      userID,
-			ProteinG:    proteinG,
-			FatG:        fatG,
-			CarbG:       carbG,
-		}
-		db.Create(&meal)
+		macros = calculated
+		calculation = gin.H{"macros": calculated, "steps": steps}
 	}
 
-	// สร้าง response macros
-	respMacros := gin.H{
-		"protein_g": int(proteinG + 0.5),
-		"fat_g":     int(fatG + 0.5),
-		"carb_g":    int(carbG + 0.5),
+	plan, meal, err := services.SaveNutritionPlan(entity.Nutrition{
+		UserID:              userID,
+		Date:                body.Date,
+		Goal:                body.Goal,
+		TotalCaloriesPerDay: body.TotalCaloriesPerDay,
+		Note:                body.Note,
+	}, macros)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create"})
+		return
 	}
 
-	// รวมข้อมูลมาโครใน response
-	response := gin.H{
-		"data":   nutrition,
-		"macros": respMacros,
-	}
+	// รวมข้อมูลมาโครใน nutrition object เพื่อให้ frontend ใช้งานได้
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"ID":                     plan.ID,
+			"CreatedAt":              plan.CreatedAt,
+			"UpdatedAt":              plan.UpdatedAt,
+			"DeletedAt":              plan.DeletedAt,
+			"user_id":                plan.UserID,
+			"date":                   plan.Date,
+			"goal":                   plan.Goal,
+			"total_calories_per_day": plan.TotalCaloriesPerDay,
+			"note":                   plan.Note,
+			"protein_g":              meal.ProteinG,
+			"fat_g":                  meal.FatG,
+			"carb_g":                 meal.CarbG,
+		},
+		"macros": gin.H{
+			"protein_g": int(meal.ProteinG + 0.5),
+			"fat_g":     int(meal.FatG + 0.5),
+			"carb_g":    int(meal.CarbG + 0.5),
+		},
+		"calculation": calculation,
+	})
+}
 
-	// เพิ่มข้อมูลมาโครใน nutrition object เพื่อให้ frontend ใช้งานได้
-	if nutritionData, ok := response["data"].(entity.Nutrition); ok {
-		// ดึงข้อมูล meal เพื่อเพิ่มมาโคร
-		var meal entity.Meal
-		if err := db.Where("nutrition_id = ?", nutritionData.ID).First(&meal).Error; err == nil {
-			// สร้าง nutrition object ที่มีมาโคร
-			nutritionWithMacros := gin.H{
-				"ID":                     nutritionData.ID,
-				"CreatedAt":              nutritionData.CreatedAt,
-				"UpdatedAt":              nutritionData.UpdatedAt,
-				"DeletedAt":              nutritionData.DeletedAt,
-				"user_id":                nutritionData.UserID,
-				"date":                   nutritionData.Date,
-				"goal":                   nutritionData.Goal,
-				"total_calories_per_day": nutritionData.TotalCaloriesPerDay,
-				"note":                   nutritionData.Note,
-				"protein_g":              meal.ProteinG,
-				"fat_g":                  meal.FatG,
-				"carb_g":                 meal.CarbG,
-			}
-			response["data"] = nutritionWithMacros
-		}
+// GET /api/nutrition/calculate?formula=&activity_level=&goal=&macro_strategy=
+// ดูผลการคำนวณพร้อมคำอธิบายแต่ละขั้นโดยไม่บันทึกแผน
+func CalculateNutrition(c *gin.Context) {
+	userIDRaw, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
 	}
-
-	c.JSON(http.StatusOK, response)
+	result, err := services.CalculateNutrition(userIDRaw.(uint), services.NutritionOptions{
+		Formula:       c.Query("formula"),
+		ActivityLevel: c.Query("activity_level"),
+		Goal:          c.Query("goal"),
+		Strategy:      c.Query("macro_strategy"),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
 }
 
 // GET /api/nutrition?date=YYYY-MM-DD
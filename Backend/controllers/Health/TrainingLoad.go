@@ -0,0 +1,90 @@
+package Health
+
+import (
+	"net/http"
+	"time"
+
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/analytics/heart-rate-zones?user_id=
+// โซนหัวใจของผู้ใช้เป็นครั้ง/นาที ตามวิธีที่ตั้งไว้ในโปรไฟล์
+func GetHeartRateZones(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	settings, err := services.GetHeartRateSettings(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบข้อมูลผู้ใช้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+		"zones":    services.HeartRateZones(settings),
+	})
+}
+
+// GET /api/analytics/training-load?user_id=&date=
+// ภาระการฝึก 7/28 วันและ ACWR นับถึงวันที่ date (ค่าเริ่มต้นคือวันนี้)
+func GetTrainingLoad(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	date, ok := parseLoadDate(c)
+	if !ok {
+		return
+	}
+	report, err := services.GetTrainingLoad(userID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถคำนวณภาระการฝึกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GET /api/analytics/training-load/clients?date=&trainer_id=
+// ภาระการฝึกของลูกค้าทุกคนของเทรนเนอร์ (ผู้ดูแลระบุ trainer_id ได้)
+func GetClientsTrainingLoad(c *gin.Context) {
+	actor, _ := c.Get("actor")
+	userIDInterface, _ := c.Get("user_id")
+	trainerID, _ := userIDInterface.(uint)
+	switch actor {
+	case "trainer":
+	case "admin":
+		trainerID = queryUint(c, "trainer_id")
+		if trainerID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ trainer_id"})
+			return
+		}
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเทรนเนอร์หรือผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	date, ok := parseLoadDate(c)
+	if !ok {
+		return
+	}
+	results, err := services.GetTrainerClientsTrainingLoad(trainerID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถคำนวณภาระการฝึกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+func parseLoadDate(c *gin.Context) (time.Time, bool) {
+	value := c.Query("date")
+	if value == "" {
+		return time.Now(), true
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+		return date, false
+	}
+	// เที่ยงวันเพื่อให้ตรงกับวันเดียวกันไม่ว่าจะแปลงเป็นโซนเวลาใด
+	return date.Add(12 * time.Hour), true
+}
@@ -0,0 +1,170 @@
+package Health
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// correlationWindowDays ช่วงเริ่มต้นของการหาสหสัมพันธ์เมื่อไม่ระบุ from
+const correlationWindowDays = 90
+
+// PUT /api/wellness/check-ins/:date
+// บันทึกสุขภาวะประจำวัน (วันละหนึ่งรายการ บันทึกซ้ำจะแทนที่ค่าเดิม)
+func SaveWellnessCheckIn(c *gin.Context) {
+	var input entity.WellnessCheckIn
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	userID, ok := resolveWorkoutUser(c, input.UserID)
+	if !ok {
+		return
+	}
+	input.UserID = userID
+	input.Date = c.Param("date")
+
+	saved, err := services.SaveWellnessCheckIn(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "บันทึกสุขภาวะสำเร็จ", "data": saved})
+}
+
+// GET /api/wellness/check-ins/:date?user_id=
+func GetWellnessCheckIn(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	checkIn, err := services.GetWellnessCheckIn(userID, c.Param("date"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบบันทึกของวันนี้"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, checkIn)
+}
+
+// GET /api/wellness/check-ins?user_id=&from=&to=
+func GetWellnessCheckIns(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	if _, _, ok := parseDateRange(c); !ok {
+		return
+	}
+	checkIns, err := services.GetWellnessCheckIns(userID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงบันทึกสุขภาวะได้"})
+		return
+	}
+	c.JSON(http.StatusOK, checkIns)
+}
+
+// DELETE /api/wellness/check-ins/:date?user_id=
+func DeleteWellnessCheckIn(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	if err := services.DeleteWellnessCheckIn(userID, c.Param("date")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบบันทึกของวันนี้"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบบันทึกสุขภาวะสำเร็จ"})
+}
+
+// GET /api/wellness/streak?user_id=
+func GetWellnessStreak(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	streak, err := services.GetWellnessStreak(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถคำนวณการบันทึกต่อเนื่องได้"})
+		return
+	}
+	c.JSON(http.StatusOK, streak)
+}
+
+// GET /api/wellness/weekly?user_id=&from=&to=
+func GetWellnessWeekly(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	if _, _, ok := parseDateRange(c); !ok {
+		return
+	}
+	weeks, err := services.GetWellnessWeekly(userID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถสรุปข้อมูลรายสัปดาห์ได้"})
+		return
+	}
+	c.JSON(http.StatusOK, weeks)
+}
+
+// GET /api/wellness/correlations?user_id=&from=&to=&x=&y=&lag=
+// ไม่ระบุ x/y จะแสดงคู่ค่าเริ่มต้น เช่น การนอนกับผลการฝึก ช่วงเริ่มต้นคือ 90 วันล่าสุด
+func GetWellnessCorrelations(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+	if to.IsZero() {
+		to = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -correlationWindowDays)
+	}
+
+	var pairs []services.CorrelationPair
+	if x, y := c.Query("x"), c.Query("y"); x != "" || y != "" {
+		if x == "" || y == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุทั้ง x และ y"})
+			return
+		}
+		lag := 0
+		if value := c.Query("lag"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "lag ต้องเป็นจำนวนเต็ม"})
+				return
+			}
+			lag = parsed
+		}
+		pairs = []services.CorrelationPair{{X: x, Y: y, Lag: lag}}
+	}
+
+	correlations, err := services.GetWellnessCorrelations(userID, from, to, pairs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":         from.Format("2006-01-02"),
+		"to":           to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"correlations": correlations,
+	})
+}
@@ -0,0 +1,243 @@
+package Health
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// POST /api/workouts
+// ลูกค้าบันทึกการฝึกของตนเอง เทรนเนอร์/ผู้ดูแลบันทึกให้ลูกค้าได้โดยระบุ user_id
+func CreateWorkout(c *gin.Context) {
+	var log entity.WorkoutLog
+	if err := c.ShouldBindJSON(&log); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	userID, ok := resolveWorkoutUser(c, log.UserID)
+	if !ok {
+		return
+	}
+	log.UserID = userID
+
+	created, records, err := services.CreateWorkoutLog(log)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":          "บันทึกการฝึกสำเร็จ",
+		"data":             created,
+		"personal_records": records,
+	})
+}
+
+// GET /api/workouts?user_id=&from=&to= (วันที่รูปแบบ YYYY-MM-DD)
+func GetWorkouts(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+	logs, err := services.GetWorkoutLogs(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลการฝึกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, logs)
+}
+
+// GET /api/workouts/:id
+func GetWorkout(c *gin.Context) {
+	log, ok := loadWorkout(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, log)
+}
+
+// PUT /api/workouts/:id
+func UpdateWorkout(c *gin.Context) {
+	existing, ok := loadWorkout(c)
+	if !ok {
+		return
+	}
+	var input entity.WorkoutLog
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	updated, records, err := services.UpdateWorkoutLog(existing.ID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "อัปเดตการฝึกสำเร็จ",
+		"data":             updated,
+		"personal_records": records,
+	})
+}
+
+// DELETE /api/workouts/:id
+func DeleteWorkout(c *gin.Context) {
+	existing, ok := loadWorkout(c)
+	if !ok {
+		return
+	}
+	if err := services.DeleteWorkoutLog(existing.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบการฝึกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบการฝึกสำเร็จ"})
+}
+
+// GET /api/workouts/:id/comparison
+// เทียบท่าที่กำหนดในเซสชันของโปรแกรมกับที่ทำจริง
+func CompareWorkout(c *gin.Context) {
+	existing, ok := loadWorkout(c)
+	if !ok {
+		return
+	}
+	comparison, err := services.CompareWorkoutWithProgram(existing.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, comparison)
+}
+
+// GET /api/workouts/records?user_id=&exercise_key=
+// ไม่ระบุ exercise_key จะได้สถิติที่ดีที่สุดของทุกท่า ระบุแล้วจะได้ประวัติการทำลายสถิติของท่านั้น
+func GetPersonalRecords(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	records, err := services.GetPersonalRecords(userID, c.Query("exercise_key"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงสถิติส่วนตัวได้"})
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// GET /api/workouts/volume?user_id=&from=&to=
+// ปริมาณการฝึกต่อกลุ่มกล้ามเนื้อต่อสัปดาห์ ค่าเริ่มต้นคือ 8 สัปดาห์ล่าสุด
+func GetMuscleVolume(c *gin.Context) {
+	userID, ok := resolveWorkoutUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	from, to, ok := parseDateRange(c)
+	if !ok {
+		return
+	}
+	if from.IsZero() {
+		from = time.Now().AddDate(0, 0, -8*7)
+	}
+	volume, err := services.GetWeeklyMuscleVolume(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถคำนวณปริมาณการฝึกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, volume)
+}
+
+// resolveWorkoutUser ลูกค้าเข้าถึงได้เฉพาะข้อมูลตนเอง
+// เทรนเนอร์เข้าถึงได้เฉพาะลูกค้าของตน ส่วนผู้ดูแลเข้าถึงได้ทุกคน (ทั้งสองต้องระบุ user_id)
+func resolveWorkoutUser(c *gin.Context, requested uint) (uint, bool) {
+	actor, _ := c.Get("actor")
+	userIDInterface, _ := c.Get("user_id")
+	userID, _ := userIDInterface.(uint)
+
+	if actor == "trainer" || actor == "admin" {
+		if requested == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ user_id ของลูกค้า"})
+			return 0, false
+		}
+		if actor == "trainer" {
+			isClient, err := services.IsTrainerClient(userID, requested)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return 0, false
+			}
+			if !isClient {
+				c.JSON(http.StatusForbidden, gin.H{"error": "ผู้ใช้นี้ไม่ใช่ลูกค้าของคุณ"})
+				return 0, false
+			}
+		}
+		return requested, true
+	}
+	if userID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ไม่พบข้อมูลผู้ใช้"})
+		return 0, false
+	}
+	if requested != 0 && requested != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์เข้าถึงข้อมูลของผู้ใช้อื่น"})
+		return 0, false
+	}
+	return userID, true
+}
+
+func loadWorkout(c *gin.Context) (entity.WorkoutLog, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสการฝึกไม่ถูกต้อง"})
+		return entity.WorkoutLog{}, false
+	}
+	log, err := services.GetWorkoutLog(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบข้อมูลการฝึก"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return log, false
+	}
+	if _, ok := resolveWorkoutUser(c, log.UserID); !ok {
+		return log, false
+	}
+	return log, true
+}
+
+func parseDateRange(c *gin.Context) (time.Time, time.Time, bool) {
+	var from, to time.Time
+	for _, item := range []struct {
+		key    string
+		target *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		value := c.Query(item.key)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return from, to, false
+		}
+		*item.target = parsed
+	}
+	if !to.IsZero() {
+		// รวมทั้งวันสุดท้ายของช่วง
+		to = to.AddDate(0, 0, 1)
+	}
+	return from, to, true
+}
+
+func queryUint(c *gin.Context, key string) uint {
+	value, err := strconv.Atoi(c.Query(key))
+	if err != nil || value < 0 {
+		return 0
+	}
+	return uint(value)
+}
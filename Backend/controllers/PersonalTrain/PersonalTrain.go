@@ -93,6 +93,7 @@ func CreatePersonalTrainingProgram(c *gin.Context) {
 		Date      string `json:"date"`
 		Time      string `json:"time"`
 		GoalID    uint   `json:"goal_id"`
+		ProgramID *uint  `json:"program_id"`
 	}
 
 	if err := c.ShouldBindJSON(&requestData); err != nil {
@@ -139,6 +140,7 @@ func CreatePersonalTrainingProgram(c *gin.Context) {
 		Date:      parsedDate,
 		Time:      requestData.Time,
 		GoalID:    requestData.GoalID,
+		ProgramID: requestData.ProgramID,
 	}
 
 	fmt.Printf("Created program entity: %+v\n", program)
@@ -191,6 +193,16 @@ func CreatePersonalTrainingProgram(c *gin.Context) {
 
 	fmt.Printf("Goal validation passed - Goal: %s\n", goal.Goal)
 
+	// Validate that the linked program belongs to this customer
+	if program.ProgramID != nil {
+		var linked entity.TrainingProgram
+		if err := config.DB().First(&linked, *program.ProgramID).Error; err != nil ||
+			linked.UserID == nil || *linked.UserID != program.UserID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่พบโปรแกรมการฝึกของลูกค้านี้"})
+			return
+		}
+	}
+
 	newProgram, err := services.CreatePersonalTrainingProgram(program)
 	if err != nil {
 		fmt.Printf("Error creating program: %v\n", err)
@@ -1,12 +1,14 @@
 package TrainBooking
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"example.com/fitness-backend/entity"
 	"example.com/fitness-backend/services"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // POST /train-bookings
@@ -17,7 +19,28 @@ func CreateTrainBooking(c *gin.Context) {
 		return
 	}
 
-	newBooking, err := services.CreateTrainBooking(trainBooking)
+	// ลูกค้าจองให้ตนเองเท่านั้น (ผู้ใช้จาก token) ผู้ดูแลระบบจองแทนลูกค้าได้ สถานะกำหนดโดยระบบ
+	actor, actorID := currentActor(c)
+	switch actor {
+	case "customer":
+		trainBooking.UsersID = actorID
+	case "admin":
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะสมาชิกหรือผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+
+	newBooking, err := services.CreateTrainBooking(entity.TrainBooking{
+		UsersID:       trainBooking.UsersID,
+		ScheduleID:    trainBooking.ScheduleID,
+		BookingDate:   trainBooking.BookingDate,
+		BookingStatus: "Booked",
+	})
+	var entitlementErr *services.EntitlementError
+	if errors.As(err, &entitlementErr) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "upgrade_required", "entitlement": entitlementErr})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "เวลานี้ถูกจองแล้ว"})
 		return
@@ -56,13 +79,137 @@ func CancelTrainBooking(c *gin.Context) {
 		return
 	}
 
-	err = services.CancelTrainBooking(uint(id))
+	actor, actorID := currentActor(c)
+	booking, err := services.CancelTrainBooking(uint(id), actor, actorID)
+	if err != nil {
+		respondBookingError(c, err, "ไม่พบข้อมูลการจองที่ต้องการยกเลิก")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "ยกเลิกการจองสำเร็จ",
+		"late_cancel": booking.LateCancel,
+		"data":        booking,
+	})
+}
+
+// POST /train-bookings/:id/reschedule
+// ย้ายการจองไปยังเวลาว่างอื่นของเทรนเนอร์คนเดิม
+func RescheduleTrainBooking(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสการจองไม่ถูกต้อง"})
+		return
+	}
+
+	var req struct {
+		ScheduleID uint `json:"schedule_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.ScheduleID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ schedule_id ใหม่"})
+		return
+	}
+
+	actor, actorID := currentActor(c)
+	booking, err := services.RescheduleTrainBooking(uint(id), req.ScheduleID, actor, actorID)
+	if err != nil {
+		respondBookingError(c, err, "ไม่พบข้อมูลการจอง")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "เลื่อนนัดสำเร็จ",
+		"data":    booking,
+	})
+}
+
+// POST /train-bookings/:id/no-show
+func MarkNoShow(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสการจองไม่ถูกต้อง"})
+		return
+	}
+
+	actor, actorID := currentActor(c)
+	booking, err := services.MarkTrainBookingNoShow(uint(id), actor, actorID)
+	if err != nil {
+		respondBookingError(c, err, "ไม่พบข้อมูลการจอง")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "บันทึกว่าไม่มาตามนัดแล้ว",
+		"data":    booking,
+	})
+}
+
+// GET /train-bookings/:id/history
+func GetBookingHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสการจองไม่ถูกต้อง"})
+		return
+	}
+
+	history, err := services.GetTrainBookingHistory(uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบข้อมูลการจองที่ต้องการยกเลิก"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงประวัติการจองได้"})
 		return
 	}
+	c.JSON(http.StatusOK, history)
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "ยกเลิกการจองสำเร็จ"})
+// GET /train-bookings/policy
+func GetPolicy(c *gin.Context) {
+	policy, err := services.GetBookingPolicy()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงนโยบายการจองได้"})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+// PUT /train-bookings/policy
+func UpdatePolicy(c *gin.Context) {
+	if actor, _ := c.Get("actor"); actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้นที่สามารถแก้ไขนโยบายได้"})
+		return
+	}
+
+	var policy entity.BookingPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+
+	updated, err := services.UpdateBookingPolicy(policy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userID, _ := c.Get("user_id")
+	actorStr, _ := actor.(string)
+	id, _ := userID.(uint)
+	return actorStr, id
+}
+
+func respondBookingError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case errors.Is(err, services.ErrNotBookingOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrScheduleTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
 }
 
 // GET /train-bookings/customers
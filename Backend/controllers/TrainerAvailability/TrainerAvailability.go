@@ -0,0 +1,267 @@
+package TrainerAvailability
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// POST /trainer-availability
+// รับ weekdays หลายวันพร้อมกัน เช่น อังคาร/พฤหัส 09:00-12:00 ช่วงละ 60 นาที พัก 15 นาที
+func CreateAvailability(c *gin.Context) {
+	var req struct {
+		TrainerID     uint   `json:"trainer_id"`
+		Weekdays      []int  `json:"weekdays"`
+		StartTime     string `json:"start_time"`
+		EndTime       string `json:"end_time"`
+		SlotMinutes   int    `json:"slot_minutes"`
+		BufferMinutes int    `json:"buffer_minutes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	if len(req.Weekdays) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาเลือกวันอย่างน้อยหนึ่งวัน"})
+		return
+	}
+
+	trainerID, ok := resolveTrainerID(c, req.TrainerID)
+	if !ok {
+		return
+	}
+	created := []entity.TrainerAvailability{}
+	for _, weekday := range req.Weekdays {
+		tpl, err := services.CreateTrainerAvailability(entity.TrainerAvailability{
+			TrainerID:     trainerID,
+			Weekday:       weekday,
+			StartTime:     req.StartTime,
+			EndTime:       req.EndTime,
+			SlotMinutes:   req.SlotMinutes,
+			BufferMinutes: req.BufferMinutes,
+		})
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		created = append(created, tpl)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "บันทึกช่วงเวลาว่างประจำสัปดาห์สำเร็จ",
+		"data":    created,
+	})
+}
+
+// GET /trainer-availability/trainer/:trainerID
+func GetAvailabilities(c *gin.Context) {
+	trainerID, err := strconv.Atoi(c.Param("trainerID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสเทรนเนอร์ไม่ถูกต้อง"})
+		return
+	}
+	templates, err := services.GetTrainerAvailabilities(uint(trainerID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	leaves, err := services.GetTrainerLeaves(uint(trainerID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"templates": templates, "leaves": leaves})
+}
+
+// DELETE /trainer-availability/:id
+func DeleteAvailability(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	tpl, err := services.GetTrainerAvailability(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบช่วงเวลาว่าง"})
+		return
+	}
+	if _, ok := resolveTrainerID(c, tpl.TrainerID); !ok {
+		return
+	}
+	if err := services.DeleteTrainerAvailability(tpl.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบช่วงเวลาว่างสำเร็จ"})
+}
+
+// POST /trainer-availability/leaves
+func CreateLeave(c *gin.Context) {
+	var leave entity.TrainerLeave
+	if err := c.ShouldBindJSON(&leave); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	trainerID, ok := resolveTrainerID(c, leave.TrainerID)
+	if !ok {
+		return
+	}
+	leave.TrainerID = trainerID
+	created, err := services.CreateTrainerLeave(leave)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "บันทึกวันลาสำเร็จ",
+		"data":    created,
+	})
+}
+
+// DELETE /trainer-availability/leaves/:id
+func DeleteLeave(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	leave, err := services.GetTrainerLeave(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบวันลา"})
+		return
+	}
+	if _, ok := resolveTrainerID(c, leave.TrainerID); !ok {
+		return
+	}
+	if err := services.DeleteTrainerLeave(leave.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบวันลาสำเร็จ"})
+}
+
+// POST /trainer-availability/generate
+// สร้างตารางเวลาจากช่วงเวลาว่างประจำสัปดาห์ สำหรับ weeks สัปดาห์ถัดไปนับจาก start_date (ค่าเริ่มต้นวันนี้)
+func GenerateSchedules(c *gin.Context) {
+	var req struct {
+		TrainerID uint   `json:"trainer_id"`
+		Weeks     int    `json:"weeks"`
+		StartDate string `json:"start_date"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+
+	from := time.Now()
+	if req.StartDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+			return
+		}
+		from = parsed
+	}
+
+	trainerID, ok := resolveTrainerID(c, req.TrainerID)
+	if !ok {
+		return
+	}
+	created, err := services.GenerateTrainerSchedules(trainerID, from, req.Weeks)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "สร้างตารางเวลาสำเร็จ",
+		"count":   len(created),
+		"data":    created,
+	})
+}
+
+type bulkRequest struct {
+	TrainerID uint   `json:"trainer_id"`
+	From      string `json:"from"` // YYYY-MM-DD
+	To        string `json:"to"`   // YYYY-MM-DD
+	Minutes   int    `json:"minutes"`
+}
+
+// POST /trainer-schedules/bulk-delete
+// ลบตารางเวลาในอนาคตที่ยังไม่มีผู้จองในช่วงวันที่ที่กำหนด
+func BulkDeleteSchedules(c *gin.Context) {
+	req, from, to, ok := bindBulkRequest(c)
+	if !ok {
+		return
+	}
+	count, err := services.BulkDeleteFutureSchedules(req.TrainerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบตารางเวลาสำเร็จ", "count": count})
+}
+
+// POST /trainer-schedules/bulk-shift
+// เลื่อนตารางเวลาในอนาคตที่ยังไม่มีผู้จองไป minutes นาที
+func BulkShiftSchedules(c *gin.Context) {
+	req, from, to, ok := bindBulkRequest(c)
+	if !ok {
+		return
+	}
+	if req.Minutes == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุจำนวนนาทีที่ต้องการเลื่อน"})
+		return
+	}
+	shifted, err := services.BulkShiftFutureSchedules(req.TrainerID, from, to, req.Minutes)
+	if err != nil {
+		var conflictErr *services.ConflictError
+		if errors.As(err, &conflictErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": conflictErr.Conflicts})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "เลื่อนตารางเวลาสำเร็จ", "count": len(shifted), "data": shifted})
+}
+
+func bindBulkRequest(c *gin.Context) (req bulkRequest, from, to time.Time, ok bool) {
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return req, time.Time{}, time.Time{}, false
+	}
+	if req.TrainerID, ok = resolveTrainerID(c, req.TrainerID); !ok {
+		return req, time.Time{}, time.Time{}, false
+	}
+	from, errFrom := time.Parse("2006-01-02", req.From)
+	to, errTo := time.Parse("2006-01-02", req.To)
+	if errFrom != nil || errTo != nil || to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ช่วงวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+		return req, from, to, false
+	}
+	return req, from, to, true
+}
+
+// resolveTrainerID เทรนเนอร์จัดการได้เฉพาะตารางของตนเอง (ไม่สนใจ trainer_id ที่ส่งมา)
+// ผู้ดูแลระบบต้องระบุ trainer_id ผู้ใช้อื่นตอบ 403
+func resolveTrainerID(c *gin.Context, trainerID uint) (uint, bool) {
+	actor, _ := c.Get("actor")
+	userIDRaw, _ := c.Get("user_id")
+	userID, _ := userIDRaw.(uint)
+	switch {
+	case actor == "trainer" && (trainerID == 0 || trainerID == userID):
+		return userID, true
+	case actor == "admin" && trainerID != 0:
+		return trainerID, true
+	case actor == "admin":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ trainer_id"})
+		return 0, false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์จัดการตารางเวลาของเทรนเนอร์คนนี้"})
+	return 0, false
+}
@@ -1,6 +1,7 @@
 package TrainerSchedule
 
 import (
+    "errors"
     "net/http"
     "strconv"
     "time"
@@ -19,6 +20,9 @@ func CreateTrainerSchedule(c *gin.Context) {
     }
     newSchedule, err := services.CreateTrainerSchedule(trainerSchedule)
     if err != nil {
+        if respondConflict(c, err) {
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
@@ -82,6 +86,13 @@ func UpdateTrainerSchedule(c *gin.Context) {
     }
     updated, err := services.UpdateSchedule(uint(id), trainerSchedule)
     if err != nil {
+        if respondConflict(c, err) {
+            return
+        }
+        if errors.Is(err, services.ErrScheduleBooked) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
@@ -97,12 +108,47 @@ func DeleteTrainerSchedule(c *gin.Context) {
     }
     err = services.DeleteSchedule(uint(id))
     if err != nil {
+        if errors.Is(err, services.ErrScheduleBooked) {
+            c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
         return
     }
     c.JSON(http.StatusOK, gin.H{"message": "ลบตารางเวลาสำเร็จ"})
 }
 
+// POST /trainer-schedules/:id/reschedule
+// เลื่อนเวลาตารางที่มีผู้จองแล้ว พร้อมแจ้งเตือนลูกค้า
+func RescheduleTrainerSchedule(c *gin.Context) {
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+        return
+    }
+    var req struct {
+        StartTime time.Time `json:"start_time"`
+        EndTime   time.Time `json:"end_time"`
+        Reason    string    `json:"reason"`
+    }
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    schedule, err := services.RescheduleTrainerSchedule(uint(id), req.StartTime, req.EndTime, req.Reason)
+    if err != nil {
+        if respondConflict(c, err) {
+            return
+        }
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
+    c.JSON(http.StatusOK, gin.H{
+        "message": "เลื่อนตารางเวลาสำเร็จ",
+        "data":    schedule,
+    })
+}
+
 // GET /trainer-schedules/trainer/:trainerId/date?date=YYYY-MM-DD
 func GetTrainerSchedulesByDate(c *gin.Context) {
     trainerIdStr := c.Param("trainerId")
@@ -132,3 +178,13 @@ func GetTrainerSchedulesByDate(c *gin.Context) {
 
     c.JSON(http.StatusOK, schedules)
 }
+
+// respondConflict ส่ง 409 พร้อมรายการที่ชนกัน หาก err เป็น ConflictError
+func respondConflict(c *gin.Context, err error) bool {
+    var conflictErr *services.ConflictError
+    if !errors.As(err, &conflictErr) {
+        return false
+    }
+    c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": conflictErr.Conflicts})
+    return true
+}
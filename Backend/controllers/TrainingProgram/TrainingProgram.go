@@ -0,0 +1,281 @@
+package TrainingProgram
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// POST /training-programs
+// เทรนเนอร์สร้างโปรแกรมหรือต้นแบบใหม่ พร้อมช่วง เซสชัน และท่าฝึก
+func CreateTrainingProgram(c *gin.Context) {
+	actor, userID := currentActor(c)
+	if actor != "trainer" && actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเทรนเนอร์หรือผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+
+	var program entity.TrainingProgram
+	if err := c.ShouldBindJSON(&program); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	if actor == "trainer" {
+		program.TrainerID = userID
+	}
+
+	created, err := services.CreateTrainingProgram(program)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "สร้างโปรแกรมการฝึกสำเร็จ",
+		"data":    created,
+	})
+}
+
+// GET /training-programs?templates=true&user_id=
+// เทรนเนอร์เห็นโปรแกรมของตน ลูกค้าเห็นเฉพาะโปรแกรมที่ได้รับมอบหมาย
+func GetTrainingPrograms(c *gin.Context) {
+	actor, userID := currentActor(c)
+	filter := services.TrainingProgramFilter{TemplatesOnly: c.Query("templates") == "true"}
+
+	switch actor {
+	case "customer":
+		filter.UserID = userID
+		filter.TemplatesOnly = false
+	case "trainer":
+		filter.TrainerID = userID
+	}
+	if id, err := strconv.Atoi(c.Query("user_id")); err == nil && actor != "customer" {
+		filter.UserID = uint(id)
+	}
+
+	programs, err := services.ListTrainingPrograms(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลโปรแกรมการฝึกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, programs)
+}
+
+// GET /training-programs/:id
+func GetTrainingProgramByID(c *gin.Context) {
+	program, ok := loadProgram(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, program)
+}
+
+// PUT /training-programs/:id
+// ส่งโครงสร้างทั้งหมดของโปรแกรมมาใหม่ หากโปรแกรมมอบหมายให้ลูกค้าแล้วจะได้เวอร์ชันใหม่
+func UpdateTrainingProgram(c *gin.Context) {
+	program, ok := loadProgram(c, true)
+	if !ok {
+		return
+	}
+
+	var input entity.TrainingProgram
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+
+	revised, err := services.ReviseTrainingProgram(program.ID, input)
+	if err != nil {
+		if errors.Is(err, services.ErrProgramSuperseded) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "อัปเดตโปรแกรมการฝึกสำเร็จ",
+		"data":    revised,
+	})
+}
+
+// POST /training-programs/:id/copy
+// คัดลอกโปรแกรมเป็นต้นแบบใหม่ของเทรนเนอร์ที่ล็อกอินอยู่
+func CopyTrainingProgram(c *gin.Context) {
+	copyProgram(c, false)
+}
+
+// POST /training-programs/:id/assign
+// มอบหมายโปรแกรม (คัดลอกจากต้นแบบ) ให้ลูกค้า รับ user_id และ start_date (YYYY-MM-DD)
+func AssignTrainingProgram(c *gin.Context) {
+	copyProgram(c, true)
+}
+
+// GET /training-programs/:id/versions
+func GetTrainingProgramVersions(c *gin.Context) {
+	program, ok := loadProgram(c, false)
+	if !ok {
+		return
+	}
+	versions, err := services.GetTrainingProgramVersions(program.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงประวัติเวอร์ชันได้"})
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// DELETE /training-programs/:id
+func DeleteTrainingProgram(c *gin.Context) {
+	program, ok := loadProgram(c, true)
+	if !ok {
+		return
+	}
+	if err := services.DeleteTrainingProgram(program.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบโปรแกรมการฝึกได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบโปรแกรมการฝึกสำเร็จ"})
+}
+
+// PUT /training-programs/sessions/:id/complete
+// ลูกค้าหรือเทรนเนอร์บันทึกว่าเซสชันทำเสร็จแล้ว ({"completed": false} เพื่อยกเลิก)
+func CompleteProgramSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสเซสชันไม่ถูกต้อง"})
+		return
+	}
+	req := struct {
+		Completed *bool `json:"completed"`
+	}{}
+	_ = c.ShouldBindJSON(&req)
+	completed := req.Completed == nil || *req.Completed
+
+	program, err := services.GetProgramOfSession(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบเซสชัน"})
+		return
+	}
+	if !canAccess(c, program, false) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์เข้าถึงโปรแกรมนี้"})
+		return
+	}
+	if program.Status == "superseded" {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrProgramSuperseded.Error()})
+		return
+	}
+
+	session, err := services.SetProgramSessionCompleted(uint(id), completed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "บันทึกสถานะเซสชันสำเร็จ", "data": session})
+}
+
+func copyProgram(c *gin.Context, assign bool) {
+	source, ok := loadProgram(c, false)
+	if !ok {
+		return
+	}
+	actor, userID := currentActor(c)
+	if actor != "trainer" && actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเทรนเนอร์หรือผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+
+	var req struct {
+		UserID    uint   `json:"user_id"`
+		StartDate string `json:"start_date"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && assign {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+
+	var customerID *uint
+	var startDate *time.Time
+	if assign {
+		if req.UserID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user_id ไม่ถูกต้อง"})
+			return
+		}
+		customerID = &req.UserID
+		if req.StartDate != "" {
+			parsed, err := time.Parse("2006-01-02", req.StartDate)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)"})
+				return
+			}
+			startDate = &parsed
+		}
+	}
+
+	trainerID := uint(0)
+	if actor == "trainer" {
+		trainerID = userID
+	}
+	program, err := services.CopyTrainingProgram(source.ID, trainerID, customerID, startDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "คัดลอกโปรแกรมการฝึกสำเร็จ"
+	if assign {
+		message = "มอบหมายโปรแกรมการฝึกให้ลูกค้าสำเร็จ"
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": message, "data": program})
+}
+
+// loadProgram ดึงโปรแกรมจาก :id และตรวจสิทธิ์ (manage = true คือต้องเป็นเจ้าของโปรแกรม)
+func loadProgram(c *gin.Context, manage bool) (entity.TrainingProgram, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสโปรแกรมไม่ถูกต้อง"})
+		return entity.TrainingProgram{}, false
+	}
+	program, err := services.GetTrainingProgram(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบโปรแกรมการฝึก"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return program, false
+	}
+	if !canAccess(c, program, manage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์เข้าถึงโปรแกรมนี้"})
+		return program, false
+	}
+	return program, true
+}
+
+// canAccess ผู้ดูแลระบบเข้าถึงได้ทั้งหมด เทรนเนอร์จัดการโปรแกรมของตนและดูต้นแบบของผู้อื่นได้
+// ลูกค้าดูได้เฉพาะโปรแกรมที่ได้รับมอบหมาย
+func canAccess(c *gin.Context, program entity.TrainingProgram, manage bool) bool {
+	actor, userID := currentActor(c)
+	switch actor {
+	case "admin":
+		return true
+	case "trainer":
+		return program.TrainerID == userID || (!manage && program.IsTemplate)
+	case "customer":
+		return !manage && program.UserID != nil && *program.UserID == userID
+	}
+	return false
+}
+
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userID, _ := c.Get("user_id")
+	id, _ := userID.(uint)
+	name, _ := actor.(string)
+	return name, id
+}
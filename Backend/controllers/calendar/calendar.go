@@ -0,0 +1,81 @@
+package calendar
+
+import (
+	"net/http"
+	"strings"
+
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// POST /calendar/token
+// ออก (หรือเปลี่ยนด้วย ?rotate=true) โทเค็นฟีดปฏิทินของผู้ใช้ที่ล็อกอินอยู่
+func IssueToken(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	actor, _ := c.Get("actor")
+	actorStr, _ := actor.(string)
+
+	token, err := services.IssueCalendarToken(actorStr, userID.(uint), c.Query("rotate") == "true")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":    token.Token,
+		"feed_url": "/calendar/" + token.Token + ".ics",
+	})
+}
+
+// GET /calendar/:token (public, เช่น /calendar/<token>.ics)
+// แอปปฏิทินส่ง Authorization header ไม่ได้ จึงใช้โทเค็นลับใน URL แทน
+func Feed(c *gin.Context) {
+	secret := strings.TrimSuffix(c.Param("token"), ".ics")
+	body, err := services.BuildCalendarFeed(secret)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบฟีดปฏิทิน"})
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="fitness.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", []byte(body))
+}
+
+// POST /calendar/import (multipart form-data key "file")
+// นำเข้าช่วงเวลาไม่ว่างของเทรนเนอร์จากไฟล์ .ics เป็นช่วงเวลาที่ปิดการจอง
+func ImportBusyTimes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	if actor, _ := c.Get("actor"); actor != "trainer" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเทรนเนอร์เท่านั้นที่สามารถนำเข้าปฏิทินได้"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาเลือกไฟล์ .ics"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถอ่านไฟล์ได้"})
+		return
+	}
+	defer file.Close()
+
+	result, err := services.ImportTrainerBusyTimes(userID.(uint), file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "นำเข้าปฏิทินสำเร็จ",
+		"data":    result,
+	})
+}
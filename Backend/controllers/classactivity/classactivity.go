@@ -1,6 +1,7 @@
 package classactivity
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
@@ -9,12 +10,13 @@ import (
 
 	"example.com/fitness-backend/config"
 	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
 )
 
 func GetAll(c *gin.Context) {
 	var items []entity.ClassActivity
 	db := config.DB()
-	result := db.Preload("Reviews.User").Find(&items)
+	result := db.Preload("Reviews.User").Preload("Facility").Preload("Trainer").Find(&items)
 	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
 		return
@@ -26,6 +28,7 @@ func GetAll(c *gin.Context) {
 			Where("class_activity_id = ? AND status <> ?", items[i].ID, "Cancelled").
 			Count(&count)
 		items[i].CurrentParticipants = int(count)
+		hideTrainerPassword(&items[i])
 	}
 	c.JSON(http.StatusOK, items)
 }
@@ -34,7 +37,7 @@ func Get(c *gin.Context) {
 	id := c.Param("id")
 	var item entity.ClassActivity
 	db := config.DB()
-	result := db.Preload("Reviews.User").First(&item, id)
+	result := db.Preload("Reviews.User").Preload("Facility").Preload("Trainer").First(&item, id)
 	if result.Error != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
@@ -45,6 +48,7 @@ func Get(c *gin.Context) {
 		Where("class_activity_id = ? AND status <> ?", item.ID, "Cancelled").
 		Count(&count)
 	item.CurrentParticipants = int(count)
+	hideTrainerPassword(&item)
 	c.JSON(http.StatusOK, item)
 }
 
@@ -56,6 +60,13 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	// ตรวจสอบสถานที่ เทรนเนอร์ ความจุ และเวลาชนก่อนบันทึก
+	payload.Facility, payload.Trainer = nil, nil
+	if err := services.ValidateClassActivity(&payload); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
 	// เพิ่มโค้ดสำหรับอัปโหลดไฟล์
 	if imageFile, err := c.FormFile("image"); err == nil {
 		fileName := filepath.Base(imageFile.Filename)
@@ -90,6 +101,12 @@ func Update(c *gin.Context) {
 		return
 	}
 
+	existing.Facility, existing.Trainer = nil, nil
+	if err := services.ValidateClassActivity(&existing); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
 	if imageFile, err := c.FormFile("image"); err == nil {
 		fileName := filepath.Base(imageFile.Filename)
 		dst := filepath.Join("./uploads/class", fileName)
@@ -117,6 +134,23 @@ func Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// respondValidationError ส่ง 409 พร้อมรายการที่ชนกัน หรือ 400 สำหรับข้อผิดพลาดอื่น
+func respondValidationError(c *gin.Context, err error) {
+	var conflictErr *services.ConflictError
+	if errors.As(err, &conflictErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "conflicts": conflictErr.Conflicts})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// hideTrainerPassword ไม่ส่งคืนรหัสผ่านของเทรนเนอร์ผู้สอน
+func hideTrainerPassword(item *entity.ClassActivity) {
+	if item.Trainer != nil {
+		item.Trainer.Password = ""
+	}
+}
+
 // ฟังก์ชัน UploadImage ที่ถูกต้อง
 func UploadImage(c *gin.Context) {
 	file, err := c.FormFile("image")
@@ -0,0 +1,88 @@
+package entitlement
+
+import (
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /entitlements?user_id=
+// สิทธิ์ตามแพ็กเกจปัจจุบันและจำนวนที่ใช้ไปในรอบนี้ (ลูกค้าดูของตัวเอง แอดมินระบุ user_id ได้)
+func GetSummary(c *gin.Context) {
+	actor, userID := currentActor(c)
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		if actor != "admin" && uint(id) != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์ดูสิทธิ์การใช้งานของผู้ใช้นี้"})
+			return
+		}
+		userID = uint(id)
+	} else if actor != "customer" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ต้องระบุ user_id"})
+		return
+	}
+
+	summary, err := services.GetEntitlementSummary(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// GET /packages/:id/entitlements
+func GetPackageEntitlements(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid package id"})
+		return
+	}
+	rows, err := services.GetPackageEntitlements(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// PUT /packages/:id/entitlements
+// แทนที่สิทธิ์ทั้งหมดของแพ็กเกจ (แอดมินเท่านั้น) body: {"entitlements": [{"capability", "monthly_limit"}]}
+func SetPackageEntitlements(c *gin.Context) {
+	if actor, _ := currentActor(c); actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid package id"})
+		return
+	}
+	var body struct {
+		Entitlements []entity.PackageEntitlement `json:"entitlements"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	rows, err := services.SetPackageEntitlements(uint(id), body.Entitlements)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "บันทึกสิทธิ์ของแพ็กเกจสำเร็จ", "data": rows})
+}
+
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userIDRaw, _ := c.Get("user_id")
+	role, _ := actor.(string)
+	userID, _ := userIDRaw.(uint)
+	return role, userID
+}
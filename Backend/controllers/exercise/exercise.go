@@ -0,0 +1,264 @@
+package exercise
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+)
+
+// ขนาดไฟล์สื่อสาธิตสูงสุด
+const maxMediaBytes = 50 << 20
+
+var mediaKinds = map[string]string{
+	".jpg": "image", ".jpeg": "image", ".png": "image", ".gif": "image", ".webp": "image",
+	".mp4": "video", ".webm": "video", ".mov": "video",
+}
+
+// GET /exercises?q=&muscle=&pattern=&equipment=&difficulty=
+func GetAll(c *gin.Context) {
+	items, err := services.SearchExercises(filterFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// GET /exercises/:id
+func Get(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	item, err := services.GetExercise(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบท่าฝึก"})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// POST /exercises
+func Create(c *gin.Context) {
+	if !canManage(c) {
+		return
+	}
+	var payload entity.Exercise
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	item, err := services.CreateExercise(payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, item)
+}
+
+// PUT /exercises/:id
+func Update(c *gin.Context) {
+	if !canManage(c) {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	var payload entity.Exercise
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	item, err := services.UpdateExercise(id, payload)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
+
+// DELETE /exercises/:id
+func Delete(c *gin.Context) {
+	if !canManage(c) {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	if err := services.DeleteExercise(id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /exercises/:id/media
+// อัปโหลดรูปหรือวิดีโอสาธิต (form-data key "file") ชนิดไฟล์ดูจากนามสกุล
+func UploadMedia(c *gin.Context) {
+	if !canManage(c) {
+		return
+	}
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาเลือกไฟล์"})
+		return
+	}
+	kind, ok := mediaKinds[strings.ToLower(filepath.Ext(file.Filename))]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รองรับเฉพาะไฟล์รูป (jpg, png, gif, webp) หรือวิดีโอ (mp4, webm, mov)"})
+		return
+	}
+	if file.Size > maxMediaBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไฟล์มีขนาดใหญ่เกิน 50MB"})
+		return
+	}
+	if _, err := services.GetExercise(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบท่าฝึก"})
+		return
+	}
+
+	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
+	dirPath := filepath.Join("uploads", "exercises")
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถสร้างโฟลเดอร์จัดเก็บไฟล์ได้"})
+		return
+	}
+	if err := c.SaveUploadedFile(file, filepath.Join(dirPath, filename)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถบันทึกไฟล์ได้"})
+		return
+	}
+
+	item, err := services.SetExerciseMedia(id, kind, "/uploads/exercises/"+filename)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "อัปโหลดไฟล์สำเร็จ", "data": item})
+}
+
+// GET /exercises/export?format=csv|json (กรองได้เหมือน GET /exercises)
+func Export(c *gin.Context) {
+	items, err := services.SearchExercises(filterFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if c.DefaultQuery("format", "json") == "csv" {
+		var buf bytes.Buffer
+		if err := services.ExportExercisesCSV(&buf, items); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="exercises.csv"`)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+
+	// ไม่ส่งรหัสและเวลาภายในระบบ เพื่อให้ยิมอื่นนำเข้าได้ทันที
+	for i := range items {
+		items[i].Model = gorm.Model{}
+	}
+	c.Header("Content-Disposition", `attachment; filename="exercises.json"`)
+	c.JSON(http.StatusOK, items)
+}
+
+// POST /exercises/import
+// รับไฟล์ (form-data key "file") นามสกุล .csv หรือ .json หรือส่ง JSON array มาใน body
+func Import(c *gin.Context) {
+	if !canManage(c) {
+		return
+	}
+
+	var reader io.Reader = c.Request.Body
+	format := "json"
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถอ่านไฟล์ได้"})
+			return
+		}
+		defer f.Close()
+		reader = f
+		if strings.EqualFold(filepath.Ext(file.Filename), ".csv") {
+			format = "csv"
+		}
+	} else if strings.HasPrefix(c.ContentType(), "text/csv") {
+		format = "csv"
+	}
+
+	var items []entity.Exercise
+	var err error
+	if format == "csv" {
+		items, err = services.ParseExercisesCSV(reader)
+	} else {
+		items, err = services.ParseExercisesJSON(reader)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := services.ImportExercises(items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "result": result})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "นำเข้าคลังท่าฝึกสำเร็จ", "result": result})
+}
+
+func filterFromQuery(c *gin.Context) services.ExerciseFilter {
+	return services.ExerciseFilter{
+		Query:           c.Query("q"),
+		Muscle:          c.Query("muscle"),
+		MovementPattern: c.Query("pattern"),
+		EquipmentType:   c.Query("equipment"),
+		Difficulty:      c.Query("difficulty"),
+	}
+}
+
+// canManage เฉพาะผู้ดูแลระบบและเทรนเนอร์ที่แก้ไขคลังท่าฝึกได้
+func canManage(c *gin.Context) bool {
+	actor, _ := c.Get("actor")
+	if actor == "admin" || actor == "trainer" {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเทรนเนอร์หรือผู้ดูแลระบบเท่านั้น"})
+	return false
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสท่าฝึกไม่ถูกต้อง"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func respondError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบท่าฝึก"})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,43 @@
+package facility
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// POST /facilities/:id/check-in
+// ลูกค้าเข้าใช้สถานที่ ตรวจสิทธิ์ตามแพ็กเกจก่อนบันทึก
+func CheckIn(c *gin.Context) {
+	actor, _ := c.Get("actor")
+	userIDRaw, _ := c.Get("user_id")
+	userID, _ := userIDRaw.(uint)
+	if actor != "customer" || userID == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะสมาชิกเท่านั้น"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	entry, err := services.EnterFacility(userID, uint(id))
+	var entitlementErr *services.EntitlementError
+	switch {
+	case errors.As(err, &entitlementErr):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "upgrade_required", "entitlement": entitlementErr})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "id not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "เข้าใช้สถานที่สำเร็จ", "data": entry})
+}
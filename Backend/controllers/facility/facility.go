@@ -58,6 +58,13 @@ func Update(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "bad payload"})
         return
     }
+    // ห้ามลดความจุให้น้อยกว่าคลาสที่ใช้สถานที่นี้อยู่
+    var oversized []entity.ClassActivity
+    db.Where("facility_id = ? AND capacity > ?", existing.ID, existing.Capacity).Find(&oversized)
+    if len(oversized) > 0 {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "ความจุน้อยกว่าจำนวนผู้เข้าร่วมของคลาสที่ใช้สถานที่นี้", "classes": oversized})
+        return
+    }
     if err := db.Save(&existing).Error; err != nil {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
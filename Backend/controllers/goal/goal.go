@@ -0,0 +1,191 @@
+package goal
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /api/goals?user_id=&status=
+func GetAll(c *gin.Context) {
+	requested, _ := strconv.Atoi(c.Query("user_id"))
+	userID, ok := resolveGoalUser(c, uint(requested))
+	if !ok {
+		return
+	}
+	goals, err := services.GetGoals(services.GoalFilter{UserID: userID, Status: c.Query("status")})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลเป้าหมายได้"})
+		return
+	}
+	c.JSON(http.StatusOK, goals)
+}
+
+// GET /api/goals/:id
+func Get(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+	if err := services.RefreshGoal(&goal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	refreshed, err := services.GetGoal(goal.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, refreshed)
+}
+
+// POST /api/goals
+// ลูกค้าตั้งเป้าหมายของตนเอง เทรนเนอร์ตั้งให้ลูกค้าของตนโดยระบุ user_id
+func Create(c *gin.Context) {
+	var input entity.Goal
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	userID, ok := resolveGoalUser(c, input.UserID)
+	if !ok {
+		return
+	}
+	input.UserID = userID
+	input.TrainerID = nil
+	if actor, id := currentActor(c); actor == "trainer" {
+		input.TrainerID = &id
+	}
+
+	created, err := services.CreateGoal(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "สร้างเป้าหมายสำเร็จ", "data": created})
+}
+
+// PUT /api/goals/:id
+func Update(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+	var input entity.Goal
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	updated, err := services.UpdateGoal(goal.ID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "อัปเดตเป้าหมายสำเร็จ", "data": updated})
+}
+
+// DELETE /api/goals/:id
+func Delete(c *gin.Context) {
+	goal, ok := loadGoal(c)
+	if !ok {
+		return
+	}
+	if err := services.DeleteGoal(goal.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบเป้าหมายได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบเป้าหมายสำเร็จ"})
+}
+
+// GET /api/goals/clients?trainer_id=
+// สถานะเป้าหมายของลูกค้าทุกคนของเทรนเนอร์ (ผู้ดูแลระบุ trainer_id)
+func Clients(c *gin.Context) {
+	actor, trainerID := currentActor(c)
+	switch actor {
+	case "trainer":
+	case "admin":
+		id, _ := strconv.Atoi(c.Query("trainer_id"))
+		if id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ trainer_id"})
+			return
+		}
+		trainerID = uint(id)
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเทรนเนอร์หรือผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	results, err := services.GetTrainerClientGoals(trainerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลเป้าหมายได้"})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// resolveGoalUser ลูกค้าเข้าถึงได้เฉพาะเป้าหมายของตนเอง
+// เทรนเนอร์เข้าถึงได้เฉพาะลูกค้าของตน ส่วนผู้ดูแลเข้าถึงได้ทุกคน (ทั้งสองต้องระบุ user_id)
+func resolveGoalUser(c *gin.Context, requested uint) (uint, bool) {
+	actor, actorID := currentActor(c)
+	switch actor {
+	case "trainer", "admin":
+		if requested == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ user_id ของลูกค้า"})
+			return 0, false
+		}
+		if actor == "trainer" {
+			isClient, err := services.IsTrainerClient(actorID, requested)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return 0, false
+			}
+			if !isClient {
+				c.JSON(http.StatusForbidden, gin.H{"error": "ผู้ใช้นี้ไม่ใช่ลูกค้าของคุณ"})
+				return 0, false
+			}
+		}
+		return requested, true
+	}
+	if actorID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ไม่พบข้อมูลผู้ใช้"})
+		return 0, false
+	}
+	if requested != 0 && requested != actorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์เข้าถึงข้อมูลของผู้ใช้อื่น"})
+		return 0, false
+	}
+	return actorID, true
+}
+
+func loadGoal(c *gin.Context) (entity.Goal, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสเป้าหมายไม่ถูกต้อง"})
+		return entity.Goal{}, false
+	}
+	goal, err := services.GetGoal(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบเป้าหมาย"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return goal, false
+	}
+	if _, ok := resolveGoalUser(c, goal.UserID); !ok {
+		return goal, false
+	}
+	return goal, true
+}
+
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userID, _ := c.Get("user_id")
+	actorStr, _ := actor.(string)
+	id, _ := userID.(uint)
+	return actorStr, id
+}
@@ -0,0 +1,196 @@
+package invoice
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /invoices?user_id=
+// ใบเสร็จ/ใบกำกับภาษีและใบลดหนี้ของผู้ใช้ (แอดมินระบุ user_id ได้)
+func GetInvoices(c *gin.Context) {
+	actor, userID := currentActor(c)
+	if raw := c.Query("user_id"); raw != "" && actor == "admin" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		userID = uint(id)
+	}
+	invoices, err := services.GetUserInvoices(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": invoices})
+}
+
+// GET /invoices/:id
+func GetInvoice(c *gin.Context) {
+	invoice, ok := loadInvoice(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": invoice})
+}
+
+// GET /invoices/:id/pdf?lang=th|en
+func DownloadPDF(c *gin.Context) {
+	invoice, ok := loadInvoice(c)
+	if !ok {
+		return
+	}
+	lang := c.DefaultQuery("lang", "th")
+	data, err := services.RenderInvoicePDF(invoice, lang)
+	if err != nil {
+		if errors.Is(err, services.ErrInvoiceFontMissing) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+invoice.Number+"-"+lang+`.pdf"`)
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// POST /invoices/:id/credit-notes body: {"amount": บาท (0 หรือไม่ระบุ = เต็มยอดที่เหลือ), "reason"}
+// ออกใบลดหนี้เพื่อคืนเงิน (แอดมินเท่านั้น)
+func CreateCreditNote(c *gin.Context) {
+	if actor, _ := currentActor(c); actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var input struct {
+		Amount float64 `json:"amount"`
+		Reason string  `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	note, err := services.IssueCreditNote(uint(id), int64(math.Round(input.Amount*100)), input.Reason)
+	switch {
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "ออกใบลดหนี้สำเร็จ", "data": note})
+}
+
+// GET /billing-profile
+func GetBillingProfile(c *gin.Context) {
+	_, userID := currentActor(c)
+	profile, err := services.GetBillingProfile(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// PUT /billing-profile
+// ข้อมูลผู้ซื้อ (ชื่อ ที่อยู่ เลขประจำตัวผู้เสียภาษี) สำหรับใบกำกับภาษีเต็มรูปที่ออกหลังจากนี้
+func SaveBillingProfile(c *gin.Context) {
+	actor, userID := currentActor(c)
+	if actor != "customer" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะสมาชิกเท่านั้น"})
+		return
+	}
+	var input entity.BillingProfile
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	profile, err := services.SaveBillingProfile(userID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "บันทึกข้อมูลผู้ซื้อสำเร็จ", "data": profile})
+}
+
+// GET /branches
+func GetBranches(c *gin.Context) {
+	branches, err := services.GetBranches()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": branches})
+}
+
+// POST /branches และ PUT /branches/:id (แอดมินเท่านั้น)
+func SaveBranch(c *gin.Context) {
+	if actor, _ := currentActor(c); actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	var id uint
+	if raw := c.Param("id"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+		id = uint(parsed)
+	}
+	var input entity.Branch
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	branch, err := services.SaveBranch(id, input)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสาขา"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "บันทึกสาขาสำเร็จ", "data": branch})
+}
+
+// loadInvoice ดึงเอกสารที่ผู้ใช้เป็นเจ้าของ (แอดมินดูได้ทุกเอกสาร)
+func loadInvoice(c *gin.Context) (entity.Invoice, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return entity.Invoice{}, false
+	}
+	invoice, err := services.GetInvoice(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return invoice, false
+	}
+	if actor, userID := currentActor(c); actor != "admin" && invoice.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์ดูเอกสารนี้"})
+		return invoice, false
+	}
+	return invoice, true
+}
+
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userIDRaw, _ := c.Get("user_id")
+	role, _ := actor.(string)
+	userID, _ := userIDRaw.(uint)
+	return role, userID
+}
@@ -0,0 +1,179 @@
+package mealplan
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /api/meal-plans?user_id=&status=
+// ลูกค้าเห็นเฉพาะแผนที่มอบให้แล้ว เทรนเนอร์เห็นแผนที่ตนสร้าง ผู้ดูแลเห็นทั้งหมด
+func GetAll(c *gin.Context) {
+	actor, actorID := currentActor(c)
+	requested, _ := strconv.Atoi(c.Query("user_id"))
+	filter := services.MealPlanFilter{UserID: uint(requested), Status: c.Query("status")}
+	switch actor {
+	case "admin":
+	case "trainer":
+		filter.TrainerID = actorID
+	default:
+		if filter.UserID != 0 && filter.UserID != actorID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์เข้าถึงข้อมูลของผู้ใช้อื่น"})
+			return
+		}
+		filter.UserID = actorID
+		filter.Status = "assigned"
+	}
+	plans, err := services.GetMealPlans(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงแผนอาหารได้"})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+// GET /api/meal-plans/:id
+func Get(c *gin.Context) {
+	plan, ok := loadMealPlan(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// POST /api/meal-plans/generate
+// เทรนเนอร์สร้างแผนอาหารฉบับร่างให้ลูกค้าจากแผนโภชนาการและข้อจำกัดด้านอาหาร
+func Generate(c *gin.Context) {
+	var req services.MealPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	if !canManageClient(c, req.UserID) {
+		return
+	}
+	var trainerID *uint
+	if actor, id := currentActor(c); actor == "trainer" {
+		trainerID = &id
+	}
+	plan, err := services.GenerateMealPlan(req, trainerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "สร้างแผนอาหารสำเร็จ", "data": plan})
+}
+
+// PUT /api/meal-plans/:id
+// แก้ไขชื่อ หมายเหตุ หรือส่ง items เพื่อแทนรายการอาหารทั้งหมด
+func Update(c *gin.Context) {
+	existing, ok := loadMealPlan(c, true)
+	if !ok {
+		return
+	}
+	var input entity.MealPlan
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	plan, err := services.UpdateMealPlan(existing.ID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "อัปเดตแผนอาหารสำเร็จ", "data": plan})
+}
+
+// POST /api/meal-plans/:id/assign
+func Assign(c *gin.Context) {
+	existing, ok := loadMealPlan(c, true)
+	if !ok {
+		return
+	}
+	plan, err := services.AssignMealPlan(existing.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "มอบแผนอาหารให้ลูกค้าสำเร็จ", "data": plan})
+}
+
+// DELETE /api/meal-plans/:id
+func Delete(c *gin.Context) {
+	existing, ok := loadMealPlan(c, true)
+	if !ok {
+		return
+	}
+	if err := services.DeleteMealPlan(existing.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบแผนอาหารได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบแผนอาหารสำเร็จ"})
+}
+
+// canManageClient เทรนเนอร์จัดการแผนได้เฉพาะลูกค้าของตน ผู้ดูแลจัดการได้ทุกคน
+func canManageClient(c *gin.Context, userID uint) bool {
+	actor, actorID := currentActor(c)
+	switch actor {
+	case "admin":
+	case "trainer":
+		if userID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ user_id ของลูกค้า"})
+			return false
+		}
+		isClient, err := services.IsTrainerClient(actorID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+		if !isClient {
+			c.JSON(http.StatusForbidden, gin.H{"error": "ผู้ใช้นี้ไม่ใช่ลูกค้าของคุณ"})
+			return false
+		}
+		return true
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเทรนเนอร์หรือผู้ดูแลระบบเท่านั้น"})
+		return false
+	}
+	if userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ user_id ของลูกค้า"})
+		return false
+	}
+	return true
+}
+
+// loadMealPlan ดึงแผนตาม :id ลูกค้าดูได้เฉพาะแผนของตนที่มอบให้แล้ว
+// manage = true ต้องเป็นผู้ดูแล หรือเทรนเนอร์ที่ดูแลลูกค้าคนนั้น
+func loadMealPlan(c *gin.Context, manage bool) (entity.MealPlan, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสแผนอาหารไม่ถูกต้อง"})
+		return entity.MealPlan{}, false
+	}
+	plan, err := services.GetMealPlan(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบแผนอาหาร"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return plan, false
+	}
+	actor, actorID := currentActor(c)
+	if actor != "trainer" && actor != "admin" {
+		if manage || plan.UserID != actorID || plan.Status != "assigned" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบแผนอาหาร"})
+			return plan, false
+		}
+		return plan, true
+	}
+	if !canManageClient(c, plan.UserID) {
+		return plan, false
+	}
+	return plan, true
+}
@@ -0,0 +1,139 @@
+package mealplan
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GET /api/recipes?q=&meal_type=&exclude=vegetarian,peanut
+func GetRecipes(c *gin.Context) {
+	recipes, err := services.GetRecipes(services.RecipeFilter{
+		Query:      c.Query("q"),
+		MealType:   c.Query("meal_type"),
+		Exclusions: splitList(c.Query("exclude")),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, recipes)
+}
+
+// GET /api/recipes/:id
+func GetRecipe(c *gin.Context) {
+	recipe, ok := loadRecipe(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, recipe)
+}
+
+// POST /api/recipes
+// เทรนเนอร์และผู้ดูแลเพิ่มสูตรอาหารได้ ค่าสารอาหารคำนวณจากส่วนผสม
+func CreateRecipe(c *gin.Context) {
+	actor, actorID := currentActor(c)
+	if actor != "trainer" && actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเทรนเนอร์หรือผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	var input entity.Recipe
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	var trainerID *uint
+	if actor == "trainer" {
+		trainerID = &actorID
+	}
+	recipe, err := services.CreateRecipe(input, trainerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "เพิ่มสูตรอาหารสำเร็จ", "data": recipe})
+}
+
+// PUT /api/recipes/:id
+func UpdateRecipe(c *gin.Context) {
+	existing, ok := loadRecipe(c, true)
+	if !ok {
+		return
+	}
+	var input entity.Recipe
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	recipe, err := services.UpdateRecipe(existing.ID, input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "อัปเดตสูตรอาหารสำเร็จ", "data": recipe})
+}
+
+// DELETE /api/recipes/:id
+func DeleteRecipe(c *gin.Context) {
+	existing, ok := loadRecipe(c, true)
+	if !ok {
+		return
+	}
+	if err := services.DeleteRecipe(existing.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบสูตรอาหารได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบสูตรอาหารสำเร็จ"})
+}
+
+// loadRecipe ดึงสูตรตาม :id ถ้า manage = true ต้องเป็นผู้ดูแล หรือเทรนเนอร์ที่สร้างสูตรนั้น
+func loadRecipe(c *gin.Context, manage bool) (entity.Recipe, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสสูตรอาหารไม่ถูกต้อง"})
+		return entity.Recipe{}, false
+	}
+	recipe, err := services.GetRecipe(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบสูตรอาหาร"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return recipe, false
+	}
+	if !manage {
+		return recipe, true
+	}
+	actor, actorID := currentActor(c)
+	owner := recipe.CreatedByTrainerID != nil && *recipe.CreatedByTrainerID == actorID
+	if actor != "admin" && !(actor == "trainer" && owner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "แก้ไขได้เฉพาะสูตรที่คุณสร้างเท่านั้น"})
+		return recipe, false
+	}
+	return recipe, true
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userID, _ := c.Get("user_id")
+	actorStr, _ := actor.(string)
+	id, _ := userID.(uint)
+	return actorStr, id
+}
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /notifications
+func GetAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	notifications, err := services.GetNotifications(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลการแจ้งเตือนได้"})
+		return
+	}
+	c.JSON(http.StatusOK, notifications)
+}
+
+// PUT /notifications/:id/read
+func MarkRead(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	if err := services.MarkNotificationRead(uint(id), userID.(uint)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบการแจ้งเตือน"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "อ่านการแจ้งเตือนแล้ว"})
+}
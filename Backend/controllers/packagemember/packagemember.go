@@ -1,10 +1,14 @@
 package packagemember
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
 	"example.com/fitness-backend/config"
 	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
 	"github.com/gin-gonic/gin"
 )
 
@@ -29,142 +33,254 @@ func Get(c *gin.Context) {
 }
 
 // GetByUserID ฟังก์ชันสำหรับดึงข้อมูล PackageMember ตาม UserID
+// data คือรอบที่ใช้งานอยู่ (active/frozen) และ history คือทุกรอบเรียงจากใหม่ไปเก่า
 func GetByUserID(c *gin.Context) {
-	var packageMembers []entity.PackageMember
-	userID := c.Param("user_id")
-
-	config.DB().Preload("Username").Preload("Package").Where("user_id = ?", userID).Find(&packageMembers)
-	c.JSON(http.StatusOK, gin.H{"data": packageMembers})
+	userID, ok := memberUserID(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+	history, err := services.GetSubscriptionHistory(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	current := []entity.PackageMember{}
+	for _, m := range history {
+		if m.Status == "active" || m.Status == "frozen" {
+			current = append(current, m)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": current, "history": history})
 }
 
-// Create ฟังก์ชันสำหรับสร้าง PackageMember ใหม่
+// Create ฟังก์ชันสำหรับสมัครแพ็กเกจใหม่: สร้างคำสั่งซื้อรอชำระเงิน
+// แพ็กเกจจะเริ่มใช้งานเมื่อชำระเงินสำเร็จ (ดู /payments/orders)
 func Create(c *gin.Context) {
-	var packageMember entity.PackageMember
-	if err := c.ShouldBindJSON(&packageMember); err != nil {
+	var input struct {
+		UserID    uint   `json:"user_id"`
+		PackageID uint   `json:"package_id"`
+		AutoRenew bool   `json:"auto_renew"`
+		Method    string `json:"method"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// ตรวจสอบว่า user_id และ package_id ซ้ำกันหรือไม่
-	var existingPackageMember entity.PackageMember
-	if err := config.DB().Where("user_id = ? AND package_id = ?", packageMember.UserID, packageMember.PackageID).First(&existingPackageMember).Error; err == nil {
-		// พบข้อมูลซ้ำ
-		c.JSON(http.StatusOK, gin.H{
-			"data":    packageMember,
-			"error":   "duplicate user_id and package_id",
-			"message": "User already has this package",
-		})
+	if input.UserID == 0 {
+		if id, ok := c.Get("user_id"); ok {
+			input.UserID, _ = id.(uint)
+		}
+	}
+	userID, ok := memberUserID(c, strconv.FormatUint(uint64(input.UserID), 10))
+	if !ok {
 		return
 	}
 
-	// สร้างข้อมูลใหม่
-	if err := config.DB().Create(&packageMember).Error; err != nil {
+	order, existing, err := services.CreatePaymentOrder(c.Request.Context(), userID, input.PackageID, input.Method, input.AutoRenew, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		if errors.Is(err, services.ErrActiveSubscriptionExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "message": "User already has an active package"})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"data": packageMember})
+	status := http.StatusCreated
+	if existing {
+		status = http.StatusOK
+	}
+	c.JSON(status, gin.H{"message": "สร้างคำสั่งซื้อแล้ว กรุณาชำระเงินเพื่อเริ่มใช้งานแพ็กเกจ", "order": order})
 }
 
-// Update ฟังก์ชันสำหรับอัปเดตข้อมูล PackageMember
-func Update(c *gin.Context) {
-	var packageMember entity.PackageMember
-	id := c.Param("id")
-
-	if err := config.DB().Where("id = ?", id).First(&packageMember).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "PackageMember not found!"})
+// DeleteByUserID ฟังก์ชันสำหรับยกเลิกแพ็กเกจตาม UserID
+// ไม่ลบข้อมูลแล้ว แต่เปลี่ยนสถานะเป็น cancelled เพื่อเก็บประวัติ
+// ?at_period_end=true ใช้ต่อได้จนหมดรอบแต่ไม่ต่ออายุ
+func DeleteByUserID(c *gin.Context) {
+	userID, ok := memberUserID(c, c.Param("user_id"))
+	if !ok {
 		return
 	}
+	packageMember, err := services.CancelSubscription(userID, c.Query("at_period_end") == "true")
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": "PackageMember cancelled successfully", "package_member": packageMember})
+}
 
-	if err := c.ShouldBindJSON(&packageMember); err != nil {
+// UpdateByUserID ฟังก์ชันสำหรับเปลี่ยนแพ็กเกจ (อัปเกรด/ดาวน์เกรด) ตาม UserID: สร้างคำสั่งซื้อรอชำระเงิน
+// มูลค่ารอบเดิมที่เหลือเป็นเครดิตหักจากราคาแพ็กเกจใหม่ แพ็กเกจเปลี่ยนเมื่อชำระเงินสำเร็จ
+// (ถ้าเครดิตครอบคลุมราคาใหม่จะเปลี่ยนทันที)
+func UpdateByUserID(c *gin.Context) {
+	userID, ok := memberUserID(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+	var updateData struct {
+		PackageID uint   `json:"package_id"`
+		Method    string `json:"method"`
+	}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	config.DB().Save(&packageMember)
-	c.JSON(http.StatusOK, gin.H{"data": packageMember})
+	order, quote, existing, err := services.CreateChangeOrder(c.Request.Context(), userID, updateData.PackageID, updateData.Method, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+	respondOrder(c, order, existing, gin.H{"proration": quote})
 }
 
-// Delete ฟังก์ชันสำหรับลบข้อมูล PackageMember
-func Delete(c *gin.Context) {
-	var packageMember entity.PackageMember
-	id := c.Param("id")
-
-	if err := config.DB().Where("id = ?", id).First(&packageMember).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "PackageMember not found!"})
+// QuoteChangeByUserID ฟังก์ชันสำหรับดูยอดชำระก่อนเปลี่ยนแพ็กเกจ (?package_id=)
+func QuoteChangeByUserID(c *gin.Context) {
+	userID, ok := memberUserID(c, c.Param("user_id"))
+	if !ok {
 		return
 	}
-
-	config.DB().Delete(&packageMember)
-	c.JSON(http.StatusOK, gin.H{"data": "PackageMember deleted successfully"})
+	packageID, _ := strconv.Atoi(c.Query("package_id"))
+	quote, err := services.QuoteSubscriptionChange(userID, uint(packageID))
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": quote})
 }
 
-// DeleteByUserID ฟังก์ชันสำหรับลบข้อมูล PackageMember ตาม UserID
-func DeleteByUserID(c *gin.Context) {
-	var packageMembers []entity.PackageMember
-	userID := c.Param("user_id")
-
-	// หาข้อมูล PackageMember ที่มี user_id ตรงกัน (รวม soft deleted)
-	if err := config.DB().Unscoped().Where("user_id = ?", userID).Find(&packageMembers).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "PackageMember not found!"})
+// RenewByUserID ฟังก์ชันสำหรับต่ออายุแพ็กเกจเดิมอีกหนึ่งรอบ: สร้างคำสั่งซื้อรอชำระเงิน (body: {"method": "counter"} ไม่บังคับ)
+// รอบใหม่เริ่มเมื่อชำระเงินสำเร็จ ถ้ามีคำสั่งซื้อต่ออายุที่รอชำระอยู่แล้วจะได้คำสั่งซื้อนั้น
+func RenewByUserID(c *gin.Context) {
+	userID, ok := memberUserID(c, c.Param("user_id"))
+	if !ok {
 		return
 	}
-
-	// ถ้าไม่พบข้อมูล
-	if len(packageMembers) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No package found for this user!"})
+	var input struct {
+		Method string `json:"method"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// ลบข้อมูลทั้งหมดที่มี user_id ตรงกัน (hard delete)
-	result := config.DB().Unscoped().Where("user_id = ?", userID).Delete(&entity.PackageMember{})
-
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete package member"})
+	order, existing, err := services.CreateRenewalOrder(c.Request.Context(), userID, input.Method, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		respondSubscriptionError(c, err)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"data": "PackageMember deleted successfully", "deleted_count": result.RowsAffected})
+	respondOrder(c, order, existing, nil)
 }
 
-// UpdateByUserID ฟังก์ชันสำหรับอัปเดต package_id ของ PackageMember ตาม UserID
-func UpdateByUserID(c *gin.Context) {
-	var packageMember entity.PackageMember
-	userID := c.Param("user_id")
+// FreezeByUserID ฟังก์ชันสำหรับพักสมาชิก (body: {"days": 14})
+func FreezeByUserID(c *gin.Context) {
+	userID, ok := memberUserID(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+	var input struct {
+		Days int `json:"days"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	packageMember, err := services.FreezeSubscription(userID, input.Days)
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": packageMember})
+}
 
-	// หาข้อมูล PackageMember ที่มี user_id ตรงกัน
-	if err := config.DB().Where("user_id = ?", userID).First(&packageMember).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "PackageMember not found!"})
+// UnfreezeByUserID ฟังก์ชันสำหรับกลับมาใช้งานก่อนครบกำหนดพัก
+func UnfreezeByUserID(c *gin.Context) {
+	userID, ok := memberUserID(c, c.Param("user_id"))
+	if !ok {
+		return
+	}
+	packageMember, err := services.UnfreezeSubscription(userID)
+	if err != nil {
+		respondSubscriptionError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": packageMember})
+}
 
-	// อัปเดต package_id
-	var updateData struct {
-		PackageID uint `json:"package_id"`
+// SetAutoRenewByUserID ฟังก์ชันสำหรับเปิด/ปิดการต่ออายุอัตโนมัติ (body: {"auto_renew": true})
+func SetAutoRenewByUserID(c *gin.Context) {
+	userID, ok := memberUserID(c, c.Param("user_id"))
+	if !ok {
+		return
 	}
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	var input struct {
+		AutoRenew bool `json:"auto_renew"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	// ตรวจสอบว่า user_id และ package_id ซ้ำกันหรือไม่
-	var existingPackageMember entity.PackageMember
-	if err := config.DB().Where("user_id = ? AND package_id = ?", userID, updateData.PackageID).First(&existingPackageMember).Error; err == nil {
-		// พบข้อมูลซ้ำ
-		c.JSON(http.StatusOK, gin.H{
-			"data":    packageMember,
-			"error":   "duplicate user_id and package_id",
-			"message": "User already has this package",
-		})
+	packageMember, err := services.SetSubscriptionAutoRenew(userID, input.AutoRenew)
+	if err != nil {
+		respondSubscriptionError(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": packageMember})
+}
 
-	// อัปเดตข้อมูล
-	packageMember.PackageID = updateData.PackageID
-	if err := config.DB().Save(&packageMember).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update package member"})
+// Process ฟังก์ชันสำหรับผู้ดูแลระบบ อัปเดตสถานะ (หมดอายุ/ต่ออายุอัตโนมัติ/ครบกำหนดพัก) ของสมาชิกทุกคน
+func Process(c *gin.Context) {
+	if actor, _ := c.Get("actor"); actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	count, err := services.ProcessSubscriptions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, gin.H{"data": "Subscriptions processed", "users": count})
+}
 
-	c.JSON(http.StatusOK, gin.H{"data": "PackageMember updated successfully", "package_member": packageMember})
+// memberUserID ลูกค้าจัดการได้เฉพาะแพ็กเกจของตนเอง ผู้ดูแลระบบจัดการให้ทุกคนได้
+func memberUserID(c *gin.Context, raw string) (uint, bool) {
+	id, err := strconv.Atoi(raw)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return 0, false
+	}
+	actor, _ := c.Get("actor")
+	userIDRaw, _ := c.Get("user_id")
+	current, _ := userIDRaw.(uint)
+	switch {
+	case actor == "admin":
+	case actor == "customer" && current == uint(id):
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์จัดการแพ็กเกจของผู้ใช้นี้"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondOrder ตอบคำสั่งซื้อที่สร้าง (201) หรือคำสั่งซื้อเดิม (200) คำสั่งซื้อที่ใช้เครดิตจนยอดเป็น 0 ชำระแล้วทันที
+func respondOrder(c *gin.Context, order entity.PaymentOrder, existing bool, extra gin.H) {
+	status := http.StatusCreated
+	if existing {
+		status = http.StatusOK
+	}
+	body := gin.H{"message": "สร้างคำสั่งซื้อแล้ว กรุณาชำระเงินเพื่อเริ่มใช้งานแพ็กเกจ", "order": order}
+	if order.Status == "paid" {
+		body["message"] = "ใช้เครดิตคงเหลือแล้ว เริ่มใช้งานแพ็กเกจ"
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.JSON(status, body)
+}
+
+func respondSubscriptionError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrNoActiveSubscription) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 }
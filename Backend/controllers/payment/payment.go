@@ -0,0 +1,203 @@
+package payment
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// POST /payments/orders
+// สร้างคำสั่งซื้อแพ็กเกจ ใส่ header Idempotency-Key เพื่อกันสร้างซ้ำเมื่อส่งคำขอซ้ำ
+// body: {"package_id", "method": "promptpay"|"counter", "auto_renew", "user_id" (แอดมิน)}
+func CreateOrder(c *gin.Context) {
+	var input struct {
+		UserID    uint   `json:"user_id"`
+		PackageID uint   `json:"package_id" binding:"required"`
+		Method    string `json:"method"`
+		AutoRenew bool   `json:"auto_renew"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	actor, userID := currentActor(c)
+	switch {
+	case actor == "admin" && input.UserID != 0:
+		userID = input.UserID
+	case actor != "customer":
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะสมาชิกหรือผู้ดูแลระบบ"})
+		return
+	}
+
+	order, existing, err := services.CreatePaymentOrder(c.Request.Context(), userID, input.PackageID, input.Method, input.AutoRenew, c.GetHeader("Idempotency-Key"))
+	if err != nil {
+		if errors.Is(err, services.ErrActiveSubscriptionExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	status := http.StatusCreated
+	if existing {
+		status = http.StatusOK
+	}
+	c.JSON(status, gin.H{"data": order})
+}
+
+// GET /payments/orders?user_id=
+func GetOrders(c *gin.Context) {
+	actor, userID := currentActor(c)
+	if raw := c.Query("user_id"); raw != "" && actor == "admin" {
+		id, err := strconv.Atoi(raw)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+		userID = uint(id)
+	}
+	orders, err := services.GetUserPaymentOrders(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": orders})
+}
+
+// GET /payments/orders/:id
+func GetOrder(c *gin.Context) {
+	order, ok := loadOrder(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": order})
+}
+
+// POST /payments/orders/:id/cancel
+func CancelOrder(c *gin.Context) {
+	order, ok := loadOrder(c)
+	if !ok {
+		return
+	}
+	order, err := services.CancelPaymentOrder(order.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ยกเลิกคำสั่งซื้อสำเร็จ", "data": order})
+}
+
+// POST /payments/orders/:id/confirm body: {"branch_id"} (ไม่บังคับ)
+// แอดมินยืนยันการรับเงิน (ชำระที่เคาน์เตอร์) ออกใบกำกับภาษีในนามสาขาที่รับเงิน แล้วเริ่มใช้งานแพ็กเกจ
+func ConfirmOrder(c *gin.Context) {
+	actor, adminID := currentActor(c)
+	if actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var input struct {
+		BranchID uint `json:"branch_id"`
+	}
+	_ = c.ShouldBindJSON(&input)
+	order, err := services.ConfirmCounterPayment(uint(id), adminID, input.BranchID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบคำสั่งซื้อ"})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ยืนยันการชำระเงินสำเร็จ", "data": order})
+}
+
+// POST /payments/orders/:id/mock-pay body: {"succeed": true}
+// จำลองผลการชำระเงินผ่านผู้ให้บริการ mock สำหรับทดสอบในเครื่อง (เฉพาะผู้ดูแลระบบ)
+func MockPay(c *gin.Context) {
+	if actor, _ := currentActor(c); actor != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะผู้ดูแลระบบเท่านั้น"})
+		return
+	}
+	order, ok := loadOrder(c)
+	if !ok {
+		return
+	}
+	input := struct {
+		Succeed *bool `json:"succeed"`
+	}{}
+	_ = c.ShouldBindJSON(&input)
+	succeed := input.Succeed == nil || *input.Succeed
+
+	event, err := services.SimulateMockPayment(order.ID, succeed)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	order, err = services.GetPaymentOrder(order.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"event": event, "data": order})
+}
+
+// POST /payments/webhooks/:provider (ไม่ต้องล็อกอิน ตรวจสอบด้วยลายเซ็นของผู้ให้บริการ)
+// เหตุการณ์ซ้ำตอบ 200 เพื่อให้ผู้ให้บริการหยุดส่งซ้ำ
+func Webhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "อ่านข้อมูลไม่สำเร็จ"})
+		return
+	}
+	event, duplicate, err := services.HandlePaymentWebhook(c.Param("provider"), c.Request.Header, body)
+	switch {
+	case errors.Is(err, services.ErrPaymentSignature):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrUnknownPaymentGateway):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"received": true, "duplicate": duplicate, "result": event.Result})
+}
+
+// loadOrder ดึงคำสั่งซื้อที่ผู้ใช้เป็นเจ้าของ (แอดมินดูได้ทุกคำสั่งซื้อ)
+func loadOrder(c *gin.Context) (entity.PaymentOrder, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return entity.PaymentOrder{}, false
+	}
+	order, err := services.GetPaymentOrder(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบคำสั่งซื้อ"})
+		return order, false
+	}
+	if actor, userID := currentActor(c); actor != "admin" && order.UserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์ดูคำสั่งซื้อนี้"})
+		return order, false
+	}
+	return order, true
+}
+
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userIDRaw, _ := c.Get("user_id")
+	role, _ := actor.(string)
+	userID, _ := userIDRaw.(uint)
+	return role, userID
+}
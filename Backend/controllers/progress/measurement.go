@@ -0,0 +1,149 @@
+package progress
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type measurementInput struct {
+	UserID       uint               `json:"user_id"`
+	Date         string             `json:"date"`
+	Measurements map[string]float64 `json:"measurements"` // ตำแหน่ง -> ซม. เช่น {"waist": 80.5}
+	Note         string             `json:"note"`
+}
+
+// POST /api/measurements
+// ลูกค้าบันทึกรอบวัดของตนเอง เทรนเนอร์บันทึกให้ลูกค้าของตนโดยระบุ user_id
+func CreateMeasurements(c *gin.Context) {
+	var input measurementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ข้อมูลไม่ถูกต้อง"})
+		return
+	}
+	userID, ok := resolveProgressUser(c, input.UserID)
+	if !ok {
+		return
+	}
+	var trainerID *uint
+	if actor, id := currentActor(c); actor == "trainer" {
+		trainerID = &id
+	}
+
+	saved, err := services.SaveBodyMeasurements(userID, input.Date, input.Measurements, input.Note, trainerID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "บันทึกรอบวัดสำเร็จ", "data": saved})
+}
+
+// GET /api/measurements?user_id=&site=&from=&to=
+func GetMeasurements(c *gin.Context) {
+	userID, ok := resolveProgressUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	measurements, err := services.GetBodyMeasurements(userID, c.Query("site"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงข้อมูลรอบวัดได้"})
+		return
+	}
+	c.JSON(http.StatusOK, measurements)
+}
+
+// GET /api/measurements/history?user_id=
+// ประวัติรอบวัดแยกตามตำแหน่ง พร้อมการเปลี่ยนแปลงจากครั้งแรกและครั้งก่อน
+func GetMeasurementHistory(c *gin.Context) {
+	userID, ok := resolveProgressUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	history, err := services.GetMeasurementHistory(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงประวัติรอบวัดได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sites": services.MeasurementSites, "history": history})
+}
+
+// DELETE /api/measurements/:id
+func DeleteMeasurement(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสรอบวัดไม่ถูกต้อง"})
+		return
+	}
+	m, err := services.GetBodyMeasurement(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบข้อมูลรอบวัด"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if _, ok := resolveProgressUser(c, m.UserID); !ok {
+		return
+	}
+	if err := services.DeleteBodyMeasurement(m.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบรอบวัดได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบรอบวัดสำเร็จ"})
+}
+
+// resolveProgressUser ลูกค้าเข้าถึงได้เฉพาะข้อมูลตนเอง เทรนเนอร์เข้าถึงได้เฉพาะลูกค้าของตน
+// รูปและรอบวัดเป็นข้อมูลส่วนตัว จึงไม่เปิดให้ผู้ดูแลระบบ
+func resolveProgressUser(c *gin.Context, requested uint) (uint, bool) {
+	actor, actorID := currentActor(c)
+	if actorID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "ไม่พบข้อมูลผู้ใช้"})
+		return 0, false
+	}
+	switch actor {
+	case "trainer":
+		if requested == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาระบุ user_id ของลูกค้า"})
+			return 0, false
+		}
+		isClient, err := services.IsTrainerClient(actorID, requested)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return 0, false
+		}
+		if !isClient {
+			c.JSON(http.StatusForbidden, gin.H{"error": "ผู้ใช้นี้ไม่ใช่ลูกค้าของคุณ"})
+			return 0, false
+		}
+		return requested, true
+	case "customer":
+		if requested != 0 && requested != actorID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "ไม่มีสิทธิ์เข้าถึงข้อมูลของผู้ใช้อื่น"})
+			return 0, false
+		}
+		return actorID, true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "เฉพาะเจ้าของข้อมูลและเทรนเนอร์ของตนเท่านั้น"})
+	return 0, false
+}
+
+func currentActor(c *gin.Context) (string, uint) {
+	actor, _ := c.Get("actor")
+	userID, _ := c.Get("user_id")
+	actorStr, _ := actor.(string)
+	id, _ := userID.(uint)
+	return actorStr, id
+}
+
+func queryUint(c *gin.Context, key string) uint {
+	value, err := strconv.Atoi(c.Query(key))
+	if err != nil || value < 0 {
+		return 0
+	}
+	return uint(value)
+}
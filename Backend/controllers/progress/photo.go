@@ -0,0 +1,156 @@
+package progress
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// POST /api/progress-photos (multipart form-data: file, date, pose, note, user_id)
+func UploadPhoto(c *gin.Context) {
+	requested, _ := strconv.Atoi(c.PostForm("user_id"))
+	userID, ok := resolveProgressUser(c, uint(requested))
+	if !ok {
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "กรุณาเลือกไฟล์รูป"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ไม่สามารถอ่านไฟล์ได้"})
+		return
+	}
+	defer file.Close()
+
+	actor, actorID := currentActor(c)
+	photo, err := services.SaveProgressPhoto(entity.ProgressPhoto{
+		UserID:          userID,
+		Date:            c.PostForm("date"),
+		Pose:            c.PostForm("pose"),
+		Note:            c.PostForm("note"),
+		UploadedByActor: actor,
+		UploadedByID:    actorID,
+	}, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	services.SignProgressPhoto(&photo)
+	c.JSON(http.StatusCreated, gin.H{"message": "อัปโหลดรูปสำเร็จ", "data": photo})
+}
+
+// GET /api/progress-photos?user_id=&pose=
+// ลิงก์รูปในผลลัพธ์ใช้ได้ชั่วคราว หมดอายุแล้วให้ดึงรายการใหม่
+func GetPhotos(c *gin.Context) {
+	userID, ok := resolveProgressUser(c, queryUint(c, "user_id"))
+	if !ok {
+		return
+	}
+	photos, err := services.GetProgressPhotos(userID, c.Query("pose"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถดึงรูปได้"})
+		return
+	}
+	for i := range photos {
+		services.SignProgressPhoto(&photos[i])
+	}
+	c.JSON(http.StatusOK, photos)
+}
+
+// GET /api/progress-photos/:id
+func GetPhoto(c *gin.Context) {
+	photo, ok := loadPhoto(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	services.SignProgressPhoto(&photo)
+	c.JSON(http.StatusOK, photo)
+}
+
+// DELETE /api/progress-photos/:id
+func DeletePhoto(c *gin.Context) {
+	photo, ok := loadPhoto(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if err := services.DeleteProgressPhoto(photo); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ไม่สามารถลบรูปได้"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "ลบรูปสำเร็จ"})
+}
+
+// GET /api/progress-photos/compare?before=&after=
+// รูปสองรูปพร้อมน้ำหนัก ไขมัน และรอบวัด ณ วันที่ของแต่ละรูปสำหรับแสดงเทียบกัน
+func ComparePhotos(c *gin.Context) {
+	before, ok := loadPhoto(c, c.Query("before"))
+	if !ok {
+		return
+	}
+	after, ok := loadPhoto(c, c.Query("after"))
+	if !ok {
+		return
+	}
+	comparison, err := services.CompareProgressPhotos(before, after)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	services.SignProgressPhoto(&comparison.Before)
+	services.SignProgressPhoto(&comparison.After)
+	c.JSON(http.StatusOK, comparison)
+}
+
+// GET /progress-photos/:id/file?expires=&signature= (public)
+// แท็ก <img> ส่ง Authorization header ไม่ได้ จึงใช้ลิงก์ชั่วคราวที่ลงลายเซ็นแทน
+func ServePhoto(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรูป"})
+		return
+	}
+	photo, err := services.VerifyProgressPhotoURL(uint(id), c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPhotoSignature), errors.Is(err, services.ErrPhotoURLExpired):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรูป"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Content-Type", photo.ContentType)
+	c.File(photo.StoragePath)
+}
+
+func loadPhoto(c *gin.Context, rawID string) (entity.ProgressPhoto, bool) {
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "รหัสรูปไม่ถูกต้อง"})
+		return entity.ProgressPhoto{}, false
+	}
+	photo, err := services.GetProgressPhoto(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ไม่พบรูป"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return photo, false
+	}
+	if _, ok := resolveProgressUser(c, photo.UserID); !ok {
+		return photo, false
+	}
+	return photo, true
+}
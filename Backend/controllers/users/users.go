@@ -10,6 +10,8 @@ import (
 	"example.com/fitness-backend/config"
 
 	"example.com/fitness-backend/entity"
+
+	"example.com/fitness-backend/services"
 )
 
 func GetAll(c *gin.Context) {
@@ -99,6 +101,11 @@ func GetProfile(c *gin.Context) {
 		"avatar":     user.Avatar, // ดึง avatar URL จากฐานข้อมูล
 		"created_at": user.CreatedAt,
 		"gender":     user.Gender,
+
+		"resting_heart_rate": user.RestingHeartRate,
+		"max_heart_rate":     user.MaxHeartRate,
+		"hr_zone_method":     user.HRZoneMethod,
+		"hr_zone_bounds":     user.HRZoneBounds,
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": response})
@@ -128,6 +135,12 @@ func UpdateProfile(c *gin.Context) {
 		FirstName string `json:"first_name"`
 		LastName  string `json:"last_name"`
 		Email     string `json:"email"`
+
+		// ค่าชีพจรสำหรับโซนหัวใจ (ไม่ส่งมา = ไม่เปลี่ยน, ส่ง 0 = กลับไปใช้ค่าประมาณ)
+		RestingHeartRate *int      `json:"resting_heart_rate"`
+		MaxHeartRate     *int      `json:"max_heart_rate"`
+		HRZoneMethod     *string   `json:"hr_zone_method"`
+		HRZoneBounds     []float64 `json:"hr_zone_bounds"`
 	}
 
 	if err := c.ShouldBindJSON(&updateData); err != nil {
@@ -145,6 +158,22 @@ func UpdateProfile(c *gin.Context) {
 	if updateData.Email != "" {
 		user.Email = updateData.Email
 	}
+	if updateData.RestingHeartRate != nil {
+		user.RestingHeartRate = *updateData.RestingHeartRate
+	}
+	if updateData.MaxHeartRate != nil {
+		user.MaxHeartRate = *updateData.MaxHeartRate
+	}
+	if updateData.HRZoneMethod != nil {
+		user.HRZoneMethod = *updateData.HRZoneMethod
+	}
+	if updateData.HRZoneBounds != nil {
+		user.HRZoneBounds = updateData.HRZoneBounds
+	}
+	if err := services.ValidateHeartRateSettings(&user); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// บันทึกการเปลี่ยนแปลง
 	result = db.Save(&user)
@@ -162,6 +191,11 @@ func UpdateProfile(c *gin.Context) {
 		"last_name":  user.LastName,
 		"avatar":     user.Avatar,
 		"created_at": user.CreatedAt,
+
+		"resting_heart_rate": user.RestingHeartRate,
+		"max_heart_rate":     user.MaxHeartRate,
+		"hr_zone_method":     user.HRZoneMethod,
+		"hr_zone_bounds":     user.HRZoneBounds,
 	}
 
 	c.JSON(http.StatusOK, gin.H{"data": response})
@@ -16,4 +16,19 @@ type Activity struct {
 	Duration float64   `json:"duration"`
 	Calories float64   `json:"calories"`
 	Date     time.Time `json:"date"`
+
+	// ประเภทกิจกรรมและค่า MET ที่ใช้คำนวณแคลอรี่ครั้งล่าสุด
+	ActivityTypeID *uint         `json:"activity_type_id"`
+	ActivityType   *ActivityType `gorm:"foreignKey:ActivityTypeID" json:"activity_type,omitempty"`
+	MET            float64       `json:"met"`
+
+	// ข้อมูลจากไฟล์นาฬิกา/แอป (ว่างสำหรับกิจกรรมที่กรอกเอง)
+	Source string         `json:"source"` // manual, gpx, tcx, fit
+	Track  *ActivityTrack `gorm:"foreignKey:ActivityID" json:"track,omitempty"`
+
+	// อัตราการเต้นหัวใจและภาระการฝึก (TRIMP) คำนวณตามโซนของผู้ใช้ ณ เวลาที่บันทึก
+	AvgHeartRate int       `json:"avg_heart_rate"`
+	MaxHeartRate int       `json:"max_heart_rate"`
+	TimeInZones  []float64 `json:"time_in_zones" gorm:"serializer:json"` // วินาทีในแต่ละโซน เรียงจากโซน 1
+	TrainingLoad float64   `json:"training_load"`
 }
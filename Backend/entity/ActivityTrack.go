@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActivityTrack เส้นทางและข้อมูลละเอียดของกิจกรรมที่นำเข้าจากไฟล์ GPX, TCX หรือ FIT
+type ActivityTrack struct {
+	gorm.Model
+	ActivityID    uint         `json:"activity_id" gorm:"uniqueIndex"`
+	Source        string       `json:"source"` // gpx, tcx, fit
+	FileName      string       `json:"file_name"`
+	StartTime     time.Time    `json:"start_time"`
+	ElevationGain float64      `json:"elevation_gain"` // เมตร
+	AvgHeartRate  int          `json:"avg_heart_rate"`
+	MaxHeartRate  int          `json:"max_heart_rate"`
+	Splits        []PaceSplit  `json:"splits" gorm:"serializer:json"`
+	Points        []TrackPoint `json:"points" gorm:"serializer:json"`
+}
+
+// TrackPoint จุดหนึ่งบนเส้นทาง (ค่าศูนย์คือไม่มีข้อมูล)
+type TrackPoint struct {
+	Time      time.Time `json:"time"`
+	Lat       float64   `json:"lat,omitempty"`
+	Lon       float64   `json:"lon,omitempty"`
+	Elevation float64   `json:"ele,omitempty"`
+	HeartRate int       `json:"hr,omitempty"`
+	Distance  float64   `json:"dist"` // ระยะสะสม (เมตร)
+}
+
+// PaceSplit เวลาต่อกิโลเมตร (ช่วงสุดท้ายอาจสั้นกว่า 1 กม.)
+type PaceSplit struct {
+	Km           int     `json:"km"`
+	DistanceM    float64 `json:"distance_m"`
+	Seconds      float64 `json:"seconds"`
+	PaceSecPerKm float64 `json:"pace_sec_per_km"`
+	AvgHeartRate int     `json:"avg_heart_rate,omitempty"`
+}
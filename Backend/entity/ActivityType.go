@@ -0,0 +1,18 @@
+package entity
+
+import "gorm.io/gorm"
+
+// ActivityType ประเภทกิจกรรมพร้อมค่า MET อ้างอิง Compendium of Physical Activities
+// ผู้ดูแลระบบเพิ่ม/แก้ไขได้ กิจกรรมที่ระบุ PaceModel จะคำนวณ MET จากความเร็วแทนค่าคงที่
+type ActivityType struct {
+	gorm.Model
+	Name      string  `json:"name" gorm:"uniqueIndex"` // ชื่อที่ผู้ใช้เลือก เช่น "วิ่ง"
+	NameEN    string  `json:"name_en"`
+	Code      string  `json:"code"` // รหัสใน Compendium เช่น "12050"
+	Category  string  `json:"category"`
+	Intensity string  `json:"intensity"` // light, moderate, vigorous
+	MET       float64 `json:"met"`
+	PaceModel string  `json:"pace_model"` // running, walking, cycling หรือว่าง
+	// ไม่ส่งมา = เปิดใช้ (ตอนสร้าง) หรือคงค่าเดิม (ตอนแก้ไข)
+	IsActive *bool `json:"is_active" gorm:"default:true"`
+}
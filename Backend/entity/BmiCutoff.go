@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// BmiCutoff เกณฑ์แบ่งกลุ่ม BMI (มีเพียงแถวเดียว ค่าเริ่มต้นตามเกณฑ์ WHO สำหรับชาวเอเชีย)
+type BmiCutoff struct {
+	gorm.Model
+	UnderweightBelow float64 `json:"underweight_below"` // ต่ำกว่านี้ = น้ำหนักน้อย
+	NormalBelow      float64 `json:"normal_below"`      // ต่ำกว่านี้ = ปกติ
+	OverweightBelow  float64 `json:"overweight_below"`  // ต่ำกว่านี้ = น้ำหนักเกิน
+	Obese1Below      float64 `json:"obese1_below"`      // ต่ำกว่านี้ = อ้วนระดับ 1 ตั้งแต่นี้ขึ้นไป = อ้วนระดับ 2
+}
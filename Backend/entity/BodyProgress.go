@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// BodyMeasurement รอบวัดร่างกายหนึ่งตำแหน่งในวันที่บันทึก (หนึ่งค่าต่อตำแหน่งต่อวัน)
+type BodyMeasurement struct {
+	gorm.Model
+	UserID  uint    `json:"user_id" gorm:"uniqueIndex:idx_measurement_user_date_site"`
+	Date    string  `json:"date" gorm:"uniqueIndex:idx_measurement_user_date_site"` // YYYY-MM-DD
+	Site    string  `json:"site" gorm:"uniqueIndex:idx_measurement_user_date_site"` // waist, hip, chest, arm, thigh
+	ValueCm float64 `json:"value_cm"`
+	Note    string  `json:"note"`
+
+	RecordedByTrainerID *uint `json:"recorded_by_trainer_id"` // เทรนเนอร์ที่วัดให้ (ถ้ามี)
+}
+
+// ProgressPhoto รูปความคืบหน้า เก็บนอกโฟลเดอร์ uploads สาธารณะ เปิดได้ผ่านลิงก์ที่ลงลายเซ็นเท่านั้น
+type ProgressPhoto struct {
+	gorm.Model
+	UserID      uint   `json:"user_id" gorm:"index"`
+	Date        string `json:"date"` // YYYY-MM-DD
+	Pose        string `json:"pose"` // front, side, back
+	Note        string `json:"note"`
+	StoragePath string `json:"-"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+
+	UploadedByActor string `json:"uploaded_by_actor"` // customer หรือ trainer
+	UploadedByID    uint   `json:"uploaded_by_id"`
+
+	// ลิงก์ชั่วคราวสำหรับเปิดรูป (ไม่เก็บลงฐานข้อมูล)
+	URL          string `gorm:"-" json:"url,omitempty"`
+	URLExpiresAt int64  `gorm:"-" json:"url_expires_at,omitempty"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// BookingPolicy นโยบายการยกเลิกและเลื่อนนัดการจองเทรนเนอร์ (มีเพียงแถวเดียว)
+type BookingPolicy struct {
+	gorm.Model
+	FreeCancelHours       int `json:"free_cancel_hours"`       // ยกเลิกฟรีได้ถึงกี่ชั่วโมงก่อนเริ่ม
+	RescheduleNoticeHours int `json:"reschedule_notice_hours"` // เลื่อนนัดได้ถึงกี่ชั่วโมงก่อนเริ่ม
+	MaxReschedules        int `json:"max_reschedules"`         // จำนวนครั้งที่เลื่อนได้ต่อการจอง
+	NoShowGraceMinutes    int `json:"no_show_grace_minutes"`   // หลังเริ่มกี่นาทีจึงบันทึกว่าไม่มาได้
+}
@@ -0,0 +1,13 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// CalendarToken โทเค็นลับสำหรับเปิดฟีดปฏิทิน (.ics) ของลูกค้าหรือเทรนเนอร์โดยไม่ต้องล็อกอิน
+type CalendarToken struct {
+	gorm.Model
+	Actor   string `json:"actor" gorm:"uniqueIndex:idx_calendar_owner"` // customer หรือ trainer
+	OwnerID uint   `json:"owner_id" gorm:"uniqueIndex:idx_calendar_owner"`
+	Token   string `json:"token" gorm:"uniqueIndex"`
+}
@@ -12,6 +12,10 @@ type ClassActivity struct {
 	StartTime           string    `json:"startTime"` // HH:mm
 	EndTime             string    `json:"endTime"`   // HH:mm
 	Location            string    `json:"location"`
+	FacilityID          *uint     `json:"facilityId"`
+	Facility            *Facility `gorm:"foreignKey:FacilityID" json:"facility,omitempty"`
+	TrainerID           *uint     `json:"trainerId"`
+	Trainer             *Trainer  `gorm:"foreignKey:TrainerID" json:"trainer,omitempty"`
 	Capacity            int       `json:"capacity"`
 	ImageURL            string    `json:"imageUrl"`
 	CurrentParticipants int       `gorm:"-" json:"currentParticipants"`
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PackageEntitlement สิทธิ์ที่แพ็กเกจให้ เช่น เข้าฟิตเนส จองคลาส เทรนส่วนตัว ซาวน่า สระว่ายน้ำ
+type PackageEntitlement struct {
+	gorm.Model
+	PackageID uint `json:"package_id" gorm:"uniqueIndex:idx_package_capability"`
+	// gym_access, class_booking, pt_session, sauna, pool
+	Capability   string `json:"capability" gorm:"uniqueIndex:idx_package_capability"`
+	MonthlyLimit int    `json:"monthly_limit"` // จำนวนครั้งต่อรอบการใช้ (เดือน) 0 = ไม่จำกัด
+}
+
+// EntitlementUsage การใช้สิทธิ์หนึ่งครั้ง นับในรอบการใช้รายเดือนที่เริ่มนับจากวันเริ่มแพ็กเกจ
+type EntitlementUsage struct {
+	gorm.Model
+	UserID          uint      `json:"user_id" gorm:"index"`
+	PackageMemberID uint      `json:"package_member_id"`
+	Capability      string    `json:"capability"`
+	PeriodStart     time.Time `json:"period_start" gorm:"index"`
+	PeriodEnd       time.Time `json:"period_end"`
+
+	// รายการที่ใช้สิทธิ์: class_booking, train_booking, facility_entry
+	RefType string `json:"ref_type" gorm:"index:idx_usage_ref"`
+	RefID   uint   `json:"ref_id" gorm:"index:idx_usage_ref"`
+}
+
+// FacilityEntry บันทึกการเข้าใช้สถานที่
+type FacilityEntry struct {
+	gorm.Model
+	UserID     uint      `json:"user_id" gorm:"index"`
+	FacilityID uint      `json:"facility_id"`
+	Facility   *Facility `gorm:"foreignKey:FacilityID" json:"facility,omitempty"`
+	EnteredAt  time.Time `json:"entered_at"`
+}
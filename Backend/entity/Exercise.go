@@ -0,0 +1,18 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Exercise ท่าฝึกในคลังท่าของยิม ใช้อ้างอิงจากโปรแกรมฝึกและบันทึกการฝึก
+type Exercise struct {
+	gorm.Model
+	NameTH           string   `json:"name_th"`
+	NameEN           string   `json:"name_en"`
+	PrimaryMuscles   []string `json:"primary_muscles" gorm:"serializer:json"`
+	SecondaryMuscles []string `json:"secondary_muscles" gorm:"serializer:json"`
+	MovementPattern  string   `json:"movement_pattern"` // squat, hinge, push, pull, lunge, carry, rotation, core, cardio
+	EquipmentType    string   `json:"equipment_type"`   // ตรงกับ Equipment.Type หรือ "bodyweight"
+	Difficulty       string   `json:"difficulty"`       // beginner, intermediate, advanced
+	Cues             []string `json:"cues" gorm:"serializer:json"`
+	ImageURL         string   `json:"image_url"`
+	VideoURL         string   `json:"video_url"`
+}
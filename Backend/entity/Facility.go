@@ -10,4 +10,7 @@ type Facility struct {
 	Zone      string    `json:"zone"`
 	Status    string    `json:"status"`
 	Capacity  int       `json:"capacity"`
+
+	// สิทธิ์ที่ต้องมีเพื่อเข้าใช้: gym_access, sauna, pool
+	Capability string `json:"capability" gorm:"default:'gym_access'"`
 }
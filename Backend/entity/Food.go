@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// Food อาหารในแคตตาล็อก ค่าพลังงานและสารอาหารคิดต่อ 100 กรัม
+type Food struct {
+	gorm.Model
+	Name       string  `json:"name"`
+	NameEN     string  `json:"name_en"`
+	Category   string  `json:"category"`
+	Brand      string  `json:"brand"`
+	Barcode    string  `json:"barcode" gorm:"index"` // EAN-13 (UPC-A เก็บแบบเติม 0 ข้างหน้า) ไม่ซ้ำในแคตตาล็อกของระบบ
+	Source     string  `json:"source"`               // ว่าง = เพิ่มในระบบ, openfoodfacts, file
+	EnergyKcal float64 `json:"energy_kcal"`
+	ProteinG   float64 `json:"protein_g"`
+	FatG       float64 `json:"fat_g"`
+	CarbG      float64 `json:"carb_g"`
+	FiberG     float64 `json:"fiber_g"`
+	SugarG     float64 `json:"sugar_g"`
+	SodiumMg   float64 `json:"sodium_mg"`
+
+	// หน่วยบริโภค เช่น จาน 300 กรัม, ทัพพี 60 กรัม
+	Servings []FoodServing `json:"servings" gorm:"serializer:json"`
+
+	// vegetarian (ไม่มีเนื้อสัตว์ ทานไข่/นมได้), halal (ไม่มีหมูและแอลกอฮอล์)
+	DietaryTags []string `json:"dietary_tags" gorm:"serializer:json"`
+	// สารก่อภูมิแพ้ เช่น peanut, shellfish, fish, egg, dairy, gluten, soy
+	Allergens []string `json:"allergens" gorm:"serializer:json"`
+
+	// อาหารที่ผู้ใช้สร้างเอง เห็นได้เฉพาะเจ้าของ
+	IsCustom        bool  `json:"is_custom"`
+	CreatedByUserID *uint `json:"created_by_user_id"`
+}
+
+// FoodServing หน่วยบริโภคของอาหาร
+type FoodServing struct {
+	Name  string  `json:"name"`
+	Grams float64 `json:"grams"`
+}
+
+// MealEntry อาหารที่ผู้ใช้กินจริงในแต่ละมื้อ ค่าสารอาหารคำนวณจากปริมาณที่กิน ณ เวลาบันทึก
+type MealEntry struct {
+	gorm.Model
+	UserID   uint   `json:"user_id" gorm:"index"`
+	Date     string `json:"date" gorm:"index"` // YYYY-MM-DD
+	MealType string `json:"meal_type"`         // breakfast, lunch, dinner, snack
+	FoodID   uint   `json:"food_id"`
+	Food     *Food  `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+	FoodName string `json:"food_name"`
+
+	ServingName string  `json:"serving_name"` // ว่าง = ระบุเป็นกรัม
+	Quantity    float64 `json:"quantity"`     // จำนวนหน่วยบริโภค
+	Grams       float64 `json:"grams"`
+	Note        string  `json:"note"`
+
+	EnergyKcal float64 `json:"energy_kcal"`
+	ProteinG   float64 `json:"protein_g"`
+	FatG       float64 `json:"fat_g"`
+	CarbG      float64 `json:"carb_g"`
+}
@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BarcodeLookup แคชผลการค้นหาบาร์โค้ดจากผู้ให้บริการภายนอก ทั้งที่พบและไม่พบ
+type BarcodeLookup struct {
+	gorm.Model
+	Barcode   string    `json:"barcode" gorm:"uniqueIndex"`
+	Source    string    `json:"source"`
+	Found     bool      `json:"found"`
+	FoodID    *uint     `json:"food_id"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// FoodCorrection ข้อมูลอาหารที่ผู้ใช้เสนอแก้ไข รอผู้ดูแลตรวจสอบ ค่าที่เป็น nil คือไม่เปลี่ยน
+type FoodCorrection struct {
+	gorm.Model
+	FoodID uint   `json:"food_id" gorm:"index"`
+	Food   *Food  `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+	UserID uint   `json:"user_id"`
+	User   *Users `gorm:"foreignKey:UserID" json:"user,omitempty"`
+
+	Name       *string  `json:"name"`
+	Brand      *string  `json:"brand"`
+	EnergyKcal *float64 `json:"energy_kcal"`
+	ProteinG   *float64 `json:"protein_g"`
+	FatG       *float64 `json:"fat_g"`
+	CarbG      *float64 `json:"carb_g"`
+	FiberG     *float64 `json:"fiber_g"`
+	SugarG     *float64 `json:"sugar_g"`
+	SodiumMg   *float64 `json:"sodium_mg"`
+	Note       string   `json:"note"`
+
+	Status     string     `json:"status"` // pending, approved, rejected
+	ReviewedBy *uint      `json:"reviewed_by"`
+	ReviewNote string     `json:"review_note"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Goal เป้าหมายส่วนตัวของสมาชิก ความคืบหน้าคำนวณจากบันทึกสุขภาพ กิจกรรม และสถิติการฝึก
+type Goal struct {
+	gorm.Model
+	UserID    uint     `json:"user_id" gorm:"index"`
+	User      *Users   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	TrainerID *uint    `json:"trainer_id"` // เทรนเนอร์ที่ตั้งเป้าหมายให้ (ถ้ามี)
+	Trainer   *Trainer `gorm:"foreignKey:TrainerID" json:"trainer,omitempty"`
+
+	Title string `json:"title"`
+	// weight, body_fat, weekly_activity_minutes, distance, strength_pr
+	Type string `json:"type"`
+	// สำหรับ strength_pr: ท่าที่วัดผล (ใช้ค่า estimated 1RM)
+	ExerciseID   *uint  `json:"exercise_id"`
+	ExerciseName string `json:"exercise_name"`
+
+	StartValue  float64   `json:"start_value"`
+	TargetValue float64   `json:"target_value"`
+	StartDate   time.Time `json:"start_date"`
+	TargetDate  time.Time `json:"target_date"`
+
+	// คำนวณโดยระบบ
+	CurrentValue float64    `json:"current_value"`
+	Progress     float64    `json:"progress"`                       // เปอร์เซ็นต์ 0-100
+	Status       string     `json:"status" gorm:"default:'active'"` // active, achieved, expired, cancelled
+	AchievedAt   *time.Time `json:"achieved_at"`
+
+	// ความคืบหน้าที่ควรเป็นตามเวลาที่ผ่านไป (ไม่เก็บลงฐานข้อมูล)
+	ExpectedProgress float64 `gorm:"-" json:"expected_progress"`
+	OnTrack          bool    `gorm:"-" json:"on_track"`
+
+	Milestones []GoalMilestone `gorm:"foreignKey:GoalID" json:"milestones"`
+}
+
+// GoalMilestone เหตุการณ์เมื่อความคืบหน้าผ่านเกณฑ์ (25/50/75/100%)
+// เป้าหมายรายสัปดาห์บันทึกแยกตามสัปดาห์ใน Period
+type GoalMilestone struct {
+	gorm.Model
+	GoalID    uint      `json:"goal_id" gorm:"index"`
+	Percent   int       `json:"percent"`
+	Period    string    `json:"period"` // วันจันทร์ของสัปดาห์ (YYYY-MM-DD) สำหรับเป้าหมายรายสัปดาห์
+	Value     float64   `json:"value"`
+	ReachedAt time.Time `json:"reached_at"`
+}
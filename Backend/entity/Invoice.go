@@ -0,0 +1,80 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Branch สาขาที่ออกใบกำกับภาษี เลขที่เอกสารรันแยกตามสาขา
+type Branch struct {
+	gorm.Model
+	BranchNo  string `json:"branch_no" gorm:"uniqueIndex"` // 00000 = สำนักงานใหญ่
+	Name      string `json:"name"`
+	NameEN    string `json:"name_en"`
+	Address   string `json:"address"`
+	AddressEN string `json:"address_en"`
+	TaxID     string `json:"tax_id"` // เลขประจำตัวผู้เสียภาษีของผู้ขาย 13 หลัก
+	Phone     string `json:"phone"`
+	IsDefault bool   `json:"is_default"` // สาขาที่ใช้กับการชำระเงินออนไลน์
+}
+
+// BillingProfile ข้อมูลผู้ซื้อที่ใช้ออกใบกำกับภาษีเต็มรูป (ว่าง = ใช้ชื่อและอีเมลจากบัญชีผู้ใช้)
+type BillingProfile struct {
+	gorm.Model
+	UserID   uint   `json:"user_id" gorm:"uniqueIndex"`
+	Name     string `json:"name"`
+	TaxID    string `json:"tax_id"`
+	BranchNo string `json:"branch_no"` // สำหรับนิติบุคคล ว่าง = บุคคลธรรมดา
+	Address  string `json:"address"`
+}
+
+// InvoiceSequence เลขที่เอกสารล่าสุดต่อสาขา ชนิดเอกสาร และปี
+type InvoiceSequence struct {
+	gorm.Model
+	BranchID uint   `gorm:"uniqueIndex:idx_invoice_sequence"`
+	DocType  string `gorm:"uniqueIndex:idx_invoice_sequence"`
+	Year     int    `gorm:"uniqueIndex:idx_invoice_sequence"`
+	LastNo   int
+}
+
+// Invoice ใบเสร็จรับเงิน/ใบกำกับภาษี หรือใบลดหนี้ ข้อมูลผู้ขายและผู้ซื้อบันทึก ณ วันที่ออกเอกสาร
+// และไม่เปลี่ยนตามข้อมูลต้นทางภายหลัง จำนวนเงินเป็นสตางค์ ราคารวมภาษีมูลค่าเพิ่มแล้ว
+type Invoice struct {
+	gorm.Model
+	// invoice หรือ credit_note
+	DocType  string    `json:"doc_type" gorm:"index"`
+	Number   string    `json:"number" gorm:"uniqueIndex"`
+	IssuedAt time.Time `json:"issued_at"`
+
+	BranchID       uint          `json:"branch_id"`
+	PaymentOrderID uint          `json:"payment_order_id" gorm:"index"`
+	PaymentOrder   *PaymentOrder `gorm:"foreignKey:PaymentOrderID" json:"payment_order,omitempty"`
+	UserID         uint          `json:"user_id" gorm:"index"`
+
+	// ใบลดหนี้อ้างถึงใบกำกับภาษีเดิม
+	ReferenceID     *uint  `json:"reference_id"`
+	ReferenceNumber string `json:"reference_number,omitempty"`
+	Reason          string `json:"reason,omitempty"`
+
+	SellerName      string `json:"seller_name"`
+	SellerNameEN    string `json:"seller_name_en"`
+	SellerAddress   string `json:"seller_address"`
+	SellerAddressEN string `json:"seller_address_en"`
+	SellerTaxID     string `json:"seller_tax_id"`
+	SellerBranchNo  string `json:"seller_branch_no"`
+
+	BuyerName     string `json:"buyer_name"`
+	BuyerEmail    string `json:"buyer_email"`
+	BuyerAddress  string `json:"buyer_address"`
+	BuyerTaxID    string `json:"buyer_tax_id"`
+	BuyerBranchNo string `json:"buyer_branch_no"`
+
+	Description   string `json:"description"`
+	DescriptionEN string `json:"description_en"`
+
+	VATRate        float64 `json:"vat_rate"`
+	SubtotalSatang int64   `json:"subtotal_satang"`
+	VATSatang      int64   `json:"vat_satang"`
+	TotalSatang    int64   `json:"total_satang"`
+}
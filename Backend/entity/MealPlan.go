@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MealPlan แผนอาหารรายวันที่เทรนเนอร์สร้างให้ลูกค้า เป้าหมายมาจากแผนโภชนาการ (Nutrition)
+type MealPlan struct {
+	gorm.Model
+	UserID      uint       `json:"user_id" gorm:"index"`
+	User        *Users     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	TrainerID   *uint      `json:"trainer_id"`
+	Trainer     *Trainer   `gorm:"foreignKey:TrainerID" json:"trainer,omitempty"`
+	NutritionID *uint      `json:"nutrition_id"`
+	Title       string     `json:"title"`
+	StartDate   string     `json:"start_date"` // YYYY-MM-DD
+	Days        int        `json:"days"`
+	Status      string     `json:"status"` // draft, assigned
+	AssignedAt  *time.Time `json:"assigned_at"`
+	Note        string     `json:"note"`
+
+	// เป้าหมายต่อวันและเงื่อนไขที่ใช้สร้างแผน
+	TargetKcal       float64  `json:"target_kcal"`
+	TargetProteinG   float64  `json:"target_protein_g"`
+	TargetFatG       float64  `json:"target_fat_g"`
+	TargetCarbG      float64  `json:"target_carb_g"`
+	TolerancePercent float64  `json:"tolerance_percent"`
+	Exclusions       []string `json:"exclusions" gorm:"serializer:json"` // vegetarian, halal หรือสารก่อภูมิแพ้
+
+	Items []MealPlanItem `gorm:"foreignKey:MealPlanID" json:"items"`
+
+	// คำนวณตอนดึงข้อมูล: ผลรวมแต่ละวันเทียบกับเป้าหมาย
+	DaySummaries []MealPlanDay `gorm:"-" json:"day_summaries"`
+}
+
+// MealPlanItem สูตรอาหารในมื้อหนึ่งของแผน
+type MealPlanItem struct {
+	gorm.Model
+	MealPlanID uint    `json:"meal_plan_id" gorm:"index"`
+	Day        int     `json:"day"` // 0 = วันเริ่มแผน
+	MealType   string  `json:"meal_type"`
+	RecipeID   uint    `json:"recipe_id"`
+	Recipe     *Recipe `gorm:"foreignKey:RecipeID" json:"recipe,omitempty"`
+	Servings   float64 `json:"servings"`
+
+	// ค่าสารอาหารตามจำนวนที่ ณ เวลาที่สร้างหรือแก้ไข
+	EnergyKcal float64 `json:"energy_kcal"`
+	ProteinG   float64 `json:"protein_g"`
+	FatG       float64 `json:"fat_g"`
+	CarbG      float64 `json:"carb_g"`
+}
+
+// MealPlanDay ผลรวมของวันในแผนเทียบกับเป้าหมาย
+type MealPlanDay struct {
+	Day             int     `json:"day"`
+	Date            string  `json:"date"`
+	EnergyKcal      float64 `json:"energy_kcal"`
+	ProteinG        float64 `json:"protein_g"`
+	FatG            float64 `json:"fat_g"`
+	CarbG           float64 `json:"carb_g"`
+	WithinTolerance bool    `json:"within_tolerance"`
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentOrder คำสั่งซื้อแพ็กเกจ สมาชิกเริ่มใช้งานเมื่อชำระเงินสำเร็จเท่านั้น
+type PaymentOrder struct {
+	gorm.Model
+	OrderNo string `json:"order_no" gorm:"uniqueIndex"`
+	UserID  uint   `json:"user_id" gorm:"index;uniqueIndex:idx_order_idempotency"`
+	// คีย์จากฝั่งผู้เรียก ส่งซ้ำด้วยคีย์เดิมจะได้คำสั่งซื้อเดิมกลับไป
+	IdempotencyKey *string `json:"idempotency_key,omitempty" gorm:"uniqueIndex:idx_order_idempotency"`
+
+	PackageID uint     `json:"package_id"`
+	Package   *Package `gorm:"foreignKey:PackageID" json:"package,omitempty"`
+	AutoRenew bool     `json:"auto_renew"`
+	Amount    uint     `json:"amount"` // บาท
+	Currency  string   `json:"currency" gorm:"default:'THB'"`
+
+	// new = สมัครใหม่, renewal = ต่ออายุรอบถัดไป, change = เปลี่ยนแพ็กเกจกลางรอบ
+	Purpose string `json:"purpose" gorm:"default:'new'"`
+	// รอบสมาชิกเดิมที่ต่ออายุหรือเปลี่ยนแพ็กเกจ ใช้ตรวจตอนชำระเงินว่ายังเป็นรอบล่าสุดอยู่
+	PreviousMemberID *uint `json:"previous_member_id,omitempty"`
+	// เครดิตที่หักจากราคาแพ็กเกจแล้ว (มูลค่าที่เหลือของรอบเดิมและเครดิตที่ยกมา)
+	ProrationCredit uint `json:"proration_credit"`
+
+	// สาขาที่รับชำระ ใช้รันเลขที่ใบกำกับภาษี
+	BranchID uint `json:"branch_id"`
+
+	// pending, paid, failed, expired, cancelled, refunded (ออกใบลดหนี้เต็มจำนวนแล้ว)
+	Status        string     `json:"status" gorm:"default:'pending';index"`
+	Method        string     `json:"method"`   // promptpay, counter
+	Provider      string     `json:"provider"` // ชื่อ payment gateway
+	ProviderRef   string     `json:"provider_ref" gorm:"index"`
+	QRPayload     string     `json:"qr_payload,omitempty"` // ข้อความ EMVCo สำหรับสร้าง QR พร้อมเพย์
+	RedirectURL   string     `json:"redirect_url,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	PaidAt        *time.Time `json:"paid_at"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+
+	// รอบสมาชิกที่สร้างหลังชำระเงิน
+	PackageMemberID *uint `json:"package_member_id"`
+}
+
+// PaymentEvent webhook ที่ได้รับจาก payment gateway เก็บไว้กันประมวลผลซ้ำ (provider + event_id ไม่ซ้ำ)
+type PaymentEvent struct {
+	gorm.Model
+	Provider       string     `json:"provider" gorm:"uniqueIndex:idx_payment_event"`
+	EventID        string     `json:"event_id" gorm:"uniqueIndex:idx_payment_event"`
+	Type           string     `json:"type"`
+	ProviderRef    string     `json:"provider_ref"`
+	PaymentOrderID *uint      `json:"payment_order_id"`
+	Payload        string     `json:"-"`
+	ProcessedAt    *time.Time `json:"processed_at"`
+	Result         string     `json:"result"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// Recipe สูตรอาหารที่ประกอบจากอาหารในแคตตาล็อก ค่าสารอาหารต่อหนึ่งที่คำนวณจากส่วนผสม
+type Recipe struct {
+	gorm.Model
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	MealTypes   []string           `json:"meal_types" gorm:"serializer:json"` // breakfast, lunch, dinner, snack
+	Servings    int                `json:"servings"`                          // จำนวนที่ที่ส่วนผสมทั้งหมดทำได้
+	Ingredients []RecipeIngredient `gorm:"foreignKey:RecipeID" json:"ingredients"`
+
+	CreatedByTrainerID *uint `json:"created_by_trainer_id"` // nil = สูตรของระบบ
+
+	// คำนวณโดยระบบจากส่วนผสม
+	EnergyKcal  float64  `json:"energy_kcal"` // ต่อหนึ่งที่
+	ProteinG    float64  `json:"protein_g"`
+	FatG        float64  `json:"fat_g"`
+	CarbG       float64  `json:"carb_g"`
+	DietaryTags []string `json:"dietary_tags" gorm:"serializer:json"` // ทุกส่วนผสมต้องมีแท็กนั้น
+	Allergens   []string `json:"allergens" gorm:"serializer:json"`    // รวมจากทุกส่วนผสม
+}
+
+// RecipeIngredient ส่วนผสมของสูตร ปริมาณรวมของทั้งสูตร
+type RecipeIngredient struct {
+	gorm.Model
+	RecipeID uint    `json:"recipe_id"`
+	FoodID   uint    `json:"food_id"`
+	Food     *Food   `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+	Grams    float64 `json:"grams"`
+}
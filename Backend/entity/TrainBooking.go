@@ -20,4 +20,25 @@ type TrainBooking struct {
 
 	// เวลาเพิ่มให้สามารถบันทึกวันจองและเวลาได้ (optional)
 	BookingDate time.Time `json:"booking_date"`
+
+	// ตามนโยบายการยกเลิก/เลื่อนนัด (BookingPolicy)
+	RescheduleCount int        `json:"reschedule_count" gorm:"default:0"`
+	LateCancel      bool       `json:"late_cancel" gorm:"default:false"`
+	NoShow          bool       `json:"no_show" gorm:"default:false"`
+	CancelledAt     *time.Time `json:"cancelled_at"`
+
+	History []TrainBookingHistory `gorm:"foreignKey:TrainBookingID" json:"history,omitempty"`
+}
+
+// ประวัติการเปลี่ยนสถานะหรือเลื่อนเวลาของการจองเทรนเนอร์
+type TrainBookingHistory struct {
+	gorm.Model
+	TrainBookingID uint   `json:"train_booking_id"`
+	FromStatus     string `json:"from_status"`
+	ToStatus       string `json:"to_status"`
+	FromScheduleID uint   `json:"from_schedule_id"`
+	ToScheduleID   uint   `json:"to_schedule_id"`
+	Actor          string `json:"actor"` // customer, trainer, admin
+	ActorID        uint   `json:"actor_id"`
+	Note           string `json:"note"`
 }
\ No newline at end of file
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// TrainerAvailability ช่วงเวลาว่างประจำสัปดาห์ของเทรนเนอร์ ใช้สร้าง TrainerSchedule อัตโนมัติ
+type TrainerAvailability struct {
+	gorm.Model
+	TrainerID uint     `json:"trainer_id"`
+	Trainer   *Trainer `gorm:"foreignKey:TrainerID" json:"-"`
+
+	Weekday       int    `json:"weekday"`    // 0 = อาทิตย์ ... 6 = เสาร์
+	StartTime     string `json:"start_time"` // HH:mm
+	EndTime       string `json:"end_time"`   // HH:mm
+	SlotMinutes   int    `json:"slot_minutes"`
+	BufferMinutes int    `json:"buffer_minutes"`
+}
+
+// TrainerLeave วันลาของเทรนเนอร์ ซึ่งจะไม่ถูกสร้างช่วงเวลาว่าง
+type TrainerLeave struct {
+	gorm.Model
+	TrainerID uint     `json:"trainer_id"`
+	Trainer   *Trainer `gorm:"foreignKey:TrainerID" json:"-"`
+
+	Date   string `json:"date"` // YYYY-MM-DD
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrainingProgram โปรแกรมฝึกแบบมีโครงสร้าง: โปรแกรม -> ช่วง (phase) -> เซสชัน -> ท่าที่กำหนด
+// ถ้า IsTemplate เป็น true จะใช้เป็นต้นแบบสำหรับคัดลอกให้ลูกค้า
+type TrainingProgram struct {
+	gorm.Model
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsTemplate  bool   `json:"is_template" gorm:"default:false"`
+	Status      string `json:"status" gorm:"default:'active'"` // active หรือ superseded (ถูกแทนด้วยเวอร์ชันใหม่)
+
+	TrainerID uint     `json:"trainer_id"`
+	Trainer   *Trainer `gorm:"foreignKey:TrainerID" json:"trainer,omitempty"`
+
+	// ลูกค้าที่ได้รับโปรแกรม (ว่างสำหรับต้นแบบ)
+	UserID    *uint      `json:"user_id"`
+	User      *Users     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	StartDate *time.Time `json:"start_date"`
+
+	// การคัดลอกและเวอร์ชัน: ทุกเวอร์ชันอ้าง RootProgramID เดียวกัน
+	SourceTemplateID  *uint `json:"source_template_id"`
+	RootProgramID     *uint `json:"root_program_id"`
+	PreviousVersionID *uint `json:"previous_version_id"`
+	Version           int   `json:"version" gorm:"default:1"`
+
+	Phases []ProgramPhase `gorm:"foreignKey:ProgramID" json:"phases"`
+}
+
+// ProgramPhase ช่วงของโปรแกรม เช่น Hypertrophy 4 สัปดาห์
+type ProgramPhase struct {
+	gorm.Model
+	ProgramID uint   `json:"program_id"`
+	Name      string `json:"name"`
+	Position  int    `json:"position"`
+	Weeks     int    `json:"weeks"`
+
+	Sessions []ProgramSession `gorm:"foreignKey:PhaseID" json:"sessions"`
+}
+
+// ProgramSession เซสชันฝึกในสัปดาห์/วันที่กำหนดของช่วงนั้น
+type ProgramSession struct {
+	gorm.Model
+	PhaseID     uint       `json:"phase_id"`
+	Name        string     `json:"name"`
+	Week        int        `json:"week"` // สัปดาห์ที่ภายในช่วง เริ่มที่ 1
+	Day         int        `json:"day"`  // วันที่ภายในสัปดาห์ เริ่มที่ 1
+	Position    int        `json:"position"`
+	Notes       string     `json:"notes"`
+	CompletedAt *time.Time `json:"completed_at"`
+
+	Exercises []ExercisePrescription `gorm:"foreignKey:SessionID" json:"exercises"`
+}
+
+// ExercisePrescription ท่าฝึกที่กำหนดในเซสชัน
+type ExercisePrescription struct {
+	gorm.Model
+	SessionID    uint      `json:"session_id"`
+	ExerciseID   *uint     `json:"exercise_id"` // ท่าในคลังท่าฝึก (ถ้ามี)
+	Exercise     *Exercise `gorm:"foreignKey:ExerciseID" json:"exercise,omitempty"`
+	ExerciseName string    `json:"exercise_name"`
+	Position     int       `json:"position"`
+	Sets         int       `json:"sets"`
+	Reps         string    `json:"reps"`         // เช่น "8-12" หรือ "5"
+	LoadKg       float64   `json:"load_kg"`      // น้ำหนักที่กำหนด (0 = น้ำหนักตัว)
+	LoadPercent  float64   `json:"load_percent"` // หรือเปอร์เซ็นต์ของ 1RM
+	Tempo        string    `json:"tempo"`        // เช่น "3-1-1-0"
+	RestSeconds  int       `json:"rest_seconds"`
+	RPE          float64   `json:"rpe"`
+	Notes        string    `json:"notes"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// WellnessCheckIn บันทึกสุขภาวะประจำวัน วันละหนึ่งรายการต่อผู้ใช้ ค่า 0 = ไม่ได้บันทึก
+type WellnessCheckIn struct {
+	gorm.Model
+	UserID uint   `json:"user_id" gorm:"uniqueIndex:idx_wellness_user_date"`
+	Date   string `json:"date" gorm:"uniqueIndex:idx_wellness_user_date"` // YYYY-MM-DD
+
+	WaterMl      int     `json:"water_ml"`
+	SleepHours   float64 `json:"sleep_hours"`   // การนอนคืนก่อนหน้า
+	SleepQuality int     `json:"sleep_quality"` // 1-5 (5 = ดีมาก)
+	RestingHR    int     `json:"resting_hr"`    // ชีพจรขณะพักตอนตื่นนอน
+	Mood         int     `json:"mood"`          // 1-5 (5 = ดีมาก)
+	Soreness     int     `json:"soreness"`      // 1-5 (5 = ปวดเมื่อยมาก)
+	Stress       int     `json:"stress"`        // 1-5 (5 = เครียดมาก)
+	Note         string  `json:"note"`
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WorkoutLog บันทึกการฝึกเวท หนึ่งครั้งประกอบด้วยหลายท่า แต่ละท่ามีหลายเซต
+type WorkoutLog struct {
+	gorm.Model
+	UserID          uint      `json:"user_id"`
+	Date            time.Time `json:"date"`
+	Title           string    `json:"title"`
+	Notes           string    `json:"notes"`
+	DurationMinutes float64   `json:"duration_minutes"`
+
+	// เชื่อมกับนัดเทรนส่วนตัว และ/หรือเซสชันในโปรแกรมฝึก เพื่อเทียบกับที่กำหนดไว้
+	PersonalTrainID  *uint           `json:"personal_train_id"`
+	PersonalTrain    *PersonalTrain  `gorm:"foreignKey:PersonalTrainID" json:"personal_train,omitempty"`
+	ProgramSessionID *uint           `json:"program_session_id"`
+	ProgramSession   *ProgramSession `gorm:"foreignKey:ProgramSessionID" json:"program_session,omitempty"`
+
+	Exercises []WorkoutExercise `gorm:"foreignKey:WorkoutLogID" json:"exercises"`
+}
+
+// WorkoutExercise ท่าที่ทำในการฝึกครั้งนั้น
+type WorkoutExercise struct {
+	gorm.Model
+	WorkoutLogID uint      `json:"workout_log_id"`
+	ExerciseID   *uint     `json:"exercise_id"`
+	Exercise     *Exercise `gorm:"foreignKey:ExerciseID" json:"exercise,omitempty"`
+	ExerciseName string    `json:"exercise_name"`
+	Position     int       `json:"position"`
+
+	Sets []WorkoutSet `gorm:"foreignKey:WorkoutExerciseID" json:"sets"`
+}
+
+// WorkoutSet เซตที่ทำจริง
+type WorkoutSet struct {
+	gorm.Model
+	WorkoutExerciseID uint    `json:"workout_exercise_id"`
+	SetNumber         int     `json:"set_number"`
+	Reps              int     `json:"reps"`
+	WeightKg          float64 `json:"weight_kg"`
+	RPE               float64 `json:"rpe"`
+	RestSeconds       int     `json:"rest_seconds"`
+	IsWarmup          bool    `json:"is_warmup"`
+	Estimated1RM      float64 `json:"estimated_1rm" gorm:"column:estimated_1rm"` // คำนวณโดยระบบ (สูตร Epley)
+}
+
+// PersonalRecord สถิติส่วนตัวต่อท่า เก็บทุกครั้งที่ทำลายสถิติเดิมเพื่อดูพัฒนาการ
+type PersonalRecord struct {
+	gorm.Model
+	UserID       uint      `json:"user_id" gorm:"index"`
+	ExerciseKey  string    `json:"exercise_key" gorm:"index"` // exercise:<id> หรือ name:<ชื่อท่า>
+	ExerciseID   *uint     `json:"exercise_id"`
+	ExerciseName string    `json:"exercise_name"`
+	Kind         string    `json:"kind"` // estimated_1rm, max_weight, max_volume, max_reps (ท่าน้ำหนักตัว)
+	Value        float64   `json:"value"`
+	Reps         int       `json:"reps"`
+	WeightKg     float64   `json:"weight_kg"`
+	WorkoutLogID uint      `json:"workout_log_id"`
+	AchievedAt   time.Time `json:"achieved_at"`
+}
@@ -16,4 +16,10 @@ type Health struct {
     UserID     uint       `json:"user_id"` 
     User       *Users     `gorm:"foreignKey:UserID" json:"-"` 
     Activities []Activity `gorm:"foreignKey:HealthID"`
+
+    // คำนวณโดยระบบ: Bmi/Status จาก Weight/Height และกลุ่มความดันตามเกณฑ์ AHA
+    BmiCategory      string `json:"bmi_category"` // underweight, normal, overweight, obese_1, obese_2
+    Systolic         int    `json:"systolic"`
+    Diastolic        int    `json:"diastolic"`
+    PressureCategory string `json:"pressure_category"` // normal, elevated, hypertension_stage_1, hypertension_stage_2, hypertensive_crisis
 }
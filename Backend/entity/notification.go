@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// Notification ข้อความแจ้งเตือนถึงสมาชิก เช่น เมื่อเทรนเนอร์เลื่อนเวลาที่จองไว้
+type Notification struct {
+	gorm.Model
+	UserID  uint   `json:"user_id"`
+	Title   string `json:"title"`
+	Message string `json:"message"`
+	IsRead  bool   `json:"is_read" gorm:"default:false"`
+}
@@ -1,12 +1,40 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// PackageMember การสมัครแพ็กเกจหนึ่งรอบ (subscription) ผู้ใช้หนึ่งคนมีได้หลายแถวเป็นประวัติ
+// แต่มีรอบที่ active หรือ frozen ได้ครั้งละหนึ่งรอบ
 type PackageMember struct {
 	gorm.Model
-	UserID   uint   `gorm:"uniqueIndex" json:"user_id"`
+	UserID   uint   `gorm:"index" json:"user_id"`
 	Username *Users `gorm:"foreignKey:UserID" json:"username"`
 
 	PackageID uint     `json:"package_id"`
 	Package   *Package `gorm:"foreignKey:PackageID" json:"package"`
-}
\ No newline at end of file
+
+	// ช่วงเวลาตามรอบของแพ็กเกจ (รายเดือน/รายปี) EndDate เลื่อนออกไปตามวันที่พักสมาชิก
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	// scheduled (ต่ออายุล่วงหน้า รอเริ่ม), active, frozen, expired, cancelled
+	Status    string `json:"status" gorm:"default:'active';index"`
+	AutoRenew bool   `json:"auto_renew"`
+
+	// new, renewal, upgrade, downgrade
+	ChangeType string `json:"change_type"`
+	PreviousID *uint  `json:"previous_id"` // รอบก่อนหน้าที่ต่ออายุหรือเปลี่ยนแพ็กเกจมา
+
+	// ราคาเป็นบาท: ราคาแพ็กเกจ ณ วันที่สมัคร, เครดิตจากรอบเดิมที่ยังไม่ได้ใช้, ยอดที่ต้องชำระ
+	// และเครดิตที่เหลือยกไปใช้ตอนต่ออายุครั้งถัดไป
+	Price           uint `json:"price"`
+	ProrationCredit uint `json:"proration_credit"`
+	AmountDue       uint `json:"amount_due"`
+	CreditCarried   uint `json:"credit_carried"`
+
+	FrozenAt    *time.Time `json:"frozen_at"`
+	FrozenUntil *time.Time `json:"frozen_until"`
+	CancelledAt *time.Time `json:"cancelled_at"`
+}
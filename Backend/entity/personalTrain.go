@@ -17,4 +17,8 @@ type PersonalTrain struct {
 	TrainerID   uint       `json:"trainer_id" gorm:"column:trainer_id"`
 	TrainerName *Trainer   `gorm:"foreignKey:TrainerID" json:"trainer_name"`
 	Time        string     `json:"time" gorm:"column:time"`
+
+	// โปรแกรมฝึกแบบมีโครงสร้างที่ใช้ในการนัดนี้ (ถ้ามี)
+	ProgramID *uint            `json:"program_id" gorm:"column:program_id"`
+	Program   *TrainingProgram `gorm:"foreignKey:ProgramID" json:"program,omitempty"`
 }
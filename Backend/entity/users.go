@@ -26,6 +26,17 @@ type Users struct {
 
 	Avatar string `json:"avatar"` // เก็บ URL ของรูปโปรไฟล์
 
+	// อัตราการเต้นหัวใจสำหรับคำนวณโซนและภาระการฝึก (0 = ใช้ค่าประมาณจากอายุ)
+	RestingHeartRate int `json:"resting_heart_rate"`
+
+	MaxHeartRate int `json:"max_heart_rate"`
+
+	// วิธีคำนวณโซน: percent_max (% ของชีพจรสูงสุด) หรือ karvonen (% ของ heart rate reserve)
+	HRZoneMethod string `json:"hr_zone_method"`
+
+	// เปอร์เซ็นต์ขอบล่างของแต่ละโซน เช่น [50, 60, 70, 80, 90] (ว่าง = ค่าเริ่มต้น)
+	HRZoneBounds []float64 `json:"hr_zone_bounds" gorm:"serializer:json"`
+
 	Healths []Health `gorm:"foreignKey:UserID"` // ใช้ UserID ใน Health
 
 	// ความสัมพันธ์กับการจองเทรนเนอร์ของผู้ใช้
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,6 +18,7 @@ import (
 	"time"
 
 	"example.com/fitness-backend/routes"
+	"example.com/fitness-backend/services"
 	"github.com/gin-contrib/cors"
 )
 
@@ -29,13 +31,48 @@ func main() {
 	// Generate databases
 	config.SetupDatabase()
 
+	// แปลงข้อมูลสุขภาพเดิม (ความดันแบบข้อความ, BMI จากฝั่งผู้ใช้) เป็นค่าที่ระบบคำนวณ
+	if err := services.MigrateHealthRecords(); err != nil {
+		log.Printf("migrate health records: %v", err)
+	}
+
+	// เติมวันเริ่ม/หมดอายุให้สมาชิกแพ็กเกจเดิม แล้วอัปเดตสถานะตามเวลาปัจจุบัน
+	if err := services.MigratePackageMembers(); err != nil {
+		log.Printf("migrate package members: %v", err)
+	}
+
+	// เติมแท็กอาหาร/สารก่อภูมิแพ้ให้แคตตาล็อกอาหารเดิมที่สร้างก่อนมีแท็ก
+	if err := services.BackfillFoodTags(); err != nil {
+		log.Printf("backfill food tags: %v", err)
+	}
+
+	// ผู้ให้บริการค้นหาบาร์โค้ด (BARCODE_PROVIDER)
+	if err := services.ConfigureBarcodeProvider(); err != nil {
+		log.Printf("barcode provider: %v", err)
+	}
+
+	// สูตรอาหารเริ่มต้นสำหรับสร้างแผนอาหาร (ต้องมีแคตตาล็อกอาหารก่อน)
+	if err := services.SeedRecipes(); err != nil {
+		log.Printf("seed recipes: %v", err)
+	}
+
+	// สิทธิ์เริ่มต้นของแพ็กเกจที่ยังไม่ได้กำหนด (จองคลาส เทรนส่วนตัว บริการเสริม)
+	if err := services.SeedPackageEntitlements(); err != nil {
+		log.Printf("seed package entitlements: %v", err)
+	}
+
+	// ผู้ให้บริการชำระเงินออนไลน์ (PAYMENT_GATEWAY) ต้องตั้งก่อนลงทะเบียน routes
+	if err := services.ConfigurePaymentGateway(); err != nil {
+		log.Printf("payment gateway: %v", err)
+	}
+
 	r := gin.Default()
 
 	// เปิด CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Idempotency-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -50,6 +87,9 @@ func main() {
 	r.POST("/upload", uploads.Upload)
 	r.GET("/genders", genders.GetAll)
 	routes.PublicClassRoutes(r)
+	routes.PublicCalendarRoutes(r)
+	routes.PublicProgressRoutes(r)
+	routes.PublicPaymentRoutes(r)
 
 	// API Group (with authentication)
 	api := r.Group("/api")
@@ -66,6 +106,15 @@ func main() {
 		// Health & Activity Routes
 		routes.HealthRoutes(api)
 
+		// Personal goal Routes
+		routes.GoalRoutes(api)
+
+		// Body measurement & progress photo Routes
+		routes.ProgressRoutes(api)
+
+		// Recipe & meal plan Routes
+		routes.MealPlanRoutes(api)
+
 		// Trainer-related Routes
 		routes.TrainerRoutes(api)
 
@@ -75,6 +124,9 @@ func main() {
 		// Equipment Routes
 		routes.EquipmentRoutes(api)
 
+		// Exercise library Routes
+		routes.ExerciseRoutes(api)
+
 		// Facility Routes
 		routes.FacilityRoutes(api)
 
@@ -86,8 +138,18 @@ func main() {
 
 		routes.PackagememberRoutes(api)
 
+		routes.EntitlementRoutes(api)
+
+		routes.PaymentRoutes(api)
+
+		routes.InvoiceRoutes(api)
+
 		routes.ServicesRoutes(api)
 
+		routes.NotificationRoutes(api)
+
+		routes.CalendarRoutes(api)
+
 	}
 
 	r.GET("/", func(c *gin.Context) {
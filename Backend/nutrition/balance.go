@@ -0,0 +1,79 @@
+package nutrition
+
+import (
+	"fmt"
+	"math"
+)
+
+// KcalPerKg พลังงานโดยประมาณต่อน้ำหนักตัวที่เปลี่ยนไป 1 กิโลกรัม
+const KcalPerKg = 7700
+
+// TrendWindowDays จำนวนวันย้อนหลังที่ใช้เทียบน้ำหนักจริงกับที่คาดไว้
+const TrendWindowDays = 14
+
+// AdjustThresholdKg ต้องคลาดเคลื่อนอย่างน้อยเท่านี้จึงแนะนำให้ปรับแผน
+const AdjustThresholdKg = 0.5
+
+// MaxDailyCorrection ปรับพลังงานเป้าหมายได้ไม่เกินเท่านี้ต่อครั้ง (kcal/วัน)
+const MaxDailyCorrection = 250
+
+// Expenditure พลังงานที่ใช้ต่อวัน: BMR × ตัวคูณขั้นต่ำ (กิจวัตรทั่วไป) + กิจกรรมที่บันทึกไว้
+// ใช้ตัวคูณ Sedentary เพื่อไม่ให้นับการออกกำลังกายซ้ำกับกิจกรรมที่บันทึก
+func Expenditure(bmr, exerciseKcal float64) (baseline, total float64) {
+	baseline = round1(bmr * ActivityFactors[Sedentary])
+	return baseline, round1(baseline + exerciseKcal)
+}
+
+// PredictWeightChange น้ำหนักที่คาดว่าจะเปลี่ยนจากพลังงานสุทธิสะสม (บวก = เพิ่ม)
+func PredictWeightChange(netKcal float64) float64 {
+	return math.Round(netKcal/KcalPerKg*100) / 100
+}
+
+// Adjustment ข้อเสนอปรับพลังงานเป้าหมายเมื่อแนวโน้มน้ำหนักจริงต่างจากที่คาด
+type Adjustment struct {
+	ExpectedChangeKg float64 `json:"expected_change_kg"`
+	ActualChangeKg   float64 `json:"actual_change_kg"`
+	DivergenceKg     float64 `json:"divergence_kg"` // จริง - คาด
+	CurrentTarget    float64 `json:"current_target"`
+	SuggestedTarget  float64 `json:"suggested_target"`
+	CorrectionKcal   float64 `json:"correction_kcal"`
+	Recommended      bool    `json:"recommended"`
+	Reason           string  `json:"reason"`
+}
+
+// TrendAdjustment เทียบน้ำหนักที่เปลี่ยนจริงกับที่คาดจากสมดุลพลังงานใน days วัน
+// ถ้าน้ำหนักขึ้นมากกว่าที่คาด แปลว่าใช้พลังงานจริงน้อยกว่าที่ประมาณ จึงลดเป้าหมายลง (และกลับกัน)
+// เป้าหมายใหม่ไม่ต่ำกว่า BMR
+func TrendAdjustment(expectedKg, actualKg float64, days int, currentTarget, bmr float64) Adjustment {
+	a := Adjustment{
+		ExpectedChangeKg: math.Round(expectedKg*100) / 100,
+		ActualChangeKg:   math.Round(actualKg*100) / 100,
+		CurrentTarget:    currentTarget,
+		SuggestedTarget:  currentTarget,
+	}
+	a.DivergenceKg = math.Round((actualKg-expectedKg)*100) / 100
+	if days <= 0 || math.Abs(a.DivergenceKg) < AdjustThresholdKg {
+		a.Reason = fmt.Sprintf("น้ำหนักจริงต่างจากที่คาดไม่ถึง %.1f กก. ยังไม่ต้องปรับแผน", AdjustThresholdKg)
+		return a
+	}
+
+	correction := -a.DivergenceKg * KcalPerKg / float64(days)
+	correction = math.Max(-MaxDailyCorrection, math.Min(MaxDailyCorrection, correction))
+	suggested := math.Round(currentTarget + correction)
+	if suggested < bmr {
+		suggested = math.Round(bmr)
+	}
+	a.SuggestedTarget = suggested
+	a.CorrectionKcal = suggested - currentTarget
+	a.Recommended = a.CorrectionKcal != 0
+	direction := "สูงกว่า"
+	if a.DivergenceKg < 0 {
+		direction = "ต่ำกว่า"
+	}
+	a.Reason = fmt.Sprintf("ใน %d วัน น้ำหนักเปลี่ยน %+.2f กก. ขณะที่คาดไว้ %+.2f กก. (%sที่คาด %.2f กก.) ปรับเป้าหมาย %+.0f kcal/วัน",
+		days, a.ActualChangeKg, a.ExpectedChangeKg, direction, math.Abs(a.DivergenceKg), a.CorrectionKcal)
+	if !a.Recommended {
+		a.Reason = "เป้าหมายเท่ากับ BMR แล้ว ไม่ควรลดพลังงานลงอีก"
+	}
+	return a
+}
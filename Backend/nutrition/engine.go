@@ -0,0 +1,356 @@
+// Package nutrition คำนวณพลังงานและสารอาหารที่ควรได้รับต่อวัน
+// ไม่มีการเข้าถึงฐานข้อมูล ผู้เรียกเป็นผู้เตรียมข้อมูลร่างกายและกิจกรรมให้
+package nutrition
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Sex เพศสำหรับเลือกค่าคงที่ในสูตร
+type Sex string
+
+const (
+	Male        Sex = "male"
+	Female      Sex = "female"
+	Unspecified Sex = "unspecified" // ใช้ค่ากึ่งกลางระหว่างสูตรชายและหญิง
+)
+
+// Formula สูตรคำนวณ BMR
+type Formula string
+
+const (
+	MifflinStJeor  Formula = "mifflin_st_jeor"
+	HarrisBenedict Formula = "harris_benedict"
+	KatchMcArdle   Formula = "katch_mcardle"
+)
+
+// ActivityLevel ระดับกิจกรรมที่ผู้ใช้เลือก
+type ActivityLevel string
+
+const (
+	Sedentary  ActivityLevel = "sedentary"
+	Light      ActivityLevel = "light"
+	Moderate   ActivityLevel = "moderate"
+	Active     ActivityLevel = "active"
+	VeryActive ActivityLevel = "very_active"
+)
+
+// Goal เป้าหมายด้านน้ำหนัก
+type Goal string
+
+const (
+	Lose     Goal = "lose"
+	Maintain Goal = "maintain"
+	Gain     Goal = "gain"
+)
+
+// MacroStrategy วิธีแบ่งพลังงานเป็นโปรตีน ไขมัน คาร์โบไฮเดรต
+type MacroStrategy string
+
+const (
+	Balanced    MacroStrategy = "balanced"
+	HighProtein MacroStrategy = "high_protein"
+	LowCarb     MacroStrategy = "low_carb"
+)
+
+// ActivityFactors ตัวคูณ BMR ของแต่ละระดับกิจกรรม
+var ActivityFactors = map[ActivityLevel]float64{
+	Sedentary:  1.2,
+	Light:      1.375,
+	Moderate:   1.55,
+	Active:     1.725,
+	VeryActive: 1.9,
+}
+
+// GoalAdjustments สัดส่วนที่ปรับจาก TDEE ตามเป้าหมาย
+var GoalAdjustments = map[Goal]float64{
+	Lose:     -0.20,
+	Maintain: 0,
+	Gain:     0.10,
+}
+
+// ActivityWindowDays จำนวนวันย้อนหลังของกิจกรรมที่ใช้ประมาณระดับกิจกรรม
+const ActivityWindowDays = 14
+
+// ระดับกิจกรรมเริ่มต้นเมื่อผู้ใช้ไม่ได้เลือกและไม่มีกิจกรรมที่บันทึกไว้
+const defaultActivityLevel = Light
+
+// Input ข้อมูลที่ใช้คำนวณ
+type Input struct {
+	Sex            Sex
+	Age            int
+	WeightKg       float64
+	HeightCm       float64
+	BodyFatPercent float64 // 0 = ไม่ทราบ
+
+	Formula       Formula       // ว่าง = Mifflin-St Jeor
+	ActivityLevel ActivityLevel // ว่าง = ประมาณจากกิจกรรมย้อนหลัง
+
+	// แคลอรี่จากกิจกรรมรวมใน ActivityWindowDays วันล่าสุด และจำนวนกิจกรรม
+	ActivityCalories float64
+	ActivityCount    int
+
+	Goal     Goal
+	Strategy MacroStrategy // ว่าง = ตามเป้าหมาย
+}
+
+// Step ขั้นตอนการคำนวณพร้อมคำอธิบาย
+type Step struct {
+	Name   string  `json:"step"`
+	Detail string  `json:"detail"`
+	Value  float64 `json:"value"`
+}
+
+// Macros ปริมาณสารอาหารหลักเป็นกรัม
+type Macros struct {
+	ProteinG float64 `json:"protein_g"`
+	FatG     float64 `json:"fat_g"`
+	CarbG    float64 `json:"carb_g"`
+}
+
+// Result ผลการคำนวณ
+type Result struct {
+	Formula         Formula       `json:"formula"`
+	BMR             float64       `json:"bmr"`
+	ActivityLevel   ActivityLevel `json:"activity_level"`
+	ActivityFactor  float64       `json:"activity_factor"`
+	ActivityDerived bool          `json:"activity_derived"` // true = ประมาณจากกิจกรรมที่บันทึก
+	TDEE            float64       `json:"tdee"`
+	Goal            Goal          `json:"goal"`
+	GoalAdjustment  float64       `json:"goal_adjustment"` // สัดส่วน เช่น -0.2
+	TargetCalories  float64       `json:"target_calories"`
+	Strategy        MacroStrategy `json:"macro_strategy"`
+	Macros          Macros        `json:"macros"`
+	Steps           []Step        `json:"steps"`
+}
+
+// ParseGoal แปลงเป้าหมายจากข้อความที่ผู้ใช้ส่งมา (รองรับค่าภาษาไทยเดิม) ค่าที่ไม่รู้จักถือเป็นรักษาน้ำหนัก
+func ParseGoal(s string) Goal {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "ลดน้ำหนัก", "ลดไขมัน", "cut", "loss", "lose":
+		return Lose
+	case "เพิ่มกล้ามเนื้อ", "เพิ่มน้ำหนัก", "bulk", "gain":
+		return Gain
+	default:
+		return Maintain
+	}
+}
+
+// Calculate คำนวณ BMR, TDEE, พลังงานเป้าหมาย และสารอาหารหลัก
+func Calculate(in Input) (Result, error) {
+	if err := validate(in); err != nil {
+		return Result{}, err
+	}
+	var r Result
+	r.Formula, r.BMR, r.Steps = bmr(in)
+
+	r.ActivityLevel, r.ActivityFactor, r.ActivityDerived = activityFactor(in, r.BMR)
+	switch {
+	case !r.ActivityDerived:
+		r.Steps = append(r.Steps, Step{"activity_factor", fmt.Sprintf("ระดับกิจกรรม %s ตัวคูณ %.3g", r.ActivityLevel, r.ActivityFactor), r.ActivityFactor})
+	default:
+		daily := in.ActivityCalories / ActivityWindowDays
+		r.Steps = append(r.Steps, Step{"activity_factor", fmt.Sprintf(
+			"เฉลี่ยเผาผลาญจากกิจกรรม %.0f kcal/วัน ใน %d วันล่าสุด (%d กิจกรรม) ตัวคูณ = 1.2 + %.0f/BMR = %.3f ใกล้เคียงระดับ %s",
+			daily, ActivityWindowDays, in.ActivityCount, daily, r.ActivityFactor, r.ActivityLevel), r.ActivityFactor})
+	}
+	r.TDEE = round1(r.BMR * r.ActivityFactor)
+	r.Steps = append(r.Steps, Step{"tdee", fmt.Sprintf("TDEE = BMR × ตัวคูณ = %.0f × %.3f", r.BMR, r.ActivityFactor), r.TDEE})
+
+	r.Goal = in.Goal
+	if _, ok := GoalAdjustments[r.Goal]; !ok {
+		r.Goal = Maintain
+	}
+	r.GoalAdjustment = GoalAdjustments[r.Goal]
+	target := r.TDEE * (1 + r.GoalAdjustment)
+	r.Steps = append(r.Steps, Step{"goal_adjustment", fmt.Sprintf("เป้าหมาย %s ปรับ %+.0f%% จาก TDEE", r.Goal, r.GoalAdjustment*100), round1(target)})
+	if target < r.BMR {
+		target = r.BMR
+		r.Steps = append(r.Steps, Step{"minimum_intake", "พลังงานเป้าหมายต่ำกว่า BMR จึงใช้ BMR เป็นขั้นต่ำ", round1(target)})
+	}
+	r.TargetCalories = math.Round(target)
+
+	r.Strategy = in.Strategy
+	if r.Strategy == "" {
+		r.Strategy = DefaultStrategy(r.Goal)
+	}
+	macros, steps, err := CalculateMacros(r.TargetCalories, in.WeightKg, r.Strategy)
+	if err != nil {
+		return r, err
+	}
+	r.Macros = macros
+	r.Steps = append(r.Steps, steps...)
+	return r, nil
+}
+
+// DefaultStrategy วิธีแบ่งสารอาหารเริ่มต้นของแต่ละเป้าหมาย
+func DefaultStrategy(goal Goal) MacroStrategy {
+	if goal == Lose {
+		return HighProtein
+	}
+	return Balanced
+}
+
+// CalculateMacros แบ่งพลังงานเป้าหมายเป็นสารอาหารหลัก
+// โปรตีนคิดตามน้ำหนักตัว ไขมันหรือคาร์โบไฮเดรตคิดเป็นสัดส่วนพลังงาน ส่วนที่เหลือเป็นสารอาหารตัวที่สาม
+func CalculateMacros(targetKcal, weightKg float64, strategy MacroStrategy) (Macros, []Step, error) {
+	if targetKcal <= 0 || weightKg <= 0 {
+		return Macros{}, nil, fmt.Errorf("พลังงานเป้าหมายและน้ำหนักต้องมากกว่า 0")
+	}
+	var proteinPerKg, fixedShare float64
+	switch strategy {
+	case Balanced:
+		proteinPerKg, fixedShare = 1.6, 0.25 // ไขมัน 25%
+	case HighProtein:
+		proteinPerKg, fixedShare = 2.2, 0.25 // ไขมัน 25%
+	case LowCarb:
+		proteinPerKg, fixedShare = 1.8, 0.20 // คาร์โบไฮเดรต 20%
+	default:
+		return Macros{}, nil, fmt.Errorf("ไม่รู้จักวิธีแบ่งสารอาหาร %s", strategy)
+	}
+
+	var m Macros
+	var steps []Step
+	m.ProteinG = weightKg * proteinPerKg
+	steps = append(steps, Step{"protein", fmt.Sprintf("โปรตีน %.1f ก./กก. × %.1f กก.", proteinPerKg, weightKg), round1(m.ProteinG)})
+	remaining := targetKcal - m.ProteinG*4
+	if remaining < 0 {
+		m.ProteinG = targetKcal / 4
+		remaining = 0
+		steps = append(steps, Step{"protein_capped", "โปรตีนเกินพลังงานเป้าหมาย จึงลดให้เท่ากับพลังงานทั้งหมด", round1(m.ProteinG)})
+	}
+
+	fixedKcal := math.Min(targetKcal*fixedShare, remaining)
+	if strategy == LowCarb {
+		m.CarbG = fixedKcal / 4
+		m.FatG = (remaining - fixedKcal) / 9
+		steps = append(steps,
+			Step{"carb", fmt.Sprintf("คาร์โบไฮเดรต %.0f%% ของพลังงาน (4 kcal/ก.)", fixedShare*100), round1(m.CarbG)},
+			Step{"fat", "ไขมันจากพลังงานที่เหลือ (9 kcal/ก.)", round1(m.FatG)})
+	} else {
+		m.FatG = fixedKcal / 9
+		m.CarbG = (remaining - fixedKcal) / 4
+		steps = append(steps,
+			Step{"fat", fmt.Sprintf("ไขมัน %.0f%% ของพลังงาน (9 kcal/ก.)", fixedShare*100), round1(m.FatG)},
+			Step{"carb", "คาร์โบไฮเดรตจากพลังงานที่เหลือ (4 kcal/ก.)", round1(m.CarbG)})
+	}
+	m.ProteinG, m.FatG, m.CarbG = round1(m.ProteinG), round1(m.FatG), round1(m.CarbG)
+	return m, steps, nil
+}
+
+func validate(in Input) error {
+	if in.WeightKg <= 0 || in.WeightKg > 500 {
+		return fmt.Errorf("น้ำหนักต้องอยู่ระหว่าง 0-500 กก.")
+	}
+	if in.HeightCm <= 0 || in.HeightCm > 300 {
+		return fmt.Errorf("ส่วนสูงต้องอยู่ระหว่าง 0-300 ซม.")
+	}
+	if in.Age <= 0 || in.Age > 120 {
+		return fmt.Errorf("ต้องทราบอายุเพื่อคำนวณ BMR")
+	}
+	if in.BodyFatPercent < 0 || in.BodyFatPercent >= 100 {
+		return fmt.Errorf("เปอร์เซ็นต์ไขมันต้องอยู่ระหว่าง 0-100")
+	}
+	switch in.Formula {
+	case "", MifflinStJeor, HarrisBenedict, KatchMcArdle:
+	default:
+		return fmt.Errorf("ไม่รู้จักสูตร %s", in.Formula)
+	}
+	if _, ok := ActivityFactors[in.ActivityLevel]; in.ActivityLevel != "" && !ok {
+		return fmt.Errorf("ไม่รู้จักระดับกิจกรรม %s", in.ActivityLevel)
+	}
+	if in.ActivityCalories < 0 {
+		return fmt.Errorf("แคลอรี่จากกิจกรรมต้องไม่ติดลบ")
+	}
+	return nil
+}
+
+// bmr คำนวณ BMR ตามสูตรที่เลือก Katch-McArdle ใช้ได้เมื่อทราบเปอร์เซ็นต์ไขมัน
+func bmr(in Input) (Formula, float64, []Step) {
+	var steps []Step
+	formula := in.Formula
+	if formula == "" {
+		formula = MifflinStJeor
+	}
+	if formula == KatchMcArdle && in.BodyFatPercent == 0 {
+		formula = MifflinStJeor
+		steps = append(steps, Step{"formula", "ไม่มีข้อมูลเปอร์เซ็นต์ไขมัน จึงใช้ Mifflin-St Jeor แทน Katch-McArdle", 0})
+	}
+	w, h, a := in.WeightKg, in.HeightCm, float64(in.Age)
+
+	var value float64
+	var detail string
+	switch formula {
+	case KatchMcArdle:
+		lbm := w * (1 - in.BodyFatPercent/100)
+		value = 370 + 21.6*lbm
+		steps = append(steps, Step{"lean_body_mass", fmt.Sprintf("มวลไร้ไขมัน = %.1f × (1 - %.1f%%)", w, in.BodyFatPercent), round1(lbm)})
+		detail = fmt.Sprintf("Katch-McArdle: 370 + 21.6 × %.1f", lbm)
+	case HarrisBenedict:
+		male := 88.362 + 13.397*w + 4.799*h - 5.677*a
+		female := 447.593 + 9.247*w + 3.098*h - 4.330*a
+		value = bySex(in.Sex, male, female)
+		detail = fmt.Sprintf("Harris-Benedict (ปรับปรุง 1984) เพศ %s", sexLabel(in.Sex))
+	default:
+		base := 10*w + 6.25*h - 5*a
+		s := bySex(in.Sex, 5, -161)
+		value = base + s
+		detail = fmt.Sprintf("Mifflin-St Jeor: 10×%.1f + 6.25×%.1f - 5×%d %+.0f", w, h, in.Age, s)
+	}
+	value = round1(value)
+	steps = append(steps, Step{"bmr", detail, value})
+	return formula, value, steps
+}
+
+// activityFactor ใช้ระดับที่ผู้ใช้เลือก ถ้าไม่ได้เลือกประมาณจากแคลอรี่กิจกรรมเฉลี่ยต่อวัน
+// ตัวคูณ = 1.2 (ชีวิตประจำวัน) + แคลอรี่กิจกรรมเฉลี่ย/BMR ไม่เกินระดับ very_active
+func activityFactor(in Input, bmr float64) (ActivityLevel, float64, bool) {
+	if in.ActivityLevel != "" {
+		return in.ActivityLevel, ActivityFactors[in.ActivityLevel], false
+	}
+	if in.ActivityCount == 0 {
+		return defaultActivityLevel, ActivityFactors[defaultActivityLevel], false
+	}
+	factor := ActivityFactors[Sedentary] + in.ActivityCalories/ActivityWindowDays/bmr
+	factor = math.Min(factor, ActivityFactors[VeryActive])
+	factor = math.Round(factor*1000) / 1000
+	return nearestLevel(factor), factor, true
+}
+
+func nearestLevel(factor float64) ActivityLevel {
+	best, bestDiff := Sedentary, math.Inf(1)
+	for _, level := range []ActivityLevel{Sedentary, Light, Moderate, Active, VeryActive} {
+		if diff := math.Abs(ActivityFactors[level] - factor); diff < bestDiff {
+			best, bestDiff = level, diff
+		}
+	}
+	return best
+}
+
+func bySex(sex Sex, male, female float64) float64 {
+	switch sex {
+	case Male:
+		return male
+	case Female:
+		return female
+	default:
+		return (male + female) / 2
+	}
+}
+
+func sexLabel(sex Sex) string {
+	switch sex {
+	case Male:
+		return "ชาย"
+	case Female:
+		return "หญิง"
+	default:
+		return "ไม่ระบุ (ค่ากึ่งกลาง)"
+	}
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,214 @@
+package nutrition
+
+import (
+	"math"
+	"testing"
+)
+
+func baseInput() Input {
+	return Input{Sex: Male, Age: 30, WeightKg: 80, HeightCm: 180, ActivityLevel: Moderate, Goal: Maintain}
+}
+
+func TestBMR(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*Input)
+		wantFormula Formula
+		want        float64
+	}{
+		{"Mifflin-St Jeor ชาย", func(in *Input) {}, MifflinStJeor, 1780},
+		{"Mifflin-St Jeor หญิง", func(in *Input) { in.Sex = Female }, MifflinStJeor, 1614},
+		{"ไม่ระบุเพศใช้ค่ากึ่งกลาง", func(in *Input) { in.Sex = Unspecified }, MifflinStJeor, 1697},
+		{"Harris-Benedict ชาย", func(in *Input) { in.Formula = HarrisBenedict }, HarrisBenedict, 1853.6},
+		{"Katch-McArdle", func(in *Input) { in.Formula = KatchMcArdle; in.BodyFatPercent = 20 }, KatchMcArdle, 1752.4},
+		{"Katch-McArdle ไม่มีไขมันใช้ Mifflin แทน", func(in *Input) { in.Formula = KatchMcArdle }, MifflinStJeor, 1780},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := baseInput()
+			tt.modify(&in)
+			r, err := Calculate(in)
+			if err != nil {
+				t.Fatalf("Calculate: %v", err)
+			}
+			if r.Formula != tt.wantFormula || math.Abs(r.BMR-tt.want) > 1e-9 {
+				t.Errorf("BMR = %s %v ต้องการ %s %v", r.Formula, r.BMR, tt.wantFormula, tt.want)
+			}
+		})
+	}
+}
+
+func TestTDEE(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*Input)
+		wantLevel   ActivityLevel
+		wantFactor  float64
+		wantDerived bool
+		wantTDEE    float64
+		wantTarget  float64
+	}{
+		{"ระดับที่ผู้ใช้เลือก", func(in *Input) {}, Moderate, 1.55, false, 2759, 2759},
+		{"ไม่มีกิจกรรมใช้ค่าเริ่มต้น", func(in *Input) { in.ActivityLevel = "" }, Light, 1.375, false, 2447.5, 2448},
+		{
+			// เฉลี่ย 356 kcal/วัน = 0.2 × BMR
+			"ประมาณจากกิจกรรม",
+			func(in *Input) {
+				in.ActivityLevel = ""
+				in.ActivityCalories = 356 * ActivityWindowDays
+				in.ActivityCount = 7
+			},
+			Light, 1.4, true, 2492, 2492,
+		},
+		{
+			"ตัวคูณไม่เกิน very_active",
+			func(in *Input) { in.ActivityLevel = ""; in.ActivityCalories = 100000; in.ActivityCount = 30 },
+			VeryActive, 1.9, true, 3382, 3382,
+		},
+		{"เพิ่มน้ำหนัก +10%", func(in *Input) { in.Goal = Gain }, Moderate, 1.55, false, 2759, 3035},
+		{"ลดน้ำหนัก -20%", func(in *Input) { in.Goal = Lose }, Moderate, 1.55, false, 2759, 2207},
+		{
+			// 2136 × 0.8 = 1708.8 ต่ำกว่า BMR 1780
+			"เป้าหมายไม่ต่ำกว่า BMR",
+			func(in *Input) { in.Goal = Lose; in.ActivityLevel = Sedentary },
+			Sedentary, 1.2, false, 2136, 1780,
+		},
+		{"เป้าหมายที่ไม่รู้จักถือเป็นรักษาน้ำหนัก", func(in *Input) { in.Goal = "unknown" }, Moderate, 1.55, false, 2759, 2759},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := baseInput()
+			tt.modify(&in)
+			r, err := Calculate(in)
+			if err != nil {
+				t.Fatalf("Calculate: %v", err)
+			}
+			if r.ActivityLevel != tt.wantLevel || r.ActivityFactor != tt.wantFactor || r.ActivityDerived != tt.wantDerived {
+				t.Errorf("กิจกรรม = %s %v %v ต้องการ %s %v %v",
+					r.ActivityLevel, r.ActivityFactor, r.ActivityDerived, tt.wantLevel, tt.wantFactor, tt.wantDerived)
+			}
+			if math.Abs(r.TDEE-tt.wantTDEE) > 1e-9 || r.TargetCalories != tt.wantTarget {
+				t.Errorf("TDEE/เป้าหมาย = %v/%v ต้องการ %v/%v", r.TDEE, r.TargetCalories, tt.wantTDEE, tt.wantTarget)
+			}
+			if clamped := hasStep(r.Steps, "minimum_intake"); clamped != (tt.wantTarget == r.BMR) {
+				t.Errorf("ขั้นตอน minimum_intake = %v", clamped)
+			}
+		})
+	}
+}
+
+func TestCalculateMacros(t *testing.T) {
+	tests := []struct {
+		name     string
+		kcal     float64
+		weight   float64
+		strategy MacroStrategy
+		want     Macros
+		wantStep string
+	}{
+		{"balanced", 2000, 70, Balanced, Macros{ProteinG: 112, FatG: 55.6, CarbG: 263}, ""},
+		{"high_protein", 2000, 70, HighProtein, Macros{ProteinG: 154, FatG: 55.6, CarbG: 221}, ""},
+		{"low_carb", 2000, 70, LowCarb, Macros{ProteinG: 126, FatG: 121.8, CarbG: 100}, ""},
+		{
+			// โปรตีน 1.6 × 200 = 320 ก. (1280 kcal) เกินพลังงานทั้งหมด
+			"โปรตีนเกินพลังงานเป้าหมาย", 1000, 200, Balanced,
+			Macros{ProteinG: 250}, "protein_capped",
+		},
+		{
+			// เหลือ 232 kcal น้อยกว่าไขมัน 25% (250 kcal) จึงไม่มีคาร์โบไฮเดรต
+			"สัดส่วนคงที่เกินพลังงานที่เหลือ", 1000, 120, Balanced,
+			Macros{ProteinG: 192, FatG: 25.8}, "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, steps, err := CalculateMacros(tt.kcal, tt.weight, tt.strategy)
+			if err != nil {
+				t.Fatalf("CalculateMacros: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Macros = %+v ต้องการ %+v", got, tt.want)
+			}
+			if tt.wantStep != "" && !hasStep(steps, tt.wantStep) {
+				t.Errorf("ไม่พบขั้นตอน %s", tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestCalculateMacrosErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		kcal     float64
+		weight   float64
+		strategy MacroStrategy
+	}{
+		{"พลังงานเป็นศูนย์", 0, 70, Balanced},
+		{"น้ำหนักเป็นศูนย์", 2000, 0, Balanced},
+		{"ไม่รู้จักวิธีแบ่ง", 2000, 70, "keto"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := CalculateMacros(tt.kcal, tt.weight, tt.strategy); err == nil {
+				t.Error("ต้องคืน error")
+			}
+		})
+	}
+}
+
+func TestCalculateValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Input)
+	}{
+		{"น้ำหนักเป็นศูนย์", func(in *Input) { in.WeightKg = 0 }},
+		{"น้ำหนักเกิน 500", func(in *Input) { in.WeightKg = 501 }},
+		{"ส่วนสูงเป็นศูนย์", func(in *Input) { in.HeightCm = 0 }},
+		{"ส่วนสูงเกิน 300", func(in *Input) { in.HeightCm = 301 }},
+		{"ไม่ทราบอายุ", func(in *Input) { in.Age = 0 }},
+		{"อายุเกิน 120", func(in *Input) { in.Age = 121 }},
+		{"ไขมันติดลบ", func(in *Input) { in.BodyFatPercent = -1 }},
+		{"ไขมัน 100%", func(in *Input) { in.BodyFatPercent = 100 }},
+		{"ไม่รู้จักสูตร", func(in *Input) { in.Formula = "unknown" }},
+		{"ไม่รู้จักระดับกิจกรรม", func(in *Input) { in.ActivityLevel = "extreme" }},
+		{"แคลอรี่กิจกรรมติดลบ", func(in *Input) { in.ActivityCalories = -1 }},
+		{"ไม่รู้จักวิธีแบ่งสารอาหาร", func(in *Input) { in.Strategy = "keto" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := baseInput()
+			tt.modify(&in)
+			if _, err := Calculate(in); err == nil {
+				t.Error("ต้องคืน error")
+			}
+		})
+	}
+}
+
+func TestParseGoal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Goal
+	}{
+		{"ลดน้ำหนัก", Lose},
+		{" Cut ", Lose},
+		{"เพิ่มกล้ามเนื้อ", Gain},
+		{"bulk", Gain},
+		{"รักษาน้ำหนัก", Maintain},
+		{"", Maintain},
+	}
+	for _, tt := range tests {
+		if got := ParseGoal(tt.in); got != tt.want {
+			t.Errorf("ParseGoal(%q) = %s ต้องการ %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func hasStep(steps []Step, name string) bool {
+	for _, s := range steps {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
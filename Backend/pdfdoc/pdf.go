@@ -0,0 +1,290 @@
+// Package pdfdoc สร้างเอกสาร PDF อย่างง่าย (ข้อความ เส้น กล่องสี) โดยไม่พึ่งไลบรารีภายนอก
+// ใช้ฟอนต์ TrueType ที่ฝังในไฟล์สำหรับภาษาไทย หรือ Helvetica ของ PDF สำหรับข้อความ ASCII
+// พิกัดเป็นพอยต์ (1/72 นิ้ว) นับจากมุมบนซ้ายของหน้า y คือเส้นฐานของข้อความ
+package pdfdoc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// ขนาดกระดาษ A4
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Document เอกสาร PDF
+type Document struct {
+	font  *Font
+	used  map[uint16]rune
+	pages []*Page
+}
+
+// Page หน้าหนึ่งของเอกสาร
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+// New สร้างเอกสารเปล่า font = nil ใช้ Helvetica (อักขระนอก ASCII จะแสดงเป็น ?)
+func New(font *Font) *Document {
+	return &Document{font: font, used: map[uint16]rune{}}
+}
+
+// AddPage เพิ่มหน้า A4 แนวตั้ง
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// TextWidth ความกว้างของข้อความ (พอยต์) ที่ขนาดตัวอักษร size
+func (d *Document) TextWidth(s string, size float64) float64 {
+	total := 0.0
+	if d.font == nil {
+		for _, r := range s {
+			total += helveticaWidth(r)
+		}
+	} else {
+		for _, r := range s {
+			total += d.font.advance(d.font.glyph(r))
+		}
+	}
+	return total * size / 1000
+}
+
+// Text เขียนข้อความโดยมุมซ้ายอยู่ที่ x
+func (p *Page) Text(x, y, size float64, s string) {
+	p.text(x, y, size, s, false)
+}
+
+// BoldText เขียนข้อความตัวหนา (ลากเส้นขอบตัวอักษรทับ ใช้ได้กับทุกฟอนต์)
+func (p *Page) BoldText(x, y, size float64, s string) {
+	p.text(x, y, size, s, true)
+}
+
+// TextRight เขียนข้อความชิดขวาที่ right
+func (p *Page) TextRight(right, y, size float64, s string, bold bool) {
+	p.text(right-p.doc.TextWidth(s, size), y, size, s, bold)
+}
+
+// TextCenter เขียนข้อความกึ่งกลางที่ center
+func (p *Page) TextCenter(center, y, size float64, s string, bold bool) {
+	p.text(center-p.doc.TextWidth(s, size)/2, y, size, s, bold)
+}
+
+// Line ลากเส้นตรงสีดำ
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w 0 G %.2f %.2f m %.2f %.2f l S\n", width, x1, A4Height-y1, x2, A4Height-y2)
+}
+
+// FillRect ระบายสีเทากล่องสี่เหลี่ยม gray 0 = ดำ, 1 = ขาว
+func (p *Page) FillRect(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "%.3f g %.2f %.2f %.2f %.2f re f 0 g\n", gray, x, A4Height-y-h, w, h)
+}
+
+func (p *Page) text(x, y, size float64, s string, bold bool) {
+	if s == "" {
+		return
+	}
+	mode := "0 Tr"
+	if bold {
+		mode = fmt.Sprintf("2 Tr %.2f w 0 G", size/40)
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %.2f Tf %s %.2f %.2f Td %s Tj ET\n", size, mode, x, A4Height-y, p.doc.encode(s))
+}
+
+// encode แปลงข้อความเป็น string ของ PDF: รหัส glyph 2 ไบต์ (Identity-H) หรือ WinAnsi สำหรับ Helvetica
+func (d *Document) encode(s string) string {
+	if d.font == nil {
+		var b strings.Builder
+		b.WriteByte('(')
+		for _, r := range s {
+			switch {
+			case r == '(' || r == ')' || r == '\\':
+				b.WriteByte('\\')
+				b.WriteRune(r)
+			case r >= 32 && r < 127:
+				b.WriteRune(r)
+			default:
+				b.WriteByte('?')
+			}
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		g := d.font.glyph(r)
+		if _, ok := d.used[g]; !ok && g != 0 {
+			d.used[g] = r
+		}
+		fmt.Fprintf(&b, "%04X", g)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// Bytes ไฟล์ PDF ทั้งหมด
+func (d *Document) Bytes() ([]byte, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// หมายเลขอ็อบเจกต์: 1 catalog, 2 pages, ต่อด้วยฟอนต์ แล้วจึงหน้าและ content ของแต่ละหน้า
+	fontObj := 3
+	next := fontObj + 1
+	if d.font != nil {
+		next = fontObj + 5
+	}
+	pageObjs := make([]int, len(d.pages))
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageObjs[i] = next + 2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageObjs[i])
+	}
+
+	w.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	w.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	if d.font == nil {
+		w.object(fontObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	} else if err := d.writeTrueType(w, fontObj); err != nil {
+		return nil, err
+	}
+	for i, p := range d.pages {
+		w.object(pageObjs[i], fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			A4Width, A4Height, fontObj, pageObjs[i]+1))
+		if err := w.stream(pageObjs[i]+1, "", p.content.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return w.finish(), nil
+}
+
+// writeTrueType ฟอนต์แบบ Type0/CIDFontType2 (Identity-H) ฝังไฟล์ .ttf ทั้งไฟล์ พร้อม ToUnicode
+// เพื่อให้คัดลอกและค้นหาข้อความใน PDF ได้
+func (d *Document) writeTrueType(w *writer, obj int) error {
+	f := d.font
+	gids := make([]int, 0, len(d.used))
+	for g := range d.used {
+		gids = append(gids, int(g))
+	}
+	sort.Ints(gids)
+
+	var widths strings.Builder
+	for _, g := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", g, int(f.advance(uint16(g))))
+	}
+	w.object(obj, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		f.name, obj+1, obj+4))
+	w.object(obj+1, fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+		f.name, obj+2, strings.TrimSpace(widths.String())))
+	w.object(obj+2, fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		f.name, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight), obj+3))
+	if err := w.stream(obj+3, fmt.Sprintf("/Length1 %d", len(f.data)), f.data); err != nil {
+		return err
+	}
+
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	cmap.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		end := start + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", end-start)
+		for _, g := range gids[start:end] {
+			fmt.Fprintf(&cmap, "<%04X> <", g)
+			for _, unit := range utf16.Encode([]rune{d.used[uint16(g)]}) {
+				fmt.Fprintf(&cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return w.stream(obj+4, "", []byte(cmap.String()))
+}
+
+// writer เขียนอ็อบเจกต์และจำตำแหน่งไว้ทำตาราง xref
+type writer struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *writer) object(num int, body string) {
+	if w.offsets == nil {
+		w.offsets = map[int]int{}
+	}
+	w.offsets[num] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", num, body)
+}
+
+// stream เขียน stream ที่บีบอัดด้วย Flate extra คือ key เพิ่มเติมใน dictionary
+func (w *writer) stream(num int, extra string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if w.offsets == nil {
+		w.offsets = map[int]int{}
+	}
+	w.offsets[num] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode %s>>\nstream\n", num, compressed.Len(), extra)
+	w.buf.Write(compressed.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+func (w *writer) finish() []byte {
+	size := 0
+	for num := range w.offsets {
+		if num > size {
+			size = num
+		}
+	}
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", size+1)
+	for num := 1; num <= size; num++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[num])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", size+1, xref)
+	return w.buf.Bytes()
+}
+
+// helveticaWidths ความกว้างของ Helvetica (1/1000 em) สำหรับอักขระ 32-126
+var helveticaWidths = [95]float64{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func helveticaWidth(r rune) float64 {
+	if r >= 32 && r < 127 {
+		return helveticaWidths[r-32]
+	}
+	return helveticaWidths['?'-32]
+}
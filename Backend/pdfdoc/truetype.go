@@ -0,0 +1,205 @@
+package pdfdoc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// Font ฟอนต์ TrueType (.ttf) ที่ฝังทั้งไฟล์ลงใน PDF ใช้กับข้อความภาษาไทยหรือภาษาอื่นนอก ASCII
+type Font struct {
+	data       []byte
+	name       string
+	unitsPerEm float64
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	advances   []uint16
+	glyphs     map[rune]uint16
+}
+
+// ParseTrueType อ่านข้อมูลที่ต้องใช้จากไฟล์ฟอนต์ รองรับเฉพาะ TrueType outlines (ไม่รองรับ CFF/.otf)
+func ParseTrueType(data []byte) (font *Font, err error) {
+	// ไฟล์เสียจะทำให้อ่านเกินขอบเขต แปลง panic เป็น error แทนการตรวจทุกตำแหน่ง
+	defer func() {
+		if r := recover(); r != nil {
+			font, err = nil, errors.New("ไฟล์ฟอนต์ไม่สมบูรณ์")
+		}
+	}()
+	if len(data) < 12 {
+		return nil, errors.New("ไฟล์ฟอนต์ไม่สมบูรณ์")
+	}
+	switch string(data[:4]) {
+	case "\x00\x01\x00\x00", "true":
+	default:
+		return nil, errors.New("รองรับเฉพาะฟอนต์ TrueType (.ttf)")
+	}
+
+	tables := map[string][]byte{}
+	for i, n := 0, int(u16(data, 4)); i < n; i++ {
+		rec := 12 + 16*i
+		offset, length := int(u32(data, rec+8)), int(u32(data, rec+12))
+		tables[string(data[rec:rec+4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("ไฟล์ฟอนต์ไม่มีตาราง %s", tag)
+		}
+	}
+
+	head, hhea := tables["head"], tables["hhea"]
+	font = &Font{
+		data:       data,
+		name:       "EmbeddedFont",
+		unitsPerEm: float64(u16(head, 18)),
+		ascent:     int(int16(u16(hhea, 4))),
+		descent:    int(int16(u16(hhea, 6))),
+	}
+	if font.unitsPerEm == 0 {
+		return nil, errors.New("ไฟล์ฟอนต์ไม่สมบูรณ์")
+	}
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(u16(head, 36+2*i)))
+	}
+	font.capHeight = font.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 90 && u16(os2, 0) >= 2 && int16(u16(os2, 88)) > 0 {
+		font.capHeight = int(int16(u16(os2, 88)))
+	}
+
+	numGlyphs := int(u16(tables["maxp"], 4))
+	numMetrics := int(u16(hhea, 34))
+	font.advances = make([]uint16, numGlyphs)
+	for g := 0; g < numGlyphs; g++ {
+		if g < numMetrics {
+			font.advances[g] = u16(tables["hmtx"], 4*g)
+		} else if numMetrics > 0 {
+			font.advances[g] = font.advances[numMetrics-1]
+		}
+	}
+
+	if font.glyphs, err = parseCmap(tables["cmap"]); err != nil {
+		return nil, err
+	}
+	if name := postScriptName(tables["name"]); name != "" {
+		font.name = name
+	}
+	return font, nil
+}
+
+// glyph รหัส glyph ของตัวอักษร (0 = ไม่มีในฟอนต์)
+func (f *Font) glyph(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// advance ความกว้างของ glyph ในหน่วย 1/1000 ของขนาดตัวอักษร
+func (f *Font) advance(g uint16) float64 {
+	if int(g) >= len(f.advances) {
+		return 0
+	}
+	return float64(f.advances[g]) * 1000 / f.unitsPerEm
+}
+
+func (f *Font) scale(v int) int {
+	return int(float64(v) * 1000 / f.unitsPerEm)
+}
+
+// parseCmap ตาราง Unicode → glyph เลือก format 12 (ทุก plane) ถ้ามี ไม่เช่นนั้นใช้ format 4 (BMP)
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	var format4, format12 []byte
+	for i, n := 0, int(u16(cmap, 2)); i < n; i++ {
+		rec := 4 + 8*i
+		platform, encoding := u16(cmap, rec), u16(cmap, rec+2)
+		sub := cmap[u32(cmap, rec+4):]
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !unicode {
+			continue
+		}
+		switch u16(sub, 0) {
+		case 4:
+			format4 = sub
+		case 12:
+			format12 = sub
+		}
+	}
+
+	glyphs := map[rune]uint16{}
+	switch {
+	case format12 != nil:
+		for i, n := 0, int(u32(format12, 12)); i < n; i++ {
+			group := 16 + 12*i
+			start, end, gid := u32(format12, group), u32(format12, group+4), u32(format12, group+8)
+			for c := start; c <= end; c++ {
+				glyphs[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case format4 != nil:
+		segs := int(u16(format4, 6)) / 2
+		endCodes, startCodes := 14, 16+2*segs
+		deltas, rangeOffsets := 16+4*segs, 16+6*segs
+		for i := 0; i < segs; i++ {
+			start, end := u16(format4, startCodes+2*i), u16(format4, endCodes+2*i)
+			delta, rangeOffset := u16(format4, deltas+2*i), u16(format4, rangeOffsets+2*i)
+			for c := uint32(start); c <= uint32(end) && c != 0xFFFF; c++ {
+				var g uint16
+				if rangeOffset == 0 {
+					g = uint16(c) + delta
+				} else {
+					addr := rangeOffsets + 2*i + int(rangeOffset) + 2*int(c-uint32(start))
+					if g = u16(format4, addr); g != 0 {
+						g += delta
+					}
+				}
+				if g != 0 {
+					glyphs[rune(c)] = g
+				}
+			}
+		}
+	default:
+		return nil, errors.New("ฟอนต์ไม่มีตาราง cmap แบบ Unicode")
+	}
+	return glyphs, nil
+}
+
+// postScriptName ชื่อฟอนต์ (name ID 6) เหลือเฉพาะอักขระที่ใช้เป็นชื่อใน PDF ได้
+func postScriptName(table []byte) string {
+	if len(table) < 6 {
+		return ""
+	}
+	storage := int(u16(table, 4))
+	for i, n := 0, int(u16(table, 2)); i < n; i++ {
+		rec := 6 + 12*i
+		if u16(table, rec+6) != 6 {
+			continue
+		}
+		platform := u16(table, rec)
+		length, offset := int(u16(table, rec+8)), int(u16(table, rec+10))
+		raw := table[storage+offset : storage+offset+length]
+		var name string
+		if platform == 0 || platform == 3 {
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = u16(raw, 2*j)
+			}
+			name = string(utf16.Decode(units))
+		} else {
+			name = string(raw)
+		}
+		name = strings.Map(func(r rune) rune {
+			if r == '-' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' {
+				return r
+			}
+			return -1
+		}, name)
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func u16(b []byte, off int) uint16 { return binary.BigEndian.Uint16(b[off:]) }
+
+func u32(b []byte, off int) uint32 { return binary.BigEndian.Uint32(b[off:]) }
@@ -0,0 +1,92 @@
+// Package promptpay สร้างข้อความสำหรับ QR พร้อมเพย์ตามมาตรฐาน EMVCo (Thai QR Payment)
+// สร้างในเครื่องทั้งหมด ไม่ต้องเรียกธนาคาร
+package promptpay
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	aidMerchantPresented = "A000000677010111" // Application ID ของพร้อมเพย์ (โอนเข้าบัญชีผู้รับ)
+	currencyTHB          = "764"
+	countryTH            = "TH"
+)
+
+// Payload ข้อความ QR พร้อมเพย์ของผู้รับ id (เบอร์มือถือ 10 หลัก, เลขบัตรประชาชน/เลขผู้เสียภาษี 13 หลัก
+// หรือ e-Wallet ID 15 หลัก) amount > 0 สร้าง QR แบบใช้ครั้งเดียวที่ระบุยอด, 0 = QR แบบไม่ระบุยอด
+func Payload(id string, amount float64) (string, error) {
+	account, err := accountField(id)
+	if err != nil {
+		return "", err
+	}
+	if amount < 0 {
+		return "", fmt.Errorf("ยอดเงินต้องไม่ติดลบ")
+	}
+
+	var b strings.Builder
+	b.WriteString(field("00", "01"))
+	if amount > 0 {
+		b.WriteString(field("01", "12")) // dynamic
+	} else {
+		b.WriteString(field("01", "11")) // static
+	}
+	b.WriteString(field("29", field("00", aidMerchantPresented)+account))
+	b.WriteString(field("58", countryTH))
+	b.WriteString(field("53", currencyTHB))
+	if amount > 0 {
+		b.WriteString(field("54", fmt.Sprintf("%.2f", amount)))
+	}
+	b.WriteString("6304")
+	return b.String() + fmt.Sprintf("%04X", CRC16(b.String())), nil
+}
+
+// CRC16 CRC-16/CCITT-FALSE (poly 0x1021, ค่าเริ่มต้น 0xFFFF) ตามที่ EMVCo กำหนดสำหรับ tag 63
+func CRC16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// accountField sub-tag ของผู้รับภายใต้ tag 29: 01 เบอร์มือถือ (0066 + เบอร์ไม่มี 0 นำหน้า),
+// 02 เลขบัตรประชาชน/เลขผู้เสียภาษี, 03 e-Wallet
+func accountField(id string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return 'x'
+	}, id)
+	if strings.ContainsRune(digits, 'x') {
+		return "", fmt.Errorf("รหัสพร้อมเพย์ต้องเป็นตัวเลขเท่านั้น")
+	}
+	switch len(digits) {
+	case 10:
+		if digits[0] != '0' {
+			return "", fmt.Errorf("เบอร์มือถือพร้อมเพย์ต้องขึ้นต้นด้วย 0")
+		}
+		return field("01", "0066"+digits[1:]), nil
+	case 13:
+		return field("02", digits), nil
+	case 15:
+		return field("03", digits), nil
+	}
+	return "", fmt.Errorf("รหัสพร้อมเพย์ต้องเป็นเบอร์มือถือ 10 หลัก เลขประจำตัว 13 หลัก หรือ e-Wallet 15 หลัก")
+}
+
+// field ข้อมูลแบบ ID + ความยาว 2 หลัก + ค่า
+func field(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
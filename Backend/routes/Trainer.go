@@ -4,7 +4,9 @@ import (
 	personalTrainController "example.com/fitness-backend/controllers/PersonalTrain"
 	trainBookingController "example.com/fitness-backend/controllers/TrainBooking"
 	trainerController "example.com/fitness-backend/controllers/Trainer"
+	trainerAvailabilityController "example.com/fitness-backend/controllers/TrainerAvailability"
 	trainerScheduleController "example.com/fitness-backend/controllers/TrainerSchedule"
+	trainingProgramController "example.com/fitness-backend/controllers/TrainingProgram"
 	"example.com/fitness-backend/middlewares"
 	"github.com/gin-gonic/gin"
 )
@@ -34,6 +36,21 @@ func TrainerRoutes(r *gin.RouterGroup) {
 		schedules.GET("/allschedules/:trainerID", trainerScheduleController.GetTrainerSchedulesByTrainerID)
 		schedules.PUT("/:id", trainerScheduleController.UpdateTrainerSchedule)
 		schedules.DELETE("/:id", trainerScheduleController.DeleteTrainerSchedule)
+		schedules.POST("/:id/reschedule", trainerScheduleController.RescheduleTrainerSchedule)
+		schedules.POST("/bulk-delete", trainerAvailabilityController.BulkDeleteSchedules)
+		schedules.POST("/bulk-shift", trainerAvailabilityController.BulkShiftSchedules)
+	}
+
+	// /trainer-availability
+	availability := r.Group("/trainer-availability")
+	availability.Use(middlewares.Authorizes())
+	{
+		availability.POST("", trainerAvailabilityController.CreateAvailability)
+		availability.GET("/trainer/:trainerID", trainerAvailabilityController.GetAvailabilities)
+		availability.DELETE("/:id", trainerAvailabilityController.DeleteAvailability)
+		availability.POST("/leaves", trainerAvailabilityController.CreateLeave)
+		availability.DELETE("/leaves/:id", trainerAvailabilityController.DeleteLeave)
+		availability.POST("/generate", trainerAvailabilityController.GenerateSchedules)
 	}
 
 	// /trainers/schedules/:trainerId
@@ -50,6 +67,11 @@ func TrainerRoutes(r *gin.RouterGroup) {
 		bookings.POST("", trainBookingController.CreateTrainBooking)
 		bookings.GET("/user/:userID", trainBookingController.GetUserBookings)
 		bookings.DELETE("/:id", trainBookingController.CancelTrainBooking)
+		bookings.POST("/:id/reschedule", trainBookingController.RescheduleTrainBooking)
+		bookings.POST("/:id/no-show", trainBookingController.MarkNoShow)
+		bookings.GET("/:id/history", trainBookingController.GetBookingHistory)
+		bookings.GET("/policy", trainBookingController.GetPolicy)
+		bookings.PUT("/policy", trainBookingController.UpdatePolicy)
 		bookings.GET("/customers", trainBookingController.GetCustomersByTrainerID)
 		bookings.GET("/customer/:customerID/times", trainBookingController.GetCustomerBookedTimes)
 	}
@@ -65,4 +87,19 @@ func TrainerRoutes(r *gin.RouterGroup) {
 		personalTraining.PUT("/:id", personalTrainController.UpdatePersonalTrainingProgram)
 		personalTraining.DELETE("/:id", personalTrainController.DeletePersonalTrainingProgram)
 	}
+
+	// /training-programs
+	programs := r.Group("/training-programs")
+	programs.Use(middlewares.Authorizes())
+	{
+		programs.POST("", trainingProgramController.CreateTrainingProgram)
+		programs.GET("", trainingProgramController.GetTrainingPrograms)
+		programs.GET("/:id", trainingProgramController.GetTrainingProgramByID)
+		programs.PUT("/:id", trainingProgramController.UpdateTrainingProgram)
+		programs.DELETE("/:id", trainingProgramController.DeleteTrainingProgram)
+		programs.POST("/:id/copy", trainingProgramController.CopyTrainingProgram)
+		programs.POST("/:id/assign", trainingProgramController.AssignTrainingProgram)
+		programs.GET("/:id/versions", trainingProgramController.GetTrainingProgramVersions)
+		programs.PUT("/sessions/:id/complete", trainingProgramController.CompleteProgramSession)
+	}
 }
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/calendar"
+	"github.com/gin-gonic/gin"
+)
+
+func CalendarRoutes(api *gin.RouterGroup) {
+	// Calendar Routes
+	api.POST("/calendar/token", calendar.IssueToken)
+	api.POST("/calendar/import", calendar.ImportBusyTimes)
+}
+
+// PublicCalendarRoutes ฟีด .ics ที่แอปปฏิทินดึงได้ด้วยโทเค็นลับโดยไม่ต้องล็อกอิน
+func PublicCalendarRoutes(r *gin.Engine) {
+	r.GET("/calendar/:token", calendar.Feed)
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/entitlement"
+	"github.com/gin-gonic/gin"
+)
+
+func EntitlementRoutes(api *gin.RouterGroup) {
+	// สิทธิ์ตามแพ็กเกจและการใช้ในรอบปัจจุบัน
+	api.GET("/entitlements", entitlement.GetSummary)
+	api.GET("/packages/:id/entitlements", entitlement.GetPackageEntitlements)
+	api.PUT("/packages/:id/entitlements", entitlement.SetPackageEntitlements)
+}
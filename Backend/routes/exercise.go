@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/exercise"
+	"github.com/gin-gonic/gin"
+)
+
+func ExerciseRoutes(api *gin.RouterGroup) {
+	// Exercise library Routes
+	api.GET("/exercises", exercise.GetAll)
+	api.GET("/exercises/export", exercise.Export)
+	api.POST("/exercises/import", exercise.Import)
+	api.GET("/exercises/:id", exercise.Get)
+	api.POST("/exercises", exercise.Create)
+	api.PUT("/exercises/:id", exercise.Update)
+	api.DELETE("/exercises/:id", exercise.Delete)
+	api.POST("/exercises/:id/media", exercise.UploadMedia)
+}
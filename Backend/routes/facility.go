@@ -12,4 +12,5 @@ func FacilityRoutes(api *gin.RouterGroup) {
 	api.POST("/facilities", facility.Create)
 	api.PUT("/facilities/:id", facility.Update)
 	api.DELETE("/facilities/:id", facility.Delete)
+	api.POST("/facilities/:id/check-in", facility.CheckIn)
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/goal"
+	"github.com/gin-gonic/gin"
+)
+
+func GoalRoutes(api *gin.RouterGroup) {
+	// Personal goal Routes
+	api.GET("/goals", goal.GetAll)
+	api.GET("/goals/clients", goal.Clients)
+	api.GET("/goals/:id", goal.Get)
+	api.POST("/goals", goal.Create)
+	api.PUT("/goals/:id", goal.Update)
+	api.DELETE("/goals/:id", goal.Delete)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/invoice"
+	"github.com/gin-gonic/gin"
+)
+
+func InvoiceRoutes(api *gin.RouterGroup) {
+	// Receipts / tax invoices and credit notes
+	api.GET("/invoices", invoice.GetInvoices)
+	api.GET("/invoices/:id", invoice.GetInvoice)
+	api.GET("/invoices/:id/pdf", invoice.DownloadPDF)
+	api.POST("/invoices/:id/credit-notes", invoice.CreateCreditNote)
+
+	// Buyer details for full tax invoices
+	api.GET("/billing-profile", invoice.GetBillingProfile)
+	api.PUT("/billing-profile", invoice.SaveBillingProfile)
+
+	// Seller branches (document numbering runs per branch)
+	api.GET("/branches", invoice.GetBranches)
+	api.POST("/branches", invoice.SaveBranch)
+	api.PUT("/branches/:id", invoice.SaveBranch)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/mealplan"
+	"github.com/gin-gonic/gin"
+)
+
+func MealPlanRoutes(api *gin.RouterGroup) {
+	// Recipe Routes
+	api.GET("/recipes", mealplan.GetRecipes)
+	api.GET("/recipes/:id", mealplan.GetRecipe)
+	api.POST("/recipes", mealplan.CreateRecipe)
+	api.PUT("/recipes/:id", mealplan.UpdateRecipe)
+	api.DELETE("/recipes/:id", mealplan.DeleteRecipe)
+
+	// Meal plan Routes
+	api.GET("/meal-plans", mealplan.GetAll)
+	api.POST("/meal-plans/generate", mealplan.Generate)
+	api.GET("/meal-plans/:id", mealplan.Get)
+	api.PUT("/meal-plans/:id", mealplan.Update)
+	api.POST("/meal-plans/:id/assign", mealplan.Assign)
+	api.DELETE("/meal-plans/:id", mealplan.Delete)
+}
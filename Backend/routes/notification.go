@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/notification"
+	"github.com/gin-gonic/gin"
+)
+
+func NotificationRoutes(api *gin.RouterGroup) {
+	// Notification Routes
+	api.GET("/notifications", notification.GetAll)
+	api.PUT("/notifications/:id/read", notification.MarkRead)
+}
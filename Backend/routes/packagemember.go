@@ -11,4 +11,12 @@ func PackagememberRoutes(api *gin.RouterGroup) {
 	api.POST("/package-members", packagemember.Create)
 	api.PUT("/package-members/user/:user_id", packagemember.UpdateByUserID)
 	api.DELETE("/package-members/user/:user_id", packagemember.DeleteByUserID)
+
+	// Subscription lifecycle
+	api.GET("/package-members/user/:user_id/change-quote", packagemember.QuoteChangeByUserID)
+	api.POST("/package-members/user/:user_id/renew", packagemember.RenewByUserID)
+	api.POST("/package-members/user/:user_id/freeze", packagemember.FreezeByUserID)
+	api.POST("/package-members/user/:user_id/unfreeze", packagemember.UnfreezeByUserID)
+	api.PUT("/package-members/user/:user_id/auto-renew", packagemember.SetAutoRenewByUserID)
+	api.POST("/package-members/process", packagemember.Process)
 }
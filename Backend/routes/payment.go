@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/payment"
+	"example.com/fitness-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+func PaymentRoutes(api *gin.RouterGroup) {
+	// Orders: membership starts only after the order is paid
+	api.POST("/payments/orders", payment.CreateOrder)
+	api.GET("/payments/orders", payment.GetOrders)
+	api.GET("/payments/orders/:id", payment.GetOrder)
+	api.POST("/payments/orders/:id/cancel", payment.CancelOrder)
+	api.POST("/payments/orders/:id/confirm", payment.ConfirmOrder)
+
+	// จำลองการชำระเงิน เปิดเฉพาะเมื่อตั้ง PAYMENT_GATEWAY=mock
+	if services.MockPaymentEnabled() {
+		api.POST("/payments/orders/:id/mock-pay", payment.MockPay)
+	}
+}
+
+// PublicPaymentRoutes webhook จากผู้ให้บริการชำระเงิน ตรวจสอบด้วยลายเซ็นแทนการล็อกอิน
+func PublicPaymentRoutes(r *gin.Engine) {
+	r.POST("/payments/webhooks/:provider", payment.Webhook)
+}
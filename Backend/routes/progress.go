@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"example.com/fitness-backend/controllers/progress"
+	"github.com/gin-gonic/gin"
+)
+
+func ProgressRoutes(api *gin.RouterGroup) {
+	// Body measurements
+	api.POST("/measurements", progress.CreateMeasurements)
+	api.GET("/measurements", progress.GetMeasurements)
+	api.GET("/measurements/history", progress.GetMeasurementHistory)
+	api.DELETE("/measurements/:id", progress.DeleteMeasurement)
+
+	// Progress photos (stored privately, viewed through signed URLs)
+	api.POST("/progress-photos", progress.UploadPhoto)
+	api.GET("/progress-photos", progress.GetPhotos)
+	api.GET("/progress-photos/compare", progress.ComparePhotos)
+	api.GET("/progress-photos/:id", progress.GetPhoto)
+	api.DELETE("/progress-photos/:id", progress.DeletePhoto)
+}
+
+// PublicProgressRoutes ไฟล์รูปความคืบหน้าที่เปิดได้ด้วยลิงก์ลงลายเซ็นเท่านั้น
+func PublicProgressRoutes(r *gin.Engine) {
+	r.GET("/progress-photos/:id/file", progress.ServePhoto)
+}
@@ -12,6 +12,11 @@ func HealthRoutes(r *gin.RouterGroup) {
 	{
 		health.POST("", healthController.CreateHealth)
 		health.GET("", healthController.GetAllHealth)
+		health.GET("/analytics", healthController.GetHealthAnalytics)
+		health.GET("/bmi-cutoff", healthController.GetBmiCutoff)
+		health.PUT("/bmi-cutoff", healthController.UpdateBmiCutoff)
+		health.PUT("/:id", healthController.UpdateHealth)
+		health.DELETE("/:id", healthController.DeleteHealth)
 	}
 
 	activity := r.Group("/activity")
@@ -21,6 +26,34 @@ func HealthRoutes(r *gin.RouterGroup) {
 		activity.GET("", healthController.GetActivities)         // ✅ เพิ่ม GET
 		activity.PUT("/:id", healthController.UpdateActivity)    // ✅ เพิ่ม PUT
 		activity.DELETE("/:id", healthController.DeleteActivity) // ✅ เพิ่ม DELETE
+
+		// นำเข้าจากไฟล์ GPX/TCX/FIT และดูเส้นทาง
+		activity.POST("/import", healthController.ImportActivity)
+		activity.GET("/:id/track", healthController.GetActivityTrack)
+	}
+
+	// Activity type (MET) catalogue routes
+	activityTypes := r.Group("/activity-types")
+	activityTypes.Use(middlewares.Authorizes())
+	{
+		activityTypes.GET("", healthController.GetActivityTypes)
+		activityTypes.POST("", healthController.CreateActivityType)
+		activityTypes.PUT("/:id", healthController.UpdateActivityType)
+		activityTypes.DELETE("/:id", healthController.DeleteActivityType)
+	}
+
+	// Strength workout log routes
+	workouts := r.Group("/workouts")
+	workouts.Use(middlewares.Authorizes())
+	{
+		workouts.POST("", healthController.CreateWorkout)
+		workouts.GET("", healthController.GetWorkouts)
+		workouts.GET("/records", healthController.GetPersonalRecords)
+		workouts.GET("/volume", healthController.GetMuscleVolume)
+		workouts.GET("/:id", healthController.GetWorkout)
+		workouts.PUT("/:id", healthController.UpdateWorkout)
+		workouts.DELETE("/:id", healthController.DeleteWorkout)
+		workouts.GET("/:id/comparison", healthController.CompareWorkout)
 	}
 
 	// Nutrition routes
@@ -30,5 +63,57 @@ func HealthRoutes(r *gin.RouterGroup) {
 		nutrition.POST("", healthController.CreateOrUpdateNutrition)
 		nutrition.GET("", healthController.GetNutrition)
 		nutrition.GET("/user/:userID", healthController.GetNutritionByUserID)
+		nutrition.GET("/summary", healthController.GetNutritionSummary)
+		nutrition.GET("/calculate", healthController.CalculateNutrition)
+		nutrition.GET("/energy-balance", healthController.GetEnergyBalance)
+		nutrition.POST("/energy-balance/adjust", healthController.ApplyEnergyBalanceAdjustment)
+	}
+
+	// Food catalogue and custom foods
+	foods := r.Group("/foods")
+	foods.Use(middlewares.Authorizes())
+	{
+		foods.GET("", healthController.GetFoods)
+		foods.GET("/barcode/:ean", healthController.GetFoodByBarcode)
+		foods.GET("/corrections", healthController.GetFoodCorrections)
+		foods.POST("/corrections/:id/approve", healthController.ApproveFoodCorrection)
+		foods.POST("/corrections/:id/reject", healthController.RejectFoodCorrection)
+		foods.GET("/:id", healthController.GetFood)
+		foods.POST("", healthController.CreateFood)
+		foods.PUT("/:id", healthController.UpdateFood)
+		foods.DELETE("/:id", healthController.DeleteFood)
+		foods.POST("/:id/corrections", healthController.CreateFoodCorrection)
+	}
+
+	// Per-meal food logging
+	mealEntries := r.Group("/meal-entries")
+	mealEntries.Use(middlewares.Authorizes())
+	{
+		mealEntries.POST("", healthController.CreateMealEntry)
+		mealEntries.GET("", healthController.GetMealEntries)
+		mealEntries.PUT("/:id", healthController.UpdateMealEntry)
+		mealEntries.DELETE("/:id", healthController.DeleteMealEntry)
+	}
+
+	// Daily wellness check-ins (water, sleep, resting HR, mood, soreness, stress)
+	wellness := r.Group("/wellness")
+	wellness.Use(middlewares.Authorizes())
+	{
+		wellness.GET("/check-ins", healthController.GetWellnessCheckIns)
+		wellness.GET("/check-ins/:date", healthController.GetWellnessCheckIn)
+		wellness.PUT("/check-ins/:date", healthController.SaveWellnessCheckIn)
+		wellness.DELETE("/check-ins/:date", healthController.DeleteWellnessCheckIn)
+		wellness.GET("/streak", healthController.GetWellnessStreak)
+		wellness.GET("/weekly", healthController.GetWellnessWeekly)
+		wellness.GET("/correlations", healthController.GetWellnessCorrelations)
+	}
+
+	// Heart-rate zones and training load analytics
+	analytics := r.Group("/analytics")
+	analytics.Use(middlewares.Authorizes())
+	{
+		analytics.GET("/heart-rate-zones", healthController.GetHeartRateZones)
+		analytics.GET("/training-load", healthController.GetTrainingLoad)
+		analytics.GET("/training-load/clients", healthController.GetClientsTrainingLoad)
 	}
 }
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// defaultMET ใช้เมื่อไม่พบประเภทกิจกรรมในแคตตาล็อก (ค่าเดิมของระบบ)
+const defaultMET = 5.0
+
+// paceMET จุดอ้างอิงความเร็ว (กม./ชม.) กับค่า MET จาก Compendium 2011
+type paceMET struct {
+	SpeedKmh float64
+	MET      float64
+}
+
+var paceTables = map[string][]paceMET{
+	// 12xxx running
+	"running": {
+		{6.4, 6.0}, {8.0, 8.3}, {8.4, 9.0}, {9.7, 9.8}, {10.8, 10.5}, {11.3, 11.0},
+		{12.1, 11.5}, {12.9, 11.8}, {13.8, 12.3}, {14.5, 12.8}, {16.1, 14.5},
+		{17.7, 16.0}, {19.3, 19.0}, {20.9, 19.8}, {22.5, 23.0},
+	},
+	// 17xxx walking
+	"walking": {
+		{3.2, 2.8}, {4.0, 3.0}, {4.8, 3.5}, {5.6, 4.3}, {6.4, 5.0}, {7.2, 7.0},
+	},
+	// 01xxx bicycling (ตารางเป็นช่วง ใช้ค่าของช่วงที่ความเร็วตกอยู่)
+	"cycling": {
+		{0, 4.0}, {16.1, 6.8}, {19.3, 8.0}, {22.5, 10.0}, {25.7, 12.0}, {32.2, 15.8},
+	},
+}
+
+// GetActivityTypes ดึงแคตตาล็อกประเภทกิจกรรม (activeOnly = true เฉพาะที่เปิดใช้)
+func GetActivityTypes(activeOnly bool) ([]entity.ActivityType, error) {
+	query := config.DB().Order("category, name")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	var types []entity.ActivityType
+	err := query.Find(&types).Error
+	return types, err
+}
+
+// CreateActivityType เพิ่มประเภทกิจกรรม
+func CreateActivityType(t entity.ActivityType) (entity.ActivityType, error) {
+	if err := validateActivityType(&t); err != nil {
+		return t, err
+	}
+	t.Model = gorm.Model{}
+	err := config.DB().Create(&t).Error
+	return t, err
+}
+
+// UpdateActivityType แก้ไขประเภทกิจกรรม (ไม่คำนวณแคลอรี่ของกิจกรรมเดิมใหม่)
+func UpdateActivityType(id uint, input entity.ActivityType) (entity.ActivityType, error) {
+	var existing entity.ActivityType
+	if err := config.DB().First(&existing, id).Error; err != nil {
+		return existing, err
+	}
+	if err := validateActivityType(&input); err != nil {
+		return existing, err
+	}
+	input.Model = existing.Model
+	if input.IsActive == nil {
+		input.IsActive = existing.IsActive
+	}
+	err := config.DB().Save(&input).Error
+	return input, err
+}
+
+// DeleteActivityType ลบประเภทกิจกรรม กิจกรรมที่บันทึกไว้แล้วยังเก็บค่า MET เดิม
+func DeleteActivityType(id uint) error {
+	result := config.DB().Delete(&entity.ActivityType{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// EstimateActivityCalories คำนวณแคลอรี่ของกิจกรรม: MET x น้ำหนัก (กก.) x ชั่วโมง
+// MET มาจากแคตตาล็อก (หรือจากความเร็วสำหรับวิ่ง/เดิน/ปั่นจักรยาน) และน้ำหนักมาจาก
+// บันทึกสุขภาพที่ใกล้วันที่ทำกิจกรรมที่สุด
+func EstimateActivityCalories(activity *entity.Activity) error {
+	if activity.Duration <= 0 {
+		return fmt.Errorf("ระยะเวลาต้องมากกว่า 0 นาที")
+	}
+	if activity.Distance < 0 {
+		return fmt.Errorf("ระยะทางไม่ถูกต้อง")
+	}
+	if activity.Date.IsZero() {
+		activity.Date = time.Now()
+	}
+
+	health, err := closestHealthRecord(activity.UserID, activity.Date)
+	if err != nil {
+		return err
+	}
+
+	// ระบุ activity_type_id มาต้องเป็นประเภทที่มีอยู่ ส่วนชื่อที่ไม่อยู่ในแคตตาล็อกใช้ MET เริ่มต้น
+	met := defaultMET
+	t, err := findActivityType(*activity)
+	switch {
+	case err == nil:
+		activity.ActivityTypeID = &t.ID
+		activity.Type = t.Name
+		met = ActivityMET(t, activity.Distance, activity.Duration)
+	case activity.ActivityTypeID != nil && errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("ไม่พบประเภทกิจกรรม")
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	activity.HealthID = health.ID
+	activity.MET = met
+	activity.Calories = math.Round(met*health.Weight*(activity.Duration/60.0)*10) / 10
+	return nil
+}
+
+// ActivityMET ค่า MET ของกิจกรรม ถ้าเป็นกิจกรรมที่คิดตามความเร็วและมีระยะทาง
+// จะคำนวณจากความเร็วเฉลี่ย (ระยะทาง กม. / ระยะเวลา นาที) แทนค่าคงที่ในแคตตาล็อก
+func ActivityMET(t entity.ActivityType, distanceKm, durationMin float64) float64 {
+	table, ok := paceTables[t.PaceModel]
+	if !ok || distanceKm <= 0 || durationMin <= 0 {
+		return t.MET
+	}
+	speed := distanceKm / (durationMin / 60.0)
+
+	if t.PaceModel == "cycling" {
+		met := table[0].MET
+		for _, p := range table {
+			if speed >= p.SpeedKmh {
+				met = p.MET
+			}
+		}
+		return met
+	}
+
+	// เดิน/วิ่ง: ประมาณค่าเชิงเส้นระหว่างจุดอ้างอิง และใช้ค่าปลายตารางเมื่อความเร็วอยู่นอกช่วง
+	if speed <= table[0].SpeedKmh {
+		return table[0].MET
+	}
+	for i := 1; i < len(table); i++ {
+		if speed <= table[i].SpeedKmh {
+			a, b := table[i-1], table[i]
+			ratio := (speed - a.SpeedKmh) / (b.SpeedKmh - a.SpeedKmh)
+			return math.Round((a.MET+ratio*(b.MET-a.MET))*10) / 10
+		}
+	}
+	return table[len(table)-1].MET
+}
+
+// findActivityType หาประเภทกิจกรรมจาก ActivityTypeID หรือชื่อ (Type) ทั้งไทยและอังกฤษ
+func findActivityType(activity entity.Activity) (entity.ActivityType, error) {
+	var t entity.ActivityType
+	db := config.DB()
+	if activity.ActivityTypeID != nil {
+		err := db.First(&t, *activity.ActivityTypeID).Error
+		return t, err
+	}
+	name := strings.TrimSpace(activity.Type)
+	err := db.Where("name = ? OR LOWER(name_en) = ?", name, strings.ToLower(name)).First(&t).Error
+	return t, err
+}
+
+// closestHealthRecord หาบันทึกสุขภาพที่มีน้ำหนักและวันที่ใกล้กับ date ที่สุด
+// ถ้าห่างเท่ากันจะเลือกบันทึกก่อนวันทำกิจกรรม
+func closestHealthRecord(userID uint, date time.Time) (entity.Health, error) {
+	var records []entity.Health
+	if err := config.DB().Where("user_id = ? AND weight > 0", userID).Find(&records).Error; err != nil {
+		return entity.Health{}, err
+	}
+	if len(records) == 0 {
+		return entity.Health{}, fmt.Errorf("ไม่พบบันทึกน้ำหนักของผู้ใช้ กรุณาบันทึกข้อมูลสุขภาพก่อน")
+	}
+
+	day := date.In(bangkok)
+	target := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, bangkok)
+	type candidate struct {
+		record entity.Health
+		diff   time.Duration
+		before bool
+	}
+	candidates := make([]candidate, 0, len(records))
+	for _, r := range records {
+		d, ok := parseHealthDate(r.Date)
+		if !ok {
+			// วันที่อ่านไม่ได้ ใช้วันที่สร้างบันทึกแทน
+			d = r.CreatedAt.In(bangkok)
+			d = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, bangkok)
+		}
+		diff := target.Sub(d)
+		candidates = append(candidates, candidate{record: r, diff: absDuration(diff), before: diff >= 0})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].diff != candidates[j].diff {
+			return candidates[i].diff < candidates[j].diff
+		}
+		if candidates[i].before != candidates[j].before {
+			return candidates[i].before
+		}
+		return candidates[i].record.ID > candidates[j].record.ID
+	})
+	return candidates[0].record, nil
+}
+
+// parseHealthDate รองรับ Health.Date แบบ YYYY-MM-DD และ ISO 8601 ที่ส่งมาจากหน้าเว็บ
+func parseHealthDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.In(bangkok)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, bangkok), true
+	}
+	if len(value) >= 10 {
+		if t, err := time.ParseInLocation("2006-01-02", value[:10], bangkok); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func validateActivityType(t *entity.ActivityType) error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Intensity = strings.ToLower(strings.TrimSpace(t.Intensity))
+	t.PaceModel = strings.ToLower(strings.TrimSpace(t.PaceModel))
+	if t.Name == "" {
+		return fmt.Errorf("กรุณาระบุชื่อประเภทกิจกรรม")
+	}
+	if t.MET <= 0 || t.MET > 25 {
+		return fmt.Errorf("ค่า MET ต้องอยู่ระหว่าง 0-25")
+	}
+	switch t.Intensity {
+	case "":
+		// แบ่งระดับตาม Compendium: ต่ำกว่า 3 MET เบา, 3-6 ปานกลาง, มากกว่า 6 หนัก
+		switch {
+		case t.MET < 3:
+			t.Intensity = "light"
+		case t.MET <= 6:
+			t.Intensity = "moderate"
+		default:
+			t.Intensity = "vigorous"
+		}
+	case "light", "moderate", "vigorous":
+	default:
+		return fmt.Errorf("ระดับความหนักต้องเป็น light, moderate หรือ vigorous")
+	}
+	if _, ok := paceTables[t.PaceModel]; t.PaceModel != "" && !ok {
+		return fmt.Errorf("pace_model ต้องเป็น running, walking หรือ cycling")
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateActivity มีกิจกรรมที่เริ่มเวลาเดียวกันอยู่แล้ว
+var ErrDuplicateActivity = errors.New("มีกิจกรรมที่เริ่มเวลาเดียวกันนี้อยู่แล้ว")
+
+// duplicateWindow ช่วงเวลาเริ่มที่ถือว่าเป็นกิจกรรมเดียวกัน (นาฬิกาแต่ละเครื่องบันทึกเวลาเริ่มต่างกันเล็กน้อย)
+const duplicateWindow = 60 * time.Second
+
+// sportActivityTypes ชื่อประเภทกิจกรรมในแคตตาล็อกของแต่ละชนิดกีฬาในไฟล์
+var sportActivityTypes = map[string]string{
+	"running":  "วิ่ง",
+	"cycling":  "ปั่นจักรยาน",
+	"walking":  "เดิน",
+	"hiking":   "เดินขึ้นเขา",
+	"swimming": "ว่ายน้ำ",
+}
+
+// ImportActivityFile นำเข้ากิจกรรมจากไฟล์ GPX, TCX หรือ FIT ของผู้ใช้
+// activityType ใช้แทนชนิดกีฬาที่อ่านได้จากไฟล์ (ว่างได้)
+// ถ้ามีกิจกรรมที่เริ่มเวลาใกล้เคียงอยู่แล้วจะคืน ErrDuplicateActivity พร้อมกิจกรรมเดิม
+func ImportActivityFile(userID uint, filename string, data []byte, activityType string) (entity.Activity, error) {
+	parsed, err := ParseTrackFile(filename, data)
+	if err != nil {
+		return entity.Activity{}, err
+	}
+	summary := SummarizeTrack(parsed.Points)
+
+	if existing, found, err := findDuplicateActivity(userID, summary.StartTime); err != nil {
+		return entity.Activity{}, err
+	} else if found {
+		return existing, ErrDuplicateActivity
+	}
+
+	activity := entity.Activity{
+		UserID:   userID,
+		Type:     strings.TrimSpace(activityType),
+		Date:     summary.StartTime,
+		Distance: summary.DistanceKm,
+		Duration: summary.DurationMin,
+		Source:   parsed.Source,
+
+		AvgHeartRate: summary.AvgHeartRate,
+		MaxHeartRate: summary.MaxHeartRate,
+	}
+	if activity.Type == "" {
+		activity.Type = sportActivityTypes[parsed.Sport]
+	}
+	if activity.Type == "" {
+		activity.Type = "อื่นๆ"
+	}
+	if err := EstimateActivityCalories(&activity); err != nil {
+		return activity, err
+	}
+	if err := ApplyHeartRateMetrics(&activity, parsed.Points); err != nil {
+		return activity, err
+	}
+
+	track := entity.ActivityTrack{
+		Source:        parsed.Source,
+		FileName:      filename,
+		StartTime:     summary.StartTime,
+		ElevationGain: summary.ElevationGain,
+		AvgHeartRate:  summary.AvgHeartRate,
+		MaxHeartRate:  summary.MaxHeartRate,
+		Splits:        summary.Splits,
+		Points:        parsed.Points,
+	}
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&activity).Error; err != nil {
+			return err
+		}
+		track.ActivityID = activity.ID
+		return tx.Create(&track).Error
+	})
+	if err != nil {
+		return activity, err
+	}
+	activity.Track = &track
+	RefreshGoalsAfterChange(userID)
+	return activity, nil
+}
+
+// GetActivityTrack ดึงเส้นทางของกิจกรรม
+func GetActivityTrack(activityID uint) (entity.ActivityTrack, error) {
+	var track entity.ActivityTrack
+	err := config.DB().Where("activity_id = ?", activityID).First(&track).Error
+	return track, err
+}
+
+// findDuplicateActivity หากิจกรรมของผู้ใช้ที่เริ่มห่างจาก start ไม่เกิน duplicateWindow
+// ดึงช่วงกว้างมาก่อนแล้วเทียบเวลาใน Go เพราะรูปแบบเวลาที่เก็บใน SQLite อาจต่างโซนเวลากัน
+func findDuplicateActivity(userID uint, start time.Time) (entity.Activity, bool, error) {
+	var candidates []entity.Activity
+	err := config.DB().
+		Where("user_id = ? AND date BETWEEN ? AND ?", userID, start.Add(-24*time.Hour), start.Add(24*time.Hour)).
+		Find(&candidates).Error
+	if err != nil {
+		return entity.Activity{}, false, err
+	}
+	for _, a := range candidates {
+		if math.Abs(a.Date.Sub(start).Seconds()) <= duplicateWindow.Seconds() {
+			return a, true, nil
+		}
+	}
+	return entity.Activity{}, false, nil
+}
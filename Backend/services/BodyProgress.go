@@ -0,0 +1,372 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// ProgressPhotoDir โฟลเดอร์เก็บรูปความคืบหน้า อยู่นอก uploads ที่เปิดเป็นไฟล์สาธารณะ
+var ProgressPhotoDir = filepath.Join("storage", "progress-photos")
+
+// ProgressPhotoURLTTL อายุของลิงก์รูปที่ลงลายเซ็น
+var ProgressPhotoURLTTL = 15 * time.Minute
+
+// maxProgressPhotoBytes ขนาดไฟล์รูปสูงสุด
+const maxProgressPhotoBytes = 10 << 20
+
+// MeasurementSites ตำแหน่งที่วัดรอบร่างกายได้
+var MeasurementSites = []string{"waist", "hip", "chest", "arm", "thigh"}
+
+// progressPoses มุมถ่ายรูปความคืบหน้า
+var progressPoses = []string{"front", "side", "back"}
+
+// progressPhotoTypes ชนิดไฟล์รูปที่รับ พร้อมนามสกุลที่ใช้บันทึก
+var progressPhotoTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+var (
+	ErrInvalidPhotoSignature = errors.New("ลิงก์รูปไม่ถูกต้อง")
+	ErrPhotoURLExpired       = errors.New("ลิงก์รูปหมดอายุแล้ว")
+)
+
+// MeasurementPoint ค่าที่วัดได้ในวันหนึ่ง
+type MeasurementPoint struct {
+	Date    string  `json:"date"`
+	ValueCm float64 `json:"value_cm"`
+}
+
+// MeasurementHistory ประวัติการวัดของตำแหน่งหนึ่ง เรียงจากเก่าไปใหม่
+type MeasurementHistory struct {
+	Site         string             `json:"site"`
+	Points       []MeasurementPoint `json:"points"`
+	Latest       float64            `json:"latest_cm"`
+	ChangeTotal  float64            `json:"change_total_cm"`  // เทียบกับครั้งแรก
+	ChangeRecent float64            `json:"change_recent_cm"` // เทียบกับครั้งก่อนหน้า
+}
+
+// ValueChange ค่าก่อน/หลังและส่วนต่าง (nil = ไม่มีข้อมูล)
+type ValueChange struct {
+	Before *float64 `json:"before"`
+	After  *float64 `json:"after"`
+	Change *float64 `json:"change"`
+}
+
+// PhotoComparison ข้อมูลประกอบการดูรูปเทียบกันสองรูป
+type PhotoComparison struct {
+	Before       entity.ProgressPhoto   `json:"before"`
+	After        entity.ProgressPhoto   `json:"after"`
+	DaysBetween  int                    `json:"days_between"`
+	SamePose     bool                   `json:"same_pose"`
+	WeightKg     ValueChange            `json:"weight_kg"`
+	BodyFat      ValueChange            `json:"body_fat"`
+	Measurements map[string]ValueChange `json:"measurements_cm"`
+}
+
+// SaveBodyMeasurements บันทึกรอบวัดหลายตำแหน่งของวันเดียวกัน ตำแหน่งที่เคยบันทึกในวันนั้นจะถูกแทนที่
+func SaveBodyMeasurements(userID uint, date string, values map[string]float64, note string, trainerID *uint) ([]entity.BodyMeasurement, error) {
+	if err := validateProgressDate(date); err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("กรุณาระบุค่าที่วัดอย่างน้อยหนึ่งตำแหน่ง")
+	}
+	sites := make([]string, 0, len(values))
+	for site, value := range values {
+		if !containsString(MeasurementSites, site) {
+			return nil, fmt.Errorf("ไม่รู้จักตำแหน่ง %s (ใช้ได้: %s)", site, strings.Join(MeasurementSites, ", "))
+		}
+		if value <= 0 || value > 300 {
+			return nil, fmt.Errorf("ค่ารอบ %s ต้องอยู่ระหว่าง 0-300 ซม.", site)
+		}
+		sites = append(sites, site)
+	}
+	sort.Strings(sites)
+
+	saved := []entity.BodyMeasurement{}
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		for _, site := range sites {
+			var m entity.BodyMeasurement
+			err := tx.Where("user_id = ? AND date = ? AND site = ?", userID, date, site).First(&m).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			m.UserID, m.Date, m.Site = userID, date, site
+			m.ValueCm = round2(values[site])
+			m.Note = strings.TrimSpace(note)
+			m.RecordedByTrainerID = trainerID
+			if err := tx.Save(&m).Error; err != nil {
+				return err
+			}
+			saved = append(saved, m)
+		}
+		return nil
+	})
+	return saved, err
+}
+
+// GetBodyMeasurements ดึงรอบวัดตามตำแหน่งและช่วงวันที่ (ว่าง = ทั้งหมด)
+func GetBodyMeasurements(userID uint, site, from, to string) ([]entity.BodyMeasurement, error) {
+	query := config.DB().Where("user_id = ?", userID).Order("date desc, site")
+	if site != "" {
+		query = query.Where("site = ?", site)
+	}
+	if from != "" {
+		query = query.Where("date >= ?", from)
+	}
+	if to != "" {
+		query = query.Where("date <= ?", to)
+	}
+	var measurements []entity.BodyMeasurement
+	err := query.Find(&measurements).Error
+	return measurements, err
+}
+
+// GetBodyMeasurement ดึงรอบวัดตามรหัส
+func GetBodyMeasurement(id uint) (entity.BodyMeasurement, error) {
+	var m entity.BodyMeasurement
+	err := config.DB().First(&m, id).Error
+	return m, err
+}
+
+// DeleteBodyMeasurement ลบรอบวัด (ลบจริงเพื่อให้บันทึกวันเดิมใหม่ได้)
+func DeleteBodyMeasurement(id uint) error {
+	return config.DB().Unscoped().Delete(&entity.BodyMeasurement{}, id).Error
+}
+
+// GetMeasurementHistory ประวัติรอบวัดแยกตามตำแหน่ง
+func GetMeasurementHistory(userID uint) ([]MeasurementHistory, error) {
+	var measurements []entity.BodyMeasurement
+	if err := config.DB().Where("user_id = ?", userID).Order("date").Find(&measurements).Error; err != nil {
+		return nil, err
+	}
+	bySite := map[string][]MeasurementPoint{}
+	for _, m := range measurements {
+		bySite[m.Site] = append(bySite[m.Site], MeasurementPoint{Date: m.Date, ValueCm: m.ValueCm})
+	}
+	history := []MeasurementHistory{}
+	for _, site := range MeasurementSites {
+		points := bySite[site]
+		if len(points) == 0 {
+			continue
+		}
+		latest := points[len(points)-1].ValueCm
+		h := MeasurementHistory{
+			Site:        site,
+			Points:      points,
+			Latest:      latest,
+			ChangeTotal: round2(latest - points[0].ValueCm),
+		}
+		if len(points) > 1 {
+			h.ChangeRecent = round2(latest - points[len(points)-2].ValueCm)
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
+
+// SaveProgressPhoto ตรวจชนิดไฟล์และเก็บรูปไว้ในโฟลเดอร์ส่วนตัว แล้วบันทึกข้อมูลรูป
+func SaveProgressPhoto(photo entity.ProgressPhoto, file io.Reader) (entity.ProgressPhoto, error) {
+	if err := validateProgressDate(photo.Date); err != nil {
+		return photo, err
+	}
+	photo.Pose = strings.ToLower(strings.TrimSpace(photo.Pose))
+	if photo.Pose == "" {
+		photo.Pose = "front"
+	}
+	if !containsString(progressPoses, photo.Pose) {
+		return photo, fmt.Errorf("มุมถ่ายต้องเป็น %s", strings.Join(progressPoses, ", "))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, maxProgressPhotoBytes+1))
+	if err != nil {
+		return photo, err
+	}
+	if len(data) > maxProgressPhotoBytes {
+		return photo, fmt.Errorf("ไฟล์รูปต้องมีขนาดไม่เกิน %d MB", maxProgressPhotoBytes>>20)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := progressPhotoTypes[contentType]
+	if !ok {
+		return photo, fmt.Errorf("รองรับเฉพาะไฟล์ JPEG, PNG หรือ WebP")
+	}
+
+	name, err := randomToken()
+	if err != nil {
+		return photo, err
+	}
+	dir := filepath.Join(ProgressPhotoDir, strconv.FormatUint(uint64(photo.UserID), 10))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return photo, err
+	}
+	path := filepath.Join(dir, name+ext)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return photo, err
+	}
+
+	photo.ID = 0
+	photo.StoragePath = path
+	photo.ContentType = contentType
+	photo.SizeBytes = int64(len(data))
+	photo.Note = strings.TrimSpace(photo.Note)
+	if err := config.DB().Create(&photo).Error; err != nil {
+		os.Remove(path)
+		return photo, err
+	}
+	return photo, nil
+}
+
+// GetProgressPhoto ดึงข้อมูลรูปตามรหัส
+func GetProgressPhoto(id uint) (entity.ProgressPhoto, error) {
+	var photo entity.ProgressPhoto
+	err := config.DB().First(&photo, id).Error
+	return photo, err
+}
+
+// GetProgressPhotos ดึงรูปของผู้ใช้ เรียงจากใหม่ไปเก่า (pose ว่าง = ทุกมุม)
+func GetProgressPhotos(userID uint, pose string) ([]entity.ProgressPhoto, error) {
+	query := config.DB().Where("user_id = ?", userID).Order("date desc, id desc")
+	if pose != "" {
+		query = query.Where("pose = ?", pose)
+	}
+	var photos []entity.ProgressPhoto
+	err := query.Find(&photos).Error
+	return photos, err
+}
+
+// DeleteProgressPhoto ลบข้อมูลรูปและไฟล์
+func DeleteProgressPhoto(photo entity.ProgressPhoto) error {
+	if err := config.DB().Unscoped().Delete(&photo).Error; err != nil {
+		return err
+	}
+	if err := os.Remove(photo.StoragePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// SignProgressPhoto ใส่ลิงก์ชั่วคราวที่ลงลายเซ็นให้รูป
+// ควรเรียกหลังตรวจแล้วว่าผู้ขอเป็นเจ้าของหรือเทรนเนอร์ของเจ้าของรูป
+func SignProgressPhoto(photo *entity.ProgressPhoto) {
+	expires := time.Now().Add(ProgressPhotoURLTTL).Unix()
+	photo.URLExpiresAt = expires
+	photo.URL = fmt.Sprintf("/progress-photos/%d/file?expires=%d&signature=%s",
+		photo.ID, expires, progressPhotoSignature(photo.ID, expires))
+}
+
+// VerifyProgressPhotoURL ตรวจลายเซ็นและเวลาหมดอายุของลิงก์ แล้วคืนข้อมูลรูป
+func VerifyProgressPhotoURL(id uint, expires, signature string) (entity.ProgressPhoto, error) {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return entity.ProgressPhoto{}, ErrInvalidPhotoSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(progressPhotoSignature(id, exp))) {
+		return entity.ProgressPhoto{}, ErrInvalidPhotoSignature
+	}
+	if time.Now().Unix() > exp {
+		return entity.ProgressPhoto{}, ErrPhotoURLExpired
+	}
+	return GetProgressPhoto(id)
+}
+
+// CompareProgressPhotos ข้อมูลประกอบการดูรูปสองรูปเทียบกัน (เรียงรูปเก่าไว้ก่อนเสมอ)
+// พร้อมน้ำหนัก เปอร์เซ็นต์ไขมัน และรอบวัดล่าสุด ณ วันที่ของแต่ละรูป
+func CompareProgressPhotos(a, b entity.ProgressPhoto) (PhotoComparison, error) {
+	if a.UserID != b.UserID {
+		return PhotoComparison{}, fmt.Errorf("เปรียบเทียบได้เฉพาะรูปของผู้ใช้เดียวกัน")
+	}
+	if b.Date < a.Date || (b.Date == a.Date && b.ID < a.ID) {
+		a, b = b, a
+	}
+	beforeDay, _ := time.ParseInLocation("2006-01-02", a.Date, bangkok)
+	afterDay, _ := time.ParseInLocation("2006-01-02", b.Date, bangkok)
+	result := PhotoComparison{
+		Before:       a,
+		After:        b,
+		DaysBetween:  int(afterDay.Sub(beforeDay).Hours() / 24),
+		SamePose:     a.Pose == b.Pose,
+		Measurements: map[string]ValueChange{},
+	}
+
+	// ใช้ค่าล่าสุด ณ สิ้นวันของรูปแต่ละรูป ตามวิธีเดียวกับความคืบหน้าของเป้าหมาย
+	for _, metric := range []struct {
+		goalType string
+		target   *ValueChange
+	}{{"weight", &result.WeightKg}, {"body_fat", &result.BodyFat}} {
+		goal := entity.Goal{UserID: a.UserID, Type: metric.goalType}
+		before, found, err := goalValueAt(goal, beforeDay.AddDate(0, 0, 1).Add(-time.Second))
+		if err != nil {
+			return result, err
+		}
+		if found {
+			metric.target.Before = floatPtr(before)
+		}
+		after, found, err := goalValueAt(goal, afterDay.AddDate(0, 0, 1).Add(-time.Second))
+		if err != nil {
+			return result, err
+		}
+		if found {
+			metric.target.After = floatPtr(after)
+		}
+		*metric.target = withChange(*metric.target)
+	}
+
+	var measurements []entity.BodyMeasurement
+	if err := config.DB().Where("user_id = ? AND date <= ?", a.UserID, b.Date).
+		Order("date").Find(&measurements).Error; err != nil {
+		return result, err
+	}
+	for _, m := range measurements {
+		change := result.Measurements[m.Site]
+		if m.Date <= a.Date {
+			change.Before = floatPtr(m.ValueCm)
+		}
+		change.After = floatPtr(m.ValueCm)
+		result.Measurements[m.Site] = change
+	}
+	for site, change := range result.Measurements {
+		result.Measurements[site] = withChange(change)
+	}
+	return result, nil
+}
+
+func withChange(v ValueChange) ValueChange {
+	if v.Before != nil && v.After != nil {
+		v.Change = floatPtr(round2(*v.After - *v.Before))
+	}
+	return v
+}
+
+func progressPhotoSignature(id uint, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(config.SecretKey))
+	fmt.Fprintf(mac, "progress-photo:%d:%d", id, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func validateProgressDate(date string) error {
+	day, err := time.ParseInLocation("2006-01-02", date, bangkok)
+	if err != nil {
+		return fmt.Errorf("รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)")
+	}
+	if day.After(time.Now().In(bangkok)) {
+		return fmt.Errorf("บันทึกล่วงหน้าไม่ได้")
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// ImportResult ผลการนำเข้าช่วงเวลาไม่ว่างจากไฟล์ .ics
+type ImportResult struct {
+	Created []entity.TrainerSchedule `json:"created"`
+	Removed []entity.TrainerSchedule `json:"removed"` // ตารางว่างที่ยังไม่มีผู้จองซึ่งทับเวลาไม่ว่าง
+	Skipped []ImportSkip             `json:"skipped"`
+}
+
+// ImportSkip เหตุการณ์ที่ไม่ได้นำเข้า พร้อมเหตุผล
+type ImportSkip struct {
+	UID     string    `json:"uid"`
+	Summary string    `json:"summary"`
+	Start   time.Time `json:"start"`
+	Reason  string    `json:"reason"`
+	// ช่วงที่มีผู้จองหรือคลาสที่ทับ ต้องจัดการเอง
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// IssueCalendarToken สร้างหรือเปลี่ยนโทเค็นฟีดปฏิทิน (rotate = true จะออกโทเค็นใหม่แทนอันเดิม)
+func IssueCalendarToken(actor string, ownerID uint, rotate bool) (entity.CalendarToken, error) {
+	db := config.DB()
+	if actor != "customer" && actor != "trainer" {
+		return entity.CalendarToken{}, fmt.Errorf("ฟีดปฏิทินรองรับเฉพาะลูกค้าและเทรนเนอร์")
+	}
+
+	var token entity.CalendarToken
+	err := db.Where("actor = ? AND owner_id = ?", actor, ownerID).First(&token).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return token, err
+	}
+	if err == nil && !rotate {
+		return token, nil
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return token, err
+	}
+	if token.ID != 0 {
+		err = db.Model(&token).Update("token", secret).Error
+		return token, err
+	}
+	token = entity.CalendarToken{Actor: actor, OwnerID: ownerID, Token: secret}
+	err = db.Create(&token).Error
+	return token, err
+}
+
+// BuildCalendarFeed สร้างไฟล์ .ics ตามโทเค็น: ลูกค้าได้คลาสและนัดเทรนเนอร์ที่จองไว้
+// เทรนเนอร์ได้ตารางเวลาของตนและคลาสที่สอน
+func BuildCalendarFeed(secret string) (string, error) {
+	var token entity.CalendarToken
+	if err := config.DB().Where("token = ?", secret).First(&token).Error; err != nil {
+		return "", err
+	}
+
+	if token.Actor == "trainer" {
+		events, err := trainerCalendarEvents(token.OwnerID)
+		if err != nil {
+			return "", err
+		}
+		return RenderICalendar("ตารางเทรนเนอร์", events), nil
+	}
+
+	events, err := customerCalendarEvents(token.OwnerID)
+	if err != nil {
+		return "", err
+	}
+	return RenderICalendar("การจองของฉัน", events), nil
+}
+
+func customerCalendarEvents(userID uint) ([]ICalEvent, error) {
+	db := config.DB()
+	var events []ICalEvent
+
+	var classBookings []entity.ClassBooking
+	if err := db.Where("user_id = ? AND status <> ?", userID, "Cancelled").
+		Preload("ClassActivity").
+		Find(&classBookings).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range classBookings {
+		start, end, err := ClassTimeRange(b.ClassActivity)
+		if err != nil {
+			continue
+		}
+		events = append(events, ICalEvent{
+			UID:         fmt.Sprintf("class-booking-%d@fitness-app", b.ID),
+			Summary:     "คลาส " + b.ClassActivity.Name,
+			Description: b.ClassActivity.Description,
+			Location:    b.ClassActivity.Location,
+			Start:       start,
+			End:         end,
+			Status:      "CONFIRMED",
+		})
+	}
+
+	var trainBookings []entity.TrainBooking
+	if err := db.Where("users_id = ? AND booking_status <> ?", userID, "Cancelled").
+		Preload("Schedule").
+		Preload("Schedule.Trainer").
+		Find(&trainBookings).Error; err != nil {
+		return nil, err
+	}
+	for _, b := range trainBookings {
+		events = append(events, ICalEvent{
+			UID:     fmt.Sprintf("train-booking-%d@fitness-app", b.ID),
+			Summary: fmt.Sprintf("ฝึกกับเทรนเนอร์ %s %s", b.Schedule.Trainer.FirstName, b.Schedule.Trainer.LastName),
+			Start:   b.Schedule.StartTime,
+			End:     b.Schedule.EndTime,
+			Status:  "CONFIRMED",
+		})
+	}
+	return events, nil
+}
+
+func trainerCalendarEvents(trainerID uint) ([]ICalEvent, error) {
+	db := config.DB()
+	var events []ICalEvent
+
+	var schedules []entity.TrainerSchedule
+	if err := db.Where("trainer_id = ?", trainerID).
+		Preload("Bookings", "booking_status <> ?", "Cancelled").
+		Preload("Bookings.Users").
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range schedules {
+		event := ICalEvent{
+			UID:   fmt.Sprintf("trainer-schedule-%d@fitness-app", s.ID),
+			Start: s.StartTime,
+			End:   s.EndTime,
+		}
+		switch {
+		case len(s.Bookings) > 0:
+			u := s.Bookings[0].Users
+			event.Summary = fmt.Sprintf("ลูกค้า %s %s", u.FirstName, u.LastName)
+			event.Status = "CONFIRMED"
+		case s.Status == "Blocked":
+			event.Summary = "ไม่ว่าง"
+			event.Status = "CONFIRMED"
+		default:
+			event.Summary = "ว่างรับจอง"
+			event.Status = "TENTATIVE"
+			event.Transparent = true
+		}
+		events = append(events, event)
+	}
+
+	var classes []entity.ClassActivity
+	if err := db.Where("trainer_id = ?", trainerID).Find(&classes).Error; err != nil {
+		return nil, err
+	}
+	for _, class := range classes {
+		start, end, err := ClassTimeRange(class)
+		if err != nil {
+			continue
+		}
+		events = append(events, ICalEvent{
+			UID:      fmt.Sprintf("class-%d@fitness-app", class.ID),
+			Summary:  "สอนคลาส " + class.Name,
+			Location: class.Location,
+			Start:    start,
+			End:      end,
+			Status:   "CONFIRMED",
+		})
+	}
+	return events, nil
+}
+
+// busyImportWindow ช่วงเวลาล่วงหน้าที่แตกเหตุการณ์ซ้ำ (RRULE) ตอนนำเข้า
+var busyImportWindow = 12 * 7 * 24 * time.Hour
+
+// ImportTrainerBusyTimes นำเข้าช่วงเวลาไม่ว่างจากไฟล์ .ics เป็นตารางเวลาสถานะ Blocked
+// เหตุการณ์ซ้ำ (RRULE) แตกเป็นแต่ละครั้งภายใน busyImportWindow ตารางว่างที่ยังไม่มีผู้จองและทับกับเวลาไม่ว่าง
+// จะถูกลบ (Removed) ส่วนช่วงที่มีผู้จองหรือสอนคลาสอยู่แล้วจะไม่ปิดและรายงานเป็นข้อขัดแย้ง
+// ข้ามเหตุการณ์ในอดีต เหตุการณ์ที่ยกเลิก/โปร่งใส และช่วงที่ปิดไว้แล้ว (นำเข้าไฟล์เดิมซ้ำได้)
+func ImportTrainerBusyTimes(trainerID uint, r io.Reader) (ImportResult, error) {
+	result := ImportResult{Created: []entity.TrainerSchedule{}, Removed: []entity.TrainerSchedule{}, Skipped: []ImportSkip{}}
+	if trainerID == 0 {
+		return result, fmt.Errorf("trainer_id ไม่ถูกต้อง")
+	}
+
+	events, err := ParseICalendar(r)
+	if err != nil {
+		return result, err
+	}
+
+	now := time.Now()
+	for _, event := range events {
+		skip := func(e ICalEvent, reason string, conflicts []Conflict) {
+			result.Skipped = append(result.Skipped, ImportSkip{UID: e.UID, Summary: e.Summary, Start: e.Start, Reason: reason, Conflicts: conflicts})
+		}
+		switch {
+		case event.Status == "CANCELLED":
+			skip(event, "เหตุการณ์ถูกยกเลิก", nil)
+			continue
+		case event.Transparent:
+			skip(event, "เหตุการณ์ไม่นับเป็นเวลาไม่ว่าง", nil)
+			continue
+		case !event.Recurring && !event.End.After(now):
+			skip(event, "เหตุการณ์ผ่านไปแล้ว", nil)
+			continue
+		case !event.End.After(event.Start):
+			skip(event, "เวลาสิ้นสุดต้องอยู่หลังเวลาเริ่ม", nil)
+			continue
+		}
+		occurrences, err := ExpandICalEvent(event, now, now.Add(busyImportWindow))
+		if err != nil {
+			skip(event, err.Error(), nil)
+			continue
+		}
+
+		for _, e := range occurrences {
+			var created *entity.TrainerSchedule
+			var removed []entity.TrainerSchedule
+			var reason string
+			var conflicts []Conflict
+			err := config.DB().Transaction(func(tx *gorm.DB) error {
+				var overlapping []entity.TrainerSchedule
+				if err := tx.Where("trainer_id = ? AND start_time < ? AND end_time > ?", trainerID, e.End, e.Start).
+					Find(&overlapping).Error; err != nil {
+					return err
+				}
+				var open []uint
+				for _, s := range overlapping {
+					if s.Status == "Blocked" {
+						if !s.StartTime.After(e.Start) && !s.EndTime.Before(e.End) {
+							reason = "ช่วงเวลานี้ปิดไว้แล้ว"
+							return nil
+						}
+						continue
+					}
+					var booked int64
+					if err := tx.Model(&entity.TrainBooking{}).
+						Where("schedule_id = ? AND booking_status <> ?", s.ID, "Cancelled").Count(&booked).Error; err != nil {
+						return err
+					}
+					if booked > 0 {
+						conflicts = append(conflicts, Conflict{
+							Kind: "trainer_schedule", ID: s.ID, Name: fmt.Sprintf("#%d", s.ID),
+							Reason: "booked", Start: s.StartTime, End: s.EndTime,
+						})
+						continue
+					}
+					open = append(open, s.ID)
+					removed = append(removed, s)
+				}
+				classConflicts, err := FindTrainerClassConflicts(trainerID, e.Start, e.End)
+				if err != nil {
+					return err
+				}
+				conflicts = append(conflicts, classConflicts...)
+
+				// ตารางว่างที่ทับเวลาไม่ว่างต้องไม่ให้จองได้อีก แม้ช่วงนั้นจะมีข้อขัดแย้งอื่น
+				if len(open) > 0 {
+					if err := tx.Delete(&entity.TrainerSchedule{}, open).Error; err != nil {
+						return err
+					}
+				}
+				if len(conflicts) > 0 {
+					reason = "ทับกับช่วงที่มีผู้จองหรือสอนคลาสอยู่แล้ว"
+					return nil
+				}
+				created = &entity.TrainerSchedule{
+					AvailableDate: e.Start,
+					StartTime:     e.Start,
+					EndTime:       e.End,
+					Status:        "Blocked",
+					TrainerID:     trainerID,
+				}
+				return tx.Create(created).Error
+			})
+			if err != nil {
+				return result, err
+			}
+			result.Removed = append(result.Removed, removed...)
+			if created != nil {
+				result.Created = append(result.Created, *created)
+			} else {
+				skip(e, reason, conflicts)
+			}
+		}
+	}
+	return result, nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+)
+
+// ClassTimeRange แปลงวันที่และเวลาเริ่ม/สิ้นสุดของคลาส (เวลาไทย) เป็น time.Time
+func ClassTimeRange(class entity.ClassActivity) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02 15:04", class.Date+" "+class.StartTime, bangkok)
+	if err != nil {
+		return start, start, fmt.Errorf("รูปแบบวันที่หรือเวลาเริ่มของคลาสไม่ถูกต้อง")
+	}
+	end, err := time.ParseInLocation("2006-01-02 15:04", class.Date+" "+class.EndTime, bangkok)
+	if err != nil {
+		return start, end, fmt.Errorf("รูปแบบเวลาสิ้นสุดของคลาสไม่ถูกต้อง")
+	}
+	if !end.After(start) {
+		return start, end, fmt.Errorf("เวลาสิ้นสุดต้องอยู่หลังเวลาเริ่มของคลาส")
+	}
+	return start, end, nil
+}
+
+// ValidateClassActivity ตรวจสอบสถานที่ เทรนเนอร์ ความจุ และเวลาที่ชนกันของคลาส
+// หากผ่านจะเติม Location จากชื่อสถานที่ให้อัตโนมัติ
+func ValidateClassActivity(class *entity.ClassActivity) error {
+	db := config.DB()
+
+	if class.Capacity <= 0 {
+		return fmt.Errorf("จำนวนผู้เข้าร่วมสูงสุดต้องมากกว่า 0")
+	}
+
+	start, end, err := ClassTimeRange(*class)
+	if err != nil {
+		return err
+	}
+
+	if class.FacilityID != nil {
+		var facility entity.Facility
+		if err := db.First(&facility, *class.FacilityID).Error; err != nil {
+			return fmt.Errorf("ไม่พบสถานที่ที่เลือก")
+		}
+		if class.Capacity > facility.Capacity {
+			return fmt.Errorf("จำนวนผู้เข้าร่วมของคลาส (%d) เกินความจุของ %s (%d)", class.Capacity, facility.Name, facility.Capacity)
+		}
+		class.Location = facility.Name
+	}
+
+	if class.TrainerID != nil {
+		var trainer entity.Trainer
+		if err := db.First(&trainer, *class.TrainerID).Error; err != nil {
+			return fmt.Errorf("ไม่พบเทรนเนอร์ผู้สอน")
+		}
+	}
+
+	conflicts, err := FindClassConflicts(class.ID, class.FacilityID, class.TrainerID, start, end)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// FindClassConflicts ค้นหาคลาสอื่นที่ใช้สถานที่หรือเทรนเนอร์เดียวกันในช่วงเวลาที่ทับกัน
+// รวมถึงตารางเวลาเทรนเนอร์ (TrainerSchedule) ของผู้สอน; excludeID ใช้ข้ามคลาสที่กำลังแก้ไข
+func FindClassConflicts(excludeID uint, facilityID, trainerID *uint, start, end time.Time) ([]Conflict, error) {
+	var conflicts []Conflict
+
+	if facilityID == nil && trainerID == nil {
+		return conflicts, nil
+	}
+
+	classConflicts, err := findOverlappingClasses(excludeID, facilityID, trainerID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	conflicts = append(conflicts, classConflicts...)
+
+	if trainerID != nil {
+		var schedules []entity.TrainerSchedule
+		if err := config.DB().Where("trainer_id = ?", *trainerID).Find(&schedules).Error; err != nil {
+			return nil, err
+		}
+		for _, s := range schedules {
+			if overlaps(start, end, s.StartTime, s.EndTime) {
+				conflicts = append(conflicts, Conflict{
+					Kind:   "trainer_schedule",
+					ID:     s.ID,
+					Name:   fmt.Sprintf("#%d", s.ID),
+					Reason: "trainer",
+					Start:  s.StartTime,
+					End:    s.EndTime,
+				})
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// FindTrainerClassConflicts ค้นหาคลาสที่เทรนเนอร์สอนในช่วงเวลาที่ทับกับ start-end
+func FindTrainerClassConflicts(trainerID uint, start, end time.Time) ([]Conflict, error) {
+	return findOverlappingClasses(0, nil, &trainerID, start, end)
+}
+
+func findOverlappingClasses(excludeID uint, facilityID, trainerID *uint, start, end time.Time) ([]Conflict, error) {
+	db := config.DB()
+
+	// คลาสเก็บวันที่เป็นข้อความ จึงดึงเฉพาะวันที่ที่ช่วงเวลาครอบคลุมแล้วเทียบเวลาอีกครั้ง
+	var dates []string
+	for d := start.In(bangkok); !d.After(end.In(bangkok)); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+
+	tx := db.Where("date IN ? AND id <> ?", dates, excludeID)
+	switch {
+	case facilityID != nil && trainerID != nil:
+		tx = tx.Where("facility_id = ? OR trainer_id = ?", *facilityID, *trainerID)
+	case facilityID != nil:
+		tx = tx.Where("facility_id = ?", *facilityID)
+	default:
+		tx = tx.Where("trainer_id = ?", *trainerID)
+	}
+
+	var classes []entity.ClassActivity
+	if err := tx.Find(&classes).Error; err != nil {
+		return nil, err
+	}
+
+	var conflicts []Conflict
+	for _, other := range classes {
+		otherStart, otherEnd, err := ClassTimeRange(other)
+		if err != nil || !overlaps(start, end, otherStart, otherEnd) {
+			continue
+		}
+		if facilityID != nil && other.FacilityID != nil && *other.FacilityID == *facilityID {
+			conflicts = append(conflicts, Conflict{Kind: "class", ID: other.ID, Name: other.Name, Reason: "facility", Start: otherStart, End: otherEnd})
+		}
+		if trainerID != nil && other.TrainerID != nil && *other.TrainerID == *trainerID {
+			conflicts = append(conflicts, Conflict{Kind: "class", ID: other.ID, Name: other.Name, Reason: "trainer", Start: otherStart, End: otherEnd})
+		}
+	}
+	return conflicts, nil
+}
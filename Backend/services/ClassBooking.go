@@ -46,7 +46,14 @@ func CreateClassBooking(booking entity.ClassBooking) (entity.ClassBooking, error
 		booking.Status = "Confirmed"
 	}
 
-	if err := db.Create(&booking).Error; err != nil {
+	// สร้างการจองพร้อมหักสิทธิ์จองคลาสของแพ็กเกจ ถ้าสิทธิ์ไม่พอจะไม่สร้างการจอง
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&booking).Error; err != nil {
+			return err
+		}
+		return ConsumeEntitlement(tx, booking.UserID, CapabilityClassBooking, "class_booking", booking.ID)
+	})
+	if err != nil {
 		return booking, err
 	}
 
@@ -55,7 +62,7 @@ func CreateClassBooking(booking entity.ClassBooking) (entity.ClassBooking, error
 	return booking, nil
 }
 
-// CancelClassBooking เปลี่ยนสถานะการจองเป็น Cancelled
+// CancelClassBooking เปลี่ยนสถานะการจองเป็น Cancelled และคืนสิทธิ์จองคลาส
 func CancelClassBooking(id uint) (entity.ClassBooking, error) {
 	db := config.DB()
 
@@ -68,9 +75,15 @@ func CancelClassBooking(id uint) (entity.ClassBooking, error) {
 		return booking, nil
 	}
 
-	if err := db.Model(&entity.ClassBooking{}).
-		Where("id = ?", id).
-		Update("status", "Cancelled").Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.ClassBooking{}).
+			Where("id = ?", id).
+			Update("status", "Cancelled").Error; err != nil {
+			return err
+		}
+		return ReleaseEntitlement(tx, "class_booking", id)
+	})
+	if err != nil {
 		return booking, err
 	}
 
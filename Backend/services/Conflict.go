@@ -0,0 +1,57 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Conflict รายการที่เวลาทับซ้อนกับรายการที่กำลังบันทึก
+type Conflict struct {
+	Kind   string    `json:"kind"` // "class" หรือ "trainer_schedule"
+	ID     uint      `json:"id"`
+	Name   string    `json:"name"`
+	Reason string    `json:"reason"` // "facility" หรือ "trainer"
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// ConflictError ส่งกลับเมื่อพบรายการที่ชนกัน พร้อมรายการทั้งหมดที่ชน
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	items := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		label := "คลาส"
+		if c.Kind == "trainer_schedule" {
+			label = "ตารางเวลาเทรนเนอร์"
+		}
+		by := "เทรนเนอร์"
+		if c.Reason == "facility" {
+			by = "สถานที่"
+		}
+		items = append(items, fmt.Sprintf("%s %s (%s-%s) ใช้%sเดียวกัน",
+			label, c.Name,
+			c.Start.In(bangkok).Format("2006-01-02 15:04"),
+			c.End.In(bangkok).Format("15:04"),
+			by))
+	}
+	return "พบรายการที่เวลาชนกัน: " + strings.Join(items, ", ")
+}
+
+// bangkok เขตเวลาที่ใช้ตีความวันที่/เวลาของคลาส (เก็บเป็นข้อความ)
+var bangkok = loadBangkok()
+
+func loadBangkok() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Bangkok"); err == nil {
+		return loc
+	}
+	return time.FixedZone("ICT", 7*60*60)
+}
+
+// overlaps ตรวจสอบว่าช่วงเวลา [aStart, aEnd) และ [bStart, bEnd) ทับกันหรือไม่
+func overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
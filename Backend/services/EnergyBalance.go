@@ -0,0 +1,259 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/nutrition"
+)
+
+// energyRollingDays ช่วงของสมดุลพลังงานสะสม
+const energyRollingDays = 7
+
+// EnergyBalanceDay สมดุลพลังงานของวันหนึ่ง
+type EnergyBalanceDay struct {
+	Date         string  `json:"date"`
+	IntakeKcal   float64 `json:"intake_kcal"`
+	IntakeSource string  `json:"intake_source"` // food_log, plan, none
+	BMR          float64 `json:"bmr"`
+	BaselineKcal float64 `json:"baseline_kcal"` // BMR × 1.2 ไม่รวมการออกกำลังกาย
+	ExerciseKcal float64 `json:"exercise_kcal"` // จาก Activity.Calories
+	Expenditure  float64 `json:"expenditure_kcal"`
+	NetKcal      float64 `json:"net_kcal"` // บวก = กินเกิน
+	TargetKcal   float64 `json:"target_kcal"`
+}
+
+// EnergyBalanceWindow สมดุลพลังงานสะสมหลายวัน คิดเฉพาะวันที่ทราบพลังงานที่ได้รับ
+type EnergyBalanceWindow struct {
+	From                    string  `json:"from"`
+	To                      string  `json:"to"`
+	DaysWithIntake          int     `json:"days_with_intake"`
+	IntakeKcal              float64 `json:"intake_kcal"`
+	ExpenditureKcal         float64 `json:"expenditure_kcal"`
+	NetKcal                 float64 `json:"net_kcal"`
+	AvgNetKcal              float64 `json:"avg_net_kcal"`
+	PredictedWeightChangeKg float64 `json:"predicted_weight_change_kg"`
+}
+
+// EnergyBalanceReport สมดุลพลังงานของวัน สะสม 7 วัน และการเทียบแนวโน้มน้ำหนักจริงกับที่คาด
+type EnergyBalanceReport struct {
+	Formula nutrition.Formula   `json:"formula"`
+	Day     EnergyBalanceDay    `json:"day"`
+	Days    []EnergyBalanceDay  `json:"days"`
+	Rolling EnergyBalanceWindow `json:"rolling_7_days"`
+
+	// nil เมื่อยังไม่มีแผนโภชนาการหรือข้อมูลน้ำหนักไม่พอ (ดู TrendNote)
+	Trend     *nutrition.Adjustment `json:"trend"`
+	TrendNote string                `json:"trend_note,omitempty"`
+}
+
+// GetEnergyBalance รวมพลังงานที่ได้รับ (จากบันทึกอาหาร หรือแผนถ้าไม่ได้บันทึก) กับพลังงานที่ใช้
+// (BMR และกิจกรรม) ของวันที่กำหนด
+func GetEnergyBalance(userID uint, date string) (EnergyBalanceReport, error) {
+	var report EnergyBalanceReport
+	end, err := time.ParseInLocation("2006-01-02", date, bangkok)
+	if err != nil {
+		return report, fmt.Errorf("รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)")
+	}
+	bmr, formula, err := energyBMR(userID, end)
+	if err != nil {
+		return report, err
+	}
+	report.Formula = formula
+
+	days, err := energyBalanceDays(userID, end, nutrition.TrendWindowDays, bmr)
+	if err != nil {
+		return report, err
+	}
+	report.Days = days[len(days)-energyRollingDays:]
+	report.Day = days[len(days)-1]
+	report.Rolling = summarizeEnergy(report.Days)
+
+	window := summarizeEnergy(days)
+	switch {
+	case report.Day.TargetKcal == 0:
+		report.TrendNote = "ยังไม่มีแผนโภชนาการ"
+	case window.DaysWithIntake*2 < len(days):
+		report.TrendNote = fmt.Sprintf("ทราบพลังงานที่ได้รับไม่ถึงครึ่งของ %d วันล่าสุด", len(days))
+	default:
+		actual, ok, err := weightTrend(userID, end.AddDate(0, 0, 1-len(days)), end, len(days))
+		if err != nil {
+			return report, err
+		}
+		if !ok {
+			report.TrendNote = fmt.Sprintf("ต้องมีน้ำหนักอย่างน้อย 2 ครั้งห่างกัน 7 วันขึ้นไปใน %d วันล่าสุด", len(days))
+			break
+		}
+		// วันที่ไม่ทราบพลังงานที่ได้รับถือว่าเป็นไปตามค่าเฉลี่ยของวันที่ทราบ
+		expected := nutrition.PredictWeightChange(window.AvgNetKcal * float64(len(days)))
+		trend := nutrition.TrendAdjustment(expected, actual, len(days), report.Day.TargetKcal, bmr)
+		report.Trend = &trend
+	}
+	return report, nil
+}
+
+// ApplyEnergyBalanceAdjustment ปรับพลังงานเป้าหมายตามแนวโน้มน้ำหนัก โดยบันทึกเป็นแผนของวันนี้
+// สารอาหารหลักคำนวณใหม่ตามเป้าหมายเดิมของแผน
+func ApplyEnergyBalanceAdjustment(userID uint) (entity.Nutrition, nutrition.Adjustment, error) {
+	today := time.Now().In(bangkok).Format("2006-01-02")
+	report, err := GetEnergyBalance(userID, today)
+	if err != nil {
+		return entity.Nutrition{}, nutrition.Adjustment{}, err
+	}
+	if report.Trend == nil {
+		return entity.Nutrition{}, nutrition.Adjustment{}, fmt.Errorf("ยังปรับแผนไม่ได้: %s", report.TrendNote)
+	}
+	adjustment := *report.Trend
+	if !adjustment.Recommended {
+		return entity.Nutrition{}, adjustment, fmt.Errorf("ยังไม่ต้องปรับแผน: %s", adjustment.Reason)
+	}
+
+	var current entity.Nutrition
+	if err := config.DB().Where("user_id = ? AND date <= ?", userID, today).Order("date desc").First(&current).Error; err != nil {
+		return current, adjustment, err
+	}
+	macros, _, err := CalculateNutritionMacros(userID, adjustment.SuggestedTarget, NutritionOptions{Goal: current.Goal})
+	if err != nil {
+		return current, adjustment, err
+	}
+	plan, _, err := SaveNutritionPlan(entity.Nutrition{
+		UserID:              userID,
+		Date:                today,
+		Goal:                current.Goal,
+		TotalCaloriesPerDay: adjustment.SuggestedTarget,
+		Note:                adjustment.Reason,
+	}, macros)
+	return plan, adjustment, err
+}
+
+// energyBMR คำนวณ BMR จากข้อมูลร่างกายล่าสุด ด้วยสูตรเริ่มต้นของ nutrition.Calculate
+func energyBMR(userID uint, at time.Time) (float64, nutrition.Formula, error) {
+	input, err := nutritionInput(userID, at.AddDate(0, 0, 1))
+	if err != nil {
+		return 0, "", err
+	}
+	input.ActivityLevel = nutrition.Sedentary
+	result, err := nutrition.Calculate(input)
+	if err != nil {
+		return 0, "", err
+	}
+	return result.BMR, result.Formula, nil
+}
+
+// energyBalanceDays สมดุลพลังงานรายวัน count วันที่สิ้นสุดที่ end เรียงจากเก่าไปใหม่
+func energyBalanceDays(userID uint, end time.Time, count int, bmr float64) ([]EnergyBalanceDay, error) {
+	start := end.AddDate(0, 0, 1-count)
+	from, to := start.Format("2006-01-02"), end.Format("2006-01-02")
+	db := config.DB()
+
+	var logged []struct {
+		Date  string
+		Total float64
+	}
+	if err := db.Model(&entity.MealEntry{}).Select("date, SUM(energy_kcal) AS total").
+		Where("user_id = ? AND date >= ? AND date <= ?", userID, from, to).
+		Group("date").Scan(&logged).Error; err != nil {
+		return nil, err
+	}
+	intake := map[string]float64{}
+	for _, l := range logged {
+		intake[l.Date] = l.Total
+	}
+
+	var plans []entity.Nutrition
+	if err := db.Where("user_id = ? AND date <= ?", userID, to).Order("date").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+
+	var activities []entity.Activity
+	if err := db.Where("user_id = ? AND date >= ? AND date < ?", userID, start, end.AddDate(0, 0, 1)).
+		Find(&activities).Error; err != nil {
+		return nil, err
+	}
+	exercise := map[string]float64{}
+	for _, a := range activities {
+		exercise[a.Date.In(bangkok).Format("2006-01-02")] += a.Calories
+	}
+
+	days := make([]EnergyBalanceDay, 0, count)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		day := EnergyBalanceDay{Date: d.Format("2006-01-02"), BMR: bmr, IntakeSource: "none"}
+		for _, p := range plans {
+			if p.Date <= day.Date {
+				day.TargetKcal = p.TotalCaloriesPerDay
+			}
+		}
+		switch kcal, ok := intake[day.Date]; {
+		case ok:
+			day.IntakeKcal, day.IntakeSource = round2(kcal), "food_log"
+		case day.TargetKcal > 0:
+			day.IntakeKcal, day.IntakeSource = day.TargetKcal, "plan"
+		}
+		day.ExerciseKcal = round2(exercise[day.Date])
+		day.BaselineKcal, day.Expenditure = nutrition.Expenditure(bmr, day.ExerciseKcal)
+		if day.IntakeSource != "none" {
+			day.NetKcal = round2(day.IntakeKcal - day.Expenditure)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+func summarizeEnergy(days []EnergyBalanceDay) EnergyBalanceWindow {
+	w := EnergyBalanceWindow{From: days[0].Date, To: days[len(days)-1].Date}
+	for _, d := range days {
+		if d.IntakeSource == "none" {
+			continue
+		}
+		w.DaysWithIntake++
+		w.IntakeKcal += d.IntakeKcal
+		w.ExpenditureKcal += d.Expenditure
+		w.NetKcal += d.NetKcal
+	}
+	w.IntakeKcal, w.ExpenditureKcal, w.NetKcal = round2(w.IntakeKcal), round2(w.ExpenditureKcal), round2(w.NetKcal)
+	if w.DaysWithIntake > 0 {
+		w.AvgNetKcal = round2(w.NetKcal / float64(w.DaysWithIntake))
+	}
+	w.PredictedWeightChangeKg = nutrition.PredictWeightChange(w.NetKcal)
+	return w
+}
+
+// weightTrend น้ำหนักที่เปลี่ยนใน days วัน จากความชันของเส้นแนวโน้ม (ลดผลของน้ำหนักที่แกว่งรายวัน)
+// ต้องมีอย่างน้อย 2 ค่าที่ห่างกันไม่น้อยกว่า 7 วัน
+func weightTrend(userID uint, from, to time.Time, days int) (float64, bool, error) {
+	var records []entity.Health
+	if err := config.DB().Where("user_id = ? AND weight > 0", userID).Find(&records).Error; err != nil {
+		return 0, false, err
+	}
+	var xs, ys []float64
+	first, last := math.Inf(1), math.Inf(-1)
+	for _, r := range records {
+		day, ok := parseHealthDate(r.Date)
+		if !ok || day.Before(from) || day.After(to) {
+			continue
+		}
+		x := day.Sub(from).Hours() / 24
+		xs = append(xs, x)
+		ys = append(ys, r.Weight)
+		first, last = math.Min(first, x), math.Max(last, x)
+	}
+	if len(xs) < 2 || last-first < 7 {
+		return 0, false, nil
+	}
+	n := float64(len(xs))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, varX float64
+	for i := range xs {
+		cov += (xs[i] - meanX) * (ys[i] - meanY)
+		varX += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	return cov / varX * float64(days), true, nil
+}
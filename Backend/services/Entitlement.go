@@ -0,0 +1,354 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// สิทธิ์ที่แพ็กเกจให้ได้
+const (
+	CapabilityGymAccess    = "gym_access"
+	CapabilityClassBooking = "class_booking"
+	CapabilityPTSession    = "pt_session"
+	CapabilitySauna        = "sauna"
+	CapabilityPool         = "pool"
+)
+
+// Capabilities สิทธิ์ทั้งหมด ตามลำดับที่แสดง
+var Capabilities = []string{CapabilityGymAccess, CapabilityClassBooking, CapabilityPTSession, CapabilitySauna, CapabilityPool}
+
+var capabilityLabels = map[string]string{
+	CapabilityGymAccess:    "เข้าใช้ฟิตเนส",
+	CapabilityClassBooking: "จองคลาส",
+	CapabilityPTSession:    "เทรนส่วนตัว",
+	CapabilitySauna:        "ห้องซาวน่า",
+	CapabilityPool:         "สระว่ายน้ำ",
+}
+
+// ErrUpgradeRequired ใช้ตรวจด้วย errors.Is ว่าถูกปฏิเสธเพราะแพ็กเกจไม่ครอบคลุม
+var ErrUpgradeRequired = errors.New("ต้องอัปเกรดแพ็กเกจ")
+
+// EntitlementError รายละเอียดเมื่อใช้สิทธิ์ไม่ได้ พร้อมแพ็กเกจที่ใช้สิทธิ์นี้ได้
+type EntitlementError struct {
+	Capability string `json:"capability"`
+	// no_membership, frozen, not_included, quota_exceeded
+	Reason         string          `json:"reason"`
+	MonthlyLimit   int             `json:"monthly_limit,omitempty"`
+	Used           int             `json:"used,omitempty"`
+	PeriodEnd      *time.Time      `json:"period_end,omitempty"`
+	UpgradeOptions []UpgradeOption `json:"upgrade_options"`
+}
+
+// UpgradeOption แพ็กเกจที่ให้สิทธิ์ที่ต้องการ (มากกว่าแพ็กเกจปัจจุบัน)
+type UpgradeOption struct {
+	PackageID    uint   `json:"package_id"`
+	PackageName  string `json:"p_name"`
+	Type         string `json:"type"`
+	Price        uint   `json:"price"`
+	MonthlyLimit int    `json:"monthly_limit"`
+}
+
+func (e *EntitlementError) Error() string {
+	label := capabilityLabels[e.Capability]
+	switch e.Reason {
+	case "no_membership":
+		return fmt.Sprintf("ต้องสมัครแพ็กเกจสมาชิกก่อนใช้สิทธิ์%s", label)
+	case "frozen":
+		return "แพ็กเกจอยู่ระหว่างพักสมาชิก กรุณายกเลิกการพักก่อนใช้บริการ"
+	case "quota_exceeded":
+		return fmt.Sprintf("ใช้สิทธิ์%sครบ %d ครั้งของรอบนี้แล้ว กรุณาอัปเกรดแพ็กเกจ", label, e.MonthlyLimit)
+	}
+	return fmt.Sprintf("แพ็กเกจปัจจุบันไม่รวมสิทธิ์%s กรุณาอัปเกรดแพ็กเกจ", label)
+}
+
+func (e *EntitlementError) Unwrap() error { return ErrUpgradeRequired }
+
+// CapabilityStatus สิทธิ์หนึ่งรายการและการใช้ในรอบปัจจุบัน
+type CapabilityStatus struct {
+	Capability   string `json:"capability"`
+	Included     bool   `json:"included"`
+	MonthlyLimit int    `json:"monthly_limit"` // 0 = ไม่จำกัด
+	Used         int    `json:"used"`
+	Remaining    *int   `json:"remaining"` // nil = ไม่จำกัด
+}
+
+// EntitlementSummary สิทธิ์ของผู้ใช้ตามแพ็กเกจที่ใช้งานอยู่
+type EntitlementSummary struct {
+	Subscription *entity.PackageMember `json:"subscription"`
+	PeriodStart  *time.Time            `json:"period_start"`
+	PeriodEnd    *time.Time            `json:"period_end"`
+	Capabilities []CapabilityStatus    `json:"capabilities"`
+}
+
+// SeedPackageEntitlements ใส่สิทธิ์เริ่มต้นให้แพ็กเกจที่ยังไม่มี: ทุกแพ็กเกจเข้าฟิตเนสได้
+// รายเดือนจองคลาส 8 ครั้ง/เทรนส่วนตัว 2 ครั้งต่อเดือน รายปีจองคลาสไม่จำกัด/เทรนส่วนตัว 4 ครั้งต่อเดือน
+// และบริการเสริมของแพ็กเกจ (ซาวน่า สระว่ายน้ำ)
+func SeedPackageEntitlements() error {
+	var packages []entity.Package
+	if err := config.DB().Preload("Service").Find(&packages).Error; err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		var count int64
+		if err := config.DB().Model(&entity.PackageEntitlement{}).Where("package_id = ?", pkg.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		classLimit, ptLimit := 8, 2
+		if years, _, err := PackagePeriod(pkg); err == nil && years > 0 {
+			classLimit, ptLimit = 0, 4
+		}
+		rows := []entity.PackageEntitlement{
+			{PackageID: pkg.ID, Capability: CapabilityGymAccess},
+			{PackageID: pkg.ID, Capability: CapabilityClassBooking, MonthlyLimit: classLimit},
+			{PackageID: pkg.ID, Capability: CapabilityPTSession, MonthlyLimit: ptLimit},
+		}
+		if pkg.Service != nil {
+			switch {
+			case strings.Contains(pkg.Service.Service, "ซาวน่า"):
+				rows = append(rows, entity.PackageEntitlement{PackageID: pkg.ID, Capability: CapabilitySauna})
+			case strings.Contains(pkg.Service.Service, "สระว่ายน้ำ"):
+				rows = append(rows, entity.PackageEntitlement{PackageID: pkg.ID, Capability: CapabilityPool})
+			}
+		}
+		if err := config.DB().Create(&rows).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPackageEntitlements สิทธิ์ของแพ็กเกจ
+func GetPackageEntitlements(packageID uint) ([]entity.PackageEntitlement, error) {
+	var rows []entity.PackageEntitlement
+	err := config.DB().Where("package_id = ?", packageID).Order("id").Find(&rows).Error
+	return rows, err
+}
+
+// SetPackageEntitlements แทนที่สิทธิ์ทั้งหมดของแพ็กเกจ
+func SetPackageEntitlements(packageID uint, rows []entity.PackageEntitlement) ([]entity.PackageEntitlement, error) {
+	if err := config.DB().First(&entity.Package{}, packageID).Error; err != nil {
+		return nil, fmt.Errorf("ไม่พบแพ็กเกจ")
+	}
+	seen := map[string]bool{}
+	for i := range rows {
+		rows[i].Capability = strings.ToLower(strings.TrimSpace(rows[i].Capability))
+		if !containsString(Capabilities, rows[i].Capability) {
+			return nil, fmt.Errorf("ไม่รู้จักสิทธิ์ %s (ใช้ได้: %s)", rows[i].Capability, strings.Join(Capabilities, ", "))
+		}
+		if seen[rows[i].Capability] {
+			return nil, fmt.Errorf("ระบุสิทธิ์ %s ซ้ำ", rows[i].Capability)
+		}
+		if rows[i].MonthlyLimit < 0 {
+			return nil, fmt.Errorf("monthly_limit ต้องไม่ติดลบ (0 = ไม่จำกัด)")
+		}
+		seen[rows[i].Capability] = true
+		rows[i].ID = 0
+		rows[i].PackageID = packageID
+	}
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("package_id = ?", packageID).Delete(&entity.PackageEntitlement{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	return rows, err
+}
+
+// GetEntitlementSummary สิทธิ์และจำนวนที่ใช้ไปในรอบปัจจุบันของผู้ใช้
+func GetEntitlementSummary(userID uint) (EntitlementSummary, error) {
+	summary := EntitlementSummary{Capabilities: []CapabilityStatus{}}
+	member, err := GetCurrentSubscription(userID)
+	if errors.Is(err, ErrNoActiveSubscription) {
+		for _, capability := range Capabilities {
+			summary.Capabilities = append(summary.Capabilities, CapabilityStatus{Capability: capability})
+		}
+		return summary, nil
+	}
+	if err != nil {
+		return summary, err
+	}
+	summary.Subscription = &member
+	start, end := usagePeriod(member, time.Now())
+	summary.PeriodStart, summary.PeriodEnd = &start, &end
+
+	entitlements, err := GetPackageEntitlements(member.PackageID)
+	if err != nil {
+		return summary, err
+	}
+	for _, capability := range Capabilities {
+		status := CapabilityStatus{Capability: capability}
+		for _, e := range entitlements {
+			if e.Capability == capability {
+				status.Included, status.MonthlyLimit = true, e.MonthlyLimit
+			}
+		}
+		if status.Included {
+			used, err := countUsage(config.DB(), userID, capability, start)
+			if err != nil {
+				return summary, err
+			}
+			status.Used = used
+			if status.MonthlyLimit > 0 {
+				remaining := status.MonthlyLimit - used
+				if remaining < 0 {
+					remaining = 0
+				}
+				status.Remaining = &remaining
+			}
+		}
+		summary.Capabilities = append(summary.Capabilities, status)
+	}
+	return summary, nil
+}
+
+// ConsumeEntitlement ตรวจสิทธิ์และบันทึกการใช้หนึ่งครั้ง ถ้าใช้ไม่ได้คืน *EntitlementError
+// เรียกภายใน transaction เดียวกับการสร้างรายการที่ใช้สิทธิ์
+func ConsumeEntitlement(tx *gorm.DB, userID uint, capability, refType string, refID uint) error {
+	now := time.Now()
+	if err := processUserSubscriptions(tx, userID, now); err != nil {
+		return err
+	}
+	member, err := currentSubscription(tx, userID)
+	if errors.Is(err, ErrNoActiveSubscription) {
+		return entitlementError(tx, capability, "no_membership", 0)
+	}
+	if err != nil {
+		return err
+	}
+	if member.Status == "frozen" {
+		return &EntitlementError{Capability: capability, Reason: "frozen", UpgradeOptions: []UpgradeOption{}}
+	}
+
+	var entitlement entity.PackageEntitlement
+	err = tx.Where("package_id = ? AND capability = ?", member.PackageID, capability).First(&entitlement).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entitlementError(tx, capability, "not_included", 0)
+	}
+	if err != nil {
+		return err
+	}
+
+	start, end := usagePeriod(member, now)
+	if entitlement.MonthlyLimit > 0 {
+		used, err := countUsage(tx, userID, capability, start)
+		if err != nil {
+			return err
+		}
+		if used >= entitlement.MonthlyLimit {
+			e := entitlementError(tx, capability, "quota_exceeded", entitlement.MonthlyLimit)
+			e.MonthlyLimit, e.Used, e.PeriodEnd = entitlement.MonthlyLimit, used, &end
+			return e
+		}
+	}
+	return tx.Create(&entity.EntitlementUsage{
+		UserID:          userID,
+		PackageMemberID: member.ID,
+		Capability:      capability,
+		PeriodStart:     start,
+		PeriodEnd:       end,
+		RefType:         refType,
+		RefID:           refID,
+	}).Error
+}
+
+// ReleaseEntitlement คืนสิทธิ์ของรายการที่ถูกยกเลิก
+func ReleaseEntitlement(tx *gorm.DB, refType string, refID uint) error {
+	return tx.Unscoped().Where("ref_type = ? AND ref_id = ?", refType, refID).Delete(&entity.EntitlementUsage{}).Error
+}
+
+// EnterFacility บันทึกการเข้าใช้สถานที่ โดยตรวจสิทธิ์ตาม Facility.Capability
+func EnterFacility(userID, facilityID uint) (entity.FacilityEntry, error) {
+	var entry entity.FacilityEntry
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var facility entity.Facility
+		if err := tx.First(&facility, facilityID).Error; err != nil {
+			return err
+		}
+		if !strings.EqualFold(facility.Status, "Open") && !strings.EqualFold(facility.Status, "Available") {
+			return fmt.Errorf("สถานที่นี้ปิดให้บริการอยู่")
+		}
+		capability := facility.Capability
+		if capability == "" {
+			capability = CapabilityGymAccess
+		}
+		entry = entity.FacilityEntry{UserID: userID, FacilityID: facility.ID, EnteredAt: time.Now()}
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+		entry.Facility = &facility
+		return ConsumeEntitlement(tx, userID, capability, "facility_entry", entry.ID)
+	})
+	return entry, err
+}
+
+// usagePeriod รอบการใช้รายเดือนที่ครอบคลุม at นับจากวันเริ่มแพ็กเกจ (ไม่เกินวันหมดอายุ)
+// เริ่มวันที่ 31 รอบถัดไปเริ่มวันสุดท้ายของเดือนที่สั้นกว่า
+func usagePeriod(member entity.PackageMember, at time.Time) (time.Time, time.Time) {
+	start := member.StartDate
+	for i := 1; ; i++ {
+		next := addMonths(member.StartDate, i)
+		if next.After(at) || !next.Before(member.EndDate) {
+			if next.After(member.EndDate) {
+				next = member.EndDate
+			}
+			return start, next
+		}
+		start = next
+	}
+}
+
+// carryOverUsage ย้ายการใช้สิทธิ์ในรอบปัจจุบันของแพ็กเกจเดิมไปนับในรอบแรกของแพ็กเกจใหม่
+// การเปลี่ยนแพ็กเกจกลางรอบจึงไม่ได้โควตาใหม่ทั้งหมด
+func carryOverUsage(tx *gorm.DB, previous, next entity.PackageMember, now time.Time) error {
+	oldStart, _ := usagePeriod(previous, now)
+	newStart, newEnd := usagePeriod(next, now)
+	return tx.Model(&entity.EntitlementUsage{}).
+		Where("user_id = ? AND period_start = ?", previous.UserID, oldStart).
+		Updates(map[string]interface{}{"package_member_id": next.ID, "period_start": newStart, "period_end": newEnd}).Error
+}
+
+func countUsage(tx *gorm.DB, userID uint, capability string, periodStart time.Time) (int, error) {
+	var count int64
+	err := tx.Model(&entity.EntitlementUsage{}).
+		Where("user_id = ? AND capability = ? AND period_start = ?", userID, capability, periodStart).
+		Count(&count).Error
+	return int(count), err
+}
+
+// entitlementError สร้างข้อผิดพลาดพร้อมแพ็กเกจที่ให้สิทธิ์นี้มากกว่า limit (0 = แพ็กเกจใดก็ได้ที่มีสิทธิ์นี้)
+func entitlementError(tx *gorm.DB, capability, reason string, limit int) *EntitlementError {
+	e := &EntitlementError{Capability: capability, Reason: reason, UpgradeOptions: []UpgradeOption{}}
+	var rows []entity.PackageEntitlement
+	if err := tx.Where("capability = ?", capability).Find(&rows).Error; err != nil {
+		return e
+	}
+	for _, row := range rows {
+		if limit > 0 && row.MonthlyLimit != 0 && row.MonthlyLimit <= limit {
+			continue
+		}
+		var pkg entity.Package
+		if err := tx.First(&pkg, row.PackageID).Error; err != nil {
+			continue
+		}
+		e.UpgradeOptions = append(e.UpgradeOptions, UpgradeOption{
+			PackageID:    pkg.ID,
+			PackageName:  pkg.PackageName,
+			Type:         pkg.Type,
+			Price:        pkg.Price,
+			MonthlyLimit: row.MonthlyLimit,
+		})
+	}
+	return e
+}
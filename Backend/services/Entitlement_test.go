@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"example.com/fitness-backend/entity"
+)
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from time.Time
+		n    int
+		want time.Time
+	}{
+		{day(2025, time.January, 15), 1, day(2025, time.February, 15)},
+		{day(2025, time.January, 31), 1, day(2025, time.February, 28)},
+		{day(2024, time.January, 31), 1, day(2024, time.February, 29)},
+		{day(2025, time.January, 31), 2, day(2025, time.March, 31)},
+		{day(2025, time.March, 31), 1, day(2025, time.April, 30)},
+		{day(2024, time.February, 29), 12, day(2025, time.February, 28)},
+		{day(2025, time.December, 31), 2, day(2026, time.February, 28)},
+	}
+	for _, tt := range tests {
+		if got := addMonths(tt.from, tt.n); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s ต้องการ %s", tt.from.Format("2006-01-02"), tt.n, got.Format("2006-01-02"), tt.want.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestUsagePeriod(t *testing.T) {
+	member := entity.PackageMember{StartDate: day(2025, time.January, 31), EndDate: day(2025, time.April, 30)}
+	tests := []struct {
+		at        time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{day(2025, time.January, 31), day(2025, time.January, 31), day(2025, time.February, 28)},
+		{day(2025, time.February, 27), day(2025, time.January, 31), day(2025, time.February, 28)},
+		{day(2025, time.February, 28), day(2025, time.February, 28), day(2025, time.March, 31)},
+		{day(2025, time.March, 15), day(2025, time.February, 28), day(2025, time.March, 31)},
+		{day(2025, time.April, 1), day(2025, time.March, 31), day(2025, time.April, 30)},
+	}
+	for _, tt := range tests {
+		start, end := usagePeriod(member, tt.at)
+		if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+			t.Errorf("usagePeriod(%s) = %s - %s ต้องการ %s - %s", tt.at.Format("2006-01-02"),
+				start.Format("2006-01-02"), end.Format("2006-01-02"), tt.wantStart.Format("2006-01-02"), tt.wantEnd.Format("2006-01-02"))
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	movementPatterns = []string{"squat", "hinge", "push", "pull", "lunge", "carry", "rotation", "core", "cardio"}
+	difficulties     = []string{"beginner", "intermediate", "advanced"}
+
+	// exerciseCSVHeader คอลัมน์ของไฟล์ CSV รายการหลายค่าคั่นด้วย |
+	exerciseCSVHeader = []string{
+		"name_th", "name_en", "primary_muscles", "secondary_muscles", "movement_pattern",
+		"equipment_type", "difficulty", "cues", "image_url", "video_url",
+	}
+)
+
+// ExerciseFilter เงื่อนไขค้นหาท่าฝึก (ค่าว่างคือไม่กรอง)
+type ExerciseFilter struct {
+	Query           string
+	Muscle          string
+	MovementPattern string
+	EquipmentType   string
+	Difficulty      string
+}
+
+// ExerciseImportResult ผลการนำเข้าคลังท่าฝึก
+type ExerciseImportResult struct {
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Errors  []string `json:"errors"`
+}
+
+// SearchExercises ค้นหาท่าฝึกตามชื่อ (ไทย/อังกฤษ) กล้ามเนื้อ รูปแบบการเคลื่อนไหว อุปกรณ์ และระดับความยาก
+func SearchExercises(filter ExerciseFilter) ([]entity.Exercise, error) {
+	query := config.DB().Model(&entity.Exercise{})
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name_th) LIKE ? OR LOWER(name_en) LIKE ?", like, like)
+	}
+	if m := normalizeTag(filter.Muscle); m != "" {
+		// กล้ามเนื้อเก็บเป็น JSON array จึงค้นหาด้วยค่าที่มีเครื่องหมายคำพูดครอบ
+		like := `%"` + m + `"%`
+		query = query.Where("primary_muscles LIKE ? OR secondary_muscles LIKE ?", like, like)
+	}
+	if p := normalizeTag(filter.MovementPattern); p != "" {
+		query = query.Where("movement_pattern = ?", p)
+	}
+	if e := normalizeTag(filter.EquipmentType); e != "" {
+		query = query.Where("LOWER(equipment_type) = ?", e)
+	}
+	if d := normalizeTag(filter.Difficulty); d != "" {
+		query = query.Where("difficulty = ?", d)
+	}
+
+	var exercises []entity.Exercise
+	err := query.Order("name_en, name_th").Find(&exercises).Error
+	return exercises, err
+}
+
+// GetExercise ดึงท่าฝึกตามรหัส
+func GetExercise(id uint) (entity.Exercise, error) {
+	var exercise entity.Exercise
+	err := config.DB().First(&exercise, id).Error
+	return exercise, err
+}
+
+// CreateExercise เพิ่มท่าฝึกในคลัง
+func CreateExercise(exercise entity.Exercise) (entity.Exercise, error) {
+	normalizeExercise(&exercise)
+	if err := validateExercise(exercise); err != nil {
+		return exercise, err
+	}
+	exercise.Model = gorm.Model{}
+	err := config.DB().Create(&exercise).Error
+	return exercise, err
+}
+
+// UpdateExercise แก้ไขข้อมูลท่าฝึก (ไม่แก้ไขไฟล์สื่อ ใช้ SetExerciseMedia แทน)
+func UpdateExercise(id uint, input entity.Exercise) (entity.Exercise, error) {
+	existing, err := GetExercise(id)
+	if err != nil {
+		return existing, err
+	}
+	normalizeExercise(&input)
+	if err := validateExercise(input); err != nil {
+		return existing, err
+	}
+	input.Model = existing.Model
+	if input.ImageURL == "" {
+		input.ImageURL = existing.ImageURL
+	}
+	if input.VideoURL == "" {
+		input.VideoURL = existing.VideoURL
+	}
+	err = config.DB().Save(&input).Error
+	return input, err
+}
+
+// SetExerciseMedia บันทึก URL รูปหรือวิดีโอสาธิตของท่าฝึก (kind = "image" หรือ "video")
+func SetExerciseMedia(id uint, kind, url string) (entity.Exercise, error) {
+	exercise, err := GetExercise(id)
+	if err != nil {
+		return exercise, err
+	}
+	column := "image_url"
+	if kind == "video" {
+		column = "video_url"
+	}
+	if err := config.DB().Model(&exercise).Update(column, url).Error; err != nil {
+		return exercise, err
+	}
+	return GetExercise(id)
+}
+
+// DeleteExercise ลบท่าฝึก (soft delete) โปรแกรมที่อ้างถึงยังเก็บชื่อท่าไว้
+func DeleteExercise(id uint) error {
+	result := config.DB().Delete(&entity.Exercise{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ExportExercisesCSV เขียนคลังท่าฝึกเป็น CSV
+func ExportExercisesCSV(w io.Writer, exercises []entity.Exercise) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exerciseCSVHeader); err != nil {
+		return err
+	}
+	for _, e := range exercises {
+		row := []string{
+			e.NameTH, e.NameEN,
+			strings.Join(e.PrimaryMuscles, "|"), strings.Join(e.SecondaryMuscles, "|"),
+			e.MovementPattern, e.EquipmentType, e.Difficulty,
+			strings.Join(e.Cues, "|"), e.ImageURL, e.VideoURL,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ParseExercisesJSON อ่านคลังท่าฝึกจาก JSON (array ของท่าฝึก)
+func ParseExercisesJSON(r io.Reader) ([]entity.Exercise, error) {
+	var exercises []entity.Exercise
+	if err := json.NewDecoder(r).Decode(&exercises); err != nil {
+		return nil, fmt.Errorf("รูปแบบไฟล์ JSON ไม่ถูกต้อง: %v", err)
+	}
+	return exercises, nil
+}
+
+// ParseExercisesCSV อ่านคลังท่าฝึกจาก CSV ที่มีหัวคอลัมน์ตาม exerciseCSVHeader (เรียงลำดับใดก็ได้)
+func ParseExercisesCSV(r io.Reader) ([]entity.Exercise, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("รูปแบบไฟล์ CSV ไม่ถูกต้อง: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("ไฟล์ CSV ว่างเปล่า")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["name_th"]; !ok {
+		if _, ok := columns["name_en"]; !ok {
+			return nil, fmt.Errorf("ไฟล์ CSV ต้องมีคอลัมน์ name_th หรือ name_en")
+		}
+	}
+
+	var exercises []entity.Exercise
+	for _, row := range rows[1:] {
+		get := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		exercises = append(exercises, entity.Exercise{
+			NameTH:           get("name_th"),
+			NameEN:           get("name_en"),
+			PrimaryMuscles:   splitList(get("primary_muscles")),
+			SecondaryMuscles: splitList(get("secondary_muscles")),
+			MovementPattern:  get("movement_pattern"),
+			EquipmentType:    get("equipment_type"),
+			Difficulty:       get("difficulty"),
+			Cues:             splitList(get("cues")),
+			ImageURL:         get("image_url"),
+			VideoURL:         get("video_url"),
+		})
+	}
+	return exercises, nil
+}
+
+// ImportExercises นำเข้าท่าฝึก ท่าที่ชื่อซ้ำกับของเดิม (ชื่ออังกฤษ หรือชื่อไทยถ้าไม่มีชื่ออังกฤษ) จะถูกแก้ไขแทน
+func ImportExercises(exercises []entity.Exercise) (ExerciseImportResult, error) {
+	result := ExerciseImportResult{Errors: []string{}}
+	db := config.DB()
+
+	for i, e := range exercises {
+		normalizeExercise(&e)
+		if err := validateExercise(e); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("แถวที่ %d: %v", i+1, err))
+			continue
+		}
+
+		var existing entity.Exercise
+		query := db.Where("LOWER(name_th) = ?", strings.ToLower(e.NameTH))
+		if e.NameEN != "" {
+			query = db.Where("LOWER(name_en) = ?", strings.ToLower(e.NameEN))
+		}
+		err := query.First(&existing).Error
+		switch {
+		case err == nil:
+			e.Model = existing.Model
+			if err := db.Save(&e).Error; err != nil {
+				return result, err
+			}
+			result.Updated++
+		case err == gorm.ErrRecordNotFound:
+			e.Model = gorm.Model{}
+			if err := db.Create(&e).Error; err != nil {
+				return result, err
+			}
+			result.Created++
+		default:
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// fillPrescriptionNames เติมชื่อท่าจากคลังท่าฝึกให้ท่าที่อ้าง ExerciseID แต่ไม่ได้ระบุชื่อ
+func fillPrescriptionNames(program *entity.TrainingProgram) error {
+	for i := range program.Phases {
+		for j := range program.Phases[i].Sessions {
+			exercises := program.Phases[i].Sessions[j].Exercises
+			for k := range exercises {
+				ex := &exercises[k]
+				if ex.ExerciseID == nil {
+					continue
+				}
+				item, err := GetExercise(*ex.ExerciseID)
+				if err != nil {
+					return fmt.Errorf("ไม่พบท่าฝึกรหัส %d ในคลัง", *ex.ExerciseID)
+				}
+				if strings.TrimSpace(ex.ExerciseName) == "" {
+					ex.ExerciseName = exerciseDisplayName(item)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func exerciseDisplayName(e entity.Exercise) string {
+	if e.NameTH != "" {
+		return e.NameTH
+	}
+	return e.NameEN
+}
+
+func normalizeExercise(e *entity.Exercise) {
+	e.NameTH = strings.TrimSpace(e.NameTH)
+	e.NameEN = strings.TrimSpace(e.NameEN)
+	e.MovementPattern = normalizeTag(e.MovementPattern)
+	e.EquipmentType = strings.TrimSpace(e.EquipmentType)
+	e.Difficulty = normalizeTag(e.Difficulty)
+	e.PrimaryMuscles = normalizeTags(e.PrimaryMuscles)
+	e.SecondaryMuscles = normalizeTags(e.SecondaryMuscles)
+	cues := []string{}
+	for _, c := range e.Cues {
+		if c = strings.TrimSpace(c); c != "" {
+			cues = append(cues, c)
+		}
+	}
+	e.Cues = cues
+}
+
+func validateExercise(e entity.Exercise) error {
+	if e.NameTH == "" && e.NameEN == "" {
+		return fmt.Errorf("กรุณาระบุชื่อท่าฝึก (ไทยหรืออังกฤษ)")
+	}
+	if len(e.PrimaryMuscles) == 0 {
+		return fmt.Errorf("กรุณาระบุกล้ามเนื้อหลักอย่างน้อยหนึ่งกลุ่ม")
+	}
+	if e.MovementPattern != "" && !containsString(movementPatterns, e.MovementPattern) {
+		return fmt.Errorf("รูปแบบการเคลื่อนไหวไม่ถูกต้อง (%s)", strings.Join(movementPatterns, ", "))
+	}
+	if e.Difficulty != "" && !containsString(difficulties, e.Difficulty) {
+		return fmt.Errorf("ระดับความยากไม่ถูกต้อง (%s)", strings.Join(difficulties, ", "))
+	}
+	return nil
+}
+
+func normalizeTag(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+func normalizeTags(items []string) []string {
+	out := []string{}
+	for _, item := range items {
+		if tag := normalizeTag(item); tag != "" && !containsString(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "|")
+}
+
+func containsString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// mealTypes มื้ออาหารที่บันทึกได้ เรียงตามลำดับในหนึ่งวัน
+var mealTypes = []string{"breakfast", "lunch", "dinner", "snack"}
+
+// FoodFilter เงื่อนไขค้นหาอาหาร UserID ใช้รวมอาหารที่ผู้ใช้สร้างเอง
+type FoodFilter struct {
+	Query      string
+	Category   string
+	UserID     uint
+	CustomOnly bool
+}
+
+// NutrientTotals ผลรวมพลังงานและสารอาหาร
+type NutrientTotals struct {
+	EnergyKcal float64 `json:"energy_kcal"`
+	ProteinG   float64 `json:"protein_g"`
+	FatG       float64 `json:"fat_g"`
+	CarbG      float64 `json:"carb_g"`
+}
+
+// MacroSplit สัดส่วนพลังงานจากโปรตีน ไขมัน คาร์โบไฮเดรต (เปอร์เซ็นต์)
+type MacroSplit struct {
+	ProteinPercent float64 `json:"protein_percent"`
+	FatPercent     float64 `json:"fat_percent"`
+	CarbPercent    float64 `json:"carb_percent"`
+}
+
+// MealSummary อาหารและผลรวมของมื้อหนึ่ง
+type MealSummary struct {
+	MealType string             `json:"meal_type"`
+	Totals   NutrientTotals     `json:"totals"`
+	Entries  []entity.MealEntry `json:"entries"`
+}
+
+// DailyNutritionSummary สรุปการกินของวันเทียบกับแผนโภชนาการ
+type DailyNutritionSummary struct {
+	Date        string          `json:"date"`
+	Meals       []MealSummary   `json:"meals"`
+	Totals      NutrientTotals  `json:"totals"`
+	Split       MacroSplit      `json:"split"`
+	Target      *NutrientTotals `json:"target"` // nil เมื่อยังไม่มีแผนโภชนาการ
+	TargetSplit *MacroSplit     `json:"target_split"`
+	Remaining   *NutrientTotals `json:"remaining"`
+	PlanDate    string          `json:"plan_date,omitempty"` // วันที่ของแผนที่ใช้เทียบ
+}
+
+// SearchFoods ค้นหาอาหารในแคตตาล็อกและอาหารที่ผู้ใช้สร้างเอง
+func SearchFoods(filter FoodFilter) ([]entity.Food, error) {
+	query := config.DB().Order("is_custom desc, name")
+	if filter.CustomOnly {
+		query = query.Where("is_custom = ? AND created_by_user_id = ?", true, filter.UserID)
+	} else {
+		query = query.Where("is_custom = ? OR created_by_user_id = ?", false, filter.UserID)
+	}
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(name_en) LIKE ? OR LOWER(brand) LIKE ?", like, like, like)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	var foods []entity.Food
+	err := query.Find(&foods).Error
+	return foods, err
+}
+
+// GetFood ดึงอาหาร อาหารที่ผู้ใช้สร้างเองดึงได้เฉพาะเจ้าของ (userID = 0 คือไม่ตรวจ)
+func GetFood(id, userID uint) (entity.Food, error) {
+	var food entity.Food
+	if err := config.DB().First(&food, id).Error; err != nil {
+		return food, err
+	}
+	if userID != 0 && food.IsCustom && (food.CreatedByUserID == nil || *food.CreatedByUserID != userID) {
+		return food, gorm.ErrRecordNotFound
+	}
+	return food, nil
+}
+
+// CreateFood เพิ่มอาหาร ownerID != nil คืออาหารที่ผู้ใช้สร้างเอง
+func CreateFood(food entity.Food, ownerID *uint) (entity.Food, error) {
+	food.Model = gorm.Model{}
+	food.IsCustom = ownerID != nil
+	food.CreatedByUserID = ownerID
+	food.Source = ""
+	if err := validateFood(&food); err != nil {
+		return food, err
+	}
+	if err := checkCatalogBarcode(food); err != nil {
+		return food, err
+	}
+	err := config.DB().Create(&food).Error
+	return food, err
+}
+
+// UpdateFood แก้ไขอาหาร (ไม่คำนวณมื้ออาหารที่บันทึกไปแล้วใหม่)
+func UpdateFood(id uint, input entity.Food) (entity.Food, error) {
+	existing, err := GetFood(id, 0)
+	if err != nil {
+		return existing, err
+	}
+	input.Model = existing.Model
+	input.IsCustom = existing.IsCustom
+	input.CreatedByUserID = existing.CreatedByUserID
+	input.Source = existing.Source
+	if err := validateFood(&input); err != nil {
+		return existing, err
+	}
+	if err := checkCatalogBarcode(input); err != nil {
+		return existing, err
+	}
+	err = config.DB().Save(&input).Error
+	return input, err
+}
+
+// DeleteFood ลบอาหาร มื้ออาหารที่บันทึกไว้ยังเก็บชื่อและค่าสารอาหารเดิม
+func DeleteFood(id uint) error {
+	result := config.DB().Delete(&entity.Food{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// BackfillFoodTags เติมแท็กอาหารและสารก่อภูมิแพ้จากแคตตาล็อกเริ่มต้นให้อาหารเดิมที่ชื่อตรงกัน
+// แก้เฉพาะอาหารของระบบที่ยังไม่มีทั้งแท็กและสารก่อภูมิแพ้ จึงเรียกซ้ำได้และไม่ทับค่าที่ผู้ดูแลแก้ไว้
+// ถ้ามีอาหารถูกเติมจะคำนวณแท็กของสูตรอาหารใหม่ด้วย
+func BackfillFoodTags() error {
+	updated := 0
+	for _, seed := range config.SeedFoods() {
+		if len(seed.DietaryTags) == 0 && len(seed.Allergens) == 0 {
+			continue
+		}
+		var foods []entity.Food
+		if err := config.DB().Where("name = ? AND is_custom = ?", seed.Name, false).Find(&foods).Error; err != nil {
+			return err
+		}
+		for _, food := range foods {
+			if len(food.DietaryTags) > 0 || len(food.Allergens) > 0 {
+				continue
+			}
+			err := config.DB().Model(&food).Select("dietary_tags", "allergens").
+				Updates(entity.Food{DietaryTags: seed.DietaryTags, Allergens: seed.Allergens}).Error
+			if err != nil {
+				return err
+			}
+			updated++
+		}
+	}
+	if updated == 0 {
+		return nil
+	}
+	return RefreshRecipeTags()
+}
+
+// CreateMealEntry บันทึกอาหารที่กินในมื้อ
+func CreateMealEntry(entry entity.MealEntry) (entity.MealEntry, error) {
+	entry.Model = gorm.Model{}
+	if err := prepareMealEntry(&entry); err != nil {
+		return entry, err
+	}
+	err := config.DB().Create(&entry).Error
+	return entry, err
+}
+
+// UpdateMealEntry แก้ไขอาหารในมื้อและคำนวณสารอาหารใหม่
+func UpdateMealEntry(id, userID uint, input entity.MealEntry) (entity.MealEntry, error) {
+	existing, err := GetMealEntry(id, userID)
+	if err != nil {
+		return existing, err
+	}
+	input.Model = existing.Model
+	input.UserID = existing.UserID
+	if input.Date == "" {
+		input.Date = existing.Date
+	}
+	if err := prepareMealEntry(&input); err != nil {
+		return existing, err
+	}
+	err = config.DB().Omit("Food").Save(&input).Error
+	return input, err
+}
+
+// DeleteMealEntry ลบอาหารออกจากมื้อ
+func DeleteMealEntry(id, userID uint) error {
+	result := config.DB().Where("id = ? AND user_id = ?", id, userID).Delete(&entity.MealEntry{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetMealEntry ดึงรายการอาหารของผู้ใช้
+func GetMealEntry(id, userID uint) (entity.MealEntry, error) {
+	var entry entity.MealEntry
+	err := config.DB().Where("id = ? AND user_id = ?", id, userID).First(&entry).Error
+	return entry, err
+}
+
+// GetMealEntries ดึงอาหารที่บันทึกในวันที่กำหนด
+func GetMealEntries(userID uint, date string) ([]entity.MealEntry, error) {
+	var entries []entity.MealEntry
+	err := config.DB().Where("user_id = ? AND date = ?", userID, date).Order("id").Find(&entries).Error
+	return entries, err
+}
+
+// GetDailyNutritionSummary รวมพลังงานและสารอาหารของวัน แยกตามมื้อ และเทียบกับแผนโภชนาการ
+// ใช้แผนของวันนั้น ถ้าไม่มีใช้แผนล่าสุดก่อนวันนั้น
+func GetDailyNutritionSummary(userID uint, date string) (DailyNutritionSummary, error) {
+	summary := DailyNutritionSummary{Date: date, Meals: []MealSummary{}}
+	entries, err := GetMealEntries(userID, date)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, mealType := range mealTypes {
+		meal := MealSummary{MealType: mealType, Entries: []entity.MealEntry{}}
+		for _, e := range entries {
+			if e.MealType != mealType {
+				continue
+			}
+			meal.Entries = append(meal.Entries, e)
+			meal.Totals = addNutrients(meal.Totals, e)
+		}
+		meal.Totals = roundNutrients(meal.Totals)
+		summary.Meals = append(summary.Meals, meal)
+		summary.Totals = addTotals(summary.Totals, meal.Totals)
+	}
+	summary.Totals = roundNutrients(summary.Totals)
+	summary.Split = macroSplit(summary.Totals)
+
+	var plan entity.Nutrition
+	err = config.DB().Where("user_id = ? AND date <= ?", userID, date).Order("date desc").First(&plan).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return summary, nil
+		}
+		return summary, err
+	}
+	target := NutrientTotals{EnergyKcal: plan.TotalCaloriesPerDay}
+	var macros entity.Meal
+	if config.DB().Where("nutrition_id = ?", plan.ID).First(&macros).Error == nil {
+		target.ProteinG, target.FatG, target.CarbG = macros.ProteinG, macros.FatG, macros.CarbG
+	}
+	target = roundNutrients(target)
+	summary.Target = &target
+	summary.PlanDate = plan.Date
+	if target.ProteinG > 0 || target.FatG > 0 || target.CarbG > 0 {
+		split := macroSplit(target)
+		summary.TargetSplit = &split
+	}
+	remaining := roundNutrients(NutrientTotals{
+		EnergyKcal: target.EnergyKcal - summary.Totals.EnergyKcal,
+		ProteinG:   target.ProteinG - summary.Totals.ProteinG,
+		FatG:       target.FatG - summary.Totals.FatG,
+		CarbG:      target.CarbG - summary.Totals.CarbG,
+	})
+	summary.Remaining = &remaining
+	return summary, nil
+}
+
+// prepareMealEntry ตรวจข้อมูล หาปริมาณเป็นกรัม และคำนวณสารอาหารจากค่าต่อ 100 กรัม
+func prepareMealEntry(entry *entity.MealEntry) error {
+	entry.MealType = strings.ToLower(strings.TrimSpace(entry.MealType))
+	if !containsString(mealTypes, entry.MealType) {
+		return fmt.Errorf("meal_type ต้องเป็น breakfast, lunch, dinner หรือ snack")
+	}
+	if entry.Date == "" {
+		entry.Date = time.Now().In(bangkok).Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", entry.Date); err != nil {
+		return fmt.Errorf("รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)")
+	}
+	food, err := GetFood(entry.FoodID, entry.UserID)
+	if err != nil {
+		return fmt.Errorf("ไม่พบอาหาร")
+	}
+
+	entry.ServingName = strings.TrimSpace(entry.ServingName)
+	if entry.ServingName != "" {
+		if entry.Quantity <= 0 {
+			entry.Quantity = 1
+		}
+		grams := 0.0
+		for _, s := range food.Servings {
+			if s.Name == entry.ServingName {
+				grams = s.Grams
+				break
+			}
+		}
+		if grams == 0 {
+			return fmt.Errorf("ไม่พบหน่วยบริโภค %s ของอาหารนี้", entry.ServingName)
+		}
+		entry.Grams = grams * entry.Quantity
+	} else {
+		entry.Quantity = 0
+	}
+	if entry.Grams <= 0 || entry.Grams > 5000 {
+		return fmt.Errorf("ปริมาณต้องอยู่ระหว่าง 0-5000 กรัม")
+	}
+
+	ratio := entry.Grams / 100
+	entry.Grams = math.Round(entry.Grams*10) / 10
+	entry.FoodName = food.Name
+	entry.Food = nil
+	entry.EnergyKcal = math.Round(food.EnergyKcal*ratio*10) / 10
+	entry.ProteinG = math.Round(food.ProteinG*ratio*10) / 10
+	entry.FatG = math.Round(food.FatG*ratio*10) / 10
+	entry.CarbG = math.Round(food.CarbG*ratio*10) / 10
+	return nil
+}
+
+func validateFood(food *entity.Food) error {
+	food.Name = strings.TrimSpace(food.Name)
+	food.NameEN = strings.TrimSpace(food.NameEN)
+	if food.Name == "" && food.NameEN == "" {
+		return fmt.Errorf("กรุณาระบุชื่ออาหาร")
+	}
+	if food.Name == "" {
+		food.Name = food.NameEN
+	}
+	if food.Barcode != "" {
+		barcode, err := NormalizeBarcode(food.Barcode)
+		if err != nil {
+			return err
+		}
+		food.Barcode = barcode
+	}
+	for _, v := range []float64{food.EnergyKcal, food.ProteinG, food.FatG, food.CarbG, food.FiberG, food.SugarG, food.SodiumMg} {
+		if v < 0 {
+			return fmt.Errorf("ค่าสารอาหารต้องไม่ติดลบ")
+		}
+	}
+	if food.ProteinG+food.FatG+food.CarbG > 100 {
+		return fmt.Errorf("ผลรวมโปรตีน ไขมัน และคาร์โบไฮเดรตต้องไม่เกิน 100 กรัมต่อ 100 กรัม")
+	}
+	if food.EnergyKcal > 900 {
+		return fmt.Errorf("พลังงานต้องไม่เกิน 900 kcal ต่อ 100 กรัม")
+	}
+	seen := map[string]bool{}
+	for i, s := range food.Servings {
+		food.Servings[i].Name = strings.TrimSpace(s.Name)
+		if food.Servings[i].Name == "" || s.Grams <= 0 {
+			return fmt.Errorf("หน่วยบริโภคต้องมีชื่อและน้ำหนักมากกว่า 0 กรัม")
+		}
+		if seen[food.Servings[i].Name] {
+			return fmt.Errorf("ชื่อหน่วยบริโภคซ้ำ: %s", food.Servings[i].Name)
+		}
+		seen[food.Servings[i].Name] = true
+	}
+	if food.Servings == nil {
+		food.Servings = []entity.FoodServing{}
+	}
+	tags, err := normalizeLabels(food.DietaryTags, dietaryTags, "แท็กอาหาร")
+	if err != nil {
+		return err
+	}
+	allergens, err := normalizeLabels(food.Allergens, knownAllergens, "สารก่อภูมิแพ้")
+	if err != nil {
+		return err
+	}
+	food.DietaryTags, food.Allergens = tags, allergens
+	return nil
+}
+
+// checkCatalogBarcode บาร์โค้ดของอาหารในแคตตาล็อกของระบบต้องไม่ซ้ำกับอาหารอื่น
+func checkCatalogBarcode(food entity.Food) error {
+	if food.IsCustom || food.Barcode == "" {
+		return nil
+	}
+	var count int64
+	err := config.DB().Model(&entity.Food{}).
+		Where("barcode = ? AND is_custom = ? AND id <> ?", food.Barcode, false, food.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("บาร์โค้ด %s มีอยู่ในแคตตาล็อกแล้ว", food.Barcode)
+	}
+	return nil
+}
+
+// normalizeLabels ตรวจว่าค่าอยู่ในรายการที่รู้จัก ตัดค่าซ้ำ และเปลี่ยนเป็นตัวพิมพ์เล็ก
+func normalizeLabels(values, known []string, label string) ([]string, error) {
+	result := []string{}
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" || containsString(result, v) {
+			continue
+		}
+		if !containsString(known, v) {
+			return nil, fmt.Errorf("ไม่รู้จัก%s %s", label, v)
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+func addNutrients(totals NutrientTotals, e entity.MealEntry) NutrientTotals {
+	totals.EnergyKcal += e.EnergyKcal
+	totals.ProteinG += e.ProteinG
+	totals.FatG += e.FatG
+	totals.CarbG += e.CarbG
+	return totals
+}
+
+func addTotals(a, b NutrientTotals) NutrientTotals {
+	return NutrientTotals{
+		EnergyKcal: a.EnergyKcal + b.EnergyKcal,
+		ProteinG:   a.ProteinG + b.ProteinG,
+		FatG:       a.FatG + b.FatG,
+		CarbG:      a.CarbG + b.CarbG,
+	}
+}
+
+func roundNutrients(t NutrientTotals) NutrientTotals {
+	return NutrientTotals{
+		EnergyKcal: math.Round(t.EnergyKcal*10) / 10,
+		ProteinG:   math.Round(t.ProteinG*10) / 10,
+		FatG:       math.Round(t.FatG*10) / 10,
+		CarbG:      math.Round(t.CarbG*10) / 10,
+	}
+}
+
+// macroSplit สัดส่วนพลังงานจากสารอาหารหลัก (โปรตีน/คาร์บ 4 kcal/g, ไขมัน 9 kcal/g)
+func macroSplit(t NutrientTotals) MacroSplit {
+	protein, fat, carb := t.ProteinG*4, t.FatG*9, t.CarbG*4
+	total := protein + fat + carb
+	if total <= 0 {
+		return MacroSplit{}
+	}
+	return MacroSplit{
+		ProteinPercent: math.Round(protein/total*1000) / 10,
+		FatPercent:     math.Round(fat/total*1000) / 10,
+		CarbPercent:    math.Round(carb/total*1000) / 10,
+	}
+}
@@ -0,0 +1,447 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidBarcode บาร์โค้ดไม่ใช่ EAN-13/UPC-A หรือเลขตรวจสอบไม่ถูกต้อง
+var ErrInvalidBarcode = errors.New("บาร์โค้ดไม่ถูกต้อง (รองรับ EAN-13 และ UPC-A)")
+
+// ErrBarcodeNotFound ไม่พบสินค้าทั้งในระบบและจากผู้ให้บริการภายนอก
+var ErrBarcodeNotFound = errors.New("ไม่พบสินค้าจากบาร์โค้ดนี้")
+
+// barcodeMissTTL ระยะเวลาที่จำว่าไม่พบบาร์โค้ด ก่อนถามผู้ให้บริการอีกครั้ง
+const barcodeMissTTL = 7 * 24 * time.Hour
+
+// BarcodeProduct ข้อมูลสินค้าจากผู้ให้บริการ ค่าสารอาหารต่อ 100 กรัม
+type BarcodeProduct struct {
+	Barcode      string  `json:"barcode"`
+	Name         string  `json:"name"`
+	Brand        string  `json:"brand"`
+	Category     string  `json:"category"`
+	EnergyKcal   float64 `json:"energy_kcal"`
+	ProteinG     float64 `json:"protein_g"`
+	FatG         float64 `json:"fat_g"`
+	CarbG        float64 `json:"carb_g"`
+	FiberG       float64 `json:"fiber_g"`
+	SugarG       float64 `json:"sugar_g"`
+	SodiumMg     float64 `json:"sodium_mg"`
+	ServingGrams float64 `json:"serving_grams"` // 0 = ไม่ระบุ
+}
+
+// BarcodeProvider แหล่งข้อมูลสินค้าภายนอก คืน nil, nil เมื่อไม่พบสินค้า
+type BarcodeProvider interface {
+	Name() string
+	Lookup(ctx context.Context, barcode string) (*BarcodeProduct, error)
+}
+
+// BarcodeResult ผลการค้นหาบาร์โค้ด
+type BarcodeResult struct {
+	Barcode string      `json:"barcode"`
+	Source  string      `json:"source"` // local, cache หรือชื่อผู้ให้บริการ
+	Food    entity.Food `json:"food"`
+}
+
+var barcodeProvider BarcodeProvider = NewOpenFoodFactsProvider("")
+
+// SetBarcodeProvider เปลี่ยนผู้ให้บริการค้นหาบาร์โค้ด (nil = ค้นหาเฉพาะในระบบ)
+func SetBarcodeProvider(provider BarcodeProvider) {
+	barcodeProvider = provider
+}
+
+// ConfigureBarcodeProvider เลือกผู้ให้บริการจากตัวแปรสภาพแวดล้อม
+//
+//	BARCODE_PROVIDER=openfoodfacts (ค่าเริ่มต้น, BARCODE_PROVIDER_URL เปลี่ยน URL ได้)
+//	BARCODE_PROVIDER=file (อ่านจากไฟล์ JSON ใน BARCODE_PROVIDER_FILE)
+//	BARCODE_PROVIDER=none (ค้นหาเฉพาะในระบบ)
+//
+// ตั้งค่าไม่ถูกต้องจะค้นหาเฉพาะในระบบและคืน error
+func ConfigureBarcodeProvider() error {
+	switch name := strings.ToLower(config.Env("BARCODE_PROVIDER", "openfoodfacts")); name {
+	case "openfoodfacts":
+		SetBarcodeProvider(NewOpenFoodFactsProvider(config.Env("BARCODE_PROVIDER_URL", "")))
+	case "file":
+		path := config.Env("BARCODE_PROVIDER_FILE", "")
+		if path == "" {
+			SetBarcodeProvider(nil)
+			return fmt.Errorf("BARCODE_PROVIDER=file ต้องระบุ BARCODE_PROVIDER_FILE")
+		}
+		SetBarcodeProvider(&FileBarcodeProvider{Path: path})
+	case "none":
+		SetBarcodeProvider(nil)
+	default:
+		SetBarcodeProvider(nil)
+		return fmt.Errorf("ไม่รู้จัก BARCODE_PROVIDER %s", name)
+	}
+	return nil
+}
+
+// NormalizeBarcode ตรวจเลขตรวจสอบของ EAN-13 หรือ UPC-A แล้วคืนเป็น EAN-13
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidBarcode
+		}
+	}
+	switch len(code) {
+	case 12:
+		code = "0" + code // UPC-A คือ EAN-13 ที่ขึ้นต้นด้วย 0
+	case 13:
+	default:
+		return "", ErrInvalidBarcode
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(code[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	if (10-sum%10)%10 != int(code[12]-'0') {
+		return "", ErrInvalidBarcode
+	}
+	return code, nil
+}
+
+// LookupBarcode ค้นหาอาหารจากบาร์โค้ด: ตารางอาหารในระบบก่อน แล้วจึงถามผู้ให้บริการภายนอก
+// สินค้าที่พบจะถูกเพิ่มเข้าแคตตาล็อก ส่วนที่ไม่พบจะจำไว้ barcodeMissTTL
+func LookupBarcode(ctx context.Context, code string) (BarcodeResult, error) {
+	barcode, err := NormalizeBarcode(code)
+	if err != nil {
+		return BarcodeResult{}, err
+	}
+	result := BarcodeResult{Barcode: barcode}
+	db := config.DB()
+
+	if err := db.Where("barcode = ? AND is_custom = ?", barcode, false).First(&result.Food).Error; err == nil {
+		result.Source = "local"
+		if result.Food.Source != "" {
+			result.Source = "cache"
+		}
+		return result, nil
+	} else if err != gorm.ErrRecordNotFound {
+		return result, err
+	}
+
+	var cached entity.BarcodeLookup
+	if err := db.Where("barcode = ?", barcode).First(&cached).Error; err == nil {
+		if !cached.Found && time.Since(cached.CheckedAt) < barcodeMissTTL {
+			return result, ErrBarcodeNotFound
+		}
+	} else if err != gorm.ErrRecordNotFound {
+		return result, err
+	}
+	if barcodeProvider == nil {
+		return result, ErrBarcodeNotFound
+	}
+
+	product, err := barcodeProvider.Lookup(ctx, barcode)
+	if err != nil {
+		return result, fmt.Errorf("ค้นหาบาร์โค้ดจาก %s ไม่สำเร็จ: %w", barcodeProvider.Name(), err)
+	}
+	cached.Barcode = barcode
+	cached.Source = barcodeProvider.Name()
+	cached.CheckedAt = time.Now()
+	cached.Found = product != nil
+	cached.FoodID = nil
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if product != nil {
+			food := productFood(*product, barcode, barcodeProvider.Name())
+			if err := validateFood(&food); err != nil {
+				return err
+			}
+			if err := tx.Create(&food).Error; err != nil {
+				return err
+			}
+			result.Food = food
+			cached.FoodID = &food.ID
+		}
+		return tx.Save(&cached).Error
+	})
+	if err != nil {
+		// คำขออื่นอาจเพิ่มสินค้าบาร์โค้ดเดียวกันเข้าแคตตาล็อกไปก่อน ใช้แถวนั้นแทน
+		if product != nil && db.Where("barcode = ? AND is_custom = ?", barcode, false).First(&result.Food).Error == nil {
+			result.Source = "cache"
+			return result, nil
+		}
+		return result, err
+	}
+	if product == nil {
+		return result, ErrBarcodeNotFound
+	}
+	result.Source = barcodeProvider.Name()
+	return result, nil
+}
+
+// CreateFoodCorrection ผู้ใช้เสนอแก้ไขข้อมูลอาหารในแคตตาล็อก
+func CreateFoodCorrection(correction entity.FoodCorrection) (entity.FoodCorrection, error) {
+	correction.Model = gorm.Model{}
+	correction.Status = "pending"
+	correction.ReviewedBy, correction.ReviewedAt, correction.ReviewNote = nil, nil, ""
+	food, err := GetFood(correction.FoodID, correction.UserID)
+	if err != nil || food.IsCustom {
+		return correction, fmt.Errorf("ไม่พบอาหารในแคตตาล็อก")
+	}
+	if correction.Name == nil && correction.Brand == nil && correction.EnergyKcal == nil &&
+		correction.ProteinG == nil && correction.FatG == nil && correction.CarbG == nil &&
+		correction.FiberG == nil && correction.SugarG == nil && correction.SodiumMg == nil {
+		return correction, fmt.Errorf("กรุณาระบุข้อมูลที่ต้องการแก้ไขอย่างน้อยหนึ่งค่า")
+	}
+	applyFoodCorrection(&food, correction)
+	if err := validateFood(&food); err != nil {
+		return correction, err
+	}
+	correction.Food = nil
+	err = config.DB().Create(&correction).Error
+	return correction, err
+}
+
+// GetFoodCorrections ดึงรายการข้อเสนอแก้ไข (status ว่าง = ทั้งหมด)
+func GetFoodCorrections(status string) ([]entity.FoodCorrection, error) {
+	query := config.DB().Preload("Food").Preload("User").Order("id desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var corrections []entity.FoodCorrection
+	err := query.Find(&corrections).Error
+	return corrections, err
+}
+
+// ReviewFoodCorrection ผู้ดูแลอนุมัติ (นำค่าไปแก้ไขอาหาร) หรือปฏิเสธข้อเสนอ และแจ้งผู้เสนอ
+func ReviewFoodCorrection(id, adminID uint, approve bool, note string) (entity.FoodCorrection, error) {
+	var correction entity.FoodCorrection
+	if err := config.DB().First(&correction, id).Error; err != nil {
+		return correction, err
+	}
+	if correction.Status != "pending" {
+		return correction, fmt.Errorf("ข้อเสนอนี้ได้รับการตรวจสอบแล้ว")
+	}
+	now := time.Now()
+	correction.ReviewedBy = &adminID
+	correction.ReviewedAt = &now
+	correction.ReviewNote = note
+	correction.Status = "rejected"
+	if approve {
+		correction.Status = "approved"
+	}
+
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var food entity.Food
+		if err := tx.First(&food, correction.FoodID).Error; err != nil {
+			return fmt.Errorf("ไม่พบอาหารที่เสนอแก้ไข")
+		}
+		if approve {
+			applyFoodCorrection(&food, correction)
+			if err := validateFood(&food); err != nil {
+				return err
+			}
+			if err := tx.Save(&food).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(&correction).Error; err != nil {
+			return err
+		}
+		title, message := "ข้อเสนอแก้ไขอาหารไม่ผ่านการตรวจสอบ", fmt.Sprintf("ข้อเสนอแก้ไข %s ไม่ได้รับการอนุมัติ", food.Name)
+		if approve {
+			title, message = "ข้อเสนอแก้ไขอาหารได้รับการอนุมัติ", fmt.Sprintf("ข้อมูล %s ได้รับการแก้ไขตามที่คุณเสนอแล้ว", food.Name)
+		}
+		if note != "" {
+			message += " (" + note + ")"
+		}
+		return NotifyUser(tx, correction.UserID, title, message)
+	})
+	return correction, err
+}
+
+func applyFoodCorrection(food *entity.Food, c entity.FoodCorrection) {
+	if c.Name != nil {
+		food.Name = *c.Name
+	}
+	if c.Brand != nil {
+		food.Brand = *c.Brand
+	}
+	for _, f := range []struct {
+		value  *float64
+		target *float64
+	}{
+		{c.EnergyKcal, &food.EnergyKcal},
+		{c.ProteinG, &food.ProteinG},
+		{c.FatG, &food.FatG},
+		{c.CarbG, &food.CarbG},
+		{c.FiberG, &food.FiberG},
+		{c.SugarG, &food.SugarG},
+		{c.SodiumMg, &food.SodiumMg},
+	} {
+		if f.value != nil {
+			*f.target = *f.value
+		}
+	}
+}
+
+func productFood(p BarcodeProduct, barcode, source string) entity.Food {
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		name = "สินค้า " + barcode
+	}
+	food := entity.Food{
+		Name:       name,
+		Category:   p.Category,
+		Brand:      p.Brand,
+		Barcode:    barcode,
+		Source:     source,
+		EnergyKcal: p.EnergyKcal,
+		ProteinG:   p.ProteinG,
+		FatG:       p.FatG,
+		CarbG:      p.CarbG,
+		FiberG:     p.FiberG,
+		SugarG:     p.SugarG,
+		SodiumMg:   p.SodiumMg,
+	}
+	if food.Category == "" {
+		food.Category = "อาหารสำเร็จรูป"
+	}
+	if p.ServingGrams > 0 {
+		food.Servings = []entity.FoodServing{{Name: "หน่วยบริโภค", Grams: p.ServingGrams}}
+	}
+	return food
+}
+
+// OpenFoodFactsProvider ค้นหาสินค้าจาก API ของ Open Food Facts (หรือบริการที่ตอบรูปแบบเดียวกัน)
+type OpenFoodFactsProvider struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewOpenFoodFactsProvider baseURL ว่าง = https://world.openfoodfacts.org
+func NewOpenFoodFactsProvider(baseURL string) *OpenFoodFactsProvider {
+	if baseURL == "" {
+		baseURL = "https://world.openfoodfacts.org"
+	}
+	return &OpenFoodFactsProvider{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p *OpenFoodFactsProvider) Name() string { return "openfoodfacts" }
+
+func (p *OpenFoodFactsProvider) Lookup(ctx context.Context, barcode string) (*BarcodeProduct, error) {
+	url := fmt.Sprintf("%s/api/v2/product/%s.json?fields=product_name,product_name_th,brands,categories,serving_quantity,nutriments", p.BaseURL, barcode)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "fitness-backend/1.0")
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Status  int `json:"status"`
+		Product struct {
+			ProductName     string                 `json:"product_name"`
+			ProductNameTH   string                 `json:"product_name_th"`
+			Brands          string                 `json:"brands"`
+			Categories      string                 `json:"categories"`
+			ServingQuantity interface{}            `json:"serving_quantity"`
+			Nutriments      map[string]interface{} `json:"nutriments"`
+		} `json:"product"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Status != 1 {
+		return nil, nil
+	}
+	prod := body.Product
+	n := prod.Nutriments
+	product := &BarcodeProduct{
+		Barcode:      barcode,
+		Name:         firstNonEmpty(prod.ProductNameTH, prod.ProductName),
+		Brand:        strings.TrimSpace(strings.Split(prod.Brands, ",")[0]),
+		EnergyKcal:   offNumber(n["energy-kcal_100g"]),
+		ProteinG:     offNumber(n["proteins_100g"]),
+		FatG:         offNumber(n["fat_100g"]),
+		CarbG:        offNumber(n["carbohydrates_100g"]),
+		FiberG:       offNumber(n["fiber_100g"]),
+		SugarG:       offNumber(n["sugars_100g"]),
+		SodiumMg:     math.Round(offNumber(n["sodium_100g"])*1000*10) / 10, // กรัม -> มิลลิกรัม
+		ServingGrams: offNumber(prod.ServingQuantity),
+	}
+	if product.EnergyKcal == 0 {
+		// บางสินค้ามีแต่ค่าพลังงานเป็นกิโลจูล
+		product.EnergyKcal = math.Round(offNumber(n["energy_100g"])/4.184*10) / 10
+	}
+	return product, nil
+}
+
+// FileBarcodeProvider อ่านสินค้าจากไฟล์ JSON (อาร์เรย์ของ BarcodeProduct) ใช้แทนบริการภายนอกตอนทดสอบ
+type FileBarcodeProvider struct {
+	Path string
+}
+
+func (p *FileBarcodeProvider) Name() string { return "file" }
+
+func (p *FileBarcodeProvider) Lookup(ctx context.Context, barcode string) (*BarcodeProduct, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+	var products []BarcodeProduct
+	if err := json.Unmarshal(data, &products); err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		if code, err := NormalizeBarcode(product.Barcode); err == nil && code == barcode {
+			return &product, nil
+		}
+	}
+	return nil, nil
+}
+
+// offNumber ค่าตัวเลขของ Open Food Facts อาจมาเป็น number หรือ string
+func offNumber(v interface{}) float64 {
+	switch value := v.(type) {
+	case float64:
+		return value
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		return f
+	default:
+		return 0
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
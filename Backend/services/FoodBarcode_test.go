@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNormalizeBarcode(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    string
+		wantErr bool
+	}{
+		{"8850000000010", "8850000000010", false},
+		{" 036000291452 ", "0036000291452", false}, // UPC-A เติม 0 ข้างหน้า
+		{"8850000000011", "", true},                // เลขตรวจสอบผิด
+		{"88500000000", "", true},                  // ความยาวไม่ถูกต้อง
+		{"88500000000A0", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeBarcode(tt.code)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeBarcode(%q) = %q, %v ต้องการ %q (error %v)", tt.code, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFileBarcodeProvider(t *testing.T) {
+	provider := &FileBarcodeProvider{Path: "testdata/barcodes.json"}
+	tests := []struct {
+		name         string
+		barcode      string
+		wantName     string
+		wantCategory string
+		wantServings int
+		wantMissing  bool
+	}{
+		{"พบสินค้า", "8850000000010", "นมถั่วเหลืองสูตรหวานน้อย", "อาหารสำเร็จรูป", 1, false},
+		{"UPC-A ในไฟล์ตรงกับ EAN-13", "0036000291452", "สินค้า 0036000291452", "อาหารสำเร็จรูป", 0, false},
+		{"ไม่พบสินค้า", "8851234567898", "", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := provider.Lookup(context.Background(), tt.barcode)
+			if err != nil {
+				t.Fatalf("Lookup: %v", err)
+			}
+			if tt.wantMissing {
+				if product != nil {
+					t.Errorf("ต้องไม่พบสินค้า ได้ %+v", product)
+				}
+				return
+			}
+			if product == nil {
+				t.Fatal("ไม่พบสินค้าในไฟล์")
+			}
+			food := productFood(*product, tt.barcode, provider.Name())
+			if food.Name != tt.wantName || food.Category != tt.wantCategory || len(food.Servings) != tt.wantServings {
+				t.Errorf("food = %s/%s/%v ต้องการ %s/%s/%d หน่วย", food.Name, food.Category, food.Servings, tt.wantName, tt.wantCategory, tt.wantServings)
+			}
+			if food.Barcode != tt.barcode || food.Source != "file" {
+				t.Errorf("barcode/source = %s/%s", food.Barcode, food.Source)
+			}
+			if err := validateFood(&food); err != nil {
+				t.Errorf("validateFood: %v", err)
+			}
+		})
+	}
+
+	if _, err := (&FileBarcodeProvider{Path: "testdata/missing.json"}).Lookup(context.Background(), "8850000000010"); err == nil {
+		t.Error("ไฟล์ไม่มีอยู่ต้องคืน error")
+	}
+}
+
+func TestConfigureBarcodeProvider(t *testing.T) {
+	defer SetBarcodeProvider(barcodeProvider)
+	tests := []struct {
+		provider string
+		file     string
+		wantName string // ว่าง = ไม่มีผู้ให้บริการ
+		wantErr  bool
+	}{
+		{"", "", "openfoodfacts", false},
+		{"file", "testdata/barcodes.json", "file", false},
+		{"file", "", "", true},
+		{"none", "", "", false},
+		{"unknown", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("BARCODE_PROVIDER", tt.provider)
+		t.Setenv("BARCODE_PROVIDER_FILE", tt.file)
+		err := ConfigureBarcodeProvider()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("BARCODE_PROVIDER=%q error = %v", tt.provider, err)
+		}
+		name := ""
+		if barcodeProvider != nil {
+			name = barcodeProvider.Name()
+		}
+		if name != tt.wantName {
+			t.Errorf("BARCODE_PROVIDER=%q ได้ผู้ให้บริการ %q ต้องการ %q", tt.provider, name, tt.wantName)
+		}
+	}
+}
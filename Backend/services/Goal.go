@@ -0,0 +1,469 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// goalTypes ชนิดเป้าหมาย หน่วย และชื่อที่ใช้ในการแจ้งเตือน
+var goalTypes = map[string]struct {
+	Unit  string
+	Label string
+}{
+	"weight":                  {"kg", "น้ำหนัก"},
+	"body_fat":                {"%", "เปอร์เซ็นต์ไขมัน"},
+	"weekly_activity_minutes": {"นาที/สัปดาห์", "เวลาออกกำลังกายต่อสัปดาห์"},
+	"distance":                {"km", "ระยะทางสะสม"},
+	"strength_pr":             {"kg", "สถิติ 1RM"},
+}
+
+// goalMilestonePercents เกณฑ์ความคืบหน้าที่บันทึกเป็นเหตุการณ์
+var goalMilestonePercents = []int{25, 50, 75, 100}
+
+// onTrackTolerance ความคืบหน้าที่ตามหลังเวลาได้ไม่เกินกี่เปอร์เซ็นต์จึงถือว่ายังตามแผน
+const onTrackTolerance = 10.0
+
+// GoalFilter เงื่อนไขค้นหาเป้าหมาย
+type GoalFilter struct {
+	UserID uint
+	Status string
+}
+
+// ClientGoals เป้าหมายของลูกค้าแต่ละคนสำหรับหน้าของเทรนเนอร์
+type ClientGoals struct {
+	UserID    uint          `json:"user_id"`
+	FirstName string        `json:"first_name"`
+	LastName  string        `json:"last_name"`
+	Active    int           `json:"active"`
+	Achieved  int           `json:"achieved"`
+	Expired   int           `json:"expired"`
+	OffTrack  int           `json:"off_track"` // เป้าหมายที่ยังไม่สำเร็จและตามหลังแผน
+	Goals     []entity.Goal `json:"goals"`
+}
+
+// CreateGoal สร้างเป้าหมาย ถ้าไม่ระบุค่าเริ่มต้นจะใช้ข้อมูลล่าสุด ณ วันเริ่ม
+func CreateGoal(goal entity.Goal) (entity.Goal, error) {
+	goal.Model = gorm.Model{}
+	goal.Status = "active"
+	goal.AchievedAt = nil
+	goal.Milestones = nil
+	if goal.StartDate.IsZero() {
+		goal.StartDate = time.Now()
+	}
+	if err := validateGoal(&goal); err != nil {
+		return goal, err
+	}
+	if goal.StartValue == 0 && !isCumulativeGoal(goal.Type) {
+		value, ok, err := goalValueAt(goal, goal.StartDate)
+		if err != nil {
+			return goal, err
+		}
+		if !ok {
+			return goal, fmt.Errorf("ไม่พบข้อมูล%s ก่อนวันเริ่ม กรุณาระบุ start_value", goalTypes[goal.Type].Label)
+		}
+		goal.StartValue = value
+	}
+	if !isCumulativeGoal(goal.Type) && goal.StartValue == goal.TargetValue {
+		return goal, fmt.Errorf("ค่าเป้าหมายต้องต่างจากค่าเริ่มต้น")
+	}
+
+	if err := config.DB().Create(&goal).Error; err != nil {
+		return goal, err
+	}
+	if err := RefreshGoal(&goal); err != nil {
+		return goal, err
+	}
+	return GetGoal(goal.ID)
+}
+
+// UpdateGoal แก้ไขชื่อ ค่าเป้าหมาย วันสิ้นสุด หรือยกเลิก/เปิดใช้เป้าหมายอีกครั้ง
+func UpdateGoal(id uint, input entity.Goal) (entity.Goal, error) {
+	goal, err := GetGoal(id)
+	if err != nil {
+		return goal, err
+	}
+	if strings.TrimSpace(input.Title) != "" {
+		goal.Title = input.Title
+	}
+	if input.TargetValue != 0 {
+		goal.TargetValue = input.TargetValue
+	}
+	if !input.TargetDate.IsZero() {
+		goal.TargetDate = input.TargetDate
+	}
+	switch input.Status {
+	case "":
+	case "cancelled":
+		goal.Status = "cancelled"
+	case "active":
+		if goal.Status == "cancelled" {
+			goal.Status = "active"
+		}
+	default:
+		return goal, fmt.Errorf("เปลี่ยนสถานะได้เฉพาะ cancelled หรือ active")
+	}
+	if err := validateGoal(&goal); err != nil {
+		return goal, err
+	}
+	if !isCumulativeGoal(goal.Type) && goal.StartValue == goal.TargetValue {
+		return goal, fmt.Errorf("ค่าเป้าหมายต้องต่างจากค่าเริ่มต้น")
+	}
+
+	err = config.DB().Model(&goal).Updates(map[string]interface{}{
+		"title":        goal.Title,
+		"target_value": goal.TargetValue,
+		"target_date":  goal.TargetDate,
+		"status":       goal.Status,
+	}).Error
+	if err != nil {
+		return goal, err
+	}
+	if err := RefreshGoal(&goal); err != nil {
+		return goal, err
+	}
+	return GetGoal(id)
+}
+
+// DeleteGoal ลบเป้าหมายพร้อมเหตุการณ์ความคืบหน้า
+func DeleteGoal(id uint) error {
+	return config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("goal_id = ?", id).Delete(&entity.GoalMilestone{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&entity.Goal{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// GetGoal ดึงเป้าหมายพร้อมเหตุการณ์ความคืบหน้า
+func GetGoal(id uint) (entity.Goal, error) {
+	var goal entity.Goal
+	err := config.DB().Preload("Milestones", func(db *gorm.DB) *gorm.DB {
+		return db.Order("reached_at, percent")
+	}).First(&goal, id).Error
+	if err == nil {
+		applyExpectedProgress(&goal, time.Now())
+	}
+	return goal, err
+}
+
+// GetGoals ดึงเป้าหมายของผู้ใช้ โดยคำนวณความคืบหน้าของเป้าหมายที่ยังดำเนินอยู่ใหม่ก่อน
+func GetGoals(filter GoalFilter) ([]entity.Goal, error) {
+	if err := RefreshUserGoals(filter.UserID); err != nil {
+		return nil, err
+	}
+	query := config.DB().Where("user_id = ?", filter.UserID).
+		Preload("Milestones", func(db *gorm.DB) *gorm.DB { return db.Order("reached_at, percent") }).
+		Order("target_date")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	var goals []entity.Goal
+	if err := query.Find(&goals).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range goals {
+		applyExpectedProgress(&goals[i], now)
+	}
+	return goals, nil
+}
+
+// GetTrainerClientGoals สถานะเป้าหมายของลูกค้าทุกคนของเทรนเนอร์
+func GetTrainerClientGoals(trainerID uint) ([]ClientGoals, error) {
+	users, err := trainerClients(trainerID)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]ClientGoals, 0, len(users))
+	for _, u := range users {
+		goals, err := GetGoals(GoalFilter{UserID: u.ID})
+		if err != nil {
+			return nil, err
+		}
+		client := ClientGoals{UserID: u.ID, FirstName: u.FirstName, LastName: u.LastName, Goals: goals}
+		for _, g := range goals {
+			switch g.Status {
+			case "active":
+				client.Active++
+				if !g.OnTrack {
+					client.OffTrack++
+				}
+			case "achieved":
+				client.Achieved++
+			case "expired":
+				client.Expired++
+			}
+		}
+		results = append(results, client)
+	}
+	return results, nil
+}
+
+// RefreshUserGoals คำนวณความคืบหน้าของเป้าหมายที่ยังดำเนินอยู่ทั้งหมดของผู้ใช้
+func RefreshUserGoals(userID uint) error {
+	var goals []entity.Goal
+	if err := config.DB().Where("user_id = ? AND status = ?", userID, "active").Find(&goals).Error; err != nil {
+		return err
+	}
+	for i := range goals {
+		if err := RefreshGoal(&goals[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshGoal คำนวณค่าปัจจุบันและความคืบหน้า บันทึกเหตุการณ์เมื่อผ่านเกณฑ์
+// พร้อมแจ้งเตือนสมาชิก และปรับสถานะเป็น achieved/expired
+func RefreshGoal(goal *entity.Goal) error {
+	if goal.Status != "active" {
+		return nil
+	}
+	now := time.Now()
+	at := now
+	if end := goalEnd(*goal); at.After(end) {
+		at = end.Add(-time.Second)
+	}
+
+	current, ok, err := goalValueAt(*goal, at)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		current = goal.StartValue
+	}
+	goal.CurrentValue = math.Round(current*100) / 100
+	goal.Progress = goalProgress(*goal)
+
+	return config.DB().Transaction(func(tx *gorm.DB) error {
+		period := ""
+		percents := goalMilestonePercents
+		if goal.Type == "weekly_activity_minutes" {
+			// เป้าหมายรายสัปดาห์บันทึกเฉพาะสัปดาห์ที่ทำได้ครบ
+			period = weekStart(at).Format("2006-01-02")
+			percents = []int{100}
+		}
+		for _, percent := range percents {
+			if goal.Progress < float64(percent) {
+				break
+			}
+			var count int64
+			if err := tx.Model(&entity.GoalMilestone{}).
+				Where("goal_id = ? AND percent = ? AND period = ?", goal.ID, percent, period).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			milestone := entity.GoalMilestone{GoalID: goal.ID, Percent: percent, Period: period, Value: goal.CurrentValue, ReachedAt: now}
+			if err := tx.Create(&milestone).Error; err != nil {
+				return err
+			}
+			if err := NotifyUser(tx, goal.UserID, "ความคืบหน้าเป้าหมาย", goalMilestoneMessage(*goal, percent)); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case goal.Type != "weekly_activity_minutes" && goal.Progress >= 100:
+			goal.Status = "achieved"
+			goal.AchievedAt = &now
+		case now.After(goalEnd(*goal)):
+			// หมดช่วงเวลาเป้าหมาย (เป้าหมายรายสัปดาห์จะจบลงด้วยสถานะนี้เสมอ)
+			goal.Status = "expired"
+		}
+		return tx.Model(&entity.Goal{}).Where("id = ?", goal.ID).Updates(map[string]interface{}{
+			"current_value": goal.CurrentValue,
+			"progress":      goal.Progress,
+			"status":        goal.Status,
+			"achieved_at":   goal.AchievedAt,
+		}).Error
+	})
+}
+
+// RefreshGoalsAfterChange คำนวณเป้าหมายใหม่หลังข้อมูลสุขภาพ/กิจกรรม/การฝึกเปลี่ยน
+// ข้อผิดพลาดไม่ทำให้การบันทึกหลักล้มเหลว เพราะความคืบหน้าจะถูกคำนวณอีกครั้งเมื่อดึงเป้าหมาย
+func RefreshGoalsAfterChange(userID uint) {
+	_ = RefreshUserGoals(userID)
+}
+
+func goalMilestoneMessage(goal entity.Goal, percent int) string {
+	name := goal.Title
+	if name == "" {
+		name = goalTypes[goal.Type].Label
+	}
+	switch {
+	case goal.Type == "weekly_activity_minutes":
+		return fmt.Sprintf("คุณทำเป้าหมาย \"%s\" ของสัปดาห์นี้ครบแล้ว (%.0f %s)", name, goal.CurrentValue, goalTypes[goal.Type].Unit)
+	case percent >= 100:
+		return fmt.Sprintf("ยินดีด้วย! คุณบรรลุเป้าหมาย \"%s\" แล้ว", name)
+	}
+	return fmt.Sprintf("เป้าหมาย \"%s\" คืบหน้าแล้ว %d%% (ปัจจุบัน %.2f %s)", name, percent, goal.CurrentValue, goalTypes[goal.Type].Unit)
+}
+
+// goalProgress เปอร์เซ็นต์ความคืบหน้า 0-100
+func goalProgress(goal entity.Goal) float64 {
+	var progress float64
+	if isCumulativeGoal(goal.Type) {
+		progress = goal.CurrentValue / goal.TargetValue * 100
+	} else {
+		progress = (goal.CurrentValue - goal.StartValue) / (goal.TargetValue - goal.StartValue) * 100
+	}
+	return math.Round(math.Max(0, math.Min(100, progress))*10) / 10
+}
+
+// applyExpectedProgress ความคืบหน้าที่ควรทำได้ตามสัดส่วนเวลาที่ผ่านไป
+func applyExpectedProgress(goal *entity.Goal, now time.Time) {
+	switch {
+	case goal.Type == "weekly_activity_minutes":
+		// เทียบกับจำนวนวันที่ผ่านไปของสัปดาห์ปัจจุบัน
+		elapsed := now.Sub(weekStart(now)).Hours() / (24 * 7)
+		goal.ExpectedProgress = math.Round(elapsed*1000) / 10
+	default:
+		total := goalEnd(*goal).Sub(goal.StartDate)
+		elapsed := now.Sub(goal.StartDate)
+		if total <= 0 || elapsed >= total {
+			goal.ExpectedProgress = 100
+		} else if elapsed > 0 {
+			goal.ExpectedProgress = math.Round(float64(elapsed)/float64(total)*1000) / 10
+		}
+	}
+	goal.OnTrack = goal.Status == "achieved" || goal.Progress+onTrackTolerance >= goal.ExpectedProgress
+}
+
+// goalEnd สิ้นสุดวันที่ TargetDate ตามเวลาไทย
+func goalEnd(goal entity.Goal) time.Time {
+	day := goal.TargetDate.In(bangkok)
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, bangkok).AddDate(0, 0, 1)
+}
+
+func isCumulativeGoal(goalType string) bool {
+	return goalType == "weekly_activity_minutes" || goalType == "distance"
+}
+
+// goalValueAt ค่าของตัวชี้วัดของเป้าหมาย ณ เวลา at
+// weight/body_fat: บันทึกสุขภาพล่าสุดที่ไม่เกินวันที่ at
+// weekly_activity_minutes: นาทีของกิจกรรมและการฝึกในสัปดาห์ของ at
+// distance: ระยะทางรวมของกิจกรรมตั้งแต่วันเริ่มถึง at
+// strength_pr: estimated 1RM ที่ดีที่สุดของท่าจนถึง at
+func goalValueAt(goal entity.Goal, at time.Time) (float64, bool, error) {
+	db := config.DB()
+	switch goal.Type {
+	case "weight", "body_fat":
+		column := "weight"
+		if goal.Type == "body_fat" {
+			column = "fat"
+		}
+		var records []entity.Health
+		if err := db.Where("user_id = ? AND "+column+" > 0", goal.UserID).Order("id").Find(&records).Error; err != nil {
+			return 0, false, err
+		}
+		limit := at.In(bangkok)
+		limitDay := time.Date(limit.Year(), limit.Month(), limit.Day(), 0, 0, 0, 0, bangkok)
+		var latest time.Time
+		value, found := 0.0, false
+		for _, r := range records {
+			day, ok := parseHealthDate(r.Date)
+			if !ok || day.After(limitDay) || day.Before(latest) {
+				continue
+			}
+			latest, found = day, true
+			value = r.Weight
+			if goal.Type == "body_fat" {
+				value = r.Fat
+			}
+		}
+		return value, found, nil
+
+	case "weekly_activity_minutes":
+		from := weekStart(at)
+		to := from.AddDate(0, 0, 7)
+		var activityMinutes, workoutMinutes float64
+		if err := db.Model(&entity.Activity{}).Select("COALESCE(SUM(duration), 0)").
+			Where("user_id = ? AND date >= ? AND date < ?", goal.UserID, from, to).
+			Scan(&activityMinutes).Error; err != nil {
+			return 0, false, err
+		}
+		if err := db.Model(&entity.WorkoutLog{}).Select("COALESCE(SUM(duration_minutes), 0)").
+			Where("user_id = ? AND date >= ? AND date < ?", goal.UserID, from, to).
+			Scan(&workoutMinutes).Error; err != nil {
+			return 0, false, err
+		}
+		return activityMinutes + workoutMinutes, true, nil
+
+	case "distance":
+		var distance float64
+		err := db.Model(&entity.Activity{}).Select("COALESCE(SUM(distance), 0)").
+			Where("user_id = ? AND date >= ? AND date <= ?", goal.UserID, goal.StartDate, at).
+			Scan(&distance).Error
+		return distance, true, err
+
+	case "strength_pr":
+		var record entity.PersonalRecord
+		err := db.Where("user_id = ? AND exercise_key = ? AND kind = ? AND achieved_at <= ?",
+			goal.UserID, exerciseKey(goal.ExerciseID, goal.ExerciseName), "estimated_1rm", at).
+			Order("value desc").First(&record).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, false, nil
+		}
+		return record.Value, err == nil, err
+	}
+	return 0, false, fmt.Errorf("ชนิดเป้าหมายไม่ถูกต้อง")
+}
+
+func validateGoal(goal *entity.Goal) error {
+	goal.Type = strings.TrimSpace(goal.Type)
+	goal.Title = strings.TrimSpace(goal.Title)
+	if _, ok := goalTypes[goal.Type]; !ok {
+		return fmt.Errorf("type ต้องเป็น weight, body_fat, weekly_activity_minutes, distance หรือ strength_pr")
+	}
+	if goal.UserID == 0 {
+		return fmt.Errorf("กรุณาระบุผู้ใช้")
+	}
+	if goal.TargetValue <= 0 || goal.StartValue < 0 {
+		return fmt.Errorf("ค่าเป้าหมายต้องมากกว่า 0")
+	}
+	if goal.Type == "body_fat" && goal.TargetValue >= 100 {
+		return fmt.Errorf("เปอร์เซ็นต์ไขมันต้องน้อยกว่า 100")
+	}
+	if goal.TargetDate.IsZero() || !goalEnd(*goal).After(goal.StartDate) {
+		return fmt.Errorf("วันสิ้นสุดต้องไม่ก่อนวันเริ่ม")
+	}
+	if goal.Type == "strength_pr" {
+		if goal.ExerciseID != nil {
+			var exercise entity.Exercise
+			if err := config.DB().First(&exercise, *goal.ExerciseID).Error; err != nil {
+				return fmt.Errorf("ไม่พบท่าออกกำลังกาย")
+			}
+			goal.ExerciseName = exerciseDisplayName(exercise)
+		}
+		goal.ExerciseName = strings.TrimSpace(goal.ExerciseName)
+		if goal.ExerciseID == nil && goal.ExerciseName == "" {
+			return fmt.Errorf("กรุณาระบุท่าออกกำลังกายของเป้าหมายสถิติ")
+		}
+	} else {
+		goal.ExerciseID, goal.ExerciseName = nil, ""
+	}
+	if goal.Title == "" {
+		goal.Title = goalTypes[goal.Type].Label
+		if goal.ExerciseName != "" {
+			goal.Title += " " + goal.ExerciseName
+		}
+	}
+	return nil
+}
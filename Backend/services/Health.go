@@ -0,0 +1,219 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// bmiLabels ชื่อกลุ่ม BMI ที่แสดงใน Status
+var bmiLabels = map[string]string{
+	"underweight": "น้ำหนักน้อย",
+	"normal":      "ปกติ",
+	"overweight":  "น้ำหนักเกิน",
+	"obese_1":     "อ้วนระดับ 1",
+	"obese_2":     "อ้วนระดับ 2",
+}
+
+// GetBmiCutoff ดึงเกณฑ์แบ่งกลุ่ม BMI ปัจจุบัน
+func GetBmiCutoff() (entity.BmiCutoff, error) {
+	var cutoff entity.BmiCutoff
+	err := config.DB().First(&cutoff).Error
+	return cutoff, err
+}
+
+// UpdateBmiCutoff แก้ไขเกณฑ์ BMI (ไม่จัดกลุ่มบันทึกเดิมใหม่)
+func UpdateBmiCutoff(updated entity.BmiCutoff) (entity.BmiCutoff, error) {
+	if updated.UnderweightBelow <= 0 || updated.UnderweightBelow >= updated.NormalBelow ||
+		updated.NormalBelow >= updated.OverweightBelow || updated.OverweightBelow >= updated.Obese1Below {
+		return updated, fmt.Errorf("เกณฑ์ BMI ต้องมากกว่า 0 และเรียงจากน้อยไปมาก")
+	}
+	cutoff, err := GetBmiCutoff()
+	if err != nil {
+		return cutoff, err
+	}
+	err = config.DB().Model(&cutoff).Updates(map[string]interface{}{
+		"underweight_below": updated.UnderweightBelow,
+		"normal_below":      updated.NormalBelow,
+		"overweight_below":  updated.OverweightBelow,
+		"obese1_below":      updated.Obese1Below,
+	}).Error
+	if err != nil {
+		return cutoff, err
+	}
+	return GetBmiCutoff()
+}
+
+// ClassifyBmi จัดกลุ่ม BMI ตามเกณฑ์ คืนรหัสกลุ่มและชื่อภาษาไทย
+func ClassifyBmi(bmi float64, cutoff entity.BmiCutoff) (string, string) {
+	var category string
+	switch {
+	case bmi < cutoff.UnderweightBelow:
+		category = "underweight"
+	case bmi < cutoff.NormalBelow:
+		category = "normal"
+	case bmi < cutoff.OverweightBelow:
+		category = "overweight"
+	case bmi < cutoff.Obese1Below:
+		category = "obese_1"
+	default:
+		category = "obese_2"
+	}
+	return category, bmiLabels[category]
+}
+
+// ClassifyBloodPressure จัดกลุ่มความดันโลหิตตามเกณฑ์ AHA/ACC 2017
+// ใช้กลุ่มที่รุนแรงกว่าเมื่อค่าตัวบนและตัวล่างตกคนละกลุ่ม
+func ClassifyBloodPressure(systolic, diastolic int) string {
+	switch {
+	case systolic > 180 || diastolic > 120:
+		return "hypertensive_crisis"
+	case systolic >= 140 || diastolic >= 90:
+		return "hypertension_stage_2"
+	case systolic >= 130 || diastolic >= 80:
+		return "hypertension_stage_1"
+	case systolic >= 120:
+		return "elevated"
+	default:
+		return "normal"
+	}
+}
+
+// PrepareHealth ตรวจข้อมูลและคำนวณค่าที่ระบบเป็นผู้กำหนด: BMI, กลุ่ม BMI และความดันแบบแยกค่า
+// ความดันรับได้ทั้ง systolic/diastolic หรือข้อความ "120/80" ใน Pressure
+func PrepareHealth(health *entity.Health) error {
+	if health.Weight <= 0 || health.Weight > 500 {
+		return fmt.Errorf("น้ำหนักต้องอยู่ระหว่าง 0-500 กก.")
+	}
+	if health.Height <= 0 || health.Height > 300 {
+		return fmt.Errorf("ส่วนสูงต้องอยู่ระหว่าง 0-300 ซม.")
+	}
+	if health.Fat < 0 || health.Fat >= 100 {
+		return fmt.Errorf("เปอร์เซ็นต์ไขมันต้องอยู่ระหว่าง 0-100")
+	}
+	if health.Date == "" {
+		health.Date = time.Now().Format("2006-01-02")
+	} else if _, ok := parseHealthDate(health.Date); !ok {
+		return fmt.Errorf("รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)")
+	}
+
+	cutoff, err := GetBmiCutoff()
+	if err != nil {
+		return err
+	}
+	health.Bmi = math.Round(health.Weight/math.Pow(health.Height/100, 2)*100) / 100
+	health.BmiCategory, health.Status = ClassifyBmi(health.Bmi, cutoff)
+
+	if health.Systolic == 0 && health.Diastolic == 0 && strings.TrimSpace(health.Pressure) != "" {
+		systolic, diastolic, ok := parsePressure(health.Pressure)
+		if !ok {
+			return fmt.Errorf("รูปแบบความดันโลหิตไม่ถูกต้อง (เช่น 120/80)")
+		}
+		health.Systolic, health.Diastolic = systolic, diastolic
+	}
+	if health.Systolic == 0 && health.Diastolic == 0 {
+		health.Pressure, health.PressureCategory = "", ""
+		return nil
+	}
+	if health.Systolic < 50 || health.Systolic > 300 || health.Diastolic < 30 || health.Diastolic > 200 {
+		return fmt.Errorf("ค่าความดันโลหิตไม่อยู่ในช่วงที่เป็นไปได้")
+	}
+	if health.Diastolic >= health.Systolic {
+		return fmt.Errorf("ความดันตัวบนต้องมากกว่าตัวล่าง")
+	}
+	health.Pressure = fmt.Sprintf("%d/%d", health.Systolic, health.Diastolic)
+	health.PressureCategory = ClassifyBloodPressure(health.Systolic, health.Diastolic)
+	return nil
+}
+
+// CreateHealth บันทึกข้อมูลสุขภาพ
+func CreateHealth(health entity.Health) (entity.Health, error) {
+	health.Model = gorm.Model{}
+	if err := PrepareHealth(&health); err != nil {
+		return health, err
+	}
+	if err := config.DB().Create(&health).Error; err != nil {
+		return health, err
+	}
+	RefreshGoalsAfterChange(health.UserID)
+	return health, nil
+}
+
+// GetHealth ดึงบันทึกสุขภาพของผู้ใช้
+func GetHealth(id, userID uint) (entity.Health, error) {
+	var health entity.Health
+	err := config.DB().Where("id = ? AND user_id = ?", id, userID).First(&health).Error
+	return health, err
+}
+
+// UpdateHealth แก้ไขบันทึกสุขภาพและคำนวณค่าต่างๆ ใหม่
+// กิจกรรมที่คำนวณแคลอรี่จากบันทึกนี้ไปแล้วจะไม่ถูกคำนวณใหม่
+func UpdateHealth(id, userID uint, input entity.Health) (entity.Health, error) {
+	existing, err := GetHealth(id, userID)
+	if err != nil {
+		return existing, err
+	}
+	input.Model = existing.Model
+	input.UserID = existing.UserID
+	if input.Date == "" {
+		input.Date = existing.Date
+	}
+	if err := PrepareHealth(&input); err != nil {
+		return existing, err
+	}
+	if err := config.DB().Save(&input).Error; err != nil {
+		return input, err
+	}
+	RefreshGoalsAfterChange(input.UserID)
+	return input, nil
+}
+
+// DeleteHealth ลบบันทึกสุขภาพของผู้ใช้
+func DeleteHealth(id, userID uint) error {
+	result := config.DB().Where("id = ? AND user_id = ?", id, userID).Delete(&entity.Health{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	RefreshGoalsAfterChange(userID)
+	return nil
+}
+
+// MigrateHealthRecords แปลงบันทึกเดิมที่เก็บความดันเป็นข้อความและ BMI/Status จากฝั่งผู้ใช้
+// ให้เป็นค่าที่ระบบคำนวณ ทำเฉพาะแถวที่ยังไม่มี bmi_category จึงเรียกซ้ำได้
+func MigrateHealthRecords() error {
+	cutoff, err := GetBmiCutoff()
+	if err != nil {
+		return err
+	}
+	var records []entity.Health
+	if err := config.DB().Where("bmi_category = '' OR bmi_category IS NULL").Find(&records).Error; err != nil {
+		return err
+	}
+	for _, r := range records {
+		updates := map[string]interface{}{}
+		if r.Weight > 0 && r.Height > 0 {
+			bmi := math.Round(r.Weight/math.Pow(r.Height/100, 2)*100) / 100
+			category, label := ClassifyBmi(bmi, cutoff)
+			updates["bmi"], updates["bmi_category"], updates["status"] = bmi, category, label
+		}
+		if systolic, diastolic, ok := parsePressure(r.Pressure); ok && r.Systolic == 0 {
+			updates["systolic"], updates["diastolic"] = systolic, diastolic
+			updates["pressure_category"] = ClassifyBloodPressure(systolic, diastolic)
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := config.DB().Model(&entity.Health{}).Where("id = ?", r.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
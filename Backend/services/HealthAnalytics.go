@@ -0,0 +1,357 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+)
+
+// healthMetrics ตัวชี้วัดที่วิเคราะห์แนวโน้มและหน่วย
+var healthMetrics = []struct {
+	Key  string
+	Unit string
+}{
+	{"weight", "kg"},
+	{"fat", "%"},
+	{"bmi", "kg/m2"},
+	{"systolic", "mmHg"},
+	{"diastolic", "mmHg"},
+}
+
+// HealthTrendFilter เงื่อนไขการวิเคราะห์ (From/To เป็นศูนย์ได้ = ทั้งหมด)
+type HealthTrendFilter struct {
+	UserID  uint
+	From    time.Time
+	To      time.Time // ไม่รวมวันที่ To
+	Bucket  string    // day, week, month
+	Window  int       // จำนวนช่วงของค่าเฉลี่ยเคลื่อนที่ (0 = ค่าเริ่มต้นของแต่ละ bucket)
+	Targets map[string]float64
+}
+
+// TrendPoint ค่าของช่วงเวลาหนึ่ง (ค่าเฉลี่ยของวันที่มีข้อมูลในช่วงนั้น)
+type TrendPoint struct {
+	Period        string  `json:"period"` // วันแรกของช่วง YYYY-MM-DD
+	Value         float64 `json:"value"`
+	MovingAverage float64 `json:"moving_average"`
+	Count         int     `json:"count"` // จำนวนวันที่มีข้อมูลในช่วง
+}
+
+// GoalProjection การคาดการณ์วันที่ถึงเป้าหมายจากอัตราการเปลี่ยนแปลงปัจจุบัน
+type GoalProjection struct {
+	Target         float64  `json:"target"`
+	Remaining      float64  `json:"remaining"`
+	Status         string   `json:"status"` // reached, projected, moving_away, insufficient_data
+	EstimatedDate  string   `json:"estimated_date,omitempty"`
+	WeeksRemaining *float64 `json:"weeks_remaining,omitempty"`
+}
+
+// MetricTrend ชุดข้อมูลและสรุปของตัวชี้วัดหนึ่ง
+type MetricTrend struct {
+	Metric        string          `json:"metric"`
+	Unit          string          `json:"unit"`
+	Series        []TrendPoint    `json:"series"`
+	Count         int             `json:"count"`
+	Start         *float64        `json:"start"`
+	Latest        *float64        `json:"latest"`
+	Min           *float64        `json:"min"`
+	MinDate       string          `json:"min_date,omitempty"`
+	Max           *float64        `json:"max"`
+	MaxDate       string          `json:"max_date,omitempty"`
+	Change        *float64        `json:"change"`
+	ChangePercent *float64        `json:"change_percent"`
+	RatePerWeek   *float64        `json:"rate_per_week"` // ความชันจาก least squares ของค่ารายวัน
+	Projection    *GoalProjection `json:"projection,omitempty"`
+}
+
+// HealthTrends ผลการวิเคราะห์ข้อมูลสุขภาพของผู้ใช้
+type HealthTrends struct {
+	UserID  uint                   `json:"user_id"`
+	Bucket  string                 `json:"bucket"`
+	Window  int                    `json:"window"`
+	From    string                 `json:"from,omitempty"`
+	To      string                 `json:"to,omitempty"`
+	Metrics map[string]MetricTrend `json:"metrics"`
+}
+
+// dailyValue ค่าของตัวชี้วัดในวันหนึ่ง
+type dailyValue struct {
+	Day   time.Time
+	Value float64
+}
+
+// GetHealthTrends วิเคราะห์น้ำหนัก ไขมัน BMI และความดันโลหิตแบบแบ่งช่วงเวลา
+// บันทึกหลายรายการในวันเดียวกันใช้รายการล่าสุด และช่วงที่ไม่มีข้อมูลจะไม่ถูกเติมค่า
+func GetHealthTrends(filter HealthTrendFilter) (HealthTrends, error) {
+	switch filter.Bucket {
+	case "":
+		filter.Bucket = "day"
+	case "day", "week", "month":
+	default:
+		return HealthTrends{}, fmt.Errorf("bucket ต้องเป็น day, week หรือ month")
+	}
+	if filter.Window < 0 || filter.Window > 60 {
+		return HealthTrends{}, fmt.Errorf("window ต้องอยู่ระหว่าง 1-60")
+	}
+	if filter.Window == 0 {
+		filter.Window = map[string]int{"day": 7, "week": 4, "month": 3}[filter.Bucket]
+	}
+	for key := range filter.Targets {
+		if !isHealthMetric(key) {
+			return HealthTrends{}, fmt.Errorf("ไม่รู้จักตัวชี้วัด %s", key)
+		}
+	}
+
+	// วันที่ของช่วงตีความเป็นวันตามเวลาไทยเช่นเดียวกับ Health.Date
+	if !filter.From.IsZero() {
+		filter.From = time.Date(filter.From.Year(), filter.From.Month(), filter.From.Day(), 0, 0, 0, 0, bangkok)
+	}
+	if !filter.To.IsZero() {
+		filter.To = time.Date(filter.To.Year(), filter.To.Month(), filter.To.Day(), 0, 0, 0, 0, bangkok)
+	}
+
+	var records []entity.Health
+	if err := config.DB().Where("user_id = ?", filter.UserID).Order("id").Find(&records).Error; err != nil {
+		return HealthTrends{}, err
+	}
+
+	daily := healthDailyValues(records, filter.From, filter.To)
+	result := HealthTrends{
+		UserID:  filter.UserID,
+		Bucket:  filter.Bucket,
+		Window:  filter.Window,
+		Metrics: map[string]MetricTrend{},
+	}
+	if !filter.From.IsZero() {
+		result.From = filter.From.Format("2006-01-02")
+	}
+	if !filter.To.IsZero() {
+		result.To = filter.To.AddDate(0, 0, -1).Format("2006-01-02")
+	}
+	for _, m := range healthMetrics {
+		trend := summarizeMetric(daily[m.Key], filter.Bucket, filter.Window)
+		trend.Metric, trend.Unit = m.Key, m.Unit
+		if target, ok := filter.Targets[m.Key]; ok {
+			trend.Projection = projectGoal(daily[m.Key], trend, target)
+		}
+		result.Metrics[m.Key] = trend
+	}
+	return result, nil
+}
+
+func isHealthMetric(key string) bool {
+	for _, m := range healthMetrics {
+		if m.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// healthDailyValues แยกค่าแต่ละตัวชี้วัดเป็นรายวัน เรียงตามวันที่
+// ค่า 0 ถือว่าไม่ได้บันทึก และวันที่ซ้ำใช้บันทึกที่สร้างทีหลัง (records เรียงตาม id)
+func healthDailyValues(records []entity.Health, from, to time.Time) map[string][]dailyValue {
+	byDay := map[string]map[time.Time]float64{}
+	for _, m := range healthMetrics {
+		byDay[m.Key] = map[time.Time]float64{}
+	}
+	for _, r := range records {
+		day, ok := parseHealthDate(r.Date)
+		if !ok {
+			local := r.CreatedAt.In(bangkok)
+			day = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, bangkok)
+		}
+		if (!from.IsZero() && day.Before(from)) || (!to.IsZero() && !day.Before(to)) {
+			continue
+		}
+		bmi := r.Bmi
+		if r.Weight > 0 && r.Height > 0 {
+			bmi = math.Round(r.Weight/math.Pow(r.Height/100, 2)*100) / 100
+		}
+		systolic, diastolic := r.Systolic, r.Diastolic
+		if systolic == 0 {
+			// บันทึกที่ยังไม่ได้แปลงความดันจากข้อความ
+			systolic, diastolic, _ = parsePressure(r.Pressure)
+		}
+		for key, value := range map[string]float64{
+			"weight":    r.Weight,
+			"fat":       r.Fat,
+			"bmi":       bmi,
+			"systolic":  float64(systolic),
+			"diastolic": float64(diastolic),
+		} {
+			if value > 0 {
+				byDay[key][day] = value
+			}
+		}
+	}
+
+	result := map[string][]dailyValue{}
+	for key, days := range byDay {
+		values := make([]dailyValue, 0, len(days))
+		for day, value := range days {
+			values = append(values, dailyValue{Day: day, Value: value})
+		}
+		sort.Slice(values, func(i, j int) bool { return values[i].Day.Before(values[j].Day) })
+		result[key] = values
+	}
+	return result
+}
+
+// parsePressure แยกความดันรูปแบบ "120/80"
+func parsePressure(value string) (int, int, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	systolic, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	diastolic, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil || systolic <= 0 || diastolic <= 0 {
+		return 0, 0, false
+	}
+	return systolic, diastolic, true
+}
+
+func bucketStart(day time.Time, bucket string) time.Time {
+	switch bucket {
+	case "week":
+		return weekStart(day)
+	case "month":
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, bangkok)
+	}
+	return day
+}
+
+// previousBuckets วันแรกของช่วงที่อยู่ก่อนหน้า start ไป n ช่วง
+func previousBuckets(start time.Time, bucket string, n int) time.Time {
+	switch bucket {
+	case "week":
+		return start.AddDate(0, 0, -7*n)
+	case "month":
+		return start.AddDate(0, -n, 0)
+	}
+	return start.AddDate(0, 0, -n)
+}
+
+func summarizeMetric(values []dailyValue, bucket string, window int) MetricTrend {
+	trend := MetricTrend{Series: []TrendPoint{}, Count: len(values)}
+	if len(values) == 0 {
+		return trend
+	}
+
+	// รวมค่ารายวันเป็นช่วง
+	var starts []time.Time
+	sums := map[time.Time]float64{}
+	counts := map[time.Time]int{}
+	for _, v := range values {
+		start := bucketStart(v.Day, bucket)
+		if counts[start] == 0 {
+			starts = append(starts, start)
+		}
+		sums[start] += v.Value
+		counts[start]++
+	}
+	for i, start := range starts {
+		point := TrendPoint{
+			Period: start.Format("2006-01-02"),
+			Value:  round2(sums[start] / float64(counts[start])),
+			Count:  counts[start],
+		}
+		// ค่าเฉลี่ยเคลื่อนที่ตามเวลาจริง: ช่วงที่ขาดหายไปไม่นับเป็นศูนย์
+		earliest := previousBuckets(start, bucket, window-1)
+		total, n := 0.0, 0
+		for j := i; j >= 0 && !starts[j].Before(earliest); j-- {
+			total += sums[starts[j]] / float64(counts[starts[j]])
+			n++
+		}
+		point.MovingAverage = round2(total / float64(n))
+		trend.Series = append(trend.Series, point)
+	}
+
+	first, last := values[0], values[len(values)-1]
+	minV, maxV := first, first
+	for _, v := range values {
+		if v.Value < minV.Value {
+			minV = v
+		}
+		if v.Value > maxV.Value {
+			maxV = v
+		}
+	}
+	trend.Start = floatPtr(first.Value)
+	trend.Latest = floatPtr(last.Value)
+	trend.Min, trend.MinDate = floatPtr(minV.Value), minV.Day.Format("2006-01-02")
+	trend.Max, trend.MaxDate = floatPtr(maxV.Value), maxV.Day.Format("2006-01-02")
+	trend.Change = floatPtr(round2(last.Value - first.Value))
+	trend.ChangePercent = floatPtr(round2((last.Value - first.Value) / first.Value * 100))
+	if slope, ok := dailySlope(values); ok {
+		trend.RatePerWeek = floatPtr(round2(slope * 7))
+	}
+	return trend
+}
+
+// dailySlope ความชัน (หน่วยต่อวัน) ด้วย least squares ต้องมีข้อมูลอย่างน้อย 2 วัน
+func dailySlope(values []dailyValue) (float64, bool) {
+	if len(values) < 2 {
+		return 0, false
+	}
+	origin := values[0].Day
+	var sumX, sumY, sumXY, sumXX float64
+	for _, v := range values {
+		x := v.Day.Sub(origin).Hours() / 24
+		sumX += x
+		sumY += v.Value
+		sumXY += x * v.Value
+		sumXX += x * x
+	}
+	n := float64(len(values))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// projectGoal คาดการณ์วันที่ถึงเป้าหมายจากค่าล่าสุดและอัตราการเปลี่ยนแปลง
+func projectGoal(values []dailyValue, trend MetricTrend, target float64) *GoalProjection {
+	projection := &GoalProjection{Target: target, Status: "insufficient_data"}
+	if trend.Latest == nil {
+		return projection
+	}
+	latest := *trend.Latest
+	projection.Remaining = round2(target - latest)
+	start := *trend.Start
+	// ถึงเป้าแล้ว: ค่าล่าสุดเท่าหรือเลยเป้าไปในทิศทางจากค่าเริ่มต้น
+	if math.Abs(projection.Remaining) < 0.005 || (start != target && (latest-target)*(start-target) <= 0) {
+		projection.Status = "reached"
+		projection.Remaining = 0
+		return projection
+	}
+	slope, ok := dailySlope(values)
+	if !ok {
+		return projection
+	}
+	if slope == 0 || (target-latest)*slope < 0 {
+		projection.Status = "moving_away"
+		return projection
+	}
+	days := (target - latest) / slope
+	weeks := round2(days / 7)
+	projection.Status = "projected"
+	projection.WeeksRemaining = &weeks
+	projection.EstimatedDate = values[len(values)-1].Day.AddDate(0, 0, int(math.Ceil(days))).Format("2006-01-02")
+	return projection
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
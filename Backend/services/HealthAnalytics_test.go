@@ -0,0 +1,222 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"example.com/fitness-backend/entity"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, bangkok)
+}
+
+func series(points ...float64) []dailyValue {
+	// points เป็นคู่ (วันที่ของเดือน ม.ค. 2025, ค่า)
+	values := make([]dailyValue, 0, len(points)/2)
+	for i := 0; i+1 < len(points); i += 2 {
+		values = append(values, dailyValue{Day: day(2025, time.January, int(points[i])), Value: points[i+1]})
+	}
+	return values
+}
+
+func TestHealthDailyValues(t *testing.T) {
+	created := time.Date(2025, time.January, 3, 20, 0, 0, 0, time.UTC) // 4 ม.ค. เวลาไทย
+	records := []entity.Health{
+		{Date: "2025-01-01", Weight: 80},
+		{Date: "2025-01-01T10:00:00+07:00", Weight: 81, Fat: 25}, // บันทึกทีหลังในวันเดียวกันใช้ค่านี้
+		{Date: "2025-01-02", Weight: 0, Fat: 24},                 // ไม่ได้ชั่งน้ำหนัก
+		{Date: "2025-01-02", Pressure: "120/80"},                 // ความดันแบบข้อความเดิม
+		{Weight: 79},                                             // ไม่มีวันที่ ใช้วันที่สร้าง
+		{Date: "2024-12-31", Weight: 90},                         // ก่อนช่วงที่ขอ
+	}
+	records[4].CreatedAt = created
+
+	got := healthDailyValues(records, day(2025, time.January, 1), time.Time{})
+	tests := []struct {
+		metric string
+		want   []dailyValue
+	}{
+		{"weight", series(1, 81, 4, 79)},
+		{"fat", series(1, 25, 2, 24)},
+		{"systolic", series(2, 120)},
+		{"diastolic", series(2, 80)},
+		{"bmi", series()},
+	}
+	for _, tt := range tests {
+		values := got[tt.metric]
+		if len(values) != len(tt.want) {
+			t.Errorf("%s: ได้ %d วัน ต้องการ %d วัน (%v)", tt.metric, len(values), len(tt.want), values)
+			continue
+		}
+		for i := range values {
+			if !values[i].Day.Equal(tt.want[i].Day) || values[i].Value != tt.want[i].Value {
+				t.Errorf("%s[%d] = %v %v ต้องการ %v %v", tt.metric, i,
+					values[i].Day.Format("2006-01-02"), values[i].Value, tt.want[i].Day.Format("2006-01-02"), tt.want[i].Value)
+			}
+		}
+	}
+}
+
+func TestSummarizeMetric(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []dailyValue
+		bucket     string
+		window     int
+		wantSeries []TrendPoint
+		wantMin    string
+		wantMax    string
+		wantRate   *float64
+	}{
+		{
+			name:       "ไม่มีข้อมูล",
+			values:     nil,
+			bucket:     "day",
+			window:     7,
+			wantSeries: []TrendPoint{},
+		},
+		{
+			name:       "จุดเดียว",
+			values:     series(5, 70),
+			bucket:     "day",
+			window:     7,
+			wantSeries: []TrendPoint{{Period: "2025-01-05", Value: 70, MovingAverage: 70, Count: 1}},
+			wantMin:    "2025-01-05",
+			wantMax:    "2025-01-05",
+		},
+		{
+			// วันที่ขาดหายไม่นับในค่าเฉลี่ยเคลื่อนที่
+			name:   "ข้อมูลเว้นช่วงรายวัน",
+			values: series(1, 10, 2, 12, 5, 20),
+			bucket: "day",
+			window: 3,
+			wantSeries: []TrendPoint{
+				{Period: "2025-01-01", Value: 10, MovingAverage: 10, Count: 1},
+				{Period: "2025-01-02", Value: 12, MovingAverage: 11, Count: 1},
+				{Period: "2025-01-05", Value: 20, MovingAverage: 20, Count: 1},
+			},
+			wantMin:  "2025-01-01",
+			wantMax:  "2025-01-05",
+			wantRate: floatPtr(17.77),
+		},
+		{
+			// 6 ม.ค. 2025 เป็นวันจันทร์ สัปดาห์ 13 ม.ค. ไม่มีข้อมูล
+			name:   "ข้อมูลเว้นช่วงรายสัปดาห์",
+			values: series(6, 80, 8, 82, 20, 78),
+			bucket: "week",
+			window: 2,
+			wantSeries: []TrendPoint{
+				{Period: "2025-01-06", Value: 81, MovingAverage: 81, Count: 2},
+				{Period: "2025-01-20", Value: 78, MovingAverage: 78, Count: 1},
+			},
+			wantMin:  "2025-01-20",
+			wantMax:  "2025-01-08",
+			wantRate: floatPtr(-1.47),
+		},
+		{
+			name:   "ค่าผิดปกติ",
+			values: series(1, 70, 2, 71, 3, 150, 4, 72),
+			bucket: "month",
+			window: 3,
+			wantSeries: []TrendPoint{
+				{Period: "2025-01-01", Value: 90.75, MovingAverage: 90.75, Count: 4},
+			},
+			wantMin:  "2025-01-01",
+			wantMax:  "2025-01-03",
+			wantRate: floatPtr(59.5),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend := summarizeMetric(tt.values, tt.bucket, tt.window)
+			if trend.Count != len(tt.values) {
+				t.Errorf("Count = %d ต้องการ %d", trend.Count, len(tt.values))
+			}
+			if len(trend.Series) != len(tt.wantSeries) {
+				t.Fatalf("Series = %+v ต้องการ %+v", trend.Series, tt.wantSeries)
+			}
+			for i := range trend.Series {
+				if trend.Series[i] != tt.wantSeries[i] {
+					t.Errorf("Series[%d] = %+v ต้องการ %+v", i, trend.Series[i], tt.wantSeries[i])
+				}
+			}
+			if len(tt.values) == 0 {
+				if trend.Start != nil || trend.Latest != nil || trend.Change != nil || trend.RatePerWeek != nil {
+					t.Errorf("ไม่มีข้อมูลต้องไม่มีค่าสรุป: %+v", trend)
+				}
+				return
+			}
+			if trend.MinDate != tt.wantMin || trend.MaxDate != tt.wantMax {
+				t.Errorf("MinDate/MaxDate = %s/%s ต้องการ %s/%s", trend.MinDate, trend.MaxDate, tt.wantMin, tt.wantMax)
+			}
+			if !equalFloatPtr(trend.RatePerWeek, tt.wantRate) {
+				t.Errorf("RatePerWeek = %v ต้องการ %v", deref(trend.RatePerWeek), deref(tt.wantRate))
+			}
+		})
+	}
+}
+
+func TestDailySlope(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []dailyValue
+		want   float64
+		wantOK bool
+	}{
+		{"ไม่มีข้อมูล", nil, 0, false},
+		{"จุดเดียวคำนวณไม่ได้", series(1, 80), 0, false},
+		{"สองจุด", series(1, 100, 8, 93), -1, true},
+		{"เว้นช่วงใช้ระยะห่างของวันจริง", series(1, 100, 2, 99, 10, 91), -1, true},
+		{"ค่าคงที่", series(1, 60, 3, 60, 9, 60), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := dailySlope(tt.values)
+			if ok != tt.wantOK || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("dailySlope = %v, %v ต้องการ %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProjectGoal(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     []dailyValue
+		target     float64
+		wantStatus string
+		wantDate   string
+		wantLeft   float64
+	}{
+		{"ไม่มีข้อมูล", nil, 60, "insufficient_data", "", 0},
+		{"จุดเดียว", series(1, 100), 90, "insufficient_data", "", -10},
+		{"ถึงเป้าแล้ว", series(1, 100, 8, 93), 95, "reached", "", 0},
+		{"เคลื่อนออกจากเป้า", series(1, 100, 8, 93), 105, "moving_away", "", 12},
+		{"คาดการณ์ได้", series(1, 100, 8, 93), 90, "projected", "2025-01-11", -3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend := summarizeMetric(tt.values, "day", 7)
+			got := projectGoal(tt.values, trend, tt.target)
+			if got.Status != tt.wantStatus || got.EstimatedDate != tt.wantDate || got.Remaining != tt.wantLeft {
+				t.Errorf("projectGoal = %+v ต้องการ status %s date %q remaining %v", got, tt.wantStatus, tt.wantDate, tt.wantLeft)
+			}
+		})
+	}
+}
+
+func equalFloatPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return math.Abs(*a-*b) < 0.005
+}
+
+func deref(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,507 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ICalEvent เหตุการณ์หนึ่งรายการในไฟล์ iCalendar (RFC 5545)
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Status      string // CONFIRMED, TENTATIVE, CANCELLED
+	Transparent bool   // TRANSP:TRANSPARENT คือไม่นับเป็นเวลาไม่ว่าง
+	Recurring   bool   // มี RRULE ใช้ ExpandICalEvent เพื่อแตกเป็นแต่ละครั้ง
+	RRule       string
+	ExDates     []time.Time
+	AllDay      bool
+}
+
+// maxICalOccurrences จำนวนครั้งสูงสุดที่แตกจาก RRULE หนึ่งรายการ กันกฎที่ไม่มีวันสิ้นสุด
+const maxICalOccurrences = 1000
+
+var icalWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+const icalTZID = "Asia/Bangkok"
+
+// RenderICalendar สร้างไฟล์ iCalendar พร้อม VTIMEZONE ของ Asia/Bangkok
+// เวลาทั้งหมดจะถูกเขียนเป็นเวลาท้องถิ่นไทยอ้างอิง TZID
+func RenderICalendar(name string, events []ICalEvent) string {
+	var b strings.Builder
+	writeLine := func(line string) {
+		b.WriteString(foldICalLine(line))
+		b.WriteString("\r\n")
+	}
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//Fitness App//Schedules//TH")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + escapeICalText(name))
+	writeLine("X-WR-TIMEZONE:" + icalTZID)
+
+	// ประเทศไทยไม่มีเวลาออมแสง จึงมีเพียง STANDARD เดียวที่ +0700
+	writeLine("BEGIN:VTIMEZONE")
+	writeLine("TZID:" + icalTZID)
+	writeLine("X-LIC-LOCATION:" + icalTZID)
+	writeLine("BEGIN:STANDARD")
+	writeLine("TZOFFSETFROM:+0700")
+	writeLine("TZOFFSETTO:+0700")
+	writeLine("TZNAME:ICT")
+	writeLine("DTSTART:19700101T000000")
+	writeLine("END:STANDARD")
+	writeLine("END:VTIMEZONE")
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, e := range events {
+		writeLine("BEGIN:VEVENT")
+		writeLine("UID:" + e.UID)
+		writeLine("DTSTAMP:" + stamp)
+		writeLine("DTSTART;TZID=" + icalTZID + ":" + e.Start.In(bangkok).Format("20060102T150405"))
+		writeLine("DTEND;TZID=" + icalTZID + ":" + e.End.In(bangkok).Format("20060102T150405"))
+		writeLine("SUMMARY:" + escapeICalText(e.Summary))
+		if e.Description != "" {
+			writeLine("DESCRIPTION:" + escapeICalText(e.Description))
+		}
+		if e.Location != "" {
+			writeLine("LOCATION:" + escapeICalText(e.Location))
+		}
+		if e.Status != "" {
+			writeLine("STATUS:" + e.Status)
+		}
+		if e.Transparent {
+			writeLine("TRANSP:TRANSPARENT")
+		}
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+	return b.String()
+}
+
+// ParseICalendar อ่านเหตุการณ์ VEVENT และช่วง FREEBUSY จากไฟล์ iCalendar
+// เวลาที่มี TZID จะตีความตาม VTIMEZONE ในไฟล์หรือฐานข้อมูลเขตเวลาของระบบ
+// ส่วนเวลาแบบ floating (ไม่มี TZID และไม่ลงท้าย Z) ถือเป็นเวลาไทย
+func ParseICalendar(r io.Reader) ([]ICalEvent, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := map[string]*time.Location{}
+	var events []ICalEvent
+	var current *ICalEvent
+	var duration time.Duration
+	var tzid string
+	inTimezone, inFreeBusy := false, false
+
+	for _, line := range lines {
+		name, params, value := splitICalProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VTIMEZONE":
+			inTimezone, tzid = true, ""
+		case name == "END" && value == "VTIMEZONE":
+			inTimezone = false
+		case inTimezone && name == "TZID":
+			tzid = value
+		case inTimezone && name == "TZOFFSETTO" && tzid != "":
+			// ใช้ออฟเซ็ตจาก VTIMEZONE เฉพาะเมื่อระบบไม่รู้จัก TZID นั้น
+			if _, ok := zones[tzid]; !ok {
+				if loc, err := time.LoadLocation(tzid); err == nil {
+					zones[tzid] = loc
+				} else if offset, ok := parseICalOffset(value); ok {
+					zones[tzid] = time.FixedZone(tzid, offset)
+				}
+			}
+
+		case name == "BEGIN" && value == "VFREEBUSY":
+			inFreeBusy = true
+		case name == "END" && value == "VFREEBUSY":
+			inFreeBusy = false
+		case inFreeBusy && name == "FREEBUSY":
+			if params["FBTYPE"] == "FREE" {
+				continue
+			}
+			for _, period := range strings.Split(value, ",") {
+				start, end, err := parseICalPeriod(period, params["TZID"], zones)
+				if err != nil {
+					return nil, err
+				}
+				events = append(events, ICalEvent{
+					UID:     fmt.Sprintf("freebusy-%d", start.Unix()),
+					Summary: "Busy",
+					Start:   start,
+					End:     end,
+				})
+			}
+
+		case name == "BEGIN" && value == "VEVENT":
+			current, duration = &ICalEvent{}, 0
+		case name == "END" && value == "VEVENT" && current != nil:
+			if current.End.IsZero() {
+				switch {
+				case duration > 0:
+					current.End = current.Start.Add(duration)
+				case current.AllDay:
+					current.End = current.Start.AddDate(0, 0, 1)
+				default:
+					current.End = current.Start
+				}
+			}
+			events = append(events, *current)
+			current = nil
+		case current != nil:
+			switch name {
+			case "UID":
+				current.UID = value
+			case "SUMMARY":
+				current.Summary = unescapeICalText(value)
+			case "DESCRIPTION":
+				current.Description = unescapeICalText(value)
+			case "LOCATION":
+				current.Location = unescapeICalText(value)
+			case "STATUS":
+				current.Status = strings.ToUpper(value)
+			case "TRANSP":
+				current.Transparent = strings.ToUpper(value) == "TRANSPARENT"
+			case "RRULE":
+				current.Recurring, current.RRule = true, strings.ToUpper(value)
+			case "EXDATE":
+				for _, v := range strings.Split(value, ",") {
+					t, _, err := parseICalTime(v, params, zones)
+					if err != nil {
+						return nil, err
+					}
+					current.ExDates = append(current.ExDates, t)
+				}
+			case "DTSTART":
+				t, allDay, err := parseICalTime(value, params, zones)
+				if err != nil {
+					return nil, err
+				}
+				current.Start, current.AllDay = t, allDay
+			case "DTEND":
+				t, _, err := parseICalTime(value, params, zones)
+				if err != nil {
+					return nil, err
+				}
+				current.End = t
+			case "DURATION":
+				d, err := parseICalDuration(value)
+				if err != nil {
+					return nil, err
+				}
+				duration = d
+			}
+		}
+	}
+	return events, nil
+}
+
+// ExpandICalEvent แตกเหตุการณ์ซ้ำเป็นแต่ละครั้งที่ทับช่วง from-to (เหตุการณ์ไม่ซ้ำคืนตัวเองถ้าอยู่ในช่วง)
+// รองรับ FREQ=DAILY/WEEKLY/MONTHLY/YEARLY กับ INTERVAL, COUNT, UNTIL, BYDAY (ไม่มีลำดับนำหน้า) และ EXDATE
+func ExpandICalEvent(e ICalEvent, from, to time.Time) ([]ICalEvent, error) {
+	inRange := func(start, end time.Time) bool { return end.After(from) && start.Before(to) }
+	if !e.Recurring {
+		if inRange(e.Start, e.End) {
+			return []ICalEvent{e}, nil
+		}
+		return nil, nil
+	}
+
+	rule := map[string]string{}
+	for _, part := range strings.Split(e.RRule, ";") {
+		if kv := strings.SplitN(part, "=", 2); len(kv) == 2 {
+			rule[kv[0]] = kv[1]
+		}
+	}
+	for key := range rule {
+		switch key {
+		case "FREQ", "INTERVAL", "COUNT", "UNTIL", "BYDAY", "WKST":
+		default:
+			return nil, fmt.Errorf("ยังไม่รองรับ RRULE ที่ใช้ %s", key)
+		}
+	}
+	interval := 1
+	if v, ok := rule["INTERVAL"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("INTERVAL ไม่ถูกต้อง: %s", v)
+		}
+		interval = n
+	}
+	count := 0
+	if v, ok := rule["COUNT"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("COUNT ไม่ถูกต้อง: %s", v)
+		}
+		count = n
+	}
+	var until time.Time
+	if v, ok := rule["UNTIL"]; ok {
+		t, allDay, err := parseICalTime(v, map[string]string{}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("UNTIL ไม่ถูกต้อง: %s", v)
+		}
+		if allDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		until = t
+	}
+	var byDay []time.Weekday
+	if v, ok := rule["BYDAY"]; ok {
+		for _, code := range strings.Split(v, ",") {
+			day, ok := icalWeekdays[code]
+			if !ok {
+				return nil, fmt.Errorf("ยังไม่รองรับ BYDAY=%s", code)
+			}
+			byDay = append(byDay, day)
+		}
+	}
+	freq := rule["FREQ"]
+	if freq != "DAILY" && freq != "WEEKLY" && freq != "MONTHLY" && freq != "YEARLY" {
+		return nil, fmt.Errorf("ยังไม่รองรับ FREQ=%s", freq)
+	}
+	if len(byDay) > 0 && freq != "DAILY" && freq != "WEEKLY" {
+		return nil, fmt.Errorf("ยังไม่รองรับ BYDAY กับ FREQ=%s", freq)
+	}
+
+	// candidates คืนวันที่เริ่มของครั้งต่าง ๆ ในรอบที่ i เรียงตามเวลา (วันที่ไม่มีจริง เช่น 31 ก.พ. จะถูกข้าม)
+	start := e.Start
+	candidates := func(i int) []time.Time {
+		switch freq {
+		case "DAILY":
+			t := start.AddDate(0, 0, i*interval)
+			if len(byDay) > 0 && !containsWeekday(byDay, t.Weekday()) {
+				return nil
+			}
+			return []time.Time{t}
+		case "WEEKLY":
+			if len(byDay) == 0 {
+				return []time.Time{start.AddDate(0, 0, 7*i*interval)}
+			}
+			// สัปดาห์เริ่มวันจันทร์ (WKST=MO)
+			monday := start.AddDate(0, 0, 7*i*interval-(int(start.Weekday())+6)%7)
+			var out []time.Time
+			for offset := 0; offset < 7; offset++ {
+				t := monday.AddDate(0, 0, offset)
+				if containsWeekday(byDay, t.Weekday()) && !t.Before(start) {
+					out = append(out, t)
+				}
+			}
+			return out
+		case "MONTHLY":
+			if t := start.AddDate(0, i*interval, 0); t.Day() == start.Day() {
+				return []time.Time{t}
+			}
+			return nil
+		case "YEARLY":
+			if t := start.AddDate(i*interval, 0, 0); t.Day() == start.Day() {
+				return []time.Time{t}
+			}
+			return nil
+		}
+		return nil
+	}
+
+	// วันแรกของรอบที่ i (ไม่เกินวันของครั้งใดในรอบนั้น) ใช้หยุดเมื่อเลยช่วงที่ต้องการ
+	roundStart := func(i int) time.Time {
+		switch freq {
+		case "DAILY":
+			return start.AddDate(0, 0, i*interval)
+		case "WEEKLY":
+			return start.AddDate(0, 0, 7*i*interval-(int(start.Weekday())+6)%7)
+		case "MONTHLY":
+			return start.AddDate(0, i*interval, 0)
+		}
+		return start.AddDate(i*interval, 0, 0)
+	}
+
+	duration := e.End.Sub(e.Start)
+	var occurrences []ICalEvent
+	generated := 0
+	for i := 0; len(occurrences) < maxICalOccurrences && roundStart(i).Before(to); i++ {
+		for _, t := range candidates(i) {
+			if (!until.IsZero() && t.After(until)) || (count > 0 && generated >= count) {
+				return occurrences, nil
+			}
+			generated++
+			if containsTime(e.ExDates, t) || !inRange(t, t.Add(duration)) {
+				continue
+			}
+			occurrence := e
+			occurrence.UID = e.UID + "#" + t.UTC().Format("20060102T150405Z")
+			occurrence.Start, occurrence.End = t, t.Add(duration)
+			occurrence.Recurring, occurrence.RRule, occurrence.ExDates = false, "", nil
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	return occurrences, nil
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTime(times []time.Time, t time.Time) bool {
+	for _, x := range times {
+		if x.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitICalProperty แยก NAME;PARAM=VALUE:value
+func splitICalProperty(line string) (string, map[string]string, string) {
+	params := map[string]string{}
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), params, ""
+	}
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+func parseICalTime(value string, params map[string]string, zones map[string]*time.Location) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, bangkok)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	loc := bangkok
+	if tzid := params["TZID"]; tzid != "" {
+		if z, ok := zones[tzid]; ok {
+			loc = z
+		} else if z, err := time.LoadLocation(tzid); err == nil {
+			loc = z
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+func parseICalPeriod(period, tzid string, zones map[string]*time.Location) (time.Time, time.Time, error) {
+	parts := strings.SplitN(period, "/", 2)
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("รูปแบบช่วงเวลา FREEBUSY ไม่ถูกต้อง: %s", period)
+	}
+	params := map[string]string{"TZID": tzid}
+	start, _, err := parseICalTime(parts[0], params, zones)
+	if err != nil {
+		return start, start, err
+	}
+	if strings.HasPrefix(parts[1], "P") || strings.HasPrefix(parts[1], "+P") {
+		d, err := parseICalDuration(parts[1])
+		return start, start.Add(d), err
+	}
+	end, _, err := parseICalTime(parts[1], params, zones)
+	return start, end, err
+}
+
+var icalDurationPattern = regexp.MustCompile(`^[+]?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+func parseICalDuration(value string) (time.Duration, error) {
+	m := icalDurationPattern.FindStringSubmatch(value)
+	if m == nil {
+		return 0, fmt.Errorf("รูปแบบ DURATION ไม่ถูกต้อง: %s", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] != "" {
+			n, _ := strconv.Atoi(m[i+1])
+			d += time.Duration(n) * unit
+		}
+	}
+	return d, nil
+}
+
+// parseICalOffset แปลง +0700 เป็นวินาที
+func parseICalOffset(value string) (int, bool) {
+	if len(value) < 5 {
+		return 0, false
+	}
+	sign := 1
+	if value[0] == '-' {
+		sign = -1
+	}
+	h, err1 := strconv.Atoi(value[1:3])
+	m, err2 := strconv.Atoi(value[3:5])
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return sign * (h*3600 + m*60), true
+}
+
+// foldICalLine ตัดบรรทัดที่ยาวเกิน 75 octets โดยไม่ตัดกลางตัวอักษร UTF-8
+func foldICalLine(line string) string {
+	if len(line) <= 75 {
+		return line
+	}
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
+
+func escapeICalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+func unescapeICalText(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// VATRate อัตราภาษีมูลค่าเพิ่ม (%) ราคาแพ็กเกจเป็นราคารวมภาษีแล้ว
+const VATRate = 7.0
+
+// ชนิดเอกสาร
+const (
+	DocTypeInvoice    = "invoice"
+	DocTypeCreditNote = "credit_note"
+)
+
+var ErrInvoiceNotFound = errors.New("ไม่พบเอกสาร")
+
+// GetBranches สาขาทั้งหมด
+func GetBranches() ([]entity.Branch, error) {
+	var branches []entity.Branch
+	err := config.DB().Order("branch_no").Find(&branches).Error
+	return branches, err
+}
+
+// SaveBranch เพิ่มหรือแก้ไขสาขา (id = 0 เพิ่มใหม่) ถ้าตั้งเป็นสาขาหลัก สาขาอื่นจะไม่เป็นสาขาหลัก
+// เอกสารที่ออกไปแล้วไม่เปลี่ยนตาม เพราะเก็บข้อมูลผู้ขาย ณ วันที่ออกไว้ในเอกสาร
+func SaveBranch(id uint, branch entity.Branch) (entity.Branch, error) {
+	branch.BranchNo = strings.TrimSpace(branch.BranchNo)
+	if len(branch.BranchNo) != 5 || strings.Trim(branch.BranchNo, "0123456789") != "" {
+		return branch, fmt.Errorf("รหัสสาขาต้องเป็นตัวเลข 5 หลัก (00000 = สำนักงานใหญ่)")
+	}
+	if err := validateTaxID(branch.TaxID); err != nil {
+		return branch, err
+	}
+	if strings.TrimSpace(branch.Name) == "" {
+		return branch, fmt.Errorf("กรุณาระบุชื่อผู้ขาย")
+	}
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if id != 0 {
+			var existing entity.Branch
+			if err := tx.First(&existing, id).Error; err != nil {
+				return err
+			}
+			branch.ID, branch.CreatedAt = existing.ID, existing.CreatedAt
+		}
+		if branch.IsDefault {
+			if err := tx.Model(&entity.Branch{}).Where("id <> ?", branch.ID).Update("is_default", false).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(&branch).Error
+	})
+	return branch, err
+}
+
+// GetBillingProfile ข้อมูลผู้ซื้อสำหรับใบกำกับภาษี (ยังไม่เคยบันทึกคืนค่าว่าง)
+func GetBillingProfile(userID uint) (entity.BillingProfile, error) {
+	profile := entity.BillingProfile{UserID: userID}
+	err := config.DB().Where("user_id = ?", userID).First(&profile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return profile, nil
+	}
+	return profile, err
+}
+
+// SaveBillingProfile บันทึกข้อมูลผู้ซื้อ ใช้กับเอกสารที่ออกหลังจากนี้
+func SaveBillingProfile(userID uint, input entity.BillingProfile) (entity.BillingProfile, error) {
+	if input.TaxID != "" {
+		if err := validateTaxID(input.TaxID); err != nil {
+			return input, err
+		}
+	}
+	if input.BranchNo != "" && (len(input.BranchNo) != 5 || strings.Trim(input.BranchNo, "0123456789") != "") {
+		return input, fmt.Errorf("รหัสสาขาของผู้ซื้อต้องเป็นตัวเลข 5 หลัก")
+	}
+	profile, err := GetBillingProfile(userID)
+	if err != nil {
+		return profile, err
+	}
+	profile.Name = strings.TrimSpace(input.Name)
+	profile.TaxID = input.TaxID
+	profile.BranchNo = input.BranchNo
+	profile.Address = strings.TrimSpace(input.Address)
+	return profile, config.DB().Save(&profile).Error
+}
+
+// GetInvoice เอกสารตาม ID
+func GetInvoice(id uint) (entity.Invoice, error) {
+	var invoice entity.Invoice
+	err := config.DB().Preload("PaymentOrder").First(&invoice, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return invoice, ErrInvoiceNotFound
+	}
+	return invoice, err
+}
+
+// GetUserInvoices เอกสารทั้งหมดของผู้ใช้ เรียงจากใหม่ไปเก่า
+func GetUserInvoices(userID uint) ([]entity.Invoice, error) {
+	var invoices []entity.Invoice
+	err := config.DB().Where("user_id = ?", userID).Order("issued_at desc, id desc").Find(&invoices).Error
+	return invoices, err
+}
+
+// IssueCreditNote ออกใบลดหนี้อ้างถึงใบกำกับภาษี amountSatang = 0 คืนเต็มยอดที่เหลือ
+// ถ้าคืนครบยอด คำสั่งซื้อจะเป็น refunded และรอบสมาชิกที่เกิดจากคำสั่งซื้อนี้ถูกยกเลิก
+func IssueCreditNote(invoiceID uint, amountSatang int64, reason string) (entity.Invoice, error) {
+	var note entity.Invoice
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return note, fmt.Errorf("กรุณาระบุเหตุผลในการออกใบลดหนี้")
+	}
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var invoice entity.Invoice
+		if err := tx.First(&invoice, invoiceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvoiceNotFound
+			}
+			return err
+		}
+		if invoice.DocType != DocTypeInvoice {
+			return fmt.Errorf("ออกใบลดหนี้ได้เฉพาะจากใบกำกับภาษี")
+		}
+		var credited int64
+		if err := tx.Model(&entity.Invoice{}).Where("reference_id = ? AND doc_type = ?", invoice.ID, DocTypeCreditNote).
+			Select("COALESCE(SUM(total_satang), 0)").Scan(&credited).Error; err != nil {
+			return err
+		}
+		remaining := invoice.TotalSatang - credited
+		if amountSatang == 0 {
+			amountSatang = remaining
+		}
+		if amountSatang <= 0 || amountSatang > remaining {
+			return fmt.Errorf("ยอดลดหนี้ต้องมากกว่า 0 และไม่เกินยอดคงเหลือ %s บาท", formatSatang(remaining))
+		}
+
+		now := time.Now()
+		number, err := nextDocumentNumber(tx, invoice.BranchID, invoice.SellerBranchNo, DocTypeCreditNote, now)
+		if err != nil {
+			return err
+		}
+		note = invoice
+		note.Model = gorm.Model{}
+		note.PaymentOrder = nil
+		note.DocType = DocTypeCreditNote
+		note.Number = number
+		note.IssuedAt = now
+		note.ReferenceID = &invoice.ID
+		note.ReferenceNumber = invoice.Number
+		note.Reason = reason
+		note.SubtotalSatang, note.VATSatang, note.TotalSatang = splitVAT(amountSatang)
+		if err := tx.Create(&note).Error; err != nil {
+			return err
+		}
+		if amountSatang < remaining {
+			return NotifyUser(tx, invoice.UserID, "ออกใบลดหนี้", fmt.Sprintf(
+				"ออกใบลดหนี้ %s ยอด %s บาท อ้างถึง %s", note.Number, formatSatang(amountSatang), invoice.Number))
+		}
+		return refundOrder(tx, invoice, note, now)
+	})
+	return note, err
+}
+
+// issueInvoice ออกใบเสร็จรับเงิน/ใบกำกับภาษีของคำสั่งซื้อที่ชำระแล้ว (ออกครั้งเดียวต่อคำสั่งซื้อ)
+// เรียกใน transaction เดียวกับการยืนยันการชำระเงิน เลขที่เอกสารจึงไม่ขาดช่วงเมื่อ rollback
+func issueInvoice(tx *gorm.DB, order entity.PaymentOrder, now time.Time) (entity.Invoice, error) {
+	var invoice entity.Invoice
+	err := tx.Where("payment_order_id = ? AND doc_type = ?", order.ID, DocTypeInvoice).First(&invoice).Error
+	if err == nil {
+		return invoice, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return invoice, err
+	}
+
+	var branch entity.Branch
+	if order.BranchID != 0 {
+		err = tx.First(&branch, order.BranchID).Error
+	} else {
+		err = tx.Order("is_default desc, branch_no").First(&branch).Error
+	}
+	if err != nil {
+		return invoice, fmt.Errorf("ไม่พบสาขาสำหรับออกใบกำกับภาษี: %w", err)
+	}
+	var user entity.Users
+	if err := tx.First(&user, order.UserID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return invoice, err
+	}
+	var profile entity.BillingProfile
+	if err := tx.Where("user_id = ?", order.UserID).First(&profile).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return invoice, err
+	}
+	var pkg entity.Package
+	if err := tx.First(&pkg, order.PackageID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return invoice, err
+	}
+
+	number, err := nextDocumentNumber(tx, branch.ID, branch.BranchNo, DocTypeInvoice, now)
+	if err != nil {
+		return invoice, err
+	}
+	period := "รายเดือน"
+	periodEN := "monthly"
+	if years, _, err := PackagePeriod(pkg); err == nil && years > 0 {
+		period, periodEN = "รายปี", "yearly"
+	}
+
+	invoice = entity.Invoice{
+		DocType:         DocTypeInvoice,
+		Number:          number,
+		IssuedAt:        now,
+		BranchID:        branch.ID,
+		PaymentOrderID:  order.ID,
+		UserID:          order.UserID,
+		SellerName:      branch.Name,
+		SellerNameEN:    branch.NameEN,
+		SellerAddress:   branch.Address,
+		SellerAddressEN: branch.AddressEN,
+		SellerTaxID:     branch.TaxID,
+		SellerBranchNo:  branch.BranchNo,
+		BuyerName:       strings.TrimSpace(user.FirstName + " " + user.LastName),
+		BuyerEmail:      user.Email,
+		BuyerAddress:    profile.Address,
+		BuyerTaxID:      profile.TaxID,
+		BuyerBranchNo:   profile.BranchNo,
+		Description:     fmt.Sprintf("ค่าสมาชิกแพ็กเกจ %s (%s)", pkg.PackageName, period),
+		DescriptionEN:   fmt.Sprintf("Membership package %s (%s)", pkg.PackageName, periodEN),
+		VATRate:         VATRate,
+	}
+	switch order.Purpose {
+	case OrderPurposeRenewal:
+		invoice.Description = fmt.Sprintf("ค่าต่ออายุสมาชิกแพ็กเกจ %s (%s)", pkg.PackageName, period)
+		invoice.DescriptionEN = fmt.Sprintf("Membership renewal %s (%s)", pkg.PackageName, periodEN)
+	case OrderPurposeChange:
+		invoice.Description = fmt.Sprintf("ค่าเปลี่ยนเป็นแพ็กเกจ %s (%s)", pkg.PackageName, period)
+		invoice.DescriptionEN = fmt.Sprintf("Membership change to %s (%s)", pkg.PackageName, periodEN)
+	}
+	if order.ProrationCredit > 0 {
+		invoice.Description += fmt.Sprintf(" หักเครดิตรอบเดิม %d บาท", order.ProrationCredit)
+		invoice.DescriptionEN += fmt.Sprintf(", less %d THB credit from previous period", order.ProrationCredit)
+	}
+	if profile.Name != "" {
+		invoice.BuyerName = profile.Name
+	}
+	invoice.SubtotalSatang, invoice.VATSatang, invoice.TotalSatang = splitVAT(int64(order.Amount) * 100)
+	return invoice, tx.Create(&invoice).Error
+}
+
+// refundOrder คืนเงินเต็มจำนวน: คำสั่งซื้อเป็น refunded และยกเลิกรอบสมาชิกที่เกิดจากคำสั่งซื้อนี้
+func refundOrder(tx *gorm.DB, invoice, note entity.Invoice, now time.Time) error {
+	var order entity.PaymentOrder
+	if err := tx.First(&order, invoice.PaymentOrderID).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&order).Update("status", "refunded").Error; err != nil {
+		return err
+	}
+	if order.PackageMemberID != nil {
+		if err := tx.Model(&entity.PackageMember{}).
+			Where("id = ? AND status IN ?", *order.PackageMemberID, []string{"active", "frozen", "scheduled"}).
+			Updates(map[string]interface{}{"status": "cancelled", "cancelled_at": now, "auto_renew": false}).Error; err != nil {
+			return err
+		}
+		if err := cancelScheduledRenewals(tx, order.UserID, now); err != nil {
+			return err
+		}
+	}
+	return NotifyUser(tx, invoice.UserID, "คืนเงินแล้ว", fmt.Sprintf(
+		"ออกใบลดหนี้ %s คืนเงิน %s บาท สำหรับคำสั่งซื้อ %s", note.Number, formatSatang(note.TotalSatang), order.OrderNo))
+}
+
+// nextDocumentNumber เลขที่เอกสารถัดไป รันต่อเนื่องแยกตามสาขา ชนิดเอกสาร และปี เช่น INV-00000-2026-000001
+func nextDocumentNumber(tx *gorm.DB, branchID uint, branchNo, docType string, at time.Time) (string, error) {
+	year := at.In(bangkok).Year()
+	seq := entity.InvoiceSequence{BranchID: branchID, DocType: docType, Year: year}
+	if err := tx.Where(&seq).FirstOrCreate(&seq).Error; err != nil {
+		return "", err
+	}
+	seq.LastNo++
+	if err := tx.Model(&seq).Update("last_no", seq.LastNo).Error; err != nil {
+		return "", err
+	}
+	prefix := "INV"
+	if docType == DocTypeCreditNote {
+		prefix = "CN"
+	}
+	return fmt.Sprintf("%s-%s-%d-%06d", prefix, branchNo, year, seq.LastNo), nil
+}
+
+// splitVAT แยกยอดรวมภาษีเป็นมูลค่าก่อนภาษีและภาษี (สตางค์) ปัดเศษที่มูลค่าก่อนภาษี
+func splitVAT(totalSatang int64) (subtotal, vat, total int64) {
+	subtotal = int64(math.Round(float64(totalSatang) * 100 / (100 + VATRate)))
+	return subtotal, totalSatang - subtotal, totalSatang
+}
+
+// validateTaxID ตรวจเลขประจำตัวผู้เสียภาษี 13 หลักพร้อมหลักตรวจสอบ
+func validateTaxID(id string) error {
+	if len(id) != 13 || strings.Trim(id, "0123456789") != "" {
+		return fmt.Errorf("เลขประจำตัวผู้เสียภาษีต้องเป็นตัวเลข 13 หลัก")
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(id[i]-'0') * (13 - i)
+	}
+	if (11-sum%11)%10 != int(id[12]-'0') {
+		return fmt.Errorf("เลขประจำตัวผู้เสียภาษีไม่ถูกต้อง")
+	}
+	return nil
+}
+
+// formatSatang จำนวนเงินแบบมีคอมมา เช่น 1,290.00
+func formatSatang(satang int64) string {
+	sign := ""
+	if satang < 0 {
+		sign, satang = "-", -satang
+	}
+	baht := fmt.Sprintf("%d", satang/100)
+	for i := len(baht) - 3; i > 0; i -= 3 {
+		baht = baht[:i] + "," + baht[i:]
+	}
+	return fmt.Sprintf("%s%s.%02d", sign, baht, satang%100)
+}
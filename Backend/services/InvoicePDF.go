@@ -0,0 +1,400 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/pdfdoc"
+)
+
+// InvoiceFontPath ฟอนต์ TrueType ภาษาไทยที่ฝังใน PDF ค่าเริ่มต้นคือ FreeSerif ที่มากับโปรเจกต์ (ดู assets/fonts/README.md)
+// เปลี่ยนได้ด้วย INVOICE_FONT_PATH (เช่น Sarabun หรือ TH Sarabun New) path แบบสัมพัทธ์หาจากโฟลเดอร์ของไฟล์โปรแกรมก่อน
+// แล้วจึงโฟลเดอร์ที่รันอยู่
+var InvoiceFontPath = config.Env("INVOICE_FONT_PATH", filepath.Join("assets", "fonts", "FreeSerif.ttf"))
+
+// ErrInvoiceFontMissing ไม่มีไฟล์ฟอนต์ภาษาไทย ยังสร้างเอกสารภาษาอังกฤษได้ด้วยฟอนต์มาตรฐานของ PDF
+var ErrInvoiceFontMissing = errors.New("ไม่พบฟอนต์ภาษาไทยสำหรับสร้าง PDF (ตั้งค่า INVOICE_FONT_PATH) ใช้ lang=en แทนได้")
+
+var invoiceLabels = map[string]map[string]string{
+	"th": {
+		DocTypeInvoice:    "ใบเสร็จรับเงิน/ใบกำกับภาษี",
+		DocTypeCreditNote: "ใบลดหนี้",
+		"original":        "ต้นฉบับ",
+		"tax_id":          "เลขประจำตัวผู้เสียภาษี",
+		"head_office":     "สำนักงานใหญ่",
+		"branch":          "สาขาที่",
+		"number":          "เลขที่",
+		"date":            "วันที่",
+		"reference":       "อ้างอิงใบกำกับภาษี",
+		"order":           "คำสั่งซื้อ",
+		"payment":         "ชำระโดย",
+		"customer":        "ลูกค้า",
+		"email":           "อีเมล",
+		"no":              "ลำดับ",
+		"description":     "รายการ",
+		"qty":             "จำนวน",
+		"amount":          "จำนวนเงิน",
+		"subtotal":        "มูลค่าก่อนภาษีมูลค่าเพิ่ม",
+		"vat":             "ภาษีมูลค่าเพิ่ม %g%%",
+		"total":           "จำนวนเงินรวมทั้งสิ้น",
+		"reason":          "เหตุผลที่ลดหนี้",
+		"original_value":  "มูลค่าตามใบกำกับภาษีเดิม",
+		"correct_value":   "มูลค่าที่ถูกต้อง",
+		"difference":      "ผลต่าง",
+		"footer":          "เอกสารนี้จัดทำด้วยระบบคอมพิวเตอร์",
+		"promptpay":       "พร้อมเพย์",
+		"counter":         "ชำระที่เคาน์เตอร์",
+	},
+	"en": {
+		DocTypeInvoice:    "Receipt / Tax Invoice",
+		DocTypeCreditNote: "Credit Note",
+		"original":        "Original",
+		"tax_id":          "Tax ID",
+		"head_office":     "Head office",
+		"branch":          "Branch",
+		"number":          "No.",
+		"date":            "Date",
+		"reference":       "Reference invoice",
+		"order":           "Order",
+		"payment":         "Paid by",
+		"customer":        "Customer",
+		"email":           "Email",
+		"no":              "#",
+		"description":     "Description",
+		"qty":             "Qty",
+		"amount":          "Amount (THB)",
+		"subtotal":        "Amount before VAT",
+		"vat":             "VAT %g%%",
+		"total":           "Grand total",
+		"reason":          "Reason",
+		"original_value":  "Original invoice value",
+		"correct_value":   "Corrected value",
+		"difference":      "Difference",
+		"footer":          "This document is computer generated.",
+		"promptpay":       "PromptPay",
+		"counter":         "Paid at counter",
+	},
+}
+
+// invoiceFontFile ตำแหน่งไฟล์ฟอนต์จริงของ InvoiceFontPath (ไม่ขึ้นกับว่ารันโปรแกรมจากโฟลเดอร์ไหน)
+func invoiceFontFile() (string, bool) {
+	candidates := []string{InvoiceFontPath}
+	if !filepath.IsAbs(InvoiceFontPath) {
+		if exe, err := os.Executable(); err == nil {
+			candidates = []string{filepath.Join(filepath.Dir(exe), InvoiceFontPath), InvoiceFontPath}
+		}
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+var thaiMonths = []string{"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+	"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+
+// RenderInvoicePDF สร้าง PDF ของเอกสาร lang: th (ต้องมีฟอนต์ที่ InvoiceFontPath) หรือ en
+// ถ้าไม่มีฟอนต์ ภาษาอังกฤษใช้ Helvetica และอักขระนอก ASCII (เช่นชื่อภาษาไทย) จะแสดงเป็น ?
+func RenderInvoicePDF(invoice entity.Invoice, lang string) ([]byte, error) {
+	if lang == "" {
+		lang = "th"
+	}
+	labels, ok := invoiceLabels[lang]
+	if !ok {
+		return nil, fmt.Errorf("รองรับภาษา th และ en")
+	}
+	var font *pdfdoc.Font
+	if path, ok := invoiceFontFile(); ok {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			font, err = pdfdoc.ParseTrueType(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("อ่านฟอนต์ %s ไม่สำเร็จ: %w", path, err)
+		}
+	} else if lang == "th" {
+		return nil, ErrInvoiceFontMissing
+	}
+
+	// ใบลดหนี้แสดงมูลค่าเดิม มูลค่าที่ถูกต้อง และผลต่าง
+	var original *entity.Invoice
+	var creditedSoFar int64
+	if invoice.DocType == DocTypeCreditNote && invoice.ReferenceID != nil {
+		var ref entity.Invoice
+		if err := config.DB().First(&ref, *invoice.ReferenceID).Error; err != nil {
+			return nil, err
+		}
+		original = &ref
+		if err := config.DB().Model(&entity.Invoice{}).
+			Where("reference_id = ? AND doc_type = ? AND id <= ?", ref.ID, DocTypeCreditNote, invoice.ID).
+			Select("COALESCE(SUM(total_satang), 0)").Scan(&creditedSoFar).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	doc := pdfdoc.New(font)
+	page := doc.AddPage()
+	const left, right = 40.0, pdfdoc.A4Width - 40
+	pick := func(th, en string) string {
+		if lang == "en" && en != "" {
+			return en
+		}
+		return th
+	}
+	branchLabel := func(no string) string {
+		if no == "" || no == "00000" {
+			return labels["head_office"]
+		}
+		return labels["branch"] + " " + no
+	}
+
+	// ผู้ขาย (ซ้าย) และชื่อเอกสาร (ขวา)
+	page.BoldText(left, 60, 16, pick(invoice.SellerName, invoice.SellerNameEN))
+	y := 78.0
+	for _, line := range wrapText(doc, pick(invoice.SellerAddress, invoice.SellerAddressEN), 10, 300) {
+		page.Text(left, y, 10, line)
+		y += 13
+	}
+	page.Text(left, y, 10, fmt.Sprintf("%s %s (%s)", labels["tax_id"], invoice.SellerTaxID, branchLabel(invoice.SellerBranchNo)))
+	page.TextRight(right, 60, 16, labels[invoice.DocType], true)
+	page.TextRight(right, 78, 10, labels["original"], false)
+
+	// รายละเอียดเอกสาร
+	details := [][2]string{
+		{labels["number"], invoice.Number},
+		{labels["date"], formatInvoiceDate(invoice.IssuedAt, lang)},
+	}
+	if invoice.ReferenceNumber != "" {
+		details = append(details, [2]string{labels["reference"], invoice.ReferenceNumber})
+	}
+	if order := invoice.PaymentOrder; order != nil {
+		details = append(details, [2]string{labels["order"], order.OrderNo})
+		if method, ok := labels[order.Method]; ok {
+			details = append(details, [2]string{labels["payment"], method})
+		}
+	}
+	dy := 100.0
+	for _, d := range details {
+		page.Text(right-200, dy, 10, d[0])
+		page.TextRight(right, dy, 10, d[1], false)
+		dy += 13
+	}
+
+	// ผู้ซื้อ
+	y = max(y+24, dy+10)
+	page.FillRect(left, y-12, right-left, 18, 0.92)
+	page.BoldText(left+6, y+1, 11, labels["customer"])
+	y += 22
+	page.Text(left, y, 10, invoice.BuyerName)
+	for _, line := range wrapText(doc, invoice.BuyerAddress, 10, right-left) {
+		y += 13
+		page.Text(left, y, 10, line)
+	}
+	if invoice.BuyerTaxID != "" {
+		y += 13
+		page.Text(left, y, 10, fmt.Sprintf("%s %s (%s)", labels["tax_id"], invoice.BuyerTaxID, branchLabel(invoice.BuyerBranchNo)))
+	}
+	if invoice.BuyerEmail != "" {
+		y += 13
+		page.Text(left, y, 10, labels["email"]+" "+invoice.BuyerEmail)
+	}
+
+	// ตารางรายการ
+	y += 26
+	colNo, colDesc, colQty := left+6, left+50, right-160
+	page.FillRect(left, y-12, right-left, 18, 0.85)
+	page.BoldText(colNo, y+1, 10, labels["no"])
+	page.BoldText(colDesc, y+1, 10, labels["description"])
+	page.TextRight(colQty+20, y+1, 10, labels["qty"], true)
+	page.TextRight(right-6, y+1, 10, labels["amount"], true)
+	y += 22
+	page.Text(colNo, y, 10, "1")
+	descLines := wrapText(doc, pick(invoice.Description, invoice.DescriptionEN), 10, colQty-colDesc-30)
+	for i, line := range descLines {
+		page.Text(colDesc, y+float64(i)*13, 10, line)
+	}
+	page.TextRight(colQty+20, y, 10, "1", false)
+	page.TextRight(right-6, y, 10, formatSatang(invoice.SubtotalSatang), false)
+	y += float64(len(descLines))*13 + 4
+	page.Line(left, y, right, y, 0.5)
+
+	// ยอดรวม
+	totals := [][2]string{}
+	if original != nil {
+		totals = append(totals,
+			[2]string{labels["original_value"], formatSatang(original.TotalSatang)},
+			[2]string{labels["correct_value"], formatSatang(original.TotalSatang - creditedSoFar)},
+			[2]string{labels["difference"], formatSatang(invoice.TotalSatang)},
+		)
+	}
+	totals = append(totals,
+		[2]string{labels["subtotal"], formatSatang(invoice.SubtotalSatang)},
+		[2]string{fmt.Sprintf(labels["vat"], invoice.VATRate), formatSatang(invoice.VATSatang)},
+	)
+	y += 18
+	for _, t := range totals {
+		page.TextRight(right-120, y, 10, t[0], false)
+		page.TextRight(right-6, y, 10, t[1], false)
+		y += 15
+	}
+	page.FillRect(right-300, y-11, 300, 18, 0.92)
+	page.TextRight(right-120, y+2, 11, labels["total"], true)
+	page.TextRight(right-6, y+2, 11, formatSatang(invoice.TotalSatang), true)
+	if lang == "th" {
+		y += 22
+		page.TextRight(right-6, y, 10, "("+ThaiBahtText(invoice.TotalSatang)+")", false)
+	}
+
+	if invoice.Reason != "" {
+		y += 30
+		page.BoldText(left, y, 10, labels["reason"])
+		for _, line := range wrapText(doc, invoice.Reason, 10, right-left) {
+			y += 13
+			page.Text(left, y, 10, line)
+		}
+	}
+
+	page.Line(left, pdfdoc.A4Height-60, right, pdfdoc.A4Height-60, 0.5)
+	page.TextCenter(pdfdoc.A4Width/2, pdfdoc.A4Height-45, 9, labels["footer"], false)
+	return doc.Bytes()
+}
+
+// ThaiBahtText อ่านจำนวนเงินเป็นตัวอักษรภาษาไทย เช่น 1,290.50 → หนึ่งพันสองร้อยเก้าสิบบาทห้าสิบสตางค์
+func ThaiBahtText(satang int64) string {
+	if satang < 0 {
+		return "ลบ" + ThaiBahtText(-satang)
+	}
+	baht, rest := satang/100, satang%100
+	switch {
+	case baht == 0 && rest == 0:
+		return "ศูนย์บาทถ้วน"
+	case rest == 0:
+		return thaiNumberText(baht) + "บาทถ้วน"
+	case baht == 0:
+		return thaiNumberText(rest) + "สตางค์"
+	}
+	return thaiNumberText(baht) + "บาท" + thaiNumberText(rest) + "สตางค์"
+}
+
+func thaiNumberText(n int64) string {
+	digits := []string{"", "หนึ่ง", "สอง", "สาม", "สี่", "ห้า", "หก", "เจ็ด", "แปด", "เก้า"}
+	places := []string{"", "สิบ", "ร้อย", "พัน", "หมื่น", "แสน"}
+	var b strings.Builder
+	// หลักหน่วยเป็น 1 อ่าน เอ็ด เมื่อมีหลักที่สูงกว่า รวมถึงหลักล้าน (1,000,001 = หนึ่งล้านเอ็ด)
+	higher := n >= 1000000
+	if n >= 1000000 {
+		b.WriteString(thaiNumberText(n / 1000000))
+		b.WriteString("ล้าน")
+		n %= 1000000
+	}
+	higher = higher || n > 9
+	for place := 5; place >= 0; place-- {
+		pow := int64(1)
+		for i := 0; i < place; i++ {
+			pow *= 10
+		}
+		d := n / pow
+		n %= pow
+		switch {
+		case d == 0:
+			continue
+		case place == 1 && d == 1:
+			// สิบ ไม่ใช่ หนึ่งสิบ
+		case place == 1 && d == 2:
+			b.WriteString("ยี่")
+		case place == 0 && d == 1 && higher:
+			b.WriteString("เอ็ด")
+			continue
+		default:
+			b.WriteString(digits[d])
+		}
+		b.WriteString(places[place])
+	}
+	return b.String()
+}
+
+// wrapText ตัดบรรทัดไม่ให้เกิน maxWidth ตัดที่ช่องว่างก่อน ถ้าไม่มี (เช่นภาษาไทย) ตัดระหว่างตัวอักษร
+// โดยไม่แยกสระบน/ล่างและวรรณยุกต์ออกจากพยัญชนะ
+func wrapText(doc *pdfdoc.Document, s string, size, maxWidth float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(s), "\n") {
+		line := ""
+		for _, word := range splitKeepSpaces(paragraph) {
+			if doc.TextWidth(line+word, size) <= maxWidth {
+				line += word
+				continue
+			}
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, strings.TrimSpace(line))
+				line = ""
+			}
+			word = strings.TrimLeft(word, " ")
+			for doc.TextWidth(word, size) > maxWidth {
+				cut := fitPrefix(doc, word, size, maxWidth)
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	return lines
+}
+
+// splitKeepSpaces แยกคำโดยให้ช่องว่างติดไปกับคำถัดไป
+func splitKeepSpaces(s string) []string {
+	var words []string
+	start := 0
+	for i := 1; i < len(s); i++ {
+		if s[i] == ' ' && s[i-1] != ' ' {
+			words = append(words, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// fitPrefix ความยาว (ไบต์) ของส่วนต้นที่ยาวที่สุดที่ไม่เกิน maxWidth และไม่ตัดกลางกลุ่มอักษร
+func fitPrefix(doc *pdfdoc.Document, s string, size, maxWidth float64) int {
+	cut := 0
+	for i, r := range s {
+		if i > 0 && !unicode.Is(unicode.Mn, r) {
+			if doc.TextWidth(s[:i], size) > maxWidth {
+				break
+			}
+			cut = i
+		}
+	}
+	if cut == 0 {
+		// ตัวอักษรแรกกว้างกว่าบรรทัด ตัดหลังกลุ่มอักษรแรก
+		for i, r := range s {
+			if i > 0 && !unicode.Is(unicode.Mn, r) {
+				return i
+			}
+		}
+		return len(s)
+	}
+	return cut
+}
+
+func formatInvoiceDate(t time.Time, lang string) string {
+	t = t.In(bangkok)
+	if lang == "th" {
+		return fmt.Sprintf("%d %s %d", t.Day(), thaiMonths[t.Month()-1], t.Year()+543)
+	}
+	return t.Format("2 January 2006")
+}
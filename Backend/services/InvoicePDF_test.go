@@ -0,0 +1,29 @@
+package services
+
+import "testing"
+
+func TestThaiBahtText(t *testing.T) {
+	tests := []struct {
+		satang int64
+		want   string
+	}{
+		{0, "ศูนย์บาทถ้วน"},
+		{100, "หนึ่งบาทถ้วน"},
+		{1100, "สิบเอ็ดบาทถ้วน"},
+		{2100, "ยี่สิบเอ็ดบาทถ้วน"},
+		{10100, "หนึ่งร้อยเอ็ดบาทถ้วน"},
+		{129050, "หนึ่งพันสองร้อยเก้าสิบบาทห้าสิบสตางค์"},
+		{25, "ยี่สิบห้าสตางค์"},
+		{1000000 * 100, "หนึ่งล้านบาทถ้วน"},
+		{1000001 * 100, "หนึ่งล้านเอ็ดบาทถ้วน"},
+		{2000021 * 100, "สองล้านยี่สิบเอ็ดบาทถ้วน"},
+		{11000000 * 100, "สิบเอ็ดล้านบาทถ้วน"},
+		{21000001*100 + 1, "ยี่สิบเอ็ดล้านเอ็ดบาทหนึ่งสตางค์"},
+		{-50000, "ลบห้าร้อยบาทถ้วน"},
+	}
+	for _, tt := range tests {
+		if got := ThaiBahtText(tt.satang); got != tt.want {
+			t.Errorf("ThaiBahtText(%d) = %s ต้องการ %s", tt.satang, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import "testing"
+
+func TestSplitVAT(t *testing.T) {
+	tests := []struct {
+		total         int64
+		subtotal, vat int64
+	}{
+		{129000, 120561, 8439},
+		{10700, 10000, 700},
+		{100, 93, 7},
+		{1, 1, 0},
+		{0, 0, 0},
+	}
+	for _, tt := range tests {
+		subtotal, vat, total := splitVAT(tt.total)
+		if subtotal != tt.subtotal || vat != tt.vat || total != tt.total {
+			t.Errorf("splitVAT(%d) = %d, %d, %d ต้องการ %d, %d, %d", tt.total, subtotal, vat, total, tt.subtotal, tt.vat, tt.total)
+		}
+		if subtotal+vat != total {
+			t.Errorf("splitVAT(%d) มูลค่ารวมภาษีไม่เท่ายอดรวม", tt.total)
+		}
+	}
+}
+
+func TestValidateTaxID(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr bool
+	}{
+		{"0105561234560", false},
+		{"3101001234565", false},
+		{"0994000161123", false},
+		{"0105561234561", true}, // หลักตรวจสอบผิด
+		{"010556123456", true},  // 12 หลัก
+		{"01055612345600", true},
+		{"01055612345A0", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if err := validateTaxID(tt.id); (err != nil) != tt.wantErr {
+			t.Errorf("validateTaxID(%q) error = %v ต้องการ error %v", tt.id, err, tt.wantErr)
+		}
+	}
+}
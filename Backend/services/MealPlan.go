@@ -0,0 +1,432 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// mealShares สัดส่วนพลังงานของแต่ละมื้อที่ใช้เลือกจำนวนที่ของสูตร
+var mealShares = map[string]float64{
+	"breakfast": 0.25,
+	"lunch":     0.35,
+	"dinner":    0.30,
+	"snack":     0.10,
+}
+
+// servingSteps จำนวนที่ที่เลือกได้ต่อมื้อ
+var servingSteps = []float64{0.5, 1, 1.5, 2}
+
+const (
+	defaultMealPlanDays      = 7
+	maxMealPlanDays          = 28
+	defaultMealPlanTolerance = 10 // เปอร์เซ็นต์
+	mealPlanAttemptsPerDay   = 400
+)
+
+// MealPlanRequest เงื่อนไขการสร้างแผนอาหาร
+type MealPlanRequest struct {
+	UserID           uint     `json:"user_id"`
+	NutritionID      *uint    `json:"nutrition_id"` // nil = แผนโภชนาการล่าสุดของลูกค้า
+	Title            string   `json:"title"`
+	StartDate        string   `json:"start_date"`
+	Days             int      `json:"days"`
+	Exclusions       []string `json:"exclusions"`
+	TolerancePercent float64  `json:"tolerance_percent"`
+	Note             string   `json:"note"`
+}
+
+// MealPlanFilter เงื่อนไขดึงรายการแผนอาหาร
+type MealPlanFilter struct {
+	UserID    uint
+	TrainerID uint
+	Status    string
+}
+
+// GenerateMealPlan สร้างแผนอาหารฉบับร่างจากเป้าหมายในแผนโภชนาการ
+// แต่ละวันสุ่มชุดสูตรอาหารหลายรอบแล้วเลือกชุดที่ใกล้เป้าหมายพลังงานและสารอาหารที่สุด
+func GenerateMealPlan(req MealPlanRequest, trainerID *uint) (entity.MealPlan, error) {
+	plan := entity.MealPlan{
+		UserID:    req.UserID,
+		TrainerID: trainerID,
+		Title:     strings.TrimSpace(req.Title),
+		StartDate: req.StartDate,
+		Days:      req.Days,
+		Status:    "draft",
+		Note:      req.Note,
+	}
+	if plan.StartDate == "" {
+		plan.StartDate = time.Now().In(bangkok).Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", plan.StartDate); err != nil {
+		return plan, fmt.Errorf("รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)")
+	}
+	if plan.Days == 0 {
+		plan.Days = defaultMealPlanDays
+	}
+	if plan.Days < 1 || plan.Days > maxMealPlanDays {
+		return plan, fmt.Errorf("จำนวนวันต้องอยู่ระหว่าง 1-%d", maxMealPlanDays)
+	}
+	plan.TolerancePercent = req.TolerancePercent
+	if plan.TolerancePercent == 0 {
+		plan.TolerancePercent = defaultMealPlanTolerance
+	}
+	if plan.TolerancePercent < 1 || plan.TolerancePercent > 50 {
+		return plan, fmt.Errorf("ค่าความคลาดเคลื่อนต้องอยู่ระหว่าง 1-50%%")
+	}
+	exclusions, err := NormalizeExclusions(req.Exclusions)
+	if err != nil {
+		return plan, err
+	}
+	plan.Exclusions = exclusions
+
+	if err := applyNutritionTarget(&plan, req.NutritionID); err != nil {
+		return plan, err
+	}
+	if plan.Title == "" {
+		plan.Title = fmt.Sprintf("แผนอาหาร %d วัน เริ่ม %s", plan.Days, plan.StartDate)
+	}
+
+	candidates, err := mealPlanCandidates(exclusions)
+	if err != nil {
+		return plan, err
+	}
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for day := 0; day < plan.Days; day++ {
+		var recent []entity.MealPlanItem
+		for _, item := range plan.Items {
+			if item.Day >= day-2 {
+				recent = append(recent, item)
+			}
+		}
+		items := bestDay(plan, candidates, recent, rng)
+		for i := range items {
+			items[i].Day = day
+		}
+		plan.Items = append(plan.Items, items...)
+	}
+
+	if err := config.DB().Create(&plan).Error; err != nil {
+		return plan, err
+	}
+	return GetMealPlan(plan.ID)
+}
+
+// GetMealPlan ดึงแผนอาหารพร้อมสูตรและผลรวมรายวัน
+func GetMealPlan(id uint) (entity.MealPlan, error) {
+	var plan entity.MealPlan
+	err := config.DB().
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("day, id") }).
+		Preload("Items.Recipe").
+		First(&plan, id).Error
+	if err != nil {
+		return plan, err
+	}
+	summarizeMealPlan(&plan)
+	return plan, nil
+}
+
+// GetMealPlans ดึงรายการแผนอาหาร (ไม่รวมรายการอาหาร)
+func GetMealPlans(filter MealPlanFilter) ([]entity.MealPlan, error) {
+	query := config.DB().Preload("User").Order("start_date desc, id desc")
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.TrainerID != 0 {
+		query = query.Where("trainer_id = ?", filter.TrainerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	var plans []entity.MealPlan
+	err := query.Find(&plans).Error
+	return plans, err
+}
+
+// UpdateMealPlan แก้ไขชื่อ หมายเหตุ และรายการอาหาร (ส่ง items มาแทนรายการเดิมทั้งหมด)
+// แผนที่มอบให้ลูกค้าแล้วจะแจ้งเตือนลูกค้าเมื่อมีการแก้ไข
+func UpdateMealPlan(id uint, input entity.MealPlan) (entity.MealPlan, error) {
+	plan, err := GetMealPlan(id)
+	if err != nil {
+		return plan, err
+	}
+	if title := strings.TrimSpace(input.Title); title != "" {
+		plan.Title = title
+	}
+	plan.Note = input.Note
+
+	var items []entity.MealPlanItem
+	if input.Items != nil {
+		for _, item := range input.Items {
+			if item.Day < 0 || item.Day >= plan.Days {
+				return plan, fmt.Errorf("วันที่ในแผนต้องอยู่ระหว่าง 0-%d", plan.Days-1)
+			}
+			item.MealType = strings.ToLower(strings.TrimSpace(item.MealType))
+			if !containsString(mealTypes, item.MealType) {
+				return plan, fmt.Errorf("meal_type ต้องเป็น breakfast, lunch, dinner หรือ snack")
+			}
+			if item.Servings <= 0 || item.Servings > 10 {
+				return plan, fmt.Errorf("จำนวนที่ต้องอยู่ระหว่าง 0-10")
+			}
+			recipe, err := GetRecipe(item.RecipeID)
+			if err != nil {
+				return plan, fmt.Errorf("ไม่พบสูตรอาหารรหัส %d", item.RecipeID)
+			}
+			items = append(items, planItem(recipe, item.MealType, item.Servings, item.Day))
+		}
+	}
+
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.MealPlan{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"title": plan.Title, "note": plan.Note}).Error; err != nil {
+			return err
+		}
+		if input.Items != nil {
+			if err := tx.Where("meal_plan_id = ?", id).Delete(&entity.MealPlanItem{}).Error; err != nil {
+				return err
+			}
+			for i := range items {
+				items[i].MealPlanID = id
+			}
+			if len(items) > 0 {
+				if err := tx.Omit("Recipe").Create(&items).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if plan.Status == "assigned" {
+			return NotifyUser(tx, plan.UserID, "แผนอาหารมีการแก้ไข", fmt.Sprintf("เทรนเนอร์แก้ไข%s", plan.Title))
+		}
+		return nil
+	})
+	if err != nil {
+		return plan, err
+	}
+	return GetMealPlan(id)
+}
+
+// AssignMealPlan มอบแผนอาหารให้ลูกค้าและแจ้งเตือน
+func AssignMealPlan(id uint) (entity.MealPlan, error) {
+	plan, err := GetMealPlan(id)
+	if err != nil {
+		return plan, err
+	}
+	if plan.Status == "assigned" {
+		return plan, fmt.Errorf("แผนอาหารนี้มอบให้ลูกค้าแล้ว")
+	}
+	if len(plan.Items) == 0 {
+		return plan, fmt.Errorf("แผนอาหารยังไม่มีรายการอาหาร")
+	}
+	now := time.Now()
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.MealPlan{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"status": "assigned", "assigned_at": now}).Error; err != nil {
+			return err
+		}
+		return NotifyUser(tx, plan.UserID, "ได้รับแผนอาหารใหม่", plan.Title)
+	})
+	if err != nil {
+		return plan, err
+	}
+	return GetMealPlan(id)
+}
+
+// DeleteMealPlan ลบแผนอาหารและรายการอาหารในแผน
+func DeleteMealPlan(id uint) error {
+	return config.DB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&entity.MealPlan{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("meal_plan_id = ?", id).Delete(&entity.MealPlanItem{}).Error
+	})
+}
+
+// applyNutritionTarget ใช้แผนโภชนาการที่ระบุ หรือแผนล่าสุดที่เริ่มไม่หลังวันเริ่มแผนอาหาร
+func applyNutritionTarget(plan *entity.MealPlan, nutritionID *uint) error {
+	var target entity.Nutrition
+	query := config.DB().Where("user_id = ?", plan.UserID)
+	if nutritionID != nil {
+		query = query.Where("id = ?", *nutritionID)
+	} else {
+		query = query.Where("date <= ?", plan.StartDate).Order("date desc")
+	}
+	if err := query.First(&target).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("ลูกค้ายังไม่มีแผนโภชนาการ กรุณาสร้างแผนโภชนาการก่อน")
+		}
+		return err
+	}
+	if target.TotalCaloriesPerDay <= 0 {
+		return fmt.Errorf("แผนโภชนาการยังไม่มีเป้าหมายพลังงานต่อวัน")
+	}
+	plan.NutritionID = &target.ID
+	plan.TargetKcal = target.TotalCaloriesPerDay
+	var macros entity.Meal
+	if config.DB().Where("nutrition_id = ?", target.ID).First(&macros).Error == nil {
+		plan.TargetProteinG, plan.TargetFatG, plan.TargetCarbG = macros.ProteinG, macros.FatG, macros.CarbG
+	}
+	return nil
+}
+
+// mealPlanCandidates สูตรอาหารที่ผ่านข้อจำกัด แยกตามมื้อ ต้องมีอย่างน้อยมื้อเช้า กลางวัน เย็น
+func mealPlanCandidates(exclusions []string) (map[string][]entity.Recipe, error) {
+	recipes, err := GetRecipes(RecipeFilter{Exclusions: exclusions})
+	if err != nil {
+		return nil, err
+	}
+	candidates := map[string][]entity.Recipe{}
+	for _, r := range recipes {
+		if r.EnergyKcal <= 0 {
+			continue
+		}
+		for _, m := range r.MealTypes {
+			candidates[m] = append(candidates[m], r)
+		}
+	}
+	for _, m := range []string{"breakfast", "lunch", "dinner"} {
+		if len(candidates[m]) == 0 {
+			return nil, fmt.Errorf("ไม่มีสูตรอาหารสำหรับมื้อ %s ที่ตรงกับข้อจำกัดที่เลือก", m)
+		}
+	}
+	return candidates, nil
+}
+
+// bestDay สุ่มชุดสูตรอาหารของวันและเลือกชุดที่มีคะแนนคลาดเคลื่อนต่ำสุด
+// มื้อว่างเลือกได้ว่าจะมีหรือไม่ ชุดที่อยู่ในช่วงที่ยอมรับได้มาก่อนเสมอ
+// และเพิ่มคะแนนให้สูตรที่ซ้ำกับสองวันก่อนหน้าเพื่อให้เมนูหลากหลาย
+func bestDay(plan entity.MealPlan, candidates map[string][]entity.Recipe, recent []entity.MealPlanItem, rng *rand.Rand) []entity.MealPlanItem {
+	var best []entity.MealPlanItem
+	bestScore := math.Inf(1)
+	for attempt := 0; attempt < mealPlanAttemptsPerDay; attempt++ {
+		var items []entity.MealPlanItem
+		for _, mealType := range mealTypes {
+			options := candidates[mealType]
+			if len(options) == 0 || (mealType == "snack" && rng.Intn(2) == 0) {
+				continue
+			}
+			recipe := options[rng.Intn(len(options))]
+			items = append(items, planItem(recipe, mealType, closestServing(plan.TargetKcal*mealShares[mealType], recipe.EnergyKcal), 0))
+		}
+		score := dayScore(plan, items)
+		if !withinTolerance(plan, sumPlanItems(items)) {
+			score++
+		}
+		for _, item := range items {
+			for _, r := range recent {
+				if r.RecipeID == item.RecipeID {
+					score += 0.1
+				}
+			}
+		}
+		if score < bestScore {
+			best, bestScore = items, score
+		}
+	}
+	return best
+}
+
+// dayScore ผลรวมความคลาดเคลื่อนสัมพัทธ์ของพลังงาน (น้ำหนัก 2 เท่า) และสารอาหารแต่ละตัว
+func dayScore(plan entity.MealPlan, items []entity.MealPlanItem) float64 {
+	day := sumPlanItems(items)
+	score := 2 * relativeDiff(day.EnergyKcal, plan.TargetKcal)
+	for _, pair := range [][2]float64{
+		{day.ProteinG, plan.TargetProteinG},
+		{day.FatG, plan.TargetFatG},
+		{day.CarbG, plan.TargetCarbG},
+	} {
+		if pair[1] > 0 {
+			score += relativeDiff(pair[0], pair[1])
+		}
+	}
+	return score
+}
+
+// summarizeMealPlan คำนวณผลรวมรายวันและตรวจว่าอยู่ในช่วงที่ยอมรับได้
+func summarizeMealPlan(plan *entity.MealPlan) {
+	start, _ := time.Parse("2006-01-02", plan.StartDate)
+	plan.DaySummaries = []entity.MealPlanDay{}
+	for day := 0; day < plan.Days; day++ {
+		var items []entity.MealPlanItem
+		for _, item := range plan.Items {
+			if item.Day == day {
+				items = append(items, item)
+			}
+		}
+		summary := sumPlanItems(items)
+		summary.Day = day
+		summary.Date = start.AddDate(0, 0, day).Format("2006-01-02")
+		summary.WithinTolerance = withinTolerance(*plan, summary)
+		plan.DaySummaries = append(plan.DaySummaries, summary)
+	}
+}
+
+// withinTolerance พลังงานและสารอาหารที่มีเป้าหมายต้องคลาดเคลื่อนไม่เกิน TolerancePercent ทุกตัว
+func withinTolerance(plan entity.MealPlan, day entity.MealPlanDay) bool {
+	tolerance := plan.TolerancePercent / 100
+	for _, pair := range [][2]float64{
+		{day.EnergyKcal, plan.TargetKcal},
+		{day.ProteinG, plan.TargetProteinG},
+		{day.FatG, plan.TargetFatG},
+		{day.CarbG, plan.TargetCarbG},
+	} {
+		if pair[1] > 0 && relativeDiff(pair[0], pair[1]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func sumPlanItems(items []entity.MealPlanItem) entity.MealPlanDay {
+	var day entity.MealPlanDay
+	for _, item := range items {
+		day.EnergyKcal += item.EnergyKcal
+		day.ProteinG += item.ProteinG
+		day.FatG += item.FatG
+		day.CarbG += item.CarbG
+	}
+	day.EnergyKcal = math.Round(day.EnergyKcal*10) / 10
+	day.ProteinG = math.Round(day.ProteinG*10) / 10
+	day.FatG = math.Round(day.FatG*10) / 10
+	day.CarbG = math.Round(day.CarbG*10) / 10
+	return day
+}
+
+func planItem(recipe entity.Recipe, mealType string, servings float64, day int) entity.MealPlanItem {
+	return entity.MealPlanItem{
+		Day:        day,
+		MealType:   mealType,
+		RecipeID:   recipe.ID,
+		Servings:   servings,
+		EnergyKcal: math.Round(recipe.EnergyKcal*servings*10) / 10,
+		ProteinG:   math.Round(recipe.ProteinG*servings*10) / 10,
+		FatG:       math.Round(recipe.FatG*servings*10) / 10,
+		CarbG:      math.Round(recipe.CarbG*servings*10) / 10,
+	}
+}
+
+// closestServing จำนวนที่ใน servingSteps ที่ให้พลังงานใกล้เป้าหมายของมื้อที่สุด
+func closestServing(targetKcal, perServing float64) float64 {
+	best, bestDiff := servingSteps[0], math.Inf(1)
+	for _, s := range servingSteps {
+		if diff := math.Abs(s*perServing - targetKcal); diff < bestDiff {
+			best, bestDiff = s, diff
+		}
+	}
+	return best
+}
+
+func relativeDiff(actual, target float64) float64 {
+	if target <= 0 {
+		return 0
+	}
+	return math.Abs(actual-target) / target
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// NotifyUser สร้างการแจ้งเตือนให้สมาชิก (ใช้ tx เดียวกับการเปลี่ยนแปลงที่เป็นต้นเหตุ)
+func NotifyUser(tx *gorm.DB, userID uint, title, message string) error {
+	return tx.Create(&entity.Notification{UserID: userID, Title: title, Message: message}).Error
+}
+
+// GetNotifications ดึงการแจ้งเตือนของสมาชิก เรียงจากใหม่ไปเก่า
+func GetNotifications(userID uint) ([]entity.Notification, error) {
+	var notifications []entity.Notification
+	err := config.DB().Where("user_id = ?", userID).Order("created_at desc").Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkNotificationRead ทำเครื่องหมายว่าอ่านแล้ว เฉพาะการแจ้งเตือนของสมาชิกคนนั้น
+func MarkNotificationRead(id, userID uint) error {
+	result := config.DB().Model(&entity.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("is_read", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
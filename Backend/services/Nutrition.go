@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/nutrition"
+	"gorm.io/gorm"
+)
+
+// NutritionOptions ตัวเลือกการคำนวณที่ผู้ใช้ส่งมา ค่าว่างใช้ค่าเริ่มต้นของ nutrition.Calculate
+type NutritionOptions struct {
+	Formula       string
+	ActivityLevel string
+	Goal          string
+	Strategy      string
+}
+
+// CalculateNutrition คำนวณพลังงานและสารอาหารจากโปรไฟล์ บันทึกสุขภาพล่าสุด
+// และกิจกรรมใน nutrition.ActivityWindowDays วันล่าสุด
+func CalculateNutrition(userID uint, opts NutritionOptions) (nutrition.Result, error) {
+	input, err := nutritionInput(userID, time.Now())
+	if err != nil {
+		return nutrition.Result{}, err
+	}
+	input.Formula = nutrition.Formula(opts.Formula)
+	input.ActivityLevel = nutrition.ActivityLevel(opts.ActivityLevel)
+	input.Goal = nutrition.ParseGoal(opts.Goal)
+	input.Strategy = nutrition.MacroStrategy(opts.Strategy)
+	return nutrition.Calculate(input)
+}
+
+// CalculateNutritionMacros แบ่งพลังงานที่ผู้ใช้กำหนดเองเป็นสารอาหารหลักตามน้ำหนักล่าสุด
+func CalculateNutritionMacros(userID uint, targetKcal float64, opts NutritionOptions) (nutrition.Macros, []nutrition.Step, error) {
+	var health entity.Health
+	if err := config.DB().Where("user_id = ?", userID).Order("date desc").First(&health).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nutrition.Macros{}, nil, fmt.Errorf("กรุณาบันทึกข้อมูลสุขภาพก่อนคำนวณสารอาหาร")
+		}
+		return nutrition.Macros{}, nil, err
+	}
+	strategy := nutrition.MacroStrategy(opts.Strategy)
+	if strategy == "" {
+		strategy = nutrition.DefaultStrategy(nutrition.ParseGoal(opts.Goal))
+	}
+	return nutrition.CalculateMacros(targetKcal, health.Weight, strategy)
+}
+
+// SaveNutritionPlan บันทึกแผนโภชนาการของวัน ถ้ามีแผนของวันนั้นอยู่แล้วจะแก้ไขแทน
+func SaveNutritionPlan(plan entity.Nutrition, macros nutrition.Macros) (entity.Nutrition, entity.Meal, error) {
+	var meal entity.Meal
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		var existing entity.Nutrition
+		err := tx.Where("user_id = ? AND date = ?", plan.UserID, plan.Date).First(&existing).Error
+		switch {
+		case err == nil:
+			existing.Goal = plan.Goal
+			existing.TotalCaloriesPerDay = plan.TotalCaloriesPerDay
+			existing.Note = plan.Note
+			if err := tx.Omit("Meals").Save(&existing).Error; err != nil {
+				return err
+			}
+			plan = existing
+		case err == gorm.ErrRecordNotFound:
+			plan.Model = gorm.Model{}
+			if err := tx.Omit("Meals").Create(&plan).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		if err := tx.Where("nutrition_id = ?", plan.ID).First(&meal).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		meal.NutritionID = plan.ID
+		meal.UserID = plan.UserID
+		meal.ProteinG, meal.FatG, meal.CarbG = macros.ProteinG, macros.FatG, macros.CarbG
+		return tx.Save(&meal).Error
+	})
+	return plan, meal, err
+}
+
+// nutritionInput รวบรวมข้อมูลร่างกายและกิจกรรมของผู้ใช้ ณ เวลา now
+func nutritionInput(userID uint, now time.Time) (nutrition.Input, error) {
+	var input nutrition.Input
+	var user entity.Users
+	if err := config.DB().Preload("Gender").First(&user, userID).Error; err != nil {
+		return input, err
+	}
+	var health entity.Health
+	if err := config.DB().Where("user_id = ?", userID).Order("date desc").First(&health).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return input, fmt.Errorf("กรุณาบันทึกข้อมูลสุขภาพก่อนคำนวณพลังงาน")
+		}
+		return input, err
+	}
+
+	input.Sex = nutrition.Unspecified
+	if user.Gender != nil {
+		switch user.Gender.Gender {
+		case "ชาย":
+			input.Sex = nutrition.Male
+		case "หญิง":
+			input.Sex = nutrition.Female
+		}
+	}
+	input.Age = userAge(user, now)
+	input.WeightKg = health.Weight
+	input.HeightCm = health.Height
+	input.BodyFatPercent = health.Fat
+
+	var activity struct {
+		Total float64
+		Count int
+	}
+	since := now.AddDate(0, 0, -nutrition.ActivityWindowDays)
+	err := config.DB().Model(&entity.Activity{}).
+		Select("COALESCE(SUM(calories), 0) AS total, COUNT(*) AS count").
+		Where("user_id = ? AND date >= ? AND date <= ?", userID, since, now).
+		Scan(&activity).Error
+	if err != nil {
+		return input, err
+	}
+	input.ActivityCalories = activity.Total
+	input.ActivityCount = activity.Count
+	return input, nil
+}
+
+// userAge ใช้วันเกิดถ้ามี ไม่เช่นนั้นใช้อายุที่กรอกไว้
+func userAge(user entity.Users, now time.Time) int {
+	birthday, err := time.Parse("2006-01-02", user.BirthDay)
+	if err != nil || birthday.After(now) {
+		return int(user.Age)
+	}
+	age := now.Year() - birthday.Year()
+	if now.Month() < birthday.Month() || (now.Month() == birthday.Month() && now.Day() < birthday.Day()) {
+		age--
+	}
+	return age
+}
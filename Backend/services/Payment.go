@@ -0,0 +1,591 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"example.com/fitness-backend/promptpay"
+	"gorm.io/gorm"
+)
+
+// PaymentOrderTTL อายุของคำสั่งซื้อ (และ QR พร้อมเพย์) ก่อนหมดอายุ
+var PaymentOrderTTL = 15 * time.Minute
+
+// RenewalOrderTTL ระยะเวลาที่ชำระคำสั่งซื้อต่ออายุอัตโนมัติได้หลังแพ็กเกจหมดอายุ
+var RenewalOrderTTL = 7 * 24 * time.Hour
+
+// PromptPayID รหัสพร้อมเพย์ของฟิตเนสที่ใช้รับเงิน (เบอร์มือถือ หรือเลขผู้เสียภาษี 13 หลัก)
+var PromptPayID = "0105561234560"
+
+// วัตถุประสงค์ของคำสั่งซื้อ (PaymentOrder.Purpose)
+const (
+	OrderPurposeNew     = "new"
+	OrderPurposeRenewal = "renewal"
+	OrderPurposeChange  = "change"
+)
+
+// ชนิดเหตุการณ์จาก payment gateway
+const (
+	PaymentEventSucceeded = "payment.succeeded"
+	PaymentEventFailed    = "payment.failed"
+)
+
+var (
+	ErrPaymentSignature      = errors.New("ลายเซ็น webhook ไม่ถูกต้อง")
+	ErrUnknownPaymentGateway = errors.New("ไม่รู้จักผู้ให้บริการชำระเงินนี้")
+	ErrPaymentOrderClosed    = errors.New("คำสั่งซื้อนี้ไม่อยู่ในสถานะรอชำระเงิน")
+	ErrMockGatewayDisabled   = errors.New("จำลองการชำระเงินได้เฉพาะเมื่อใช้ผู้ให้บริการ mock")
+	ErrStaleOrder            = errors.New("แพ็กเกจของผู้ใช้เปลี่ยนไปหลังสร้างคำสั่งซื้อนี้")
+)
+
+// PaymentCharge ผลการสร้างรายการเรียกเก็บเงินที่ผู้ให้บริการ
+type PaymentCharge struct {
+	ProviderRef string
+	QRPayload   string
+	RedirectURL string
+}
+
+// PaymentWebhookEvent เหตุการณ์ที่ตรวจลายเซ็นแล้ว
+type PaymentWebhookEvent struct {
+	EventID       string
+	Type          string // PaymentEventSucceeded หรือ PaymentEventFailed
+	ProviderRef   string
+	Amount        uint // บาท
+	FailureReason string
+}
+
+// PaymentGateway ผู้ให้บริการรับชำระเงิน
+type PaymentGateway interface {
+	Name() string
+	// CreateCharge สร้างรายการเรียกเก็บเงินของคำสั่งซื้อ
+	CreateCharge(ctx context.Context, order entity.PaymentOrder) (PaymentCharge, error)
+	// ParseWebhook ตรวจลายเซ็นแล้วแปลงเป็นเหตุการณ์ ลายเซ็นไม่ถูกต้องคืน ErrPaymentSignature
+	ParseWebhook(header http.Header, body []byte) (PaymentWebhookEvent, error)
+}
+
+// paymentGateway nil = ไม่มีช่องทางชำระออนไลน์ (ชำระที่เคาน์เตอร์ได้อย่างเดียว)
+var paymentGateway PaymentGateway
+
+// SetPaymentGateway เปลี่ยนผู้ให้บริการรับชำระเงิน
+func SetPaymentGateway(gateway PaymentGateway) {
+	paymentGateway = gateway
+}
+
+// ConfigurePaymentGateway เลือกผู้ให้บริการจากตัวแปรสภาพแวดล้อม
+//
+//	PAYMENT_GATEWAY ว่าง = ไม่มีช่องทางชำระออนไลน์
+//	PAYMENT_GATEWAY=mock ผู้ให้บริการจำลองสำหรับพัฒนาในเครื่อง ต้องตั้ง PAYMENT_WEBHOOK_SECRET
+//
+// secret ของ webhook แยกจาก secret ที่ใช้ลงลายเซ็น token ของผู้ใช้
+func ConfigurePaymentGateway() error {
+	switch name := strings.ToLower(config.Env("PAYMENT_GATEWAY", "")); name {
+	case "":
+		SetPaymentGateway(nil)
+	case "mock":
+		secret := config.Env("PAYMENT_WEBHOOK_SECRET", "")
+		if secret == "" {
+			SetPaymentGateway(nil)
+			return fmt.Errorf("PAYMENT_GATEWAY=mock ต้องระบุ PAYMENT_WEBHOOK_SECRET")
+		}
+		SetPaymentGateway(NewMockPaymentGateway(secret))
+	default:
+		SetPaymentGateway(nil)
+		return fmt.Errorf("ไม่รู้จัก PAYMENT_GATEWAY %s", name)
+	}
+	return nil
+}
+
+// MockPaymentEnabled ใช้ผู้ให้บริการ mock อยู่หรือไม่ (เปิด endpoint จำลองการชำระเงินเฉพาะกรณีนี้)
+func MockPaymentEnabled() bool {
+	_, ok := paymentGateway.(*MockPaymentGateway)
+	return ok
+}
+
+// CreatePaymentOrder สร้างคำสั่งซื้อแพ็กเกจ method: promptpay (ผ่าน gateway) หรือ counter (ชำระที่เคาน์เตอร์ รอแอดมินยืนยัน)
+// ส่ง idempotencyKey เดิมซ้ำจะได้คำสั่งซื้อเดิม (existing = true) โดยไม่สร้างใหม่
+func CreatePaymentOrder(ctx context.Context, userID, packageID uint, method string, autoRenew bool, idempotencyKey string) (order entity.PaymentOrder, existing bool, err error) {
+	return placeOrder(ctx, userID, method, idempotencyKey, func(tx *gorm.DB, now time.Time) (entity.PaymentOrder, error) {
+		if _, err := currentSubscription(tx, userID); err == nil {
+			return entity.PaymentOrder{}, ErrActiveSubscriptionExists
+		} else if !errors.Is(err, ErrNoActiveSubscription) {
+			return entity.PaymentOrder{}, err
+		}
+		pkg, _, err := packageTerm(tx, packageID, now)
+		if err != nil {
+			return entity.PaymentOrder{}, err
+		}
+		return entity.PaymentOrder{Purpose: OrderPurposeNew, PackageID: pkg.ID, AutoRenew: autoRenew, Amount: pkg.Price}, nil
+	})
+}
+
+// CreateRenewalOrder สร้างคำสั่งซื้อต่ออายุแพ็กเกจเดิมอีกหนึ่งรอบ ยอดชำระหักเครดิตที่ยกมาแล้ว
+// รอบใหม่สร้างเมื่อชำระเงินสำเร็จ ถ้ามีคำสั่งซื้อต่ออายุที่รอชำระอยู่ (เช่น จากการต่ออายุอัตโนมัติ) จะได้คำสั่งซื้อนั้นกลับไป
+func CreateRenewalOrder(ctx context.Context, userID uint, method, idempotencyKey string) (order entity.PaymentOrder, existing bool, err error) {
+	return placeOrder(ctx, userID, method, idempotencyKey, func(tx *gorm.DB, now time.Time) (entity.PaymentOrder, error) {
+		var scheduled int64
+		if err := tx.Model(&entity.PackageMember{}).Where("user_id = ? AND status = ?", userID, "scheduled").
+			Count(&scheduled).Error; err != nil {
+			return entity.PaymentOrder{}, err
+		}
+		if scheduled > 0 {
+			return entity.PaymentOrder{}, fmt.Errorf("ต่ออายุรอบถัดไปไว้แล้ว")
+		}
+		latest, err := latestSubscription(tx, userID)
+		if err != nil {
+			return entity.PaymentOrder{}, err
+		}
+		if pending, err := pendingOrder(tx, userID, OrderPurposeRenewal, latest.ID, now); err == nil {
+			return pending, nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.PaymentOrder{}, err
+		}
+		return renewalOrder(tx, latest, now)
+	})
+}
+
+// CreateChangeOrder สร้างคำสั่งซื้อเปลี่ยนแพ็กเกจ (อัปเกรด/ดาวน์เกรด) ยอดชำระตาม QuoteSubscriptionChange
+// แพ็กเกจเปลี่ยนเมื่อชำระเงินสำเร็จ ถ้าเครดิตครอบคลุมราคาแพ็กเกจใหม่จะเปลี่ยนทันทีโดยไม่ต้องชำระ
+func CreateChangeOrder(ctx context.Context, userID, packageID uint, method, idempotencyKey string) (order entity.PaymentOrder, quote SubscriptionChangeQuote, existing bool, err error) {
+	order, existing, err = placeOrder(ctx, userID, method, idempotencyKey, func(tx *gorm.DB, now time.Time) (entity.PaymentOrder, error) {
+		current, err := currentSubscription(tx, userID)
+		if err != nil {
+			return entity.PaymentOrder{}, err
+		}
+		if current.Status == "frozen" {
+			return entity.PaymentOrder{}, fmt.Errorf("กรุณายกเลิกการพักสมาชิกก่อนเปลี่ยนแพ็กเกจ")
+		}
+		if quote, err = quoteChange(tx, current, packageID, now); err != nil {
+			return entity.PaymentOrder{}, err
+		}
+		return entity.PaymentOrder{
+			Purpose:          OrderPurposeChange,
+			PackageID:        packageID,
+			AutoRenew:        current.AutoRenew,
+			Amount:           quote.AmountDue,
+			ProrationCredit:  quote.Credit,
+			PreviousMemberID: &current.ID,
+		}, nil
+	})
+	return order, quote, existing, err
+}
+
+// placeOrder ขั้นตอนร่วมของการสร้างคำสั่งซื้อ build กำหนดวัตถุประสงค์ แพ็กเกจ และยอดชำระ
+// (คืนคำสั่งซื้อที่มี ID แล้วเพื่อใช้คำสั่งซื้อเดิม) ยอด 0 บาทยืนยันทันทีโดยไม่ผ่านผู้ให้บริการ
+func placeOrder(ctx context.Context, userID uint, method, idempotencyKey string, build func(tx *gorm.DB, now time.Time) (entity.PaymentOrder, error)) (order entity.PaymentOrder, existing bool, err error) {
+	db := config.DB()
+	idempotencyKey = strings.TrimSpace(idempotencyKey)
+	if idempotencyKey != "" {
+		err = db.Preload("Package").Where("user_id = ? AND idempotency_key = ?", userID, idempotencyKey).First(&order).Error
+		if err == nil {
+			return order, true, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return order, false, err
+		}
+	}
+
+	method = strings.ToLower(strings.TrimSpace(method))
+	if method == "" {
+		method = "promptpay"
+	}
+	if method != "promptpay" && method != "counter" {
+		return order, false, fmt.Errorf("ไม่รองรับวิธีชำระเงิน %s (ใช้ promptpay หรือ counter)", method)
+	}
+	token, err := randomToken()
+	if err != nil {
+		return order, false, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := processUserSubscriptions(tx, userID, now); err != nil {
+			return err
+		}
+		spec, err := build(tx, now)
+		if err != nil {
+			return err
+		}
+		if spec.ID != 0 {
+			order, existing = spec, true
+			return nil
+		}
+
+		// ชำระออนไลน์ออกเอกสารในนามสาขาหลัก
+		var branch entity.Branch
+		if err := tx.Order("is_default desc, branch_no").First(&branch).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		order = spec
+		order.OrderNo = "PO" + now.In(bangkok).Format("20060102") + "-" + strings.ToUpper(token[:8])
+		order.UserID = userID
+		order.BranchID = branch.ID
+		order.Currency = "THB"
+		order.Status = "pending"
+		order.Method = method
+		order.Provider = "counter"
+		order.ExpiresAt = now.Add(PaymentOrderTTL)
+		if idempotencyKey != "" {
+			order.IdempotencyKey = &idempotencyKey
+		}
+		if order.Amount == 0 {
+			// เครดิตครอบคลุมทั้งหมด ไม่มียอดให้เรียกเก็บ
+			order.Provider = "credit"
+			if err := tx.Create(&order).Error; err != nil {
+				return err
+			}
+			_, err := confirmPaidOrder(tx, &order, 0, now)
+			return err
+		}
+		if method == "promptpay" && paymentGateway == nil {
+			return ErrUnknownPaymentGateway
+		}
+		return tx.Create(&order).Error
+	})
+	if err != nil {
+		return order, false, err
+	}
+
+	// เรียกผู้ให้บริการนอก transaction แล้วบันทึกผลตามมา
+	// คำสั่งซื้อเดิมที่ยังไม่มีรายการเรียกเก็บ (ต่ออายุอัตโนมัติ) สร้างรายการตอนผู้ใช้เลือกชำระ
+	if order.Status == "pending" && order.ProviderRef == "" && method == "promptpay" {
+		if paymentGateway == nil {
+			return order, existing, ErrUnknownPaymentGateway
+		}
+		order.Method, order.Provider = method, paymentGateway.Name()
+		charge, err := paymentGateway.CreateCharge(ctx, order)
+		if err != nil {
+			db.Model(&order).Updates(map[string]interface{}{"status": "failed", "failure_reason": err.Error()})
+			return order, existing, fmt.Errorf("สร้างรายการชำระเงินไม่สำเร็จ: %w", err)
+		}
+		order.ProviderRef, order.QRPayload, order.RedirectURL = charge.ProviderRef, charge.QRPayload, charge.RedirectURL
+		if err := db.Save(&order).Error; err != nil {
+			return order, existing, err
+		}
+	}
+	order, err = GetPaymentOrder(order.ID)
+	return order, existing, err
+}
+
+// pendingOrder คำสั่งซื้อที่ยังรอชำระของรอบสมาชิก previousID ตามวัตถุประสงค์
+func pendingOrder(tx *gorm.DB, userID uint, purpose string, previousID uint, now time.Time) (entity.PaymentOrder, error) {
+	var order entity.PaymentOrder
+	err := tx.Where("user_id = ? AND purpose = ? AND previous_member_id = ? AND status = ? AND expires_at > ?",
+		userID, purpose, previousID, "pending", now).Order("id desc").First(&order).Error
+	return order, err
+}
+
+// GetPaymentOrder คำสั่งซื้อตาม ID (คำสั่งซื้อที่เลยเวลาชำระจะถูกเปลี่ยนเป็น expired)
+func GetPaymentOrder(id uint) (entity.PaymentOrder, error) {
+	var order entity.PaymentOrder
+	if err := config.DB().Preload("Package").First(&order, id).Error; err != nil {
+		return order, err
+	}
+	return order, expirePaymentOrder(&order, time.Now())
+}
+
+// GetUserPaymentOrders คำสั่งซื้อทั้งหมดของผู้ใช้ เรียงจากใหม่ไปเก่า
+func GetUserPaymentOrders(userID uint) ([]entity.PaymentOrder, error) {
+	var orders []entity.PaymentOrder
+	if err := config.DB().Preload("Package").Where("user_id = ?", userID).Order("id desc").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range orders {
+		if err := expirePaymentOrder(&orders[i], now); err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
+// CancelPaymentOrder ยกเลิกคำสั่งซื้อที่ยังไม่ได้ชำระ
+func CancelPaymentOrder(id uint) (entity.PaymentOrder, error) {
+	order, err := GetPaymentOrder(id)
+	if err != nil {
+		return order, err
+	}
+	if order.Status != "pending" {
+		return order, ErrPaymentOrderClosed
+	}
+	order.Status = "cancelled"
+	return order, config.DB().Model(&order).Update("status", "cancelled").Error
+}
+
+// ConfirmCounterPayment แอดมินยืนยันว่ารับเงินแล้ว (ชำระที่เคาน์เตอร์หรือโอนเงินที่ตรวจสอบเอง)
+// branchID คือสาขาที่รับเงิน ใช้ออกใบกำกับภาษี (0 = สาขาเดิมของคำสั่งซื้อ)
+func ConfirmCounterPayment(id, adminID, branchID uint) (entity.PaymentOrder, error) {
+	var order entity.PaymentOrder
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&order, id).Error; err != nil {
+			return err
+		}
+		if order.Status == "paid" || order.Status == "cancelled" {
+			return ErrPaymentOrderClosed
+		}
+		if branchID != 0 {
+			if err := tx.First(&entity.Branch{}, branchID).Error; err != nil {
+				return fmt.Errorf("ไม่พบสาขา")
+			}
+			order.BranchID = branchID
+		}
+		order.Provider = "counter"
+		order.ProviderRef = fmt.Sprintf("admin:%d", adminID)
+		_, err := confirmPaidOrder(tx, &order, order.Amount, time.Now())
+		return err
+	})
+	if err != nil {
+		return order, err
+	}
+	return GetPaymentOrder(order.ID)
+}
+
+// HandlePaymentWebhook ตรวจลายเซ็นและประมวลผลเหตุการณ์จากผู้ให้บริการ
+// เหตุการณ์ที่เคยได้รับแล้ว (event id เดิม) จะไม่ประมวลผลซ้ำ และคืน duplicate = true
+func HandlePaymentWebhook(provider string, header http.Header, body []byte) (event entity.PaymentEvent, duplicate bool, err error) {
+	if paymentGateway == nil || paymentGateway.Name() != provider {
+		return event, false, ErrUnknownPaymentGateway
+	}
+	parsed, err := paymentGateway.ParseWebhook(header, body)
+	if err != nil {
+		return event, false, err
+	}
+	if parsed.EventID == "" {
+		return event, false, fmt.Errorf("webhook ไม่มี event id")
+	}
+
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("provider = ? AND event_id = ?", provider, parsed.EventID).First(&event).Error
+		if err == nil {
+			duplicate = true
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		now := time.Now()
+		event = entity.PaymentEvent{
+			Provider:    provider,
+			EventID:     parsed.EventID,
+			Type:        parsed.Type,
+			ProviderRef: parsed.ProviderRef,
+			Payload:     string(body),
+			ProcessedAt: &now,
+		}
+
+		var order entity.PaymentOrder
+		err = tx.Where("provider = ? AND provider_ref = ?", provider, parsed.ProviderRef).First(&order).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			event.Result = "ไม่พบคำสั่งซื้อ"
+		case err != nil:
+			return err
+		default:
+			event.PaymentOrderID = &order.ID
+			switch parsed.Type {
+			case PaymentEventSucceeded:
+				if event.Result, err = confirmPaidOrder(tx, &order, parsed.Amount, now); err != nil {
+					return err
+				}
+			case PaymentEventFailed:
+				event.Result = "ข้ามเพราะคำสั่งซื้อไม่ได้รอชำระเงิน"
+				if order.Status == "pending" {
+					reason := parsed.FailureReason
+					if reason == "" {
+						reason = "ชำระเงินไม่สำเร็จ"
+					}
+					if err := tx.Model(&order).Updates(map[string]interface{}{"status": "failed", "failure_reason": reason}).Error; err != nil {
+						return err
+					}
+					event.Result = "บันทึกว่าชำระเงินไม่สำเร็จ"
+				}
+			default:
+				event.Result = "ไม่รู้จักชนิดเหตุการณ์"
+			}
+		}
+		return tx.Create(&event).Error
+	})
+	return event, duplicate, err
+}
+
+// SimulateMockPayment จำลองให้ผู้ให้บริการ mock ส่ง webhook ผลการชำระเงินของคำสั่งซื้อ (ใช้ทดสอบในเครื่อง)
+func SimulateMockPayment(orderID uint, succeed bool) (entity.PaymentEvent, error) {
+	mock, ok := paymentGateway.(*MockPaymentGateway)
+	if !ok {
+		return entity.PaymentEvent{}, ErrMockGatewayDisabled
+	}
+	order, err := GetPaymentOrder(orderID)
+	if err != nil {
+		return entity.PaymentEvent{}, err
+	}
+	if order.Provider != mock.Name() {
+		return entity.PaymentEvent{}, fmt.Errorf("คำสั่งซื้อนี้ไม่ได้ชำระผ่าน mock")
+	}
+	token, err := randomToken()
+	if err != nil {
+		return entity.PaymentEvent{}, err
+	}
+	payload := mockWebhookPayload{ID: "evt_" + token[:16], Type: PaymentEventSucceeded, ChargeID: order.ProviderRef, Amount: order.Amount}
+	if !succeed {
+		payload.Type, payload.FailureReason = PaymentEventFailed, "ผู้ใช้ยกเลิกการชำระเงิน"
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return entity.PaymentEvent{}, err
+	}
+	header := http.Header{}
+	header.Set(MockSignatureHeader, mock.Sign(body))
+	event, _, err := HandlePaymentWebhook(mock.Name(), header, body)
+	return event, err
+}
+
+// confirmPaidOrder บันทึกการชำระเงินและเริ่มรอบสมาชิกตามวัตถุประสงค์ของคำสั่งซื้อ คืนข้อความผลการประมวลผล
+// รับชำระแม้คำสั่งซื้อหมดอายุแล้วเพราะเงินเข้าแล้ว ยอดไม่ตรงจะไม่เริ่มสมาชิก
+func confirmPaidOrder(tx *gorm.DB, order *entity.PaymentOrder, amount uint, now time.Time) (string, error) {
+	if order.Status == "paid" || order.Status == "refunded" {
+		return "ชำระเงินแล้วก่อนหน้านี้", nil
+	}
+	if amount != order.Amount {
+		order.FailureReason = fmt.Sprintf("ยอดที่ชำระ %d บาท ไม่ตรงกับยอดคำสั่งซื้อ %d บาท", amount, order.Amount)
+		return order.FailureReason, tx.Save(order).Error
+	}
+	order.Status = "paid"
+	order.PaidAt = &now
+	order.FailureReason = ""
+	// ยอด 0 บาท (ใช้เครดิตทั้งหมด) ไม่ออกใบกำกับภาษี
+	var invoice entity.Invoice
+	if order.Amount > 0 {
+		var err error
+		if invoice, err = issueInvoice(tx, *order, now); err != nil {
+			return "", err
+		}
+	}
+
+	member, err := activateOrder(tx, *order, now)
+	switch {
+	case errors.Is(err, ErrActiveSubscriptionExists):
+		// เงินเข้าแล้วแต่มีแพ็กเกจอื่นเริ่มไปก่อน ให้แอดมินตรวจสอบและคืนเงิน
+		order.FailureReason = "ชำระเงินแล้วแต่ผู้ใช้มีแพ็กเกจที่ใช้งานอยู่ ต้องตรวจสอบเพื่อคืนเงิน"
+		return order.FailureReason, tx.Save(order).Error
+	case errors.Is(err, ErrStaleOrder):
+		order.FailureReason = "ชำระเงินแล้วแต่แพ็กเกจของผู้ใช้เปลี่ยนไปก่อน ต้องตรวจสอบเพื่อคืนเงิน"
+		return order.FailureReason, tx.Save(order).Error
+	case err != nil:
+		return "", err
+	}
+	order.PackageMemberID = &member.ID
+	if err := tx.Save(order).Error; err != nil {
+		return "", err
+	}
+	// คำสั่งซื้อต่ออายุ/เปลี่ยนแพ็กเกจอื่นที่ค้างอยู่อ้างอิงรอบเดิม ชำระไม่ได้แล้ว
+	if err := tx.Model(&entity.PaymentOrder{}).
+		Where("user_id = ? AND id <> ? AND status = ? AND purpose IN ?", order.UserID, order.ID, "pending",
+			[]string{OrderPurposeRenewal, OrderPurposeChange}).
+		Update("status", "cancelled").Error; err != nil {
+		return "", err
+	}
+
+	until := member.EndDate.In(bangkok).Format("2006-01-02")
+	message := fmt.Sprintf("ได้รับชำระเงิน %d บาท (คำสั่งซื้อ %s ใบเสร็จ %s) แพ็กเกจใช้งานได้ถึงวันที่ %s",
+		order.Amount, order.OrderNo, invoice.Number, until)
+	if order.Amount == 0 {
+		message = fmt.Sprintf("ใช้เครดิตคงเหลือแทนการชำระเงิน (คำสั่งซื้อ %s) แพ็กเกจใช้งานได้ถึงวันที่ %s", order.OrderNo, until)
+	}
+	if err := NotifyUser(tx, order.UserID, "ชำระเงินสำเร็จ", message); err != nil {
+		return "", err
+	}
+	return "ชำระเงินสำเร็จ เริ่มใช้งานแพ็กเกจ", nil
+}
+
+// activateOrder สร้างรอบสมาชิกของคำสั่งซื้อที่ชำระแล้ว
+func activateOrder(tx *gorm.DB, order entity.PaymentOrder, now time.Time) (entity.PackageMember, error) {
+	switch order.Purpose {
+	case OrderPurposeRenewal:
+		return applyRenewal(tx, order, now)
+	case OrderPurposeChange:
+		return applyPackageChange(tx, order, now)
+	}
+	return subscribe(tx, order, now)
+}
+
+func expirePaymentOrder(order *entity.PaymentOrder, now time.Time) error {
+	if order.Status != "pending" || order.ExpiresAt.After(now) {
+		return nil
+	}
+	order.Status = "expired"
+	return config.DB().Model(order).Update("status", "expired").Error
+}
+
+// MockSignatureHeader header ที่ผู้ให้บริการ mock ใส่ลายเซ็น HMAC-SHA256 (hex) ของ body
+const MockSignatureHeader = "X-Mock-Signature"
+
+// MockPaymentGateway ผู้ให้บริการจำลองสำหรับทดสอบในเครื่อง สร้าง QR พร้อมเพย์จริงจาก PromptPayID
+// แต่ไม่มีการตัดเงิน ผลการชำระเงินส่งเข้ามาทาง webhook ที่ลงลายเซ็นด้วย secret
+type MockPaymentGateway struct {
+	secret []byte
+}
+
+type mockWebhookPayload struct {
+	ID            string `json:"id"`
+	Type          string `json:"type"`
+	ChargeID      string `json:"charge_id"`
+	Amount        uint   `json:"amount"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// NewMockPaymentGateway secret ใช้ลงลายเซ็น webhook
+func NewMockPaymentGateway(secret string) *MockPaymentGateway {
+	return &MockPaymentGateway{secret: []byte(secret)}
+}
+
+func (g *MockPaymentGateway) Name() string { return "mock" }
+
+func (g *MockPaymentGateway) CreateCharge(ctx context.Context, order entity.PaymentOrder) (PaymentCharge, error) {
+	token, err := randomToken()
+	if err != nil {
+		return PaymentCharge{}, err
+	}
+	payload, err := promptpay.Payload(PromptPayID, float64(order.Amount))
+	if err != nil {
+		return PaymentCharge{}, err
+	}
+	return PaymentCharge{ProviderRef: "chrg_mock_" + token[:16], QRPayload: payload}, nil
+}
+
+func (g *MockPaymentGateway) ParseWebhook(header http.Header, body []byte) (PaymentWebhookEvent, error) {
+	signature, err := hex.DecodeString(header.Get(MockSignatureHeader))
+	if err != nil || !hmac.Equal(signature, g.mac(body)) {
+		return PaymentWebhookEvent{}, ErrPaymentSignature
+	}
+	var payload mockWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return PaymentWebhookEvent{}, fmt.Errorf("รูปแบบ webhook ไม่ถูกต้อง: %w", err)
+	}
+	return PaymentWebhookEvent{
+		EventID:       payload.ID,
+		Type:          payload.Type,
+		ProviderRef:   payload.ChargeID,
+		Amount:        payload.Amount,
+		FailureReason: payload.FailureReason,
+	}, nil
+}
+
+// Sign ลายเซ็นของ body สำหรับใส่ใน MockSignatureHeader
+func (g *MockPaymentGateway) Sign(body []byte) string {
+	return hex.EncodeToString(g.mac(body))
+}
+
+func (g *MockPaymentGateway) mac(body []byte) []byte {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// dietaryTags แท็กที่สูตรอาหารต้องมีเมื่อผู้ใช้เลือกเป็นข้อจำกัด
+var dietaryTags = []string{"vegetarian", "halal"}
+
+// knownAllergens สารก่อภูมิแพ้ที่ระบุในอาหารและตัดออกจากแผนได้
+var knownAllergens = []string{"peanut", "tree_nut", "shellfish", "fish", "egg", "dairy", "gluten", "soy", "sesame"}
+
+// RecipeFilter เงื่อนไขค้นหาสูตรอาหาร
+type RecipeFilter struct {
+	Query      string
+	MealType   string
+	Exclusions []string
+}
+
+// NormalizeExclusions ตรวจและจัดรูปแบบข้อจำกัดด้านอาหาร (แท็ก หรือสารก่อภูมิแพ้)
+func NormalizeExclusions(exclusions []string) ([]string, error) {
+	result := []string{}
+	for _, e := range exclusions {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" || containsString(result, e) {
+			continue
+		}
+		if !containsString(dietaryTags, e) && !containsString(knownAllergens, e) {
+			return nil, fmt.Errorf("ไม่รู้จักข้อจำกัดด้านอาหาร %s", e)
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+// RecipeAllowed ตรวจว่าสูตรอาหารผ่านข้อจำกัดทั้งหมดหรือไม่
+func RecipeAllowed(recipe entity.Recipe, exclusions []string) bool {
+	for _, e := range exclusions {
+		if containsString(dietaryTags, e) && !containsString(recipe.DietaryTags, e) {
+			return false
+		}
+		if containsString(knownAllergens, e) && containsString(recipe.Allergens, e) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetRecipes ค้นหาสูตรอาหาร กรองตามมื้อและข้อจำกัดด้านอาหาร
+func GetRecipes(filter RecipeFilter) ([]entity.Recipe, error) {
+	exclusions, err := NormalizeExclusions(filter.Exclusions)
+	if err != nil {
+		return nil, err
+	}
+	query := config.DB().Preload("Ingredients.Food").Order("name")
+	if q := strings.TrimSpace(filter.Query); q != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+strings.ToLower(q)+"%")
+	}
+	var recipes []entity.Recipe
+	if err := query.Find(&recipes).Error; err != nil {
+		return nil, err
+	}
+	result := []entity.Recipe{}
+	for _, r := range recipes {
+		if filter.MealType != "" && !containsString(r.MealTypes, filter.MealType) {
+			continue
+		}
+		if RecipeAllowed(r, exclusions) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// GetRecipe ดึงสูตรอาหารพร้อมส่วนผสม
+func GetRecipe(id uint) (entity.Recipe, error) {
+	var recipe entity.Recipe
+	err := config.DB().Preload("Ingredients.Food").First(&recipe, id).Error
+	return recipe, err
+}
+
+// CreateRecipe เพิ่มสูตรอาหาร trainerID = nil คือสูตรของระบบ
+func CreateRecipe(recipe entity.Recipe, trainerID *uint) (entity.Recipe, error) {
+	recipe.Model = gorm.Model{}
+	recipe.CreatedByTrainerID = trainerID
+	if err := prepareRecipe(&recipe); err != nil {
+		return recipe, err
+	}
+	if err := config.DB().Create(&recipe).Error; err != nil {
+		return recipe, err
+	}
+	return GetRecipe(recipe.ID)
+}
+
+// UpdateRecipe แก้ไขสูตรอาหารและส่วนผสม แผนอาหารที่สร้างไปแล้วยังใช้ค่าสารอาหารเดิม
+func UpdateRecipe(id uint, input entity.Recipe) (entity.Recipe, error) {
+	existing, err := GetRecipe(id)
+	if err != nil {
+		return existing, err
+	}
+	input.Model = existing.Model
+	input.CreatedByTrainerID = existing.CreatedByTrainerID
+	if err := prepareRecipe(&input); err != nil {
+		return existing, err
+	}
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("recipe_id = ?", id).Delete(&entity.RecipeIngredient{}).Error; err != nil {
+			return err
+		}
+		ingredients := input.Ingredients
+		input.Ingredients = nil
+		if err := tx.Save(&input).Error; err != nil {
+			return err
+		}
+		for i := range ingredients {
+			ingredients[i].RecipeID = id
+		}
+		return tx.Create(&ingredients).Error
+	})
+	if err != nil {
+		return existing, err
+	}
+	return GetRecipe(id)
+}
+
+// DeleteRecipe ลบสูตรอาหาร
+func DeleteRecipe(id uint) error {
+	return config.DB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&entity.Recipe{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("recipe_id = ?", id).Delete(&entity.RecipeIngredient{}).Error
+	})
+}
+
+// RefreshRecipeTags คำนวณแท็กและสารก่อภูมิแพ้ของทุกสูตรใหม่จากส่วนผสมปัจจุบัน
+// สูตรที่ตรวจไม่ผ่าน (เช่น ส่วนผสมถูกลบจากแคตตาล็อก) คงค่าเดิมไว้
+func RefreshRecipeTags() error {
+	var recipes []entity.Recipe
+	if err := config.DB().Preload("Ingredients").Find(&recipes).Error; err != nil {
+		return err
+	}
+	for _, recipe := range recipes {
+		if err := prepareRecipe(&recipe); err != nil {
+			continue
+		}
+		err := config.DB().Model(&entity.Recipe{}).Where("id = ?", recipe.ID).
+			Select("dietary_tags", "allergens").
+			Updates(entity.Recipe{DietaryTags: recipe.DietaryTags, Allergens: recipe.Allergens}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepareRecipe ตรวจข้อมูลและคำนวณสารอาหารต่อที่ แท็ก และสารก่อภูมิแพ้จากส่วนผสม
+func prepareRecipe(recipe *entity.Recipe) error {
+	recipe.Name = strings.TrimSpace(recipe.Name)
+	if recipe.Name == "" {
+		return fmt.Errorf("กรุณาระบุชื่อสูตรอาหาร")
+	}
+	if recipe.Servings <= 0 {
+		recipe.Servings = 1
+	}
+	if len(recipe.MealTypes) == 0 {
+		return fmt.Errorf("กรุณาระบุมื้อที่เหมาะกับสูตรนี้อย่างน้อยหนึ่งมื้อ")
+	}
+	for i, m := range recipe.MealTypes {
+		recipe.MealTypes[i] = strings.ToLower(strings.TrimSpace(m))
+		if !containsString(mealTypes, recipe.MealTypes[i]) {
+			return fmt.Errorf("meal_types ต้องเป็น breakfast, lunch, dinner หรือ snack")
+		}
+	}
+	if len(recipe.Ingredients) == 0 {
+		return fmt.Errorf("สูตรอาหารต้องมีส่วนผสมอย่างน้อยหนึ่งรายการ")
+	}
+
+	var totals NutrientTotals
+	var tags []string
+	allergens := []string{}
+	for i, ing := range recipe.Ingredients {
+		if ing.Grams <= 0 {
+			return fmt.Errorf("ปริมาณส่วนผสมต้องมากกว่า 0 กรัม")
+		}
+		var food entity.Food
+		if err := config.DB().Where("id = ? AND is_custom = ?", ing.FoodID, false).First(&food).Error; err != nil {
+			return fmt.Errorf("ไม่พบอาหารรหัส %d ในแคตตาล็อก", ing.FoodID)
+		}
+		ratio := ing.Grams / 100
+		totals.EnergyKcal += food.EnergyKcal * ratio
+		totals.ProteinG += food.ProteinG * ratio
+		totals.FatG += food.FatG * ratio
+		totals.CarbG += food.CarbG * ratio
+
+		if i == 0 {
+			tags = append([]string{}, food.DietaryTags...)
+		} else {
+			kept := []string{}
+			for _, t := range tags {
+				if containsString(food.DietaryTags, t) {
+					kept = append(kept, t)
+				}
+			}
+			tags = kept
+		}
+		for _, a := range food.Allergens {
+			if !containsString(allergens, a) {
+				allergens = append(allergens, a)
+			}
+		}
+		recipe.Ingredients[i] = entity.RecipeIngredient{FoodID: food.ID, Grams: ing.Grams}
+	}
+	sort.Strings(allergens)
+
+	servings := float64(recipe.Servings)
+	recipe.EnergyKcal = math.Round(totals.EnergyKcal/servings*10) / 10
+	recipe.ProteinG = math.Round(totals.ProteinG/servings*10) / 10
+	recipe.FatG = math.Round(totals.FatG/servings*10) / 10
+	recipe.CarbG = math.Round(totals.CarbG/servings*10) / 10
+	recipe.DietaryTags = tags
+	recipe.Allergens = allergens
+	return nil
+}
+
+// SeedRecipes เพิ่มสูตรอาหารเริ่มต้นจากอาหารไทยในแคตตาล็อก ทำเฉพาะเมื่อยังไม่มีสูตรใดเลย
+func SeedRecipes() error {
+	var count int64
+	if err := config.DB().Model(&entity.Recipe{}).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+	type part struct {
+		food  string
+		grams float64
+	}
+	seeds := []struct {
+		name      string
+		mealTypes []string
+		parts     []part
+	}{
+		{"โจ๊กหมูใส่ไข่", []string{"breakfast"}, []part{{"โจ๊กหมู", 350}, {"ไข่ต้ม", 50}}},
+		{"ข้าวโอ๊ตนมกล้วยหอม", []string{"breakfast"}, []part{{"ข้าวโอ๊ต", 40}, {"นมจืด", 200}, {"กล้วยหอม", 120}}},
+		{"ขนมปังโฮลวีตกับไข่ต้ม", []string{"breakfast"}, []part{{"ขนมปังโฮลวีต", 60}, {"ไข่ต้ม", 100}}},
+		{"ข้าวต้มเต้าหู้ไข่", []string{"breakfast"}, []part{{"ข้าวสวย", 120}, {"เต้าหู้แข็ง", 100}, {"ไข่ต้ม", 50}}},
+		{"ข้าวกะเพราไก่", []string{"lunch", "dinner"}, []part{{"ผัดกะเพราไก่ราดข้าว", 350}}},
+		{"ข้าวกล้องอกไก่", []string{"lunch", "dinner"}, []part{{"ข้าวกล้อง", 160}, {"อกไก่ต้ม", 150}}},
+		{"ส้มตำไก่ย่างข้าวเหนียว", []string{"lunch", "dinner"}, []part{{"ส้มตำไทย", 200}, {"ไก่ย่าง", 150}, {"ข้าวเหนียว", 120}}},
+		{"ข้าวแกงเขียวหวานไก่", []string{"lunch", "dinner"}, []part{{"ข้าวสวย", 160}, {"แกงเขียวหวานไก่", 250}}},
+		{"ข้าวต้มยำกุ้งไข่เจียว", []string{"lunch", "dinner"}, []part{{"ข้าวสวย", 160}, {"ต้มยำกุ้ง", 250}, {"ไข่เจียว", 70}}},
+		{"ลาบหมูข้าวเหนียว", []string{"lunch", "dinner"}, []part{{"ลาบหมู", 150}, {"ข้าวเหนียว", 120}}},
+		{"ข้าวกล้องเต้าหู้ไข่เจียว", []string{"lunch", "dinner"}, []part{{"ข้าวกล้อง", 160}, {"เต้าหู้แข็ง", 150}, {"ไข่เจียว", 70}}},
+		{"ผัดไทย", []string{"lunch", "dinner"}, []part{{"ผัดไทย", 290}}},
+		{"ข้าวมันไก่", []string{"lunch"}, []part{{"ข้าวมันไก่", 300}}},
+		{"ข้าวกล้องไก่ย่าง", []string{"lunch", "dinner"}, []part{{"ข้าวกล้อง", 160}, {"ไก่ย่าง", 150}}},
+		{"กล้วยหอม", []string{"snack"}, []part{{"กล้วยหอม", 120}}},
+		{"นมจืด", []string{"snack", "breakfast"}, []part{{"นมจืด", 200}}},
+		{"มะม่วงสุก", []string{"snack"}, []part{{"มะม่วงสุก", 200}}},
+		{"ไข่ต้ม 2 ฟอง", []string{"snack"}, []part{{"ไข่ต้ม", 100}}},
+	}
+	for _, seed := range seeds {
+		recipe := entity.Recipe{Name: seed.name, MealTypes: seed.mealTypes, Servings: 1}
+		for _, p := range seed.parts {
+			var food entity.Food
+			if err := config.DB().Where("name = ? AND is_custom = ?", p.food, false).First(&food).Error; err != nil {
+				return fmt.Errorf("seed recipe %s: ไม่พบอาหาร %s", seed.name, p.food)
+			}
+			recipe.Ingredients = append(recipe.Ingredients, entity.RecipeIngredient{FoodID: food.ID, Grams: p.grams})
+		}
+		if _, err := CreateRecipe(recipe, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,599 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// maxFreezeDays พักสมาชิกได้นานสุดต่อครั้ง
+const maxFreezeDays = 90
+
+// maxSubscriptionSteps จำนวนขั้นที่เปลี่ยนสถานะได้ในการประมวลผลครั้งเดียว (กันวนไม่รู้จบ)
+const maxSubscriptionSteps = 48
+
+var (
+	ErrNoActiveSubscription     = errors.New("ไม่พบแพ็กเกจที่ใช้งานอยู่")
+	ErrActiveSubscriptionExists = errors.New("ผู้ใช้มีแพ็กเกจที่ใช้งานอยู่แล้ว")
+)
+
+// currentSubscriptionStatuses สถานะของรอบที่ยังใช้สิทธิ์ได้ (frozen ยังนับเป็นสมาชิก)
+var currentSubscriptionStatuses = []string{"active", "frozen"}
+
+// SubscriptionChangeQuote ยอดที่ต้องชำระเมื่อเปลี่ยนแพ็กเกจกลางรอบ
+type SubscriptionChangeQuote struct {
+	ChangeType       string    `json:"change_type"` // upgrade หรือ downgrade
+	CurrentPackageID uint      `json:"current_package_id"`
+	NewPackageID     uint      `json:"new_package_id"`
+	RemainingDays    int       `json:"remaining_days"`
+	Credit           uint      `json:"credit"` // มูลค่าส่วนที่ยังไม่ได้ใช้ของรอบเดิม รวมเครดิตที่ยกมา
+	NewPrice         uint      `json:"new_price"`
+	AmountDue        uint      `json:"amount_due"`
+	CreditCarried    uint      `json:"credit_carried"` // เครดิตที่เกินราคาใหม่ ยกไปใช้ตอนต่ออายุ
+	StartDate        time.Time `json:"start_date"`
+	EndDate          time.Time `json:"end_date"`
+}
+
+// PackagePeriod รอบของแพ็กเกจจาก Package.Type
+func PackagePeriod(pkg entity.Package) (years, months int, err error) {
+	switch strings.ToLower(strings.TrimSpace(pkg.Type)) {
+	case "รายเดือน", "monthly", "month":
+		return 0, 1, nil
+	case "รายปี", "yearly", "annual", "year":
+		return 1, 0, nil
+	}
+	return 0, 0, fmt.Errorf("ไม่รู้จักรอบของแพ็กเกจ %q (ใช้ รายเดือน หรือ รายปี)", pkg.Type)
+}
+
+// subscribe เริ่มรอบสมาชิกใหม่ทันทีหลังชำระเงิน (เรียกจาก confirmPaidOrder เท่านั้น)
+func subscribe(tx *gorm.DB, order entity.PaymentOrder, now time.Time) (entity.PackageMember, error) {
+	if err := processUserSubscriptions(tx, order.UserID, now); err != nil {
+		return entity.PackageMember{}, err
+	}
+	if _, err := currentSubscription(tx, order.UserID); err == nil {
+		return entity.PackageMember{}, ErrActiveSubscriptionExists
+	} else if !errors.Is(err, ErrNoActiveSubscription) {
+		return entity.PackageMember{}, err
+	}
+	pkg, end, err := packageTerm(tx, order.PackageID, now)
+	if err != nil {
+		return entity.PackageMember{}, err
+	}
+	member := entity.PackageMember{
+		UserID:     order.UserID,
+		PackageID:  pkg.ID,
+		StartDate:  now,
+		EndDate:    end,
+		Status:     "active",
+		AutoRenew:  order.AutoRenew,
+		ChangeType: "new",
+		Price:      pkg.Price,
+		AmountDue:  order.Amount,
+	}
+	return member, tx.Create(&member).Error
+}
+
+// GetCurrentSubscription รอบที่ใช้งานอยู่ (active หรือ frozen) หลังอัปเดตสถานะตามเวลาปัจจุบัน
+func GetCurrentSubscription(userID uint) (entity.PackageMember, error) {
+	if err := ProcessUserSubscriptions(userID); err != nil {
+		return entity.PackageMember{}, err
+	}
+	return currentSubscription(config.DB().Preload("Package"), userID)
+}
+
+// GetSubscriptionHistory ทุกรอบของผู้ใช้ เรียงจากใหม่ไปเก่า
+func GetSubscriptionHistory(userID uint) ([]entity.PackageMember, error) {
+	if err := ProcessUserSubscriptions(userID); err != nil {
+		return nil, err
+	}
+	var members []entity.PackageMember
+	err := config.DB().Preload("Username").Preload("Package").Where("user_id = ?", userID).
+		Order("start_date desc, id desc").Find(&members).Error
+	return members, err
+}
+
+// applyRenewal สร้างรอบต่ออายุของคำสั่งซื้อที่ชำระแล้ว ถ้ารอบเดิมยังใช้งานอยู่จะเริ่มต่อจากวันหมดอายุ (scheduled)
+// ถ้าหมดอายุหรือยกเลิกไปแล้วจะเริ่มทันที รอบเดิมต้องยังเป็นรอบล่าสุดของผู้ใช้
+func applyRenewal(tx *gorm.DB, order entity.PaymentOrder, now time.Time) (entity.PackageMember, error) {
+	if err := processUserSubscriptions(tx, order.UserID, now); err != nil {
+		return entity.PackageMember{}, err
+	}
+	latest, err := latestSubscription(tx, order.UserID)
+	if err != nil && !errors.Is(err, ErrNoActiveSubscription) {
+		return entity.PackageMember{}, err
+	}
+	if order.PreviousMemberID == nil || latest.ID != *order.PreviousMemberID || latest.CreditCarried < order.ProrationCredit {
+		return entity.PackageMember{}, ErrStaleOrder
+	}
+	start := now
+	if containsString(currentSubscriptionStatuses, latest.Status) {
+		start = latest.EndDate
+	}
+	return createRenewal(tx, latest, order, start, now)
+}
+
+// QuoteSubscriptionChange คำนวณยอดเมื่อเปลี่ยนเป็นแพ็กเกจอื่นทันที:
+// มูลค่ารอบเดิมที่เหลือ (ตามสัดส่วนเวลา) เป็นเครดิตหักจากราคาแพ็กเกจใหม่ ส่วนที่เกินยกไปรอบถัดไป
+func QuoteSubscriptionChange(userID, packageID uint) (SubscriptionChangeQuote, error) {
+	if err := ProcessUserSubscriptions(userID); err != nil {
+		return SubscriptionChangeQuote{}, err
+	}
+	current, err := currentSubscription(config.DB(), userID)
+	if err != nil {
+		return SubscriptionChangeQuote{}, err
+	}
+	return quoteChange(config.DB(), current, packageID, time.Now())
+}
+
+// applyPackageChange เปลี่ยนแพ็กเกจของคำสั่งซื้อที่ชำระแล้ว รอบเดิมสิ้นสุด ณ ตอนนี้ และเริ่มรอบใหม่เต็มรอบ
+// เครดิตและยอดชำระใช้ตามคำสั่งซื้อ รอบเดิมต้องยังเป็นรอบที่ใช้งานอยู่และไม่ได้พัก
+func applyPackageChange(tx *gorm.DB, order entity.PaymentOrder, now time.Time) (entity.PackageMember, error) {
+	if err := processUserSubscriptions(tx, order.UserID, now); err != nil {
+		return entity.PackageMember{}, err
+	}
+	current, err := currentSubscription(tx, order.UserID)
+	if err != nil && !errors.Is(err, ErrNoActiveSubscription) {
+		return entity.PackageMember{}, err
+	}
+	if order.PreviousMemberID == nil || current.ID != *order.PreviousMemberID || current.Status != "active" {
+		return entity.PackageMember{}, ErrStaleOrder
+	}
+	pkg, end, err := packageTerm(tx, order.PackageID, now)
+	if err != nil {
+		return entity.PackageMember{}, err
+	}
+
+	previous := current
+	current.Status = "expired"
+	current.EndDate = now
+	current.CreditCarried = 0
+	if err := tx.Save(&current).Error; err != nil {
+		return entity.PackageMember{}, err
+	}
+	if err := cancelScheduledRenewals(tx, order.UserID, now); err != nil {
+		return entity.PackageMember{}, err
+	}
+	changeType := "upgrade"
+	if pkg.Price < previous.Price {
+		changeType = "downgrade"
+	}
+	member := entity.PackageMember{
+		UserID:          order.UserID,
+		PackageID:       pkg.ID,
+		StartDate:       now,
+		EndDate:         end,
+		Status:          "active",
+		AutoRenew:       order.AutoRenew,
+		ChangeType:      changeType,
+		PreviousID:      &current.ID,
+		Price:           pkg.Price,
+		ProrationCredit: order.ProrationCredit,
+		AmountDue:       order.Amount,
+	}
+	if order.ProrationCredit > pkg.Price {
+		member.CreditCarried = order.ProrationCredit - pkg.Price
+	}
+	if err := tx.Create(&member).Error; err != nil {
+		return member, err
+	}
+	return member, carryOverUsage(tx, previous, member, now)
+}
+
+// FreezeSubscription พักสมาชิก days วัน วันหมดอายุ (และรอบที่ต่ออายุไว้) เลื่อนออกไปเท่ากัน
+func FreezeSubscription(userID uint, days int) (entity.PackageMember, error) {
+	var member entity.PackageMember
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if days < 1 || days > maxFreezeDays {
+			return fmt.Errorf("พักสมาชิกได้ 1-%d วัน", maxFreezeDays)
+		}
+		if err := processUserSubscriptions(tx, userID, now); err != nil {
+			return err
+		}
+		var err error
+		member, err = currentSubscription(tx, userID)
+		if err != nil {
+			return err
+		}
+		if member.Status == "frozen" {
+			return fmt.Errorf("แพ็กเกจนี้พักอยู่แล้ว")
+		}
+		shift := time.Duration(days) * 24 * time.Hour
+		until := now.Add(shift)
+		member.Status = "frozen"
+		member.FrozenAt = &now
+		member.FrozenUntil = &until
+		member.EndDate = member.EndDate.Add(shift)
+		if err := tx.Save(&member).Error; err != nil {
+			return err
+		}
+		return shiftScheduledRenewals(tx, userID, shift)
+	})
+	return member, err
+}
+
+// UnfreezeSubscription กลับมาใช้งานก่อนครบกำหนดพัก วันที่พักไม่ครบจะคืนออกจากวันหมดอายุ
+func UnfreezeSubscription(userID uint) (entity.PackageMember, error) {
+	var member entity.PackageMember
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := processUserSubscriptions(tx, userID, now); err != nil {
+			return err
+		}
+		var err error
+		member, err = currentSubscription(tx, userID)
+		if err != nil {
+			return err
+		}
+		if member.Status != "frozen" {
+			return fmt.Errorf("แพ็กเกจนี้ไม่ได้พักอยู่")
+		}
+		unused := member.FrozenUntil.Sub(now)
+		if unused < 0 {
+			unused = 0
+		}
+		member.Status = "active"
+		member.FrozenAt, member.FrozenUntil = nil, nil
+		member.EndDate = member.EndDate.Add(-unused)
+		if err := tx.Save(&member).Error; err != nil {
+			return err
+		}
+		return shiftScheduledRenewals(tx, userID, -unused)
+	})
+	return member, err
+}
+
+// CancelSubscription ยกเลิกแพ็กเกจ atPeriodEnd = true ใช้ต่อได้จนหมดรอบแต่ไม่ต่ออายุ
+// ไม่เช่นนั้นสิ้นสุดทันที ทั้งสองแบบยกเลิกรอบที่ต่ออายุล่วงหน้าไว้และเก็บประวัติไว้
+func CancelSubscription(userID uint, atPeriodEnd bool) (entity.PackageMember, error) {
+	var member entity.PackageMember
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := processUserSubscriptions(tx, userID, now); err != nil {
+			return err
+		}
+		var err error
+		member, err = currentSubscription(tx, userID)
+		if err != nil {
+			return err
+		}
+		member.AutoRenew = false
+		if !atPeriodEnd {
+			member.Status = "cancelled"
+			member.CancelledAt = &now
+			member.FrozenAt, member.FrozenUntil = nil, nil
+		}
+		if err := tx.Save(&member).Error; err != nil {
+			return err
+		}
+		return cancelScheduledRenewals(tx, userID, now)
+	})
+	return member, err
+}
+
+// SetSubscriptionAutoRenew เปิด/ปิดการต่ออายุอัตโนมัติของรอบปัจจุบัน
+func SetSubscriptionAutoRenew(userID uint, autoRenew bool) (entity.PackageMember, error) {
+	member, err := GetCurrentSubscription(userID)
+	if err != nil {
+		return member, err
+	}
+	member.AutoRenew = autoRenew
+	err = config.DB().Model(&member).Update("auto_renew", autoRenew).Error
+	return member, err
+}
+
+// ProcessUserSubscriptions อัปเดตสถานะตามเวลาปัจจุบัน: ครบกำหนดพัก, เริ่มรอบที่ต่ออายุไว้,
+// หมดอายุ และสร้างคำสั่งซื้อต่ออายุอัตโนมัติ เรียกก่อนอ่านหรือแก้ไขการสมัครของผู้ใช้
+func ProcessUserSubscriptions(userID uint) error {
+	return config.DB().Transaction(func(tx *gorm.DB) error {
+		return processUserSubscriptions(tx, userID, time.Now())
+	})
+}
+
+// ProcessSubscriptions ประมวลผลสถานะของสมาชิกทุกคน คืนจำนวนผู้ใช้ที่ประมวลผล
+func ProcessSubscriptions() (int, error) {
+	var userIDs []uint
+	err := config.DB().Model(&entity.PackageMember{}).
+		Where("status IN ?", []string{"active", "frozen", "scheduled"}).
+		Distinct().Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range userIDs {
+		if err := ProcessUserSubscriptions(id); err != nil {
+			return 0, err
+		}
+	}
+	return len(userIDs), nil
+}
+
+// MigratePackageMembers เติมวันเริ่ม/หมดอายุ สถานะ และราคาให้ข้อมูลสมาชิกเดิมที่ยังไม่มี
+// (นับจากวันที่สร้าง) ทำเฉพาะแถวที่ยังไม่มี start_date จึงเรียกซ้ำได้
+func MigratePackageMembers() error {
+	var members []entity.PackageMember
+	if err := config.DB().Preload("Package").Where("start_date IS NULL").Find(&members).Error; err != nil {
+		return err
+	}
+	for _, m := range members {
+		updates := map[string]interface{}{
+			"start_date":  m.CreatedAt,
+			"end_date":    m.CreatedAt,
+			"status":      "expired",
+			"change_type": "new",
+		}
+		if m.Package != nil {
+			if years, months, err := PackagePeriod(*m.Package); err == nil {
+				updates["end_date"] = addMonths(m.CreatedAt, years*12+months)
+				updates["status"] = "active"
+				updates["price"], updates["amount_due"] = m.Package.Price, m.Package.Price
+			}
+		}
+		if err := config.DB().Model(&entity.PackageMember{}).Where("id = ?", m.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	_, err := ProcessSubscriptions()
+	return err
+}
+
+func processUserSubscriptions(tx *gorm.DB, userID uint, now time.Time) error {
+	for i := 0; i < maxSubscriptionSteps; i++ {
+		changed, err := stepUserSubscription(tx, userID, now)
+		if err != nil || !changed {
+			return err
+		}
+	}
+	return nil
+}
+
+// stepUserSubscription เปลี่ยนสถานะหนึ่งขั้น คืน true ถ้ามีการเปลี่ยนแปลง
+func stepUserSubscription(tx *gorm.DB, userID uint, now time.Time) (bool, error) {
+	var rows []entity.PackageMember
+	if err := tx.Where("user_id = ? AND status IN ?", userID, []string{"active", "frozen", "scheduled"}).
+		Order("start_date, id").Find(&rows).Error; err != nil {
+		return false, err
+	}
+	for _, r := range rows {
+		switch r.Status {
+		case "frozen":
+			if r.FrozenUntil != nil && !r.FrozenUntil.After(now) {
+				r.Status = "active"
+				r.FrozenAt, r.FrozenUntil = nil, nil
+				return true, tx.Save(&r).Error
+			}
+		case "scheduled":
+			if !r.StartDate.After(now) {
+				return true, tx.Model(&r).Update("status", "active").Error
+			}
+		case "active":
+			if r.EndDate.After(now) {
+				continue
+			}
+			if err := tx.Model(&r).Update("status", "expired").Error; err != nil {
+				return false, err
+			}
+			// มีรอบอื่นที่ใช้งานหรือต่ออายุไว้แล้ว ไม่ต้องต่ออายุซ้ำ
+			if len(rows) > 1 {
+				return true, nil
+			}
+			// ต่ออายุอัตโนมัติ = สร้างคำสั่งซื้อรอชำระ รอบใหม่เริ่มเมื่อชำระเงินแล้ว ไม่ชำระถือว่าหมดอายุ
+			// สร้างคำสั่งซื้อไม่ได้ (เช่น แพ็กเกจถูกลบ) ถือว่าหมดอายุตามปกติ
+			if r.AutoRenew {
+				order, err := createAutoRenewalOrder(tx, r, now)
+				if err != nil {
+					return false, err
+				}
+				if order.ID != 0 {
+					return true, nil
+				}
+			}
+			return true, NotifyUser(tx, userID, "แพ็กเกจหมดอายุ",
+				fmt.Sprintf("แพ็กเกจของคุณหมดอายุเมื่อ %s", r.EndDate.In(bangkok).Format("2006-01-02")))
+		}
+	}
+	return false, nil
+}
+
+// createRenewal สร้างรอบถัดไปด้วยแพ็กเกจเดิมเริ่มที่ start ตามคำสั่งซื้อต่ออายุที่ชำระแล้ว
+// เครดิตที่ยกมาจากรอบก่อนลดลงตามที่คำสั่งซื้อหักไป
+func createRenewal(tx *gorm.DB, previous entity.PackageMember, order entity.PaymentOrder, start, now time.Time) (entity.PackageMember, error) {
+	pkg, end, err := packageTerm(tx, previous.PackageID, start)
+	if err != nil {
+		return entity.PackageMember{}, err
+	}
+	renewal := entity.PackageMember{
+		UserID:          previous.UserID,
+		PackageID:       pkg.ID,
+		StartDate:       start,
+		EndDate:         end,
+		Status:          "active",
+		AutoRenew:       order.AutoRenew,
+		ChangeType:      "renewal",
+		PreviousID:      &previous.ID,
+		Price:           pkg.Price,
+		ProrationCredit: order.ProrationCredit,
+		AmountDue:       order.Amount,
+		CreditCarried:   previous.CreditCarried - order.ProrationCredit,
+	}
+	if start.After(now) {
+		renewal.Status = "scheduled"
+	}
+	if err := tx.Create(&renewal).Error; err != nil {
+		return renewal, err
+	}
+	return renewal, tx.Model(&previous).Update("credit_carried", 0).Error
+}
+
+// renewalOrder คำสั่งซื้อต่ออายุรอบถัดไปของ previous (ยังไม่บันทึก) หักเครดิตที่ยกมาไม่เกินราคาแพ็กเกจ
+func renewalOrder(tx *gorm.DB, previous entity.PackageMember, now time.Time) (entity.PaymentOrder, error) {
+	pkg, _, err := packageTerm(tx, previous.PackageID, now)
+	if err != nil {
+		return entity.PaymentOrder{}, err
+	}
+	credit := previous.CreditCarried
+	if credit > pkg.Price {
+		credit = pkg.Price
+	}
+	return entity.PaymentOrder{
+		Purpose:          OrderPurposeRenewal,
+		PackageID:        pkg.ID,
+		AutoRenew:        previous.AutoRenew,
+		Amount:           pkg.Price - credit,
+		ProrationCredit:  credit,
+		PreviousMemberID: &previous.ID,
+	}, nil
+}
+
+// createAutoRenewalOrder สร้างคำสั่งซื้อต่ออายุเมื่อรอบ previous ที่เปิดต่ออายุอัตโนมัติหมดอายุ แล้วแจ้งผู้ใช้ให้ชำระ
+// ชำระได้ที่เคาน์เตอร์หรือเลือกพร้อมเพย์ผ่านการต่ออายุ ภายใน RenewalOrderTTL
+// เครดิตครอบคลุมทั้งหมดจะต่ออายุทันที คืนคำสั่งซื้อว่าง (ID = 0) ถ้าแพ็กเกจต่ออายุไม่ได้
+func createAutoRenewalOrder(tx *gorm.DB, previous entity.PackageMember, now time.Time) (entity.PaymentOrder, error) {
+	if pending, err := pendingOrder(tx, previous.UserID, OrderPurposeRenewal, previous.ID, now); err == nil {
+		return pending, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return pending, err
+	}
+	order, err := renewalOrder(tx, previous, now)
+	if err != nil {
+		return entity.PaymentOrder{}, nil
+	}
+	token, err := randomToken()
+	if err != nil {
+		return order, err
+	}
+	var branch entity.Branch
+	if err := tx.Order("is_default desc, branch_no").First(&branch).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return order, err
+	}
+	order.OrderNo = "PO" + now.In(bangkok).Format("20060102") + "-" + strings.ToUpper(token[:8])
+	order.UserID = previous.UserID
+	order.BranchID = branch.ID
+	order.Currency = "THB"
+	order.Status = "pending"
+	order.Method, order.Provider = "counter", "counter"
+	order.ExpiresAt = now.Add(RenewalOrderTTL)
+	if order.Amount == 0 {
+		order.Provider = "credit"
+		if err := tx.Create(&order).Error; err != nil {
+			return order, err
+		}
+		_, err := confirmPaidOrder(tx, &order, 0, now)
+		return order, err
+	}
+	if err := tx.Create(&order).Error; err != nil {
+		return order, err
+	}
+	return order, NotifyUser(tx, previous.UserID, "ถึงกำหนดต่ออายุแพ็กเกจ", fmt.Sprintf(
+		"แพ็กเกจของคุณหมดอายุเมื่อ %s กรุณาชำระ %d บาท (คำสั่งซื้อ %s) ภายในวันที่ %s เพื่อต่ออายุ",
+		previous.EndDate.In(bangkok).Format("2006-01-02"), order.Amount, order.OrderNo,
+		order.ExpiresAt.In(bangkok).Format("2006-01-02")))
+}
+
+func quoteChange(tx *gorm.DB, current entity.PackageMember, packageID uint, now time.Time) (SubscriptionChangeQuote, error) {
+	if current.PackageID == packageID {
+		return SubscriptionChangeQuote{}, fmt.Errorf("ผู้ใช้มีแพ็กเกจนี้อยู่แล้ว")
+	}
+	pkg, end, err := packageTerm(tx, packageID, now)
+	if err != nil {
+		return SubscriptionChangeQuote{}, err
+	}
+	quote := SubscriptionChangeQuote{
+		ChangeType:       "upgrade",
+		CurrentPackageID: current.PackageID,
+		NewPackageID:     pkg.ID,
+		NewPrice:         pkg.Price,
+		StartDate:        now,
+		EndDate:          end,
+	}
+	if pkg.Price < current.Price {
+		quote.ChangeType = "downgrade"
+	}
+
+	total := current.EndDate.Sub(current.StartDate)
+	remaining := current.EndDate.Sub(now)
+	if remaining > 0 && total > 0 {
+		quote.RemainingDays = int(math.Ceil(remaining.Hours() / 24))
+		quote.Credit = uint(math.Round(float64(current.Price) * float64(remaining) / float64(total)))
+	}
+	quote.Credit += current.CreditCarried
+	if quote.Credit >= pkg.Price {
+		quote.CreditCarried = quote.Credit - pkg.Price
+	} else {
+		quote.AmountDue = pkg.Price - quote.Credit
+	}
+	return quote, nil
+}
+
+// packageTerm ดึงแพ็กเกจและวันสิ้นสุดของรอบที่เริ่มที่ start
+func packageTerm(tx *gorm.DB, packageID uint, start time.Time) (entity.Package, time.Time, error) {
+	var pkg entity.Package
+	if err := tx.First(&pkg, packageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return pkg, time.Time{}, fmt.Errorf("ไม่พบแพ็กเกจ")
+		}
+		return pkg, time.Time{}, err
+	}
+	years, months, err := PackagePeriod(pkg)
+	if err != nil {
+		return pkg, time.Time{}, err
+	}
+	return pkg, addMonths(start, years*12+months), nil
+}
+
+// addMonths เลื่อนไป n เดือน ถ้าวันที่เกินวันสุดท้ายของเดือนปลายทางใช้วันสุดท้ายแทน
+// (31 ม.ค. + 1 เดือน = 28/29 ก.พ. ไม่ล้นไปเป็น 3 มี.ค. แบบ time.AddDate)
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); d > last {
+		d = last
+	}
+	return first.AddDate(0, 0, d-1)
+}
+
+// latestSubscription รอบล่าสุดของผู้ใช้ ไม่นับรอบที่ถูกยกเลิกก่อนเริ่ม (เช่น รอบต่ออายุที่ยกเลิกเมื่อเปลี่ยนแพ็กเกจ)
+func latestSubscription(tx *gorm.DB, userID uint) (entity.PackageMember, error) {
+	var member entity.PackageMember
+	err := tx.Where("user_id = ? AND (status <> ? OR cancelled_at > start_date)", userID, "cancelled").
+		Order("start_date desc, id desc").First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return member, ErrNoActiveSubscription
+	}
+	return member, err
+}
+
+func currentSubscription(tx *gorm.DB, userID uint) (entity.PackageMember, error) {
+	var member entity.PackageMember
+	err := tx.Where("user_id = ? AND status IN ?", userID, currentSubscriptionStatuses).
+		Order("start_date desc").First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return member, ErrNoActiveSubscription
+	}
+	return member, err
+}
+
+func cancelScheduledRenewals(tx *gorm.DB, userID uint, now time.Time) error {
+	return tx.Model(&entity.PackageMember{}).Where("user_id = ? AND status = ?", userID, "scheduled").
+		Updates(map[string]interface{}{"status": "cancelled", "cancelled_at": now}).Error
+}
+
+func shiftScheduledRenewals(tx *gorm.DB, userID uint, shift time.Duration) error {
+	var scheduled []entity.PackageMember
+	if err := tx.Where("user_id = ? AND status = ?", userID, "scheduled").Find(&scheduled).Error; err != nil {
+		return err
+	}
+	for _, s := range scheduled {
+		s.StartDate = s.StartDate.Add(shift)
+		s.EndDate = s.EndDate.Add(shift)
+		if err := tx.Save(&s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
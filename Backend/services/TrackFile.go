@@ -0,0 +1,493 @@
+package services
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/entity"
+)
+
+// ParsedTrack ผลการอ่านไฟล์กิจกรรม
+type ParsedTrack struct {
+	Source string // gpx, tcx, fit
+	Sport  string // running, cycling, walking, hiking, swimming หรือว่าง
+	Points []entity.TrackPoint
+}
+
+// TrackSummary ค่าสรุปที่คำนวณจากจุดบนเส้นทาง
+type TrackSummary struct {
+	StartTime     time.Time
+	DurationMin   float64
+	DistanceKm    float64
+	ElevationGain float64
+	AvgHeartRate  int
+	MaxHeartRate  int
+	Splits        []entity.PaceSplit
+}
+
+// ParseTrackFile อ่านไฟล์ตามนามสกุล (.gpx, .tcx, .fit)
+func ParseTrackFile(filename string, data []byte) (ParsedTrack, error) {
+	var track ParsedTrack
+	var err error
+	switch ext := strings.ToLower(filename[strings.LastIndex(filename, ".")+1:]); ext {
+	case "gpx":
+		track, err = ParseGPX(data)
+	case "tcx":
+		track, err = ParseTCX(data)
+	case "fit":
+		track, err = ParseFIT(data)
+	default:
+		return track, fmt.Errorf("รองรับเฉพาะไฟล์ .gpx, .tcx และ .fit")
+	}
+	if err != nil {
+		return track, err
+	}
+	if len(track.Points) < 2 {
+		return track, fmt.Errorf("ไม่พบข้อมูลเส้นทางที่มีเวลาในไฟล์")
+	}
+	sort.SliceStable(track.Points, func(i, j int) bool { return track.Points[i].Time.Before(track.Points[j].Time) })
+	fillDistances(track.Points)
+	return track, nil
+}
+
+type gpxFile struct {
+	Tracks []struct {
+		Type     string `xml:"type"`
+		Segments []struct {
+			Points []struct {
+				Lat  float64  `xml:"lat,attr"`
+				Lon  float64  `xml:"lon,attr"`
+				Ele  *float64 `xml:"ele"`
+				Time string   `xml:"time"`
+				HR   []int    `xml:"extensions>TrackPointExtension>hr"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// ParseGPX อ่านไฟล์ GPX 1.1 รวมอัตราการเต้นหัวใจจาก Garmin TrackPointExtension
+func ParseGPX(data []byte) (ParsedTrack, error) {
+	var file gpxFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return ParsedTrack{}, fmt.Errorf("รูปแบบไฟล์ GPX ไม่ถูกต้อง: %v", err)
+	}
+	track := ParsedTrack{Source: "gpx"}
+	for _, trk := range file.Tracks {
+		if track.Sport == "" {
+			track.Sport = normalizeSport(trk.Type)
+		}
+		for _, seg := range trk.Segments {
+			for _, p := range seg.Points {
+				t, err := time.Parse(time.RFC3339, strings.TrimSpace(p.Time))
+				if err != nil {
+					continue
+				}
+				point := entity.TrackPoint{Time: t, Lat: p.Lat, Lon: p.Lon}
+				if p.Ele != nil {
+					point.Elevation = *p.Ele
+				}
+				if len(p.HR) > 0 {
+					point.HeartRate = p.HR[0]
+				}
+				track.Points = append(track.Points, point)
+			}
+		}
+	}
+	return track, nil
+}
+
+type tcxFile struct {
+	Activities []struct {
+		Sport string `xml:"Sport,attr"`
+		Laps  []struct {
+			Points []struct {
+				Time     string   `xml:"Time"`
+				Lat      *float64 `xml:"Position>LatitudeDegrees"`
+				Lon      *float64 `xml:"Position>LongitudeDegrees"`
+				Altitude *float64 `xml:"AltitudeMeters"`
+				Distance *float64 `xml:"DistanceMeters"`
+				HR       *int     `xml:"HeartRateBpm>Value"`
+			} `xml:"Track>Trackpoint"`
+		} `xml:"Lap"`
+	} `xml:"Activities>Activity"`
+}
+
+// ParseTCX อ่านไฟล์ Garmin Training Center (TCX)
+func ParseTCX(data []byte) (ParsedTrack, error) {
+	var file tcxFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return ParsedTrack{}, fmt.Errorf("รูปแบบไฟล์ TCX ไม่ถูกต้อง: %v", err)
+	}
+	track := ParsedTrack{Source: "tcx"}
+	hasDistance := true
+	for _, activity := range file.Activities {
+		if track.Sport == "" {
+			track.Sport = normalizeSport(activity.Sport)
+		}
+		for _, lap := range activity.Laps {
+			for _, p := range lap.Points {
+				t, err := time.Parse(time.RFC3339, strings.TrimSpace(p.Time))
+				if err != nil {
+					continue
+				}
+				point := entity.TrackPoint{Time: t}
+				if p.Lat != nil && p.Lon != nil {
+					point.Lat, point.Lon = *p.Lat, *p.Lon
+				}
+				if p.Altitude != nil {
+					point.Elevation = *p.Altitude
+				}
+				if p.HR != nil {
+					point.HeartRate = *p.HR
+				}
+				if p.Distance != nil {
+					point.Distance = *p.Distance
+				} else {
+					hasDistance = false
+				}
+				track.Points = append(track.Points, point)
+			}
+		}
+	}
+	if !hasDistance {
+		// บางจุดไม่มีระยะสะสม ให้คำนวณจากพิกัดทั้งหมดแทน
+		for i := range track.Points {
+			track.Points[i].Distance = 0
+		}
+	}
+	return track, nil
+}
+
+// FIT: global message numbers และ field ที่ใช้
+const (
+	fitMesgSport   = 12
+	fitMesgSession = 18
+	fitMesgRecord  = 20
+)
+
+// fitEpoch จุดเริ่มนับเวลาของไฟล์ FIT (1989-12-31 00:00:00 UTC)
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+type fitField struct {
+	Num, Size, BaseType byte
+}
+
+type fitDefinition struct {
+	Global    uint16
+	Order     binary.ByteOrder
+	Fields    []fitField
+	DevFields int // ขนาดรวมของ developer fields (ข้าม)
+}
+
+// ParseFIT อ่านไฟล์ ANT FIT เฉพาะ record (จุดบนเส้นทาง) และชนิดกีฬาจาก session/sport
+func ParseFIT(data []byte) (ParsedTrack, error) {
+	track := ParsedTrack{Source: "fit"}
+	if len(data) < 12 || string(data[8:12]) != ".FIT" {
+		return track, fmt.Errorf("รูปแบบไฟล์ FIT ไม่ถูกต้อง")
+	}
+	headerSize := int(data[0])
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerSize + dataSize
+	if headerSize < 12 || end > len(data) {
+		return track, fmt.Errorf("ไฟล์ FIT ไม่สมบูรณ์")
+	}
+
+	defs := map[byte]*fitDefinition{}
+	var lastTimestamp uint32
+	pos := headerSize
+	for pos < end {
+		header := data[pos]
+		pos++
+
+		var local byte
+		var timeOffset = -1
+		switch {
+		case header&0x80 != 0:
+			// compressed timestamp header
+			local = (header >> 5) & 0x03
+			timeOffset = int(header & 0x1F)
+		case header&0x40 != 0:
+			// definition message
+			local = header & 0x0F
+			if pos+5 > end {
+				return track, fmt.Errorf("ไฟล์ FIT ไม่สมบูรณ์")
+			}
+			def := &fitDefinition{Order: binary.LittleEndian}
+			if data[pos+1] == 1 {
+				def.Order = binary.BigEndian
+			}
+			def.Global = def.Order.Uint16(data[pos+2 : pos+4])
+			count := int(data[pos+4])
+			pos += 5
+			if pos+count*3 > end {
+				return track, fmt.Errorf("ไฟล์ FIT ไม่สมบูรณ์")
+			}
+			for i := 0; i < count; i++ {
+				def.Fields = append(def.Fields, fitField{data[pos], data[pos+1], data[pos+2]})
+				pos += 3
+			}
+			if header&0x20 != 0 {
+				devCount := int(data[pos])
+				pos++
+				for i := 0; i < devCount && pos+3 <= end; i++ {
+					def.DevFields += int(data[pos+1])
+					pos += 3
+				}
+			}
+			defs[local] = def
+			continue
+		default:
+			local = header & 0x0F
+		}
+
+		def, ok := defs[local]
+		if !ok {
+			return track, fmt.Errorf("ไฟล์ FIT อ้างถึงข้อความที่ไม่ได้นิยาม")
+		}
+		values := map[byte]int64{}
+		for _, f := range def.Fields {
+			if pos+int(f.Size) > end {
+				return track, fmt.Errorf("ไฟล์ FIT ไม่สมบูรณ์")
+			}
+			if v, ok := fitValue(data[pos:pos+int(f.Size)], f.BaseType, def.Order); ok {
+				values[f.Num] = v
+			}
+			pos += int(f.Size)
+		}
+		pos += def.DevFields
+
+		if ts, ok := values[253]; ok {
+			lastTimestamp = uint32(ts)
+		} else if timeOffset >= 0 {
+			lastTimestamp += (uint32(timeOffset) - lastTimestamp&0x1F) & 0x1F
+			values[253] = int64(lastTimestamp)
+		}
+
+		switch def.Global {
+		case fitMesgRecord:
+			ts, ok := values[253]
+			if !ok {
+				continue
+			}
+			point := entity.TrackPoint{Time: fitEpoch.Add(time.Duration(ts) * time.Second)}
+			lat, hasLat := values[0]
+			lon, hasLon := values[1]
+			if hasLat && hasLon {
+				point.Lat = float64(lat) * 180 / math.Pow(2, 31)
+				point.Lon = float64(lon) * 180 / math.Pow(2, 31)
+			}
+			if alt, ok := values[78]; ok {
+				point.Elevation = float64(alt)/5 - 500
+			} else if alt, ok := values[2]; ok {
+				point.Elevation = float64(alt)/5 - 500
+			}
+			if hr, ok := values[3]; ok {
+				point.HeartRate = int(hr)
+			}
+			if dist, ok := values[5]; ok {
+				point.Distance = float64(dist) / 100
+			}
+			track.Points = append(track.Points, point)
+		case fitMesgSession:
+			if sport, ok := values[5]; ok && track.Sport == "" {
+				track.Sport = fitSport(sport)
+			}
+		case fitMesgSport:
+			if sport, ok := values[0]; ok && track.Sport == "" {
+				track.Sport = fitSport(sport)
+			}
+		}
+	}
+	return track, nil
+}
+
+// fitValue อ่านค่าตัวเลขของ field คืน false เมื่อเป็นค่า invalid ตามสเปก FIT
+func fitValue(raw []byte, baseType byte, order binary.ByteOrder) (int64, bool) {
+	switch len(raw) {
+	case 1:
+		v := raw[0]
+		if (baseType == 0x01 && v == 0x7F) || (baseType != 0x01 && v == 0xFF) {
+			return 0, false
+		}
+		if baseType == 0x01 {
+			return int64(int8(v)), true
+		}
+		return int64(v), true
+	case 2:
+		v := order.Uint16(raw)
+		if baseType == 0x83 {
+			return int64(int16(v)), v != 0x7FFF
+		}
+		return int64(v), v != 0xFFFF
+	case 4:
+		v := order.Uint32(raw)
+		if baseType == 0x85 {
+			return int64(int32(v)), v != 0x7FFFFFFF
+		}
+		return int64(v), v != 0xFFFFFFFF
+	}
+	return 0, false
+}
+
+func fitSport(v int64) string {
+	switch v {
+	case 1:
+		return "running"
+	case 2:
+		return "cycling"
+	case 5:
+		return "swimming"
+	case 11:
+		return "walking"
+	case 17:
+		return "hiking"
+	}
+	return ""
+}
+
+func normalizeSport(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case strings.Contains(s, "run"):
+		return "running"
+	case strings.Contains(s, "bik"), strings.Contains(s, "cycl"), strings.Contains(s, "ride"):
+		return "cycling"
+	case strings.Contains(s, "walk"):
+		return "walking"
+	case strings.Contains(s, "hik"):
+		return "hiking"
+	case strings.Contains(s, "swim"):
+		return "swimming"
+	}
+	return ""
+}
+
+// fillDistances คำนวณระยะสะสมจากพิกัดเมื่อไฟล์ไม่มีระยะมาให้
+func fillDistances(points []entity.TrackPoint) {
+	hasDistance := false
+	for _, p := range points {
+		if p.Distance > 0 {
+			hasDistance = true
+			break
+		}
+	}
+	if hasDistance {
+		return
+	}
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		step := 0.0
+		if (a.Lat != 0 || a.Lon != 0) && (b.Lat != 0 || b.Lon != 0) {
+			step = haversineMeters(a.Lat, a.Lon, b.Lat, b.Lon)
+		}
+		points[i].Distance = points[i-1].Distance + step
+	}
+}
+
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000.0
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// elevationThreshold ความสูงที่ต้องเพิ่มขึ้นก่อนนับเป็นการไต่ขึ้น เพื่อตัดสัญญาณรบกวนของ GPS
+const elevationThreshold = 3.0
+
+// SummarizeTrack คำนวณระยะทาง เวลา ความสูงที่ไต่ขึ้น อัตราการเต้นหัวใจ และเวลาต่อกิโลเมตร
+func SummarizeTrack(points []entity.TrackPoint) TrackSummary {
+	summary := TrackSummary{Splits: []entity.PaceSplit{}}
+	if len(points) == 0 {
+		return summary
+	}
+	first, last := points[0], points[len(points)-1]
+	summary.StartTime = first.Time
+	summary.DurationMin = math.Round(last.Time.Sub(first.Time).Minutes()*100) / 100
+	summary.DistanceKm = math.Round((last.Distance-first.Distance)/10) / 100
+
+	var hrTotal, hrCount int
+	anchor, hasAnchor := 0.0, false
+	for _, p := range points {
+		if p.HeartRate > 0 {
+			hrTotal += p.HeartRate
+			hrCount++
+			if p.HeartRate > summary.MaxHeartRate {
+				summary.MaxHeartRate = p.HeartRate
+			}
+		}
+		if p.Elevation == 0 {
+			continue
+		}
+		switch {
+		case !hasAnchor, p.Elevation < anchor:
+			anchor, hasAnchor = p.Elevation, true
+		case p.Elevation-anchor >= elevationThreshold:
+			summary.ElevationGain += p.Elevation - anchor
+			anchor = p.Elevation
+		}
+	}
+	summary.ElevationGain = math.Round(summary.ElevationGain*10) / 10
+	if hrCount > 0 {
+		summary.AvgHeartRate = int(math.Round(float64(hrTotal) / float64(hrCount)))
+	}
+
+	summary.Splits = paceSplits(points)
+	return summary
+}
+
+// paceSplits แบ่งเวลาเป็นช่วงละ 1 กม. โดยประมาณเวลาที่ข้ามแต่ละกิโลเมตรแบบเชิงเส้น
+func paceSplits(points []entity.TrackPoint) []entity.PaceSplit {
+	splits := []entity.PaceSplit{}
+	base := points[0].Distance
+	splitStart := points[0].Time
+	next := 1000.0
+	var hrTotal, hrCount int
+
+	addSplit := func(distance float64, at time.Time) {
+		seconds := at.Sub(splitStart).Seconds()
+		split := entity.PaceSplit{
+			Km:        len(splits) + 1,
+			DistanceM: math.Round(distance),
+			Seconds:   math.Round(seconds),
+		}
+		if distance > 0 {
+			split.PaceSecPerKm = math.Round(seconds / distance * 1000)
+		}
+		if hrCount > 0 {
+			split.AvgHeartRate = int(math.Round(float64(hrTotal) / float64(hrCount)))
+		}
+		splits = append(splits, split)
+		splitStart = at
+		hrTotal, hrCount = 0, 0
+	}
+
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if b.HeartRate > 0 {
+			hrTotal += b.HeartRate
+			hrCount++
+		}
+		for b.Distance-base >= next && b.Distance > a.Distance {
+			ratio := (base + next - a.Distance) / (b.Distance - a.Distance)
+			at := a.Time.Add(time.Duration(ratio * float64(b.Time.Sub(a.Time))))
+			addSplit(1000, at)
+			next += 1000
+		}
+	}
+
+	// ช่วงสุดท้ายที่ไม่ครบกิโลเมตร (นับเมื่อยาวอย่างน้อย 100 เมตร)
+	last := points[len(points)-1]
+	remaining := last.Distance - base - (next - 1000)
+	if remaining >= 100 {
+		addSplit(remaining, last.Time)
+	}
+	return splits
+}
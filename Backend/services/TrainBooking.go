@@ -1,13 +1,21 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"example.com/fitness-backend/config"
 	"example.com/fitness-backend/entity"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrBookingStarted  = errors.New("ไม่สามารถยกเลิกการจองที่เริ่มไปแล้วได้")
+	ErrNotBookingOwner = errors.New("ไม่มีสิทธิ์จัดการการจองนี้")
+	ErrScheduleTaken   = errors.New("เวลานี้ถูกจองแล้ว")
+)
+
 // CreateTrainBooking สร้างการจองใหม่ในฐานข้อมูล
 func CreateTrainBooking(booking entity.TrainBooking) (entity.TrainBooking, error) {
 	db := config.DB()
@@ -16,6 +24,15 @@ func CreateTrainBooking(booking entity.TrainBooking) (entity.TrainBooking, error
 		return booking, fmt.Errorf("user_id หรือ schedule_id ไม่ถูกต้อง")
 	}
 
+	// ช่วงเวลาที่เทรนเนอร์ปิดไว้ (เช่น นำเข้าจากปฏิทินภายนอก) จองไม่ได้
+	var schedule entity.TrainerSchedule
+	if err := db.First(&schedule, booking.ScheduleID).Error; err != nil {
+		return booking, err
+	}
+	if schedule.Status == "Blocked" {
+		return booking, ErrScheduleTaken
+	}
+
 	// ตรวจสอบว่ามีการจองแล้วหรือยัง
 	var existingBooking entity.TrainBooking
 	result := db.Where("schedule_id = ?", booking.ScheduleID).First(&existingBooking)
@@ -31,6 +48,16 @@ func CreateTrainBooking(booking entity.TrainBooking) (entity.TrainBooking, error
 		if err := tx.Create(&booking).Error; err != nil {
 			return err
 		}
+		if err := tx.Create(&entity.TrainBookingHistory{
+			TrainBookingID: booking.ID,
+			ToStatus:       booking.BookingStatus,
+			ToScheduleID:   booking.ScheduleID,
+			Actor:          "customer",
+			ActorID:        booking.UsersID,
+			Note:           "จอง",
+		}).Error; err != nil {
+			return err
+		}
 
 		// 2. update schedule status = "Booked"
 		if err := tx.Model(&entity.TrainerSchedule{}).
@@ -39,7 +66,8 @@ func CreateTrainBooking(booking entity.TrainBooking) (entity.TrainBooking, error
 			return err
 		}
 
-		return nil
+		// 3. หักสิทธิ์เทรนส่วนตัวของแพ็กเกจ
+		return ConsumeEntitlement(tx, booking.UsersID, CapabilityPTSession, "train_booking", booking.ID)
 	})
 
 	if err != nil {
@@ -127,19 +155,54 @@ func GetCustomersByTrainerID(trainerID uint) ([]entity.Users, error) {
 }
 
 // CancelTrainBooking ยกเลิกการจองด้วย ID (soft delete และเปลี่ยนสถานะ)
-func CancelTrainBooking(id uint) error {
+// หากยกเลิกช้ากว่าที่นโยบายกำหนดจะถูกบันทึกเป็น late cancel
+func CancelTrainBooking(id uint, actor string, actorID uint) (entity.TrainBooking, error) {
 	db := config.DB()
 
 	var booking entity.TrainBooking
-	if err := db.First(&booking, id).Error; err != nil {
-		return err
+	if err := db.Preload("Schedule").First(&booking, id).Error; err != nil {
+		return booking, err
+	}
+	if err := checkBookingOwner(booking, actor, actorID); err != nil {
+		return booking, err
 	}
 
-	return db.Transaction(func(tx *gorm.DB) error {
+	policy, err := GetBookingPolicy()
+	if err != nil {
+		return booking, err
+	}
+	now := time.Now()
+	if !booking.Schedule.StartTime.IsZero() && !now.Before(booking.Schedule.StartTime) {
+		return booking, ErrBookingStarted
+	}
+	lateCancel := booking.Schedule.StartTime.Sub(now) < time.Duration(policy.FreeCancelHours)*time.Hour
+
+	err = db.Transaction(func(tx *gorm.DB) error {
 		// 1) อัปเดตสถานะการจองเป็น Cancelled
 		if err := tx.Model(&entity.TrainBooking{}).
 			Where("id = ?", booking.ID).
-			Update("booking_status", "Cancelled").Error; err != nil {
+			Updates(map[string]interface{}{
+				"booking_status": "Cancelled",
+				"late_cancel":    lateCancel,
+				"cancelled_at":   now,
+			}).Error; err != nil {
+			return err
+		}
+
+		note := "ยกเลิก"
+		if lateCancel {
+			note = fmt.Sprintf("ยกเลิกล่าช้า (น้อยกว่า %d ชั่วโมงก่อนเริ่ม)", policy.FreeCancelHours)
+		}
+		if err := tx.Create(&entity.TrainBookingHistory{
+			TrainBookingID: booking.ID,
+			FromStatus:     booking.BookingStatus,
+			ToStatus:       "Cancelled",
+			FromScheduleID: booking.ScheduleID,
+			ToScheduleID:   booking.ScheduleID,
+			Actor:          actor,
+			ActorID:        actorID,
+			Note:           note,
+		}).Error; err != nil {
 			return err
 		}
 
@@ -155,8 +218,206 @@ func CancelTrainBooking(id uint) error {
 			return err
 		}
 
+		// 4) ยกเลิกตามนโยบายได้สิทธิ์เทรนส่วนตัวคืน ยกเลิกล่าช้าถือว่าใช้สิทธิ์แล้ว
+		if !lateCancel {
+			return ReleaseEntitlement(tx, "train_booking", booking.ID)
+		}
 		return nil
 	})
+	if err != nil {
+		return booking, err
+	}
+
+	booking.BookingStatus = "Cancelled"
+	booking.LateCancel = lateCancel
+	booking.CancelledAt = &now
+	return booking, nil
+}
+
+// RescheduleTrainBooking ย้ายการจองไปยังตารางเวลาอื่นที่ว่างของเทรนเนอร์คนเดิมภายใน transaction เดียว
+func RescheduleTrainBooking(id, newScheduleID uint, actor string, actorID uint) (entity.TrainBooking, error) {
+	db := config.DB()
+
+	var booking entity.TrainBooking
+	if err := db.Preload("Schedule").First(&booking, id).Error; err != nil {
+		return booking, err
+	}
+	if err := checkBookingOwner(booking, actor, actorID); err != nil {
+		return booking, err
+	}
+	if booking.BookingStatus == "Cancelled" || booking.NoShow {
+		return booking, fmt.Errorf("การจองนี้ไม่สามารถเลื่อนได้")
+	}
+	if newScheduleID == booking.ScheduleID {
+		return booking, fmt.Errorf("กรุณาเลือกเวลาใหม่ที่ต่างจากเดิม")
+	}
+
+	policy, err := GetBookingPolicy()
+	if err != nil {
+		return booking, err
+	}
+	if booking.RescheduleCount >= policy.MaxReschedules {
+		return booking, fmt.Errorf("เลื่อนนัดครบ %d ครั้งตามนโยบายแล้ว", policy.MaxReschedules)
+	}
+	now := time.Now()
+	if booking.Schedule.StartTime.Sub(now) < time.Duration(policy.RescheduleNoticeHours)*time.Hour {
+		return booking, fmt.Errorf("ต้องเลื่อนนัดล่วงหน้าอย่างน้อย %d ชั่วโมงก่อนเริ่ม", policy.RescheduleNoticeHours)
+	}
+
+	var target entity.TrainerSchedule
+	if err := db.First(&target, newScheduleID).Error; err != nil {
+		return booking, fmt.Errorf("ไม่พบตารางเวลาใหม่")
+	}
+	if target.TrainerID != booking.Schedule.TrainerID {
+		return booking, fmt.Errorf("เลื่อนได้เฉพาะเวลาของเทรนเนอร์คนเดิมเท่านั้น")
+	}
+	if !target.StartTime.After(now) {
+		return booking, fmt.Errorf("ตารางเวลาใหม่ต้องเป็นเวลาในอนาคต")
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var taken int64
+		if err := tx.Model(&entity.TrainBooking{}).
+			Where("schedule_id = ? AND booking_status <> ?", target.ID, "Cancelled").
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		// จองช่วงเวลาใหม่แบบมีเงื่อนไข เพื่อกันการจองซ้อนพร้อมกัน
+		claim := tx.Model(&entity.TrainerSchedule{}).
+			Where("id = ? AND status NOT IN ?", target.ID, []string{"Booked", "Blocked"}).
+			Update("status", "Booked")
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if taken > 0 || claim.RowsAffected == 0 {
+			return ErrScheduleTaken
+		}
+
+		if err := tx.Model(&entity.TrainerSchedule{}).
+			Where("id = ?", booking.ScheduleID).
+			Update("status", "Available").Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.TrainBooking{}).
+			Where("id = ?", booking.ID).
+			Updates(map[string]interface{}{
+				"schedule_id":      target.ID,
+				"reschedule_count": booking.RescheduleCount + 1,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.TrainBookingHistory{
+			TrainBookingID: booking.ID,
+			FromStatus:     booking.BookingStatus,
+			ToStatus:       booking.BookingStatus,
+			FromScheduleID: booking.ScheduleID,
+			ToScheduleID:   target.ID,
+			Actor:          actor,
+			ActorID:        actorID,
+			Note:           "เลื่อนนัด",
+		}).Error
+	})
+	if err != nil {
+		return booking, err
+	}
+
+	return GetTrainBookingByID(booking.ID)
+}
+
+// MarkTrainBookingNoShow บันทึกว่าลูกค้าไม่มาตามนัด (ทำได้หลังเวลาเริ่ม + ช่วงผ่อนผัน)
+func MarkTrainBookingNoShow(id uint, actor string, actorID uint) (entity.TrainBooking, error) {
+	db := config.DB()
+
+	var booking entity.TrainBooking
+	if err := db.Preload("Schedule").First(&booking, id).Error; err != nil {
+		return booking, err
+	}
+	if actor == "trainer" && booking.Schedule.TrainerID != actorID {
+		return booking, ErrNotBookingOwner
+	}
+	if actor == "customer" {
+		return booking, ErrNotBookingOwner
+	}
+
+	policy, err := GetBookingPolicy()
+	if err != nil {
+		return booking, err
+	}
+	if time.Now().Before(booking.Schedule.StartTime.Add(time.Duration(policy.NoShowGraceMinutes) * time.Minute)) {
+		return booking, fmt.Errorf("บันทึกว่าไม่มาได้หลังเวลาเริ่ม %d นาที", policy.NoShowGraceMinutes)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entity.TrainBooking{}).
+			Where("id = ?", booking.ID).
+			Updates(map[string]interface{}{"booking_status": "NoShow", "no_show": true}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.TrainBookingHistory{
+			TrainBookingID: booking.ID,
+			FromStatus:     booking.BookingStatus,
+			ToStatus:       "NoShow",
+			FromScheduleID: booking.ScheduleID,
+			ToScheduleID:   booking.ScheduleID,
+			Actor:          actor,
+			ActorID:        actorID,
+			Note:           "ไม่มาตามนัด",
+		}).Error
+	})
+	if err != nil {
+		return booking, err
+	}
+	return GetTrainBookingByID(booking.ID)
+}
+
+// GetTrainBookingHistory ดึงประวัติการเปลี่ยนแปลงของการจอง (รวมการจองที่ถูกยกเลิกแล้ว)
+func GetTrainBookingHistory(id uint) ([]entity.TrainBookingHistory, error) {
+	var history []entity.TrainBookingHistory
+	err := config.DB().Where("train_booking_id = ?", id).Order("created_at asc").Find(&history).Error
+	return history, err
+}
+
+// GetBookingPolicy ดึงนโยบายการยกเลิก/เลื่อนนัดปัจจุบัน
+func GetBookingPolicy() (entity.BookingPolicy, error) {
+	var policy entity.BookingPolicy
+	err := config.DB().First(&policy).Error
+	return policy, err
+}
+
+// UpdateBookingPolicy แก้ไขนโยบายการยกเลิก/เลื่อนนัด
+func UpdateBookingPolicy(updated entity.BookingPolicy) (entity.BookingPolicy, error) {
+	if updated.FreeCancelHours < 0 || updated.RescheduleNoticeHours < 0 || updated.MaxReschedules < 0 || updated.NoShowGraceMinutes < 0 {
+		return updated, fmt.Errorf("ค่าของนโยบายต้องไม่ติดลบ")
+	}
+	policy, err := GetBookingPolicy()
+	if err != nil {
+		return policy, err
+	}
+	err = config.DB().Model(&policy).Updates(map[string]interface{}{
+		"free_cancel_hours":       updated.FreeCancelHours,
+		"reschedule_notice_hours": updated.RescheduleNoticeHours,
+		"max_reschedules":         updated.MaxReschedules,
+		"no_show_grace_minutes":   updated.NoShowGraceMinutes,
+	}).Error
+	if err != nil {
+		return policy, err
+	}
+	return GetBookingPolicy()
+}
+
+// checkBookingOwner ลูกค้าจัดการได้เฉพาะการจองของตน ส่วนเทรนเนอร์จัดการได้เฉพาะการจองในตารางของตน
+func checkBookingOwner(booking entity.TrainBooking, actor string, actorID uint) error {
+	switch actor {
+	case "customer":
+		if booking.UsersID != actorID {
+			return ErrNotBookingOwner
+		}
+	case "trainer":
+		if booking.Schedule.TrainerID != actorID {
+			return ErrNotBookingOwner
+		}
+	}
+	return nil
 }
 
 // GetCustomerBookedTimes ดึงข้อมูลเวลาที่ลูกค้าจองไว้
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// CreateTrainerAvailability บันทึกช่วงเวลาว่างประจำสัปดาห์ของเทรนเนอร์
+func CreateTrainerAvailability(tpl entity.TrainerAvailability) (entity.TrainerAvailability, error) {
+	if err := validateAvailability(tpl); err != nil {
+		return tpl, err
+	}
+	err := config.DB().Create(&tpl).Error
+	return tpl, err
+}
+
+// GetTrainerAvailabilities ดึงช่วงเวลาว่างประจำสัปดาห์ทั้งหมดของเทรนเนอร์
+func GetTrainerAvailabilities(trainerID uint) ([]entity.TrainerAvailability, error) {
+	var items []entity.TrainerAvailability
+	err := config.DB().
+		Where("trainer_id = ?", trainerID).
+		Order("weekday, start_time").
+		Find(&items).Error
+	return items, err
+}
+
+// GetTrainerAvailability ดึงช่วงเวลาว่างประจำสัปดาห์ตาม ID
+func GetTrainerAvailability(id uint) (entity.TrainerAvailability, error) {
+	var tpl entity.TrainerAvailability
+	err := config.DB().First(&tpl, id).Error
+	return tpl, err
+}
+
+// DeleteTrainerAvailability ลบช่วงเวลาว่างประจำสัปดาห์ (ไม่กระทบตารางเวลาที่สร้างไปแล้ว)
+func DeleteTrainerAvailability(id uint) error {
+	return config.DB().Delete(&entity.TrainerAvailability{}, id).Error
+}
+
+// CreateTrainerLeave บันทึกวันลาของเทรนเนอร์
+func CreateTrainerLeave(leave entity.TrainerLeave) (entity.TrainerLeave, error) {
+	if leave.TrainerID == 0 {
+		return leave, fmt.Errorf("trainer_id ไม่ถูกต้อง")
+	}
+	if _, err := time.Parse("2006-01-02", leave.Date); err != nil {
+		return leave, fmt.Errorf("รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)")
+	}
+	err := config.DB().Create(&leave).Error
+	return leave, err
+}
+
+// GetTrainerLeaves ดึงวันลาทั้งหมดของเทรนเนอร์
+func GetTrainerLeaves(trainerID uint) ([]entity.TrainerLeave, error) {
+	var leaves []entity.TrainerLeave
+	err := config.DB().Where("trainer_id = ?", trainerID).Order("date").Find(&leaves).Error
+	return leaves, err
+}
+
+// GetTrainerLeave ดึงวันลาตาม ID
+func GetTrainerLeave(id uint) (entity.TrainerLeave, error) {
+	var leave entity.TrainerLeave
+	err := config.DB().First(&leave, id).Error
+	return leave, err
+}
+
+// DeleteTrainerLeave ลบวันลา
+func DeleteTrainerLeave(id uint) error {
+	return config.DB().Delete(&entity.TrainerLeave{}, id).Error
+}
+
+// GenerateTrainerSchedules สร้าง TrainerSchedule จากช่วงเวลาว่างประจำสัปดาห์
+// ตั้งแต่วันที่ from เป็นเวลา weeks สัปดาห์ โดยข้ามวันลา ช่วงเวลาที่มีอยู่แล้ว และเวลาที่สอนคลาส
+func GenerateTrainerSchedules(trainerID uint, from time.Time, weeks int) ([]entity.TrainerSchedule, error) {
+	db := config.DB()
+	created := []entity.TrainerSchedule{}
+
+	if weeks <= 0 || weeks > 52 {
+		return created, fmt.Errorf("จำนวนสัปดาห์ต้องอยู่ระหว่าง 1-52")
+	}
+
+	templates, err := GetTrainerAvailabilities(trainerID)
+	if err != nil {
+		return created, err
+	}
+	if len(templates) == 0 {
+		return created, fmt.Errorf("เทรนเนอร์ยังไม่มีช่วงเวลาว่างประจำสัปดาห์")
+	}
+
+	leaves, err := GetTrainerLeaves(trainerID)
+	if err != nil {
+		return created, err
+	}
+	onLeave := map[string]bool{}
+	for _, l := range leaves {
+		onLeave[l.Date] = true
+	}
+
+	var existing []entity.TrainerSchedule
+	if err := db.Where("trainer_id = ?", trainerID).Find(&existing).Error; err != nil {
+		return created, err
+	}
+
+	now := time.Now()
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, bangkok)
+	last := day.AddDate(0, 0, 7*weeks)
+
+	// คำนวณช่วงเวลาทั้งหมดก่อน แล้วจึงบันทึกใน transaction เดียว
+	var pending []entity.TrainerSchedule
+	for ; day.Before(last); day = day.AddDate(0, 0, 1) {
+		if onLeave[day.Format("2006-01-02")] {
+			continue
+		}
+		for _, tpl := range templates {
+			if int(day.Weekday()) != tpl.Weekday {
+				continue
+			}
+			for _, slot := range availabilitySlots(tpl, day) {
+				if !slot.StartTime.After(now) || overlapsAny(slot, existing) || overlapsAny(slot, pending) {
+					continue
+				}
+				classConflicts, err := FindTrainerClassConflicts(trainerID, slot.StartTime, slot.EndTime)
+				if err != nil {
+					return created, err
+				}
+				if len(classConflicts) > 0 {
+					continue
+				}
+				pending = append(pending, slot)
+			}
+		}
+	}
+	if len(pending) == 0 {
+		return created, nil
+	}
+
+	if err := db.Create(&pending).Error; err != nil {
+		return created, err
+	}
+	return pending, nil
+}
+
+// BulkDeleteFutureSchedules ลบตารางเวลาในอนาคตที่ยังไม่มีผู้จอง ในช่วงวันที่ from ถึง to (รวม to)
+func BulkDeleteFutureSchedules(trainerID uint, from, to time.Time) (int, error) {
+	slots, err := futureUnbookedSchedules(trainerID, from, to)
+	if err != nil || len(slots) == 0 {
+		return 0, err
+	}
+	ids := make([]uint, 0, len(slots))
+	for _, s := range slots {
+		ids = append(ids, s.ID)
+	}
+	if err := config.DB().Delete(&entity.TrainerSchedule{}, ids).Error; err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// BulkShiftFutureSchedules เลื่อนตารางเวลาในอนาคตที่ยังไม่มีผู้จองไป minutes นาที (ติดลบคือเลื่อนเร็วขึ้น)
+func BulkShiftFutureSchedules(trainerID uint, from, to time.Time, minutes int) ([]entity.TrainerSchedule, error) {
+	slots, err := futureUnbookedSchedules(trainerID, from, to)
+	if err != nil || len(slots) == 0 {
+		return slots, err
+	}
+
+	// ตารางเวลาที่ไม่ถูกเลื่อน (รวมช่วงที่มีผู้จอง) ต้องไม่ทับกับช่วงที่เลื่อนแล้ว
+	moving := map[uint]bool{}
+	for _, s := range slots {
+		moving[s.ID] = true
+	}
+	var all []entity.TrainerSchedule
+	if err := config.DB().Where("trainer_id = ?", trainerID).Find(&all).Error; err != nil {
+		return nil, err
+	}
+
+	shift := time.Duration(minutes) * time.Minute
+	var conflicts []Conflict
+	for i := range slots {
+		slots[i].AvailableDate = slots[i].AvailableDate.Add(shift)
+		slots[i].StartTime = slots[i].StartTime.Add(shift)
+		slots[i].EndTime = slots[i].EndTime.Add(shift)
+		for _, other := range all {
+			if !moving[other.ID] && overlaps(slots[i].StartTime, slots[i].EndTime, other.StartTime, other.EndTime) {
+				conflicts = append(conflicts, Conflict{
+					Kind:   "trainer_schedule",
+					ID:     other.ID,
+					Name:   fmt.Sprintf("#%d", other.ID),
+					Reason: "trainer",
+					Start:  other.StartTime,
+					End:    other.EndTime,
+				})
+			}
+		}
+		found, err := FindTrainerClassConflicts(trainerID, slots[i].StartTime, slots[i].EndTime)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, found...)
+	}
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		for _, s := range slots {
+			if err := tx.Model(&entity.TrainerSchedule{}).Where("id = ?", s.ID).Updates(map[string]interface{}{
+				"available_date": s.AvailableDate,
+				"start_time":     s.StartTime,
+				"end_time":       s.EndTime,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return slots, err
+}
+
+// futureUnbookedSchedules ตารางเวลาของเทรนเนอร์ที่ยังไม่เริ่มและไม่มีการจองที่ยังใช้งาน (ไม่รวมช่วงที่ปิดไว้)
+// การจองที่ยกเลิกแล้วไม่นับ ตรงกับ activeScheduleBookings
+func futureUnbookedSchedules(trainerID uint, from, to time.Time) ([]entity.TrainerSchedule, error) {
+	var schedules []entity.TrainerSchedule
+	err := config.DB().
+		Where("trainer_id = ? AND status <> ?", trainerID, "Blocked").
+		Where("NOT EXISTS (SELECT 1 FROM train_bookings tb WHERE tb.schedule_id = trainer_schedules.id AND tb.deleted_at IS NULL AND tb.booking_status <> ?)", "Cancelled").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, bangkok)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, bangkok).AddDate(0, 0, 1)
+
+	result := []entity.TrainerSchedule{}
+	for _, s := range schedules {
+		if s.StartTime.After(now) && !s.StartTime.Before(start) && s.StartTime.Before(end) {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+// availabilitySlots แบ่งช่วงเวลาว่างของวันนั้นเป็นช่วงละ SlotMinutes คั่นด้วย BufferMinutes
+func availabilitySlots(tpl entity.TrainerAvailability, day time.Time) []entity.TrainerSchedule {
+	var slots []entity.TrainerSchedule
+	date := day.Format("2006-01-02")
+	windowStart, err := time.ParseInLocation("2006-01-02 15:04", date+" "+tpl.StartTime, bangkok)
+	if err != nil {
+		return slots
+	}
+	windowEnd, err := time.ParseInLocation("2006-01-02 15:04", date+" "+tpl.EndTime, bangkok)
+	if err != nil {
+		return slots
+	}
+
+	length := time.Duration(tpl.SlotMinutes) * time.Minute
+	buffer := time.Duration(tpl.BufferMinutes) * time.Minute
+	for start := windowStart; !start.Add(length).After(windowEnd); start = start.Add(length + buffer) {
+		slots = append(slots, entity.TrainerSchedule{
+			AvailableDate: start,
+			StartTime:     start,
+			EndTime:       start.Add(length),
+			Status:        "Available",
+			TrainerID:     tpl.TrainerID,
+		})
+	}
+	return slots
+}
+
+func overlapsAny(slot entity.TrainerSchedule, schedules []entity.TrainerSchedule) bool {
+	for _, s := range schedules {
+		if overlaps(slot.StartTime, slot.EndTime, s.StartTime, s.EndTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateAvailability(tpl entity.TrainerAvailability) error {
+	if tpl.TrainerID == 0 {
+		return fmt.Errorf("trainer_id ไม่ถูกต้อง")
+	}
+	if tpl.Weekday < 0 || tpl.Weekday > 6 {
+		return fmt.Errorf("weekday ต้องอยู่ระหว่าง 0 (อาทิตย์) ถึง 6 (เสาร์)")
+	}
+	start, err := time.Parse("15:04", tpl.StartTime)
+	if err != nil {
+		return fmt.Errorf("รูปแบบเวลาเริ่มไม่ถูกต้อง (HH:mm)")
+	}
+	end, err := time.Parse("15:04", tpl.EndTime)
+	if err != nil {
+		return fmt.Errorf("รูปแบบเวลาสิ้นสุดไม่ถูกต้อง (HH:mm)")
+	}
+	if !end.After(start) {
+		return fmt.Errorf("เวลาสิ้นสุดต้องอยู่หลังเวลาเริ่ม")
+	}
+	if tpl.SlotMinutes <= 0 {
+		return fmt.Errorf("ความยาวช่วงเวลาต้องมากกว่า 0 นาที")
+	}
+	if tpl.BufferMinutes < 0 {
+		return fmt.Errorf("เวลาพักระหว่างช่วงต้องไม่ติดลบ")
+	}
+	if end.Sub(start) < time.Duration(tpl.SlotMinutes)*time.Minute {
+		return fmt.Errorf("ช่วงเวลาว่างสั้นกว่าความยาวของหนึ่งช่วง")
+	}
+	return nil
+}
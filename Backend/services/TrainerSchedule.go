@@ -1,13 +1,23 @@
 package services
 
 import (
+    "errors"
+    "fmt"
+    "time"
+
     "example.com/fitness-backend/config"
     "example.com/fitness-backend/entity"
-    "time"
+    "gorm.io/gorm"
 )
 
+// ErrScheduleBooked ตารางเวลาที่มีผู้จองแล้วห้ามแก้ไขเวลาหรือลบโดยตรง
+var ErrScheduleBooked = errors.New("ตารางเวลานี้มีผู้จองแล้ว กรุณายกเลิกการจองหรือใช้การเลื่อนนัดแทน")
+
 // Create
 func CreateTrainerSchedule(schedule entity.TrainerSchedule) (entity.TrainerSchedule, error) {
+    if err := validateScheduleTimes(0, schedule.TrainerID, schedule.StartTime, schedule.EndTime); err != nil {
+        return schedule, err
+    }
     err := config.DB().Create(&schedule).Error
     if err != nil {
         return schedule, err
@@ -60,6 +70,29 @@ func UpdateSchedule(id uint, updatedSchedule entity.TrainerSchedule) (entity.Tra
     if err := config.DB().First(&schedule, id).Error; err != nil {
         return schedule, err
     }
+    trainerID, start, end := schedule.TrainerID, schedule.StartTime, schedule.EndTime
+    if updatedSchedule.TrainerID != 0 {
+        trainerID = updatedSchedule.TrainerID
+    }
+    if !updatedSchedule.StartTime.IsZero() {
+        start = updatedSchedule.StartTime
+    }
+    if !updatedSchedule.EndTime.IsZero() {
+        end = updatedSchedule.EndTime
+    }
+    timeChanged := trainerID != schedule.TrainerID || !start.Equal(schedule.StartTime) || !end.Equal(schedule.EndTime)
+    if timeChanged {
+        bookings, err := activeScheduleBookings(schedule.ID)
+        if err != nil {
+            return schedule, err
+        }
+        if len(bookings) > 0 {
+            return schedule, ErrScheduleBooked
+        }
+    }
+    if err := validateScheduleTimes(schedule.ID, trainerID, start, end); err != nil {
+        return schedule, err
+    }
     err := config.DB().Model(&schedule).Updates(updatedSchedule).Error
     if err != nil {
         return schedule, err
@@ -72,11 +105,112 @@ func UpdateSchedule(id uint, updatedSchedule entity.TrainerSchedule) (entity.Tra
     return schedule, nil
 }
 
+// RescheduleTrainerSchedule เลื่อนเวลาของตารางที่อาจมีผู้จองแล้ว และแจ้งเตือนลูกค้าที่จองไว้
+func RescheduleTrainerSchedule(id uint, start, end time.Time, reason string) (entity.TrainerSchedule, error) {
+    var schedule entity.TrainerSchedule
+    if err := config.DB().First(&schedule, id).Error; err != nil {
+        return schedule, err
+    }
+    if err := validateScheduleTimes(schedule.ID, schedule.TrainerID, start, end); err != nil {
+        return schedule, err
+    }
+    bookings, err := activeScheduleBookings(schedule.ID)
+    if err != nil {
+        return schedule, err
+    }
+
+    oldStart := schedule.StartTime
+    err = config.DB().Transaction(func(tx *gorm.DB) error {
+        if err := tx.Model(&schedule).Updates(map[string]interface{}{
+            "available_date": start,
+            "start_time":     start,
+            "end_time":       end,
+        }).Error; err != nil {
+            return err
+        }
+        for _, b := range bookings {
+            message := fmt.Sprintf("นัดฝึกกับเทรนเนอร์เวลา %s ถูกเลื่อนเป็น %s-%s",
+                oldStart.In(bangkok).Format("02/01/2006 15:04"),
+                start.In(bangkok).Format("02/01/2006 15:04"),
+                end.In(bangkok).Format("15:04"))
+            if reason != "" {
+                message += " (" + reason + ")"
+            }
+            if err := NotifyUser(tx, b.UsersID, "เลื่อนนัดฝึก", message); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return schedule, err
+    }
+    return GetScheduleByID(schedule.ID)
+}
+
 // Delete
 func DeleteSchedule(id uint) error {
+    bookings, err := activeScheduleBookings(id)
+    if err != nil {
+        return err
+    }
+    if len(bookings) > 0 {
+        return ErrScheduleBooked
+    }
     return config.DB().Delete(&entity.TrainerSchedule{}, id).Error
 }
 
+// validateScheduleTimes ตรวจสอบช่วงเวลาและการทับซ้อนกับตารางเวลาอื่นของเทรนเนอร์และคลาสที่สอน
+// excludeID ใช้ข้ามตารางเวลาที่กำลังแก้ไข
+func validateScheduleTimes(excludeID, trainerID uint, start, end time.Time) error {
+    if trainerID == 0 {
+        return fmt.Errorf("trainer_id ไม่ถูกต้อง")
+    }
+    if start.IsZero() || end.IsZero() {
+        return fmt.Errorf("กรุณาระบุเวลาเริ่มและเวลาสิ้นสุด")
+    }
+    if !end.After(start) {
+        return fmt.Errorf("เวลาสิ้นสุดต้องอยู่หลังเวลาเริ่ม")
+    }
+
+    var schedules []entity.TrainerSchedule
+    if err := config.DB().Where("trainer_id = ? AND id <> ?", trainerID, excludeID).Find(&schedules).Error; err != nil {
+        return err
+    }
+    var conflicts []Conflict
+    for _, s := range schedules {
+        if overlaps(start, end, s.StartTime, s.EndTime) {
+            conflicts = append(conflicts, Conflict{
+                Kind:   "trainer_schedule",
+                ID:     s.ID,
+                Name:   fmt.Sprintf("#%d", s.ID),
+                Reason: "trainer",
+                Start:  s.StartTime,
+                End:    s.EndTime,
+            })
+        }
+    }
+
+    classConflicts, err := FindTrainerClassConflicts(trainerID, start, end)
+    if err != nil {
+        return err
+    }
+    conflicts = append(conflicts, classConflicts...)
+    if len(conflicts) > 0 {
+        return &ConflictError{Conflicts: conflicts}
+    }
+    return nil
+}
+
+// activeScheduleBookings การจองที่ยังไม่ถูกยกเลิกของตารางเวลา
+func activeScheduleBookings(scheduleID uint) ([]entity.TrainBooking, error) {
+    var bookings []entity.TrainBooking
+    err := config.DB().
+        Where("schedule_id = ? AND booking_status <> ?", scheduleID, "Cancelled").
+        Find(&bookings).Error
+    return bookings, err
+}
+
 // Get schedules by date
 func GetTrainerSchedulesByDate(trainerId uint, date time.Time) ([]entity.TrainerSchedule, error) {
     var schedules []entity.TrainerSchedule
@@ -96,8 +230,11 @@ func GetTrainerSchedulesByDate(trainerId uint, date time.Time) ([]entity.Trainer
         return nil, err
     }
 
-    // อัปเดตสถานะตาม bookings จริง
+    // อัปเดตสถานะตาม bookings จริง (ช่วงที่ปิดไว้คงสถานะ Blocked)
     for i := range schedules {
+        if schedules[i].Status == "Blocked" {
+            continue
+        }
         if len(schedules[i].Bookings) > 0 {
             schedules[i].Status = "Booked"
         } else {
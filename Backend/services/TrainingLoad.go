@@ -0,0 +1,359 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+)
+
+// ค่าเริ่มต้นเมื่อผู้ใช้ยังไม่ได้ตั้งค่าอัตราการเต้นหัวใจ
+const (
+	defaultRestingHeartRate = 60
+	defaultMaxHeartRate     = 190
+	// maxTrackGapSeconds ช่วงห่างระหว่างจุดที่ยาวกว่านี้ถือว่าหยุดพัก ไม่นับเวลาในโซน
+	maxTrackGapSeconds = 60.0
+)
+
+// defaultZoneBounds เปอร์เซ็นต์ขอบล่างของโซน 1-5
+var defaultZoneBounds = []float64{50, 60, 70, 80, 90}
+
+// HeartRateSettings ค่าที่ใช้คำนวณโซนของผู้ใช้ (รวมค่าที่ประมาณให้เมื่อผู้ใช้ไม่ได้ตั้ง)
+type HeartRateSettings struct {
+	RestingHeartRate int       `json:"resting_heart_rate"`
+	MaxHeartRate     int       `json:"max_heart_rate"`
+	Method           string    `json:"method"`
+	Bounds           []float64 `json:"bounds"`
+	Estimated        bool      `json:"estimated"` // true เมื่อชีพจรสูงสุดมาจากสูตร 208 - 0.7 x อายุ
+	Female           bool      `json:"-"`
+}
+
+// HeartRateZone ช่วงของโซนเป็นครั้ง/นาที (MaxBPM ของโซนสุดท้ายคือชีพจรสูงสุด)
+type HeartRateZone struct {
+	Zone       int     `json:"zone"`
+	MinPercent float64 `json:"min_percent"`
+	MaxPercent float64 `json:"max_percent"`
+	MinBPM     int     `json:"min_bpm"`
+	MaxBPM     int     `json:"max_bpm"`
+}
+
+// DailyLoad ภาระการฝึกรวมต่อวัน
+type DailyLoad struct {
+	Date       string  `json:"date"`
+	Load       float64 `json:"load"`
+	Activities int     `json:"activities"`
+}
+
+// TrainingLoadReport ภาระการฝึกระยะสั้น (7 วัน) เทียบระยะยาว (28 วัน)
+type TrainingLoadReport struct {
+	UserID          uint              `json:"user_id"`
+	Date            string            `json:"date"`
+	AcuteLoad       float64           `json:"acute_load"`   // ค่าเฉลี่ยต่อวันใน 7 วัน
+	ChronicLoad     float64           `json:"chronic_load"` // ค่าเฉลี่ยต่อวันใน 28 วัน
+	ACWR            float64           `json:"acwr"`
+	Risk            string            `json:"risk"` // insufficient_data, low, optimal, caution, high
+	TimeInZones     []float64         `json:"time_in_zones"`
+	WithoutHR       int               `json:"activities_without_hr"` // กิจกรรมใน 28 วันที่ไม่มีข้อมูลชีพจร (ไม่ถูกนับภาระ)
+	Daily           []DailyLoad       `json:"daily"`
+	HeartRateConfig HeartRateSettings `json:"heart_rate"`
+}
+
+// ClientTrainingLoad ภาระการฝึกของลูกค้าแต่ละคนของเทรนเนอร์
+type ClientTrainingLoad struct {
+	UserID    uint               `json:"user_id"`
+	FirstName string             `json:"first_name"`
+	LastName  string             `json:"last_name"`
+	Report    TrainingLoadReport `json:"report"`
+}
+
+// GetHeartRateSettings ดึงค่าชีพจรของผู้ใช้ ถ้าไม่ได้ตั้งชีพจรสูงสุดจะประมาณจากอายุ
+func GetHeartRateSettings(userID uint) (HeartRateSettings, error) {
+	var user entity.Users
+	if err := config.DB().Preload("Gender").First(&user, userID).Error; err != nil {
+		return HeartRateSettings{}, err
+	}
+	return heartRateSettings(user), nil
+}
+
+func heartRateSettings(user entity.Users) HeartRateSettings {
+	settings := HeartRateSettings{
+		RestingHeartRate: user.RestingHeartRate,
+		MaxHeartRate:     user.MaxHeartRate,
+		Method:           user.HRZoneMethod,
+		Bounds:           user.HRZoneBounds,
+		Female:           user.Gender != nil && user.Gender.Gender == "หญิง",
+	}
+	if settings.RestingHeartRate <= 0 {
+		settings.RestingHeartRate = defaultRestingHeartRate
+	}
+	if settings.MaxHeartRate <= 0 {
+		settings.Estimated = true
+		settings.MaxHeartRate = defaultMaxHeartRate
+		if user.Age > 0 {
+			settings.MaxHeartRate = int(math.Round(208 - 0.7*float64(user.Age)))
+		}
+	}
+	if settings.Method == "" {
+		settings.Method = "percent_max"
+	}
+	if len(settings.Bounds) == 0 {
+		settings.Bounds = defaultZoneBounds
+	}
+	return settings
+}
+
+// ValidateHeartRateSettings ตรวจค่าชีพจรและการตั้งค่าโซนก่อนบันทึกลงโปรไฟล์
+func ValidateHeartRateSettings(user *entity.Users) error {
+	user.HRZoneMethod = strings.ToLower(strings.TrimSpace(user.HRZoneMethod))
+	if user.RestingHeartRate != 0 && (user.RestingHeartRate < 25 || user.RestingHeartRate > 120) {
+		return fmt.Errorf("ชีพจรขณะพักต้องอยู่ระหว่าง 25-120 ครั้ง/นาที")
+	}
+	if user.MaxHeartRate != 0 && (user.MaxHeartRate < 100 || user.MaxHeartRate > 230) {
+		return fmt.Errorf("ชีพจรสูงสุดต้องอยู่ระหว่าง 100-230 ครั้ง/นาที")
+	}
+	if user.RestingHeartRate != 0 && user.MaxHeartRate != 0 && user.RestingHeartRate >= user.MaxHeartRate {
+		return fmt.Errorf("ชีพจรขณะพักต้องน้อยกว่าชีพจรสูงสุด")
+	}
+	switch user.HRZoneMethod {
+	case "", "percent_max", "karvonen":
+	default:
+		return fmt.Errorf("hr_zone_method ต้องเป็น percent_max หรือ karvonen")
+	}
+	for i, b := range user.HRZoneBounds {
+		if b <= 0 || b >= 100 {
+			return fmt.Errorf("ขอบโซนต้องอยู่ระหว่าง 0-100 เปอร์เซ็นต์")
+		}
+		if i > 0 && b <= user.HRZoneBounds[i-1] {
+			return fmt.Errorf("ขอบโซนต้องเรียงจากน้อยไปมาก")
+		}
+	}
+	return nil
+}
+
+// HeartRateZones แปลงเปอร์เซ็นต์ของแต่ละโซนเป็นครั้ง/นาที
+func HeartRateZones(settings HeartRateSettings) []HeartRateZone {
+	zones := make([]HeartRateZone, len(settings.Bounds))
+	for i, lower := range settings.Bounds {
+		upper := 100.0
+		if i+1 < len(settings.Bounds) {
+			upper = settings.Bounds[i+1]
+		}
+		zones[i] = HeartRateZone{
+			Zone:       i + 1,
+			MinPercent: lower,
+			MaxPercent: upper,
+			MinBPM:     int(math.Round(settings.bpmAt(lower))),
+			MaxBPM:     int(math.Round(settings.bpmAt(upper))),
+		}
+	}
+	return zones
+}
+
+// bpmAt ชีพจรที่เปอร์เซ็นต์ที่กำหนดตามวิธีของผู้ใช้
+func (s HeartRateSettings) bpmAt(percent float64) float64 {
+	if s.Method == "karvonen" {
+		return float64(s.RestingHeartRate) + float64(s.MaxHeartRate-s.RestingHeartRate)*percent/100
+	}
+	return float64(s.MaxHeartRate) * percent / 100
+}
+
+// zoneOf โซนของชีพจร (0 = ต่ำกว่าโซน 1)
+func (s HeartRateSettings) zoneOf(hr int) int {
+	zone := 0
+	for i, lower := range s.Bounds {
+		if float64(hr) >= s.bpmAt(lower) {
+			zone = i + 1
+		}
+	}
+	return zone
+}
+
+// trimp ภาระการฝึกแบบ Banister TRIMP ของช่วงเวลา minutes ที่ชีพจรเฉลี่ย hr
+func (s HeartRateSettings) trimp(hr int, minutes float64) float64 {
+	reserve := float64(s.MaxHeartRate - s.RestingHeartRate)
+	if reserve <= 0 || hr <= 0 {
+		return 0
+	}
+	ratio := math.Max(0, math.Min(1, float64(hr-s.RestingHeartRate)/reserve))
+	a, b := 0.64, 1.92
+	if s.Female {
+		a, b = 0.86, 1.67
+	}
+	return minutes * ratio * a * math.Exp(b*ratio)
+}
+
+// ApplyHeartRateMetrics คำนวณเวลาในแต่ละโซนและ TRIMP ของกิจกรรม
+// ถ้ามีจุดบนเส้นทางจะคิดทีละช่วง ไม่อย่างนั้นใช้ชีพจรเฉลี่ยตลอดระยะเวลากิจกรรม
+func ApplyHeartRateMetrics(activity *entity.Activity, points []entity.TrackPoint) error {
+	if activity.AvgHeartRate < 0 || activity.AvgHeartRate > 250 || activity.MaxHeartRate < 0 || activity.MaxHeartRate > 250 {
+		return fmt.Errorf("อัตราการเต้นหัวใจไม่ถูกต้อง")
+	}
+	if activity.MaxHeartRate != 0 && activity.MaxHeartRate < activity.AvgHeartRate {
+		return fmt.Errorf("ชีพจรสูงสุดต้องไม่น้อยกว่าชีพจรเฉลี่ย")
+	}
+	settings, err := GetHeartRateSettings(activity.UserID)
+	if err != nil {
+		return err
+	}
+
+	zones := make([]float64, len(settings.Bounds))
+	load := 0.0
+	hasTrackHR := false
+	for i := 1; i < len(points); i++ {
+		hr := points[i].HeartRate
+		if hr <= 0 {
+			hr = points[i-1].HeartRate
+		}
+		seconds := points[i].Time.Sub(points[i-1].Time).Seconds()
+		if hr <= 0 || seconds <= 0 || seconds > maxTrackGapSeconds {
+			continue
+		}
+		hasTrackHR = true
+		if z := settings.zoneOf(hr); z > 0 {
+			zones[z-1] += seconds
+		}
+		load += settings.trimp(hr, seconds/60)
+	}
+	if !hasTrackHR && activity.AvgHeartRate > 0 {
+		seconds := activity.Duration * 60
+		if z := settings.zoneOf(activity.AvgHeartRate); z > 0 {
+			zones[z-1] = seconds
+		}
+		load = settings.trimp(activity.AvgHeartRate, activity.Duration)
+	}
+
+	for i := range zones {
+		zones[i] = math.Round(zones[i])
+	}
+	activity.TimeInZones = zones
+	activity.TrainingLoad = math.Round(load*10) / 10
+	return nil
+}
+
+// GetTrainingLoad ภาระการฝึกย้อนหลัง 28 วันนับถึงวันที่ date และอัตราส่วน acute:chronic
+func GetTrainingLoad(userID uint, date time.Time) (TrainingLoadReport, error) {
+	settings, err := GetHeartRateSettings(userID)
+	if err != nil {
+		return TrainingLoadReport{}, err
+	}
+
+	local := date.In(bangkok)
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, bangkok).AddDate(0, 0, 1)
+	start := end.AddDate(0, 0, -28)
+
+	var activities []entity.Activity
+	if err := config.DB().
+		Where("user_id = ? AND date >= ? AND date < ?", userID, start, end).
+		Find(&activities).Error; err != nil {
+		return TrainingLoadReport{}, err
+	}
+
+	report := TrainingLoadReport{
+		UserID:          userID,
+		Date:            end.AddDate(0, 0, -1).Format("2006-01-02"),
+		TimeInZones:     make([]float64, len(settings.Bounds)),
+		Daily:           make([]DailyLoad, 28),
+		HeartRateConfig: settings,
+	}
+	for i := range report.Daily {
+		report.Daily[i].Date = start.AddDate(0, 0, i).Format("2006-01-02")
+	}
+
+	acuteStart := end.AddDate(0, 0, -7)
+	acute, chronic := 0.0, 0.0
+	for _, a := range activities {
+		day := a.Date.In(bangkok)
+		index := int(time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, bangkok).Sub(start).Hours() / 24)
+		if index < 0 || index >= len(report.Daily) {
+			continue
+		}
+		report.Daily[index].Load += a.TrainingLoad
+		report.Daily[index].Activities++
+		chronic += a.TrainingLoad
+		if !a.Date.Before(acuteStart) {
+			acute += a.TrainingLoad
+		}
+		if a.AvgHeartRate == 0 && a.TrainingLoad == 0 {
+			report.WithoutHR++
+		}
+		for z := 0; z < len(a.TimeInZones) && z < len(report.TimeInZones); z++ {
+			report.TimeInZones[z] += a.TimeInZones[z]
+		}
+	}
+	for i := range report.Daily {
+		report.Daily[i].Load = math.Round(report.Daily[i].Load*10) / 10
+	}
+
+	report.AcuteLoad = math.Round(acute/7*10) / 10
+	report.ChronicLoad = math.Round(chronic/28*10) / 10
+	if report.ChronicLoad > 0 {
+		report.ACWR = math.Round(acute/7/(chronic/28)*100) / 100
+	}
+	report.Risk = acwrRisk(report.ACWR, report.ChronicLoad)
+	return report, nil
+}
+
+// acwrRisk แปลผล ACWR: ช่วง 0.8-1.3 ถือว่าเหมาะสม มากกว่า 1.5 เสี่ยงบาดเจ็บ/ฝึกหนักเกิน
+func acwrRisk(acwr, chronic float64) string {
+	switch {
+	case chronic <= 0:
+		return "insufficient_data"
+	case acwr < 0.8:
+		return "low"
+	case acwr <= 1.3:
+		return "optimal"
+	case acwr <= 1.5:
+		return "caution"
+	default:
+		return "high"
+	}
+}
+
+// GetTrainerClientsTrainingLoad ภาระการฝึกของลูกค้าทุกคนของเทรนเนอร์
+// เรียงจาก ACWR สูงสุดเพื่อให้เห็นผู้ที่เสี่ยงฝึกหนักเกินก่อน
+func GetTrainerClientsTrainingLoad(trainerID uint, date time.Time) ([]ClientTrainingLoad, error) {
+	users, err := trainerClients(trainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ClientTrainingLoad, 0, len(users))
+	for _, u := range users {
+		report, err := GetTrainingLoad(u.ID, date)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, ClientTrainingLoad{UserID: u.ID, FirstName: u.FirstName, LastName: u.LastName, Report: report})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Report.ACWR > results[j].Report.ACWR })
+	return results, nil
+}
+
+// trainerClients ลูกค้าของเทรนเนอร์ (จากนัดเทรนส่วนตัวและโปรแกรมฝึกที่มอบหมาย)
+func trainerClients(trainerID uint) ([]entity.Users, error) {
+	db := config.DB()
+	var users []entity.Users
+	err := db.Where("id IN (?) OR id IN (?)",
+		db.Model(&entity.PersonalTrain{}).Select("user_id").Where("trainer_id = ?", trainerID),
+		db.Model(&entity.TrainingProgram{}).Select("user_id").Where("trainer_id = ? AND user_id IS NOT NULL", trainerID),
+	).Find(&users).Error
+	return users, err
+}
+
+// IsTrainerClient ตรวจว่าผู้ใช้เป็นลูกค้าของเทรนเนอร์หรือไม่
+func IsTrainerClient(trainerID, userID uint) (bool, error) {
+	users, err := trainerClients(trainerID)
+	if err != nil {
+		return false, err
+	}
+	for _, u := range users {
+		if u.ID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// ErrProgramSuperseded ส่งกลับเมื่อพยายามแก้ไขโปรแกรมเวอร์ชันเก่า
+var ErrProgramSuperseded = errors.New("โปรแกรมนี้ถูกแทนด้วยเวอร์ชันใหม่แล้ว กรุณาแก้ไขเวอร์ชันล่าสุด")
+
+// TrainingProgramFilter เงื่อนไขการค้นหาโปรแกรมฝึก (ค่าศูนย์/nil คือไม่กรอง)
+type TrainingProgramFilter struct {
+	TrainerID     uint
+	UserID        uint
+	TemplatesOnly bool
+}
+
+// CreateTrainingProgram สร้างโปรแกรมฝึกพร้อมช่วง เซสชัน และท่าฝึกทั้งหมด
+func CreateTrainingProgram(program entity.TrainingProgram) (entity.TrainingProgram, error) {
+	if err := fillPrescriptionNames(&program); err != nil {
+		return program, err
+	}
+	if err := validateTrainingProgram(program); err != nil {
+		return program, err
+	}
+	if program.IsTemplate {
+		program.UserID = nil
+	}
+	program.Status = "active"
+	program.Version = 1
+	program.RootProgramID = nil
+	program.PreviousVersionID = nil
+
+	if err := config.DB().Create(&program).Error; err != nil {
+		return program, err
+	}
+	return GetTrainingProgram(program.ID)
+}
+
+// GetTrainingProgram ดึงโปรแกรมพร้อมโครงสร้างทั้งหมด เรียงตามลำดับที่กำหนด
+func GetTrainingProgram(id uint) (entity.TrainingProgram, error) {
+	var program entity.TrainingProgram
+	err := preloadProgramTree(config.DB()).
+		Preload("Trainer").
+		Preload("User").
+		First(&program, id).Error
+	if err != nil {
+		return program, err
+	}
+	hideProgramPasswords(&program)
+	return program, nil
+}
+
+// ListTrainingPrograms ดึงโปรแกรมเวอร์ชันล่าสุดตามเงื่อนไข (ไม่รวมโครงสร้างภายใน)
+func ListTrainingPrograms(filter TrainingProgramFilter) ([]entity.TrainingProgram, error) {
+	var programs []entity.TrainingProgram
+	query := config.DB().
+		Preload("Trainer").
+		Preload("User").
+		Where("status <> ?", "superseded")
+	if filter.TrainerID != 0 {
+		query = query.Where("trainer_id = ?", filter.TrainerID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.TemplatesOnly {
+		query = query.Where("is_template = ?", true)
+	}
+	if err := query.Order("updated_at DESC").Find(&programs).Error; err != nil {
+		return programs, err
+	}
+	for i := range programs {
+		hideProgramPasswords(&programs[i])
+	}
+	return programs, nil
+}
+
+// CopyTrainingProgram คัดลอกโปรแกรม (มักเป็นต้นแบบ) เป็นโปรแกรมใหม่
+// ถ้าระบุ userID จะเป็นการมอบหมายโปรแกรมให้ลูกค้า มิฉะนั้นจะได้ต้นแบบใหม่
+func CopyTrainingProgram(sourceID uint, trainerID uint, userID *uint, startDate *time.Time) (entity.TrainingProgram, error) {
+	source, err := GetTrainingProgram(sourceID)
+	if err != nil {
+		return source, err
+	}
+	if userID != nil {
+		var user entity.Users
+		if err := config.DB().First(&user, *userID).Error; err != nil {
+			return source, fmt.Errorf("ไม่พบข้อมูลลูกค้า")
+		}
+	}
+
+	program := cloneTrainingProgram(source)
+	program.IsTemplate = userID == nil
+	program.UserID = userID
+	program.StartDate = startDate
+	program.Version = 1
+	program.Status = "active"
+	program.RootProgramID = nil
+	program.PreviousVersionID = nil
+	if trainerID != 0 {
+		program.TrainerID = trainerID
+	}
+	if source.IsTemplate {
+		program.SourceTemplateID = &source.ID
+	}
+
+	if err := config.DB().Create(&program).Error; err != nil {
+		return program, err
+	}
+	return GetTrainingProgram(program.ID)
+}
+
+// ReviseTrainingProgram แก้ไขโครงสร้างโปรแกรม
+// ต้นแบบจะถูกแก้ไขในที่เดิม ส่วนโปรแกรมที่มอบหมายให้ลูกค้าแล้วจะสร้างเวอร์ชันใหม่
+// โดยเวอร์ชันเดิมยังอยู่ครบ และคัดลอกสถานะเซสชันที่ทำแล้วไปยังเซสชันตำแหน่งเดียวกัน
+func ReviseTrainingProgram(id uint, input entity.TrainingProgram) (entity.TrainingProgram, error) {
+	current, err := GetTrainingProgram(id)
+	if err != nil {
+		return current, err
+	}
+	if current.Status == "superseded" {
+		return current, ErrProgramSuperseded
+	}
+	input.TrainerID = current.TrainerID
+	if err := fillPrescriptionNames(&input); err != nil {
+		return current, err
+	}
+	if err := validateTrainingProgram(input); err != nil {
+		return current, err
+	}
+
+	if current.UserID == nil {
+		return replaceProgramTree(current, input)
+	}
+
+	revised := cloneTrainingProgram(input)
+	revised.TrainerID = current.TrainerID
+	revised.UserID = current.UserID
+	revised.IsTemplate = false
+	revised.SourceTemplateID = current.SourceTemplateID
+	revised.StartDate = current.StartDate
+	if input.StartDate != nil {
+		revised.StartDate = input.StartDate
+	}
+	revised.Status = "active"
+	revised.Version = current.Version + 1
+	revised.PreviousVersionID = &current.ID
+	root := programRootID(current)
+	revised.RootProgramID = &root
+	carryCompletedSessions(current, &revised)
+
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		// กันการแก้ไขซ้อนกัน: ต้องเป็นเวอร์ชันล่าสุดอยู่ในขณะที่บันทึก
+		result := tx.Model(&entity.TrainingProgram{}).
+			Where("id = ? AND status <> ?", current.ID, "superseded").
+			Update("status", "superseded")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrProgramSuperseded
+		}
+		return tx.Create(&revised).Error
+	})
+	if err != nil {
+		return current, err
+	}
+	return GetTrainingProgram(revised.ID)
+}
+
+// GetTrainingProgramVersions ดึงทุกเวอร์ชันของโปรแกรม เรียงจากเก่าไปใหม่
+func GetTrainingProgramVersions(id uint) ([]entity.TrainingProgram, error) {
+	var program entity.TrainingProgram
+	if err := config.DB().First(&program, id).Error; err != nil {
+		return nil, err
+	}
+	root := programRootID(program)
+
+	var versions []entity.TrainingProgram
+	err := preloadProgramTree(config.DB()).
+		Where("id = ? OR root_program_id = ?", root, root).
+		Order("version").
+		Find(&versions).Error
+	return versions, err
+}
+
+// SetProgramSessionCompleted บันทึกหรือยกเลิกสถานะทำเซสชันเสร็จแล้ว
+func SetProgramSessionCompleted(sessionID uint, completed bool) (entity.ProgramSession, error) {
+	var session entity.ProgramSession
+	if err := config.DB().First(&session, sessionID).Error; err != nil {
+		return session, err
+	}
+	var completedAt *time.Time
+	if completed {
+		now := time.Now()
+		completedAt = &now
+	}
+	if err := config.DB().Model(&session).Update("completed_at", completedAt).Error; err != nil {
+		return session, err
+	}
+	session.CompletedAt = completedAt
+	return session, nil
+}
+
+// GetProgramOfSession ดึงโปรแกรมที่เซสชันนั้นอยู่ (ใช้ตรวจสิทธิ์)
+func GetProgramOfSession(sessionID uint) (entity.TrainingProgram, error) {
+	var program entity.TrainingProgram
+	err := config.DB().
+		Joins("JOIN program_phases ON program_phases.program_id = training_programs.id").
+		Joins("JOIN program_sessions ON program_sessions.phase_id = program_phases.id").
+		Where("program_sessions.id = ?", sessionID).
+		First(&program).Error
+	return program, err
+}
+
+// DeleteTrainingProgram ลบโปรแกรม (soft delete) เวอร์ชันก่อนหน้ายังเก็บไว้เป็นประวัติ
+func DeleteTrainingProgram(id uint) error {
+	return config.DB().Delete(&entity.TrainingProgram{}, id).Error
+}
+
+func preloadProgramTree(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Phases", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		Preload("Phases.Sessions", func(db *gorm.DB) *gorm.DB { return db.Order("week, day, position, id") }).
+		Preload("Phases.Sessions.Exercises", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		Preload("Phases.Sessions.Exercises.Exercise")
+}
+
+// replaceProgramTree แทนที่โครงสร้างของโปรแกรมเดิม (ใช้กับต้นแบบซึ่งไม่มีประวัติการฝึก)
+func replaceProgramTree(current, input entity.TrainingProgram) (entity.TrainingProgram, error) {
+	phases := cloneTrainingProgram(input).Phases
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		phaseIDs := tx.Model(&entity.ProgramPhase{}).Select("id").Where("program_id = ?", current.ID)
+		sessionIDs := tx.Model(&entity.ProgramSession{}).Select("id").Where("phase_id IN (?)", phaseIDs)
+		if err := tx.Where("session_id IN (?)", sessionIDs).Delete(&entity.ExercisePrescription{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("phase_id IN (?)", phaseIDs).Delete(&entity.ProgramSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("program_id = ?", current.ID).Delete(&entity.ProgramPhase{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entity.TrainingProgram{}).Where("id = ?", current.ID).Updates(map[string]interface{}{
+			"name":        input.Name,
+			"description": input.Description,
+		}).Error; err != nil {
+			return err
+		}
+		for i := range phases {
+			phases[i].ProgramID = current.ID
+		}
+		if len(phases) == 0 {
+			return nil
+		}
+		return tx.Create(&phases).Error
+	})
+	if err != nil {
+		return current, err
+	}
+	return GetTrainingProgram(current.ID)
+}
+
+// cloneTrainingProgram คัดลอกโปรแกรมทั้งโครงสร้างโดยล้างรหัสเดิมและสถานะการทำเซสชัน เพื่อให้บันทึกเป็นแถวใหม่
+func cloneTrainingProgram(src entity.TrainingProgram) entity.TrainingProgram {
+	program := entity.TrainingProgram{
+		Name:             src.Name,
+		Description:      src.Description,
+		IsTemplate:       src.IsTemplate,
+		TrainerID:        src.TrainerID,
+		UserID:           src.UserID,
+		StartDate:        src.StartDate,
+		SourceTemplateID: src.SourceTemplateID,
+		Phases:           make([]entity.ProgramPhase, 0, len(src.Phases)),
+	}
+	for _, ph := range src.Phases {
+		phase := entity.ProgramPhase{
+			Name:     ph.Name,
+			Position: ph.Position,
+			Weeks:    ph.Weeks,
+			Sessions: make([]entity.ProgramSession, 0, len(ph.Sessions)),
+		}
+		for _, se := range ph.Sessions {
+			session := entity.ProgramSession{
+				Name:      se.Name,
+				Week:      se.Week,
+				Day:       se.Day,
+				Position:  se.Position,
+				Notes:     se.Notes,
+				Exercises: make([]entity.ExercisePrescription, 0, len(se.Exercises)),
+			}
+			for _, ex := range se.Exercises {
+				ex.Model = gorm.Model{}
+				ex.SessionID = 0
+				ex.Exercise = nil
+				session.Exercises = append(session.Exercises, ex)
+			}
+			phase.Sessions = append(phase.Sessions, session)
+		}
+		program.Phases = append(program.Phases, phase)
+	}
+	return program
+}
+
+// carryCompletedSessions คัดลอกเวลาที่ทำเซสชันเสร็จจากเวอร์ชันเดิม
+// จับคู่ด้วย (ลำดับช่วง, สัปดาห์, วัน, ลำดับเซสชัน)
+func carryCompletedSessions(previous entity.TrainingProgram, revised *entity.TrainingProgram) {
+	completed := map[string]*time.Time{}
+	for _, ph := range previous.Phases {
+		for _, se := range ph.Sessions {
+			if se.CompletedAt != nil {
+				completed[sessionKey(ph.Position, se)] = se.CompletedAt
+			}
+		}
+	}
+	for i := range revised.Phases {
+		phase := &revised.Phases[i]
+		for j := range phase.Sessions {
+			if at, ok := completed[sessionKey(phase.Position, phase.Sessions[j])]; ok {
+				phase.Sessions[j].CompletedAt = at
+			}
+		}
+	}
+}
+
+func sessionKey(phasePosition int, s entity.ProgramSession) string {
+	return fmt.Sprintf("%d/%d/%d/%d", phasePosition, s.Week, s.Day, s.Position)
+}
+
+func programRootID(p entity.TrainingProgram) uint {
+	if p.RootProgramID != nil {
+		return *p.RootProgramID
+	}
+	return p.ID
+}
+
+func hideProgramPasswords(p *entity.TrainingProgram) {
+	if p.Trainer != nil {
+		p.Trainer.Password = ""
+	}
+	if p.User != nil {
+		p.User.Password = ""
+	}
+}
+
+func validateTrainingProgram(p entity.TrainingProgram) error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("กรุณาระบุชื่อโปรแกรม")
+	}
+	if p.TrainerID == 0 {
+		return fmt.Errorf("trainer_id ไม่ถูกต้อง")
+	}
+	for _, ph := range p.Phases {
+		if strings.TrimSpace(ph.Name) == "" {
+			return fmt.Errorf("กรุณาระบุชื่อช่วงของโปรแกรม")
+		}
+		if ph.Weeks < 1 {
+			return fmt.Errorf("ช่วง %s ต้องมีอย่างน้อย 1 สัปดาห์", ph.Name)
+		}
+		for _, se := range ph.Sessions {
+			if se.Week < 1 || se.Week > ph.Weeks {
+				return fmt.Errorf("เซสชัน %s: สัปดาห์ต้องอยู่ระหว่าง 1-%d", se.Name, ph.Weeks)
+			}
+			if se.Day < 1 || se.Day > 7 {
+				return fmt.Errorf("เซสชัน %s: วันต้องอยู่ระหว่าง 1-7", se.Name)
+			}
+			for _, ex := range se.Exercises {
+				if err := validatePrescription(ex); err != nil {
+					return fmt.Errorf("เซสชัน %s: %v", se.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validatePrescription(ex entity.ExercisePrescription) error {
+	switch {
+	case strings.TrimSpace(ex.ExerciseName) == "":
+		return fmt.Errorf("กรุณาระบุชื่อท่าฝึก")
+	case ex.Sets < 1:
+		return fmt.Errorf("ท่า %s ต้องมีอย่างน้อย 1 เซต", ex.ExerciseName)
+	case ex.LoadKg < 0 || ex.LoadPercent < 0 || ex.LoadPercent > 100:
+		return fmt.Errorf("น้ำหนักของท่า %s ไม่ถูกต้อง", ex.ExerciseName)
+	case ex.RestSeconds < 0:
+		return fmt.Errorf("เวลาพักของท่า %s ไม่ถูกต้อง", ex.ExerciseName)
+	case ex.RPE < 0 || ex.RPE > 10:
+		return fmt.Errorf("RPE ของท่า %s ต้องอยู่ระหว่าง 0-10", ex.ExerciseName)
+	}
+	return nil
+}
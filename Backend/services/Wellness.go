@@ -0,0 +1,431 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// minCorrelationDays จำนวนวันขั้นต่ำที่มีข้อมูลทั้งสองค่าก่อนคำนวณสหสัมพันธ์
+const minCorrelationDays = 5
+
+// wellnessMetrics ค่าจากการบันทึกประจำวัน
+var wellnessMetrics = []string{"water_ml", "sleep_hours", "sleep_quality", "resting_hr", "mood", "soreness", "stress"}
+
+// performanceMetrics ค่าจากการฝึกและกิจกรรมของวันนั้น
+var performanceMetrics = []string{"workout_volume", "workout_rpe", "training_load", "activity_minutes"}
+
+// CorrelationPair คู่ค่าที่ต้องการดูความสัมพันธ์ Lag คือจำนวนวันที่ Y ตามหลัง X
+type CorrelationPair struct {
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	Lag int    `json:"lag_days"`
+}
+
+// defaultCorrelationPairs คู่ค่าที่แสดงเมื่อไม่ได้ระบุ
+var defaultCorrelationPairs = []CorrelationPair{
+	{"sleep_hours", "workout_volume", 0},
+	{"sleep_quality", "workout_volume", 0},
+	{"sleep_hours", "workout_rpe", 0},
+	{"sleep_hours", "mood", 0},
+	{"stress", "sleep_quality", 0},
+	{"training_load", "soreness", 1},
+	{"training_load", "resting_hr", 1},
+}
+
+// WellnessStreak จำนวนวันที่บันทึกต่อเนื่อง
+type WellnessStreak struct {
+	Current        int    `json:"current"` // นับถึงวันนี้ หรือเมื่อวานถ้าวันนี้ยังไม่บันทึก
+	Longest        int    `json:"longest"`
+	CheckedInToday bool   `json:"checked_in_today"`
+	LastCheckIn    string `json:"last_check_in,omitempty"`
+	TotalDays      int    `json:"total_days"`
+}
+
+// WellnessWeek ค่าเฉลี่ยรายสัปดาห์ (เริ่มวันจันทร์) คิดเฉพาะวันที่บันทึกค่านั้น nil = ไม่มีข้อมูล
+type WellnessWeek struct {
+	WeekStart       string   `json:"week_start"`
+	DaysLogged      int      `json:"days_logged"`
+	AvgWaterMl      *float64 `json:"avg_water_ml"`
+	AvgSleepHours   *float64 `json:"avg_sleep_hours"`
+	AvgSleepQuality *float64 `json:"avg_sleep_quality"`
+	AvgRestingHR    *float64 `json:"avg_resting_hr"`
+	AvgMood         *float64 `json:"avg_mood"`
+	AvgSoreness     *float64 `json:"avg_soreness"`
+	AvgStress       *float64 `json:"avg_stress"`
+}
+
+// Correlation สหสัมพันธ์แบบเพียร์สันของคู่ค่า
+type Correlation struct {
+	CorrelationPair
+	Days        int      `json:"days"`
+	Coefficient *float64 `json:"coefficient"` // nil เมื่อข้อมูลไม่พอหรือค่าไม่แปรผัน
+	Strength    string   `json:"strength"`    // none, weak, moderate, strong, insufficient_data
+	Direction   string   `json:"direction,omitempty"`
+}
+
+// SaveWellnessCheckIn บันทึกของวันที่กำหนด ถ้ามีอยู่แล้วจะแทนที่ค่าเดิม
+func SaveWellnessCheckIn(checkIn entity.WellnessCheckIn) (entity.WellnessCheckIn, error) {
+	if err := validateWellnessCheckIn(&checkIn); err != nil {
+		return checkIn, err
+	}
+	var existing entity.WellnessCheckIn
+	err := config.DB().Where("user_id = ? AND date = ?", checkIn.UserID, checkIn.Date).First(&existing).Error
+	switch {
+	case err == nil:
+		checkIn.Model = existing.Model
+		err = config.DB().Save(&checkIn).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		checkIn.Model = gorm.Model{}
+		err = config.DB().Create(&checkIn).Error
+	}
+	return checkIn, err
+}
+
+// GetWellnessCheckIn ดึงบันทึกของวันที่กำหนด
+func GetWellnessCheckIn(userID uint, date string) (entity.WellnessCheckIn, error) {
+	var checkIn entity.WellnessCheckIn
+	err := config.DB().Where("user_id = ? AND date = ?", userID, date).First(&checkIn).Error
+	return checkIn, err
+}
+
+// GetWellnessCheckIns ดึงบันทึกในช่วงวันที่ (ว่าง = ไม่จำกัด) เรียงจากใหม่ไปเก่า
+func GetWellnessCheckIns(userID uint, from, to string) ([]entity.WellnessCheckIn, error) {
+	query := config.DB().Where("user_id = ?", userID).Order("date desc")
+	if from != "" {
+		query = query.Where("date >= ?", from)
+	}
+	if to != "" {
+		query = query.Where("date <= ?", to)
+	}
+	var checkIns []entity.WellnessCheckIn
+	err := query.Find(&checkIns).Error
+	return checkIns, err
+}
+
+// DeleteWellnessCheckIn ลบบันทึกของวัน (ลบจริงเพื่อให้บันทึกวันเดิมใหม่ได้)
+func DeleteWellnessCheckIn(userID uint, date string) error {
+	result := config.DB().Unscoped().Where("user_id = ? AND date = ?", userID, date).Delete(&entity.WellnessCheckIn{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetWellnessStreak นับวันที่บันทึกต่อเนื่องปัจจุบันและยาวที่สุด
+func GetWellnessStreak(userID uint) (WellnessStreak, error) {
+	var dates []string
+	err := config.DB().Model(&entity.WellnessCheckIn{}).Where("user_id = ?", userID).
+		Order("date").Pluck("date", &dates).Error
+	if err != nil {
+		return WellnessStreak{}, err
+	}
+	streak := WellnessStreak{TotalDays: len(dates)}
+	if len(dates) == 0 {
+		return streak, nil
+	}
+	streak.LastCheckIn = dates[len(dates)-1]
+
+	run := 0
+	var prev time.Time
+	for i, d := range dates {
+		day, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			continue
+		}
+		if i > 0 && day.Sub(prev) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		if run > streak.Longest {
+			streak.Longest = run
+		}
+		prev = day
+	}
+
+	now := time.Now().In(bangkok)
+	today := now.Format("2006-01-02")
+	yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
+	streak.CheckedInToday = streak.LastCheckIn == today
+	if streak.LastCheckIn == today || streak.LastCheckIn == yesterday {
+		streak.Current = run
+	}
+	return streak, nil
+}
+
+// GetWellnessWeekly ค่าเฉลี่ยรายสัปดาห์ในช่วงวันที่
+func GetWellnessWeekly(userID uint, from, to string) ([]WellnessWeek, error) {
+	checkIns, err := GetWellnessCheckIns(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	type acc struct{ sum, n float64 }
+	weeks := map[string]map[string]*acc{}
+	days := map[string]int{}
+	for _, c := range checkIns {
+		day, err := time.ParseInLocation("2006-01-02", c.Date, bangkok)
+		if err != nil {
+			continue
+		}
+		key := weekStart(day).Format("2006-01-02")
+		if weeks[key] == nil {
+			weeks[key] = map[string]*acc{}
+		}
+		days[key]++
+		for metric, value := range wellnessValues(c) {
+			if weeks[key][metric] == nil {
+				weeks[key][metric] = &acc{}
+			}
+			weeks[key][metric].sum += value
+			weeks[key][metric].n++
+		}
+	}
+
+	result := []WellnessWeek{}
+	for key, metrics := range weeks {
+		avg := func(metric string) *float64 {
+			if a := metrics[metric]; a != nil && a.n > 0 {
+				return floatPtr(round2(a.sum / a.n))
+			}
+			return nil
+		}
+		result = append(result, WellnessWeek{
+			WeekStart:       key,
+			DaysLogged:      days[key],
+			AvgWaterMl:      avg("water_ml"),
+			AvgSleepHours:   avg("sleep_hours"),
+			AvgSleepQuality: avg("sleep_quality"),
+			AvgRestingHR:    avg("resting_hr"),
+			AvgMood:         avg("mood"),
+			AvgSoreness:     avg("soreness"),
+			AvgStress:       avg("stress"),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].WeekStart < result[j].WeekStart })
+	return result, nil
+}
+
+// GetWellnessCorrelations หาสหสัมพันธ์ระหว่างค่าสุขภาวะกับผลการฝึกรายวันในช่วง [from, to)
+// เช่น ชั่วโมงการนอนกับปริมาณการฝึก (volume) ในวันเดียวกัน หรือภาระการฝึกกับอาการปวดเมื่อยวันถัดไป
+func GetWellnessCorrelations(userID uint, from, to time.Time, pairs []CorrelationPair) ([]Correlation, error) {
+	if len(pairs) == 0 {
+		pairs = defaultCorrelationPairs
+	}
+	for _, p := range pairs {
+		for _, metric := range []string{p.X, p.Y} {
+			if !containsString(wellnessMetrics, metric) && !containsString(performanceMetrics, metric) {
+				return nil, fmt.Errorf("ไม่รู้จักค่า %s (ใช้ได้: %s)", metric,
+					strings.Join(append(append([]string{}, wellnessMetrics...), performanceMetrics...), ", "))
+			}
+		}
+		if p.Lag < 0 || p.Lag > 7 {
+			return nil, fmt.Errorf("lag_days ต้องอยู่ระหว่าง 0-7")
+		}
+	}
+	daily, err := dailyWellnessSeries(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Correlation{}
+	for _, p := range pairs {
+		var xs, ys []float64
+		for day, values := range daily {
+			x, ok := values[p.X]
+			if !ok {
+				continue
+			}
+			next, err := time.Parse("2006-01-02", day)
+			if err != nil {
+				continue
+			}
+			y, ok := daily[next.AddDate(0, 0, p.Lag).Format("2006-01-02")][p.Y]
+			if !ok {
+				continue
+			}
+			xs = append(xs, x)
+			ys = append(ys, y)
+		}
+		result = append(result, correlate(p, xs, ys))
+	}
+	return result, nil
+}
+
+// dailyWellnessSeries รวมค่าสุขภาวะและผลการฝึกเป็นรายวัน วันที่ไม่มีการฝึกจะไม่มีค่าการฝึก
+func dailyWellnessSeries(userID uint, from, to time.Time) (map[string]map[string]float64, error) {
+	daily := map[string]map[string]float64{}
+	set := func(day, metric string, value float64) {
+		if daily[day] == nil {
+			daily[day] = map[string]float64{}
+		}
+		daily[day][metric] = value
+	}
+	add := func(day, metric string, value float64) {
+		if daily[day] == nil {
+			daily[day] = map[string]float64{}
+		}
+		daily[day][metric] += value
+	}
+
+	checkIns, err := GetWellnessCheckIns(userID, from.In(bangkok).Format("2006-01-02"),
+		to.AddDate(0, 0, -1).In(bangkok).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range checkIns {
+		for metric, value := range wellnessValues(c) {
+			set(c.Date, metric, value)
+		}
+	}
+
+	// ขยายช่วงหนึ่งสัปดาห์เพื่อรองรับ lag
+	var logs []entity.WorkoutLog
+	err = config.DB().Preload("Exercises.Sets").
+		Where("user_id = ? AND date >= ? AND date < ?", userID, from, to.AddDate(0, 0, 7)).Find(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+	rpe := map[string][2]float64{}
+	for _, log := range logs {
+		day := log.Date.In(bangkok).Format("2006-01-02")
+		volume := 0.0
+		for _, ex := range log.Exercises {
+			for _, s := range ex.Sets {
+				if s.IsWarmup {
+					continue
+				}
+				volume += float64(s.Reps) * s.WeightKg
+				if s.RPE > 0 {
+					r := rpe[day]
+					rpe[day] = [2]float64{r[0] + s.RPE, r[1] + 1}
+				}
+			}
+		}
+		add(day, "workout_volume", volume)
+	}
+	for day, r := range rpe {
+		set(day, "workout_rpe", r[0]/r[1])
+	}
+
+	var activities []entity.Activity
+	err = config.DB().Where("user_id = ? AND date >= ? AND date < ?", userID, from, to.AddDate(0, 0, 7)).Find(&activities).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range activities {
+		day := a.Date.In(bangkok).Format("2006-01-02")
+		add(day, "activity_minutes", a.Duration)
+		add(day, "training_load", a.TrainingLoad)
+	}
+	return daily, nil
+}
+
+// wellnessValues ค่าที่บันทึกไว้ของวัน (ข้ามค่า 0 ซึ่งหมายถึงไม่ได้บันทึก)
+func wellnessValues(c entity.WellnessCheckIn) map[string]float64 {
+	values := map[string]float64{}
+	for metric, value := range map[string]float64{
+		"water_ml":      float64(c.WaterMl),
+		"sleep_hours":   c.SleepHours,
+		"sleep_quality": float64(c.SleepQuality),
+		"resting_hr":    float64(c.RestingHR),
+		"mood":          float64(c.Mood),
+		"soreness":      float64(c.Soreness),
+		"stress":        float64(c.Stress),
+	} {
+		if value > 0 {
+			values[metric] = value
+		}
+	}
+	return values
+}
+
+func correlate(pair CorrelationPair, xs, ys []float64) Correlation {
+	result := Correlation{CorrelationPair: pair, Days: len(xs), Strength: "insufficient_data"}
+	if len(xs) < minCorrelationDays {
+		return result
+	}
+	n := float64(len(xs))
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return result
+	}
+	r := cov / math.Sqrt(varX*varY)
+	result.Coefficient = floatPtr(math.Round(r*1000) / 1000)
+	switch abs := math.Abs(r); {
+	case abs < 0.1:
+		result.Strength = "none"
+	case abs < 0.3:
+		result.Strength = "weak"
+	case abs < 0.5:
+		result.Strength = "moderate"
+	default:
+		result.Strength = "strong"
+	}
+	if result.Strength != "none" {
+		result.Direction = "positive"
+		if r < 0 {
+			result.Direction = "negative"
+		}
+	}
+	return result
+}
+
+func validateWellnessCheckIn(c *entity.WellnessCheckIn) error {
+	day, err := time.ParseInLocation("2006-01-02", c.Date, bangkok)
+	if err != nil {
+		return fmt.Errorf("รูปแบบวันที่ไม่ถูกต้อง (YYYY-MM-DD)")
+	}
+	if day.After(time.Now().In(bangkok)) {
+		return fmt.Errorf("บันทึกล่วงหน้าไม่ได้")
+	}
+	if c.WaterMl < 0 || c.WaterMl > 10000 {
+		return fmt.Errorf("ปริมาณน้ำต้องอยู่ระหว่าง 0-10000 มล.")
+	}
+	if c.SleepHours < 0 || c.SleepHours > 24 {
+		return fmt.Errorf("ชั่วโมงการนอนต้องอยู่ระหว่าง 0-24")
+	}
+	if c.RestingHR != 0 && (c.RestingHR < 25 || c.RestingHR > 150) {
+		return fmt.Errorf("ชีพจรขณะพักต้องอยู่ระหว่าง 25-150 ครั้ง/นาที")
+	}
+	for _, rating := range []struct {
+		label string
+		value int
+	}{
+		{"คุณภาพการนอน", c.SleepQuality},
+		{"อารมณ์", c.Mood},
+		{"อาการปวดเมื่อย", c.Soreness},
+		{"ความเครียด", c.Stress},
+	} {
+		if rating.value < 0 || rating.value > 5 {
+			return fmt.Errorf("%sต้องอยู่ระหว่าง 1-5", rating.label)
+		}
+	}
+	if c.WaterMl == 0 && c.SleepHours == 0 && c.SleepQuality == 0 && c.RestingHR == 0 &&
+		c.Mood == 0 && c.Soreness == 0 && c.Stress == 0 && strings.TrimSpace(c.Note) == "" {
+		return fmt.Errorf("กรุณาบันทึกอย่างน้อยหนึ่งค่า")
+	}
+	return nil
+}
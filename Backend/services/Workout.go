@@ -0,0 +1,597 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"example.com/fitness-backend/config"
+	"example.com/fitness-backend/entity"
+	"gorm.io/gorm"
+)
+
+// MuscleVolume ปริมาณการฝึกต่อกลุ่มกล้ามเนื้อต่อสัปดาห์
+// กล้ามเนื้อรองนับครึ่งหนึ่งของเซตและปริมาณ
+type MuscleVolume struct {
+	WeekStart string  `json:"week_start"` // วันจันทร์ของสัปดาห์ (YYYY-MM-DD)
+	Muscle    string  `json:"muscle"`
+	Sets      float64 `json:"sets"`
+	VolumeKg  float64 `json:"volume_kg"` // ผลรวม reps x weight
+}
+
+// ExerciseComparison เทียบท่าที่กำหนดในโปรแกรมกับที่ทำจริง
+type ExerciseComparison struct {
+	ExerciseName string                       `json:"exercise_name"`
+	Prescribed   *entity.ExercisePrescription `json:"prescribed"`
+	Performed    *PerformedSummary            `json:"performed"`
+}
+
+// PerformedSummary สรุปเซตที่ทำจริงของท่าหนึ่ง (ไม่รวมเซตวอร์มอัพ)
+type PerformedSummary struct {
+	Sets        int     `json:"sets"`
+	Reps        []int   `json:"reps"`
+	TopWeightKg float64 `json:"top_weight_kg"`
+	AverageRPE  float64 `json:"average_rpe"`
+	VolumeKg    float64 `json:"volume_kg"`
+}
+
+// WorkoutComparison ผลเทียบการฝึกกับเซสชันในโปรแกรม
+type WorkoutComparison struct {
+	WorkoutLogID     uint                 `json:"workout_log_id"`
+	ProgramSessionID uint                 `json:"program_session_id"`
+	SessionName      string               `json:"session_name"`
+	Exercises        []ExerciseComparison `json:"exercises"`
+}
+
+// EstimateOneRepMax ประมาณ 1RM ด้วยสูตร Epley (น้ำหนัก x (1 + reps/30))
+// เซตที่ทำ 1 ครั้งใช้น้ำหนักจริง และไม่ประมาณค่า (คืน 0) เมื่อเกิน 12 ครั้งเพราะคลาดเคลื่อนสูง
+func EstimateOneRepMax(weightKg float64, reps int) float64 {
+	switch {
+	case weightKg <= 0 || reps <= 0 || reps > 12:
+		return 0
+	case reps == 1:
+		return weightKg
+	}
+	return math.Round(weightKg*(1+float64(reps)/30)*10) / 10
+}
+
+// CreateWorkoutLog บันทึกการฝึก คำนวณ 1RM ของแต่ละเซต และตรวจหาสถิติส่วนตัวใหม่
+// ส่งกลับสถิติที่ทำได้ในการฝึกครั้งนี้
+func CreateWorkoutLog(log entity.WorkoutLog) (entity.WorkoutLog, []entity.PersonalRecord, error) {
+	if err := prepareWorkoutLog(&log); err != nil {
+		return log, nil, err
+	}
+	log.Model = gorm.Model{}
+
+	err := config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&log).Error; err != nil {
+			return err
+		}
+		if err := markSessionCompleted(tx, log); err != nil {
+			return err
+		}
+		return rebuildPersonalRecords(tx, log.UserID, workoutExerciseKeys(log))
+	})
+	if err != nil {
+		return log, nil, err
+	}
+	RefreshGoalsAfterChange(log.UserID)
+	return workoutWithRecords(log.ID)
+}
+
+// UpdateWorkoutLog แทนที่ข้อมูลการฝึกทั้งหมด แล้วคำนวณสถิติส่วนตัวใหม่
+func UpdateWorkoutLog(id uint, input entity.WorkoutLog) (entity.WorkoutLog, []entity.PersonalRecord, error) {
+	existing, err := GetWorkoutLog(id)
+	if err != nil {
+		return existing, nil, err
+	}
+	input.UserID = existing.UserID
+	if err := prepareWorkoutLog(&input); err != nil {
+		return existing, nil, err
+	}
+	keys := append(workoutExerciseKeys(existing), workoutExerciseKeys(input)...)
+	exercises := input.Exercises
+
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		exerciseIDs := tx.Model(&entity.WorkoutExercise{}).Select("id").Where("workout_log_id = ?", id)
+		if err := tx.Where("workout_exercise_id IN (?)", exerciseIDs).Delete(&entity.WorkoutSet{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workout_log_id = ?", id).Delete(&entity.WorkoutExercise{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entity.WorkoutLog{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"date":               input.Date,
+			"title":              input.Title,
+			"notes":              input.Notes,
+			"duration_minutes":   input.DurationMinutes,
+			"personal_train_id":  input.PersonalTrainID,
+			"program_session_id": input.ProgramSessionID,
+		}).Error; err != nil {
+			return err
+		}
+		for i := range exercises {
+			exercises[i].WorkoutLogID = id
+		}
+		if len(exercises) > 0 {
+			if err := tx.Create(&exercises).Error; err != nil {
+				return err
+			}
+		}
+		input.ID = id
+		if err := markSessionCompleted(tx, input); err != nil {
+			return err
+		}
+		return rebuildPersonalRecords(tx, existing.UserID, keys)
+	})
+	if err != nil {
+		return existing, nil, err
+	}
+	RefreshGoalsAfterChange(existing.UserID)
+	return workoutWithRecords(id)
+}
+
+// DeleteWorkoutLog ลบการฝึก (soft delete) และคำนวณสถิติส่วนตัวของท่าที่เกี่ยวข้องใหม่
+func DeleteWorkoutLog(id uint) error {
+	log, err := GetWorkoutLog(id)
+	if err != nil {
+		return err
+	}
+	err = config.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&entity.WorkoutLog{}, id).Error; err != nil {
+			return err
+		}
+		return rebuildPersonalRecords(tx, log.UserID, workoutExerciseKeys(log))
+	})
+	if err != nil {
+		return err
+	}
+	RefreshGoalsAfterChange(log.UserID)
+	return nil
+}
+
+// GetWorkoutLog ดึงการฝึกพร้อมท่าและเซตทั้งหมด
+func GetWorkoutLog(id uint) (entity.WorkoutLog, error) {
+	var log entity.WorkoutLog
+	err := preloadWorkoutTree(config.DB()).First(&log, id).Error
+	return log, err
+}
+
+// GetWorkoutLogs ดึงการฝึกของผู้ใช้ในช่วงวันที่ (from/to เป็นศูนย์คือไม่จำกัด) ล่าสุดก่อน
+func GetWorkoutLogs(userID uint, from, to time.Time) ([]entity.WorkoutLog, error) {
+	query := preloadWorkoutTree(config.DB()).Where("user_id = ?", userID)
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("date < ?", to)
+	}
+	var logs []entity.WorkoutLog
+	err := query.Order("date DESC").Find(&logs).Error
+	return logs, err
+}
+
+// GetPersonalRecords ดึงสถิติที่ดีที่สุดปัจจุบันของทุกท่า (ระบุ exerciseKey เพื่อดูประวัติทั้งหมดของท่านั้น)
+func GetPersonalRecords(userID uint, exerciseKey string) ([]entity.PersonalRecord, error) {
+	query := config.DB().Where("user_id = ?", userID)
+	if exerciseKey != "" {
+		var history []entity.PersonalRecord
+		err := query.Where("exercise_key = ?", exerciseKey).Order("achieved_at, id").Find(&history).Error
+		return history, err
+	}
+
+	var records []entity.PersonalRecord
+	if err := query.Order("exercise_key, kind").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	best := map[string]int{}
+	var current []entity.PersonalRecord
+	for _, r := range records {
+		key := r.ExerciseKey + "/" + r.Kind
+		if i, ok := best[key]; ok {
+			if r.Value > current[i].Value {
+				current[i] = r
+			}
+			continue
+		}
+		best[key] = len(current)
+		current = append(current, r)
+	}
+	return current, nil
+}
+
+// GetWeeklyMuscleVolume สรุปจำนวนเซตและปริมาณการฝึกต่อกลุ่มกล้ามเนื้อต่อสัปดาห์
+// ท่าที่ไม่ได้เชื่อมกับคลังท่าฝึกจะนับเป็นกลุ่ม "unassigned"
+func GetWeeklyMuscleVolume(userID uint, from, to time.Time) ([]MuscleVolume, error) {
+	logs, err := GetWorkoutLogs(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[string]*MuscleVolume{}
+	add := func(week, muscle string, sets, volume float64) {
+		key := week + "/" + muscle
+		if totals[key] == nil {
+			totals[key] = &MuscleVolume{WeekStart: week, Muscle: muscle}
+		}
+		totals[key].Sets += sets
+		totals[key].VolumeKg += volume
+	}
+
+	for _, log := range logs {
+		week := weekStart(log.Date).Format("2006-01-02")
+		for _, ex := range log.Exercises {
+			var sets, volume float64
+			for _, s := range ex.Sets {
+				if s.IsWarmup {
+					continue
+				}
+				sets++
+				volume += float64(s.Reps) * s.WeightKg
+			}
+			if sets == 0 {
+				continue
+			}
+			if ex.Exercise == nil || len(ex.Exercise.PrimaryMuscles) == 0 {
+				add(week, "unassigned", sets, volume)
+				continue
+			}
+			for _, m := range ex.Exercise.PrimaryMuscles {
+				add(week, m, sets, volume)
+			}
+			for _, m := range ex.Exercise.SecondaryMuscles {
+				add(week, m, sets/2, volume/2)
+			}
+		}
+	}
+
+	result := make([]MuscleVolume, 0, len(totals))
+	for _, v := range totals {
+		v.VolumeKg = math.Round(v.VolumeKg*10) / 10
+		result = append(result, *v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].WeekStart != result[j].WeekStart {
+			return result[i].WeekStart < result[j].WeekStart
+		}
+		return result[i].Muscle < result[j].Muscle
+	})
+	return result, nil
+}
+
+// CompareWorkoutWithProgram เทียบการฝึกกับท่าที่กำหนดในเซสชันของโปรแกรม
+// จับคู่ท่าด้วย ExerciseID ถ้ามี มิฉะนั้นใช้ชื่อท่า
+func CompareWorkoutWithProgram(workoutID uint) (WorkoutComparison, error) {
+	log, err := GetWorkoutLog(workoutID)
+	if err != nil {
+		return WorkoutComparison{}, err
+	}
+	if log.ProgramSessionID == nil {
+		return WorkoutComparison{}, fmt.Errorf("การฝึกนี้ไม่ได้เชื่อมกับเซสชันในโปรแกรมฝึก")
+	}
+
+	var session entity.ProgramSession
+	if err := config.DB().
+		Preload("Exercises", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		First(&session, *log.ProgramSessionID).Error; err != nil {
+		return WorkoutComparison{}, err
+	}
+
+	result := WorkoutComparison{
+		WorkoutLogID:     log.ID,
+		ProgramSessionID: session.ID,
+		SessionName:      session.Name,
+		Exercises:        []ExerciseComparison{},
+	}
+	used := map[int]bool{}
+	for i := range session.Exercises {
+		p := session.Exercises[i]
+		item := ExerciseComparison{ExerciseName: p.ExerciseName, Prescribed: &p}
+		for j, ex := range log.Exercises {
+			if used[j] || !sameExercise(p.ExerciseID, p.ExerciseName, ex.ExerciseID, ex.ExerciseName) {
+				continue
+			}
+			used[j] = true
+			item.Performed = summarizeSets(ex.Sets)
+			break
+		}
+		result.Exercises = append(result.Exercises, item)
+	}
+	// ท่าที่ทำเพิ่มนอกเหนือจากโปรแกรม
+	for j, ex := range log.Exercises {
+		if !used[j] {
+			result.Exercises = append(result.Exercises, ExerciseComparison{
+				ExerciseName: ex.ExerciseName,
+				Performed:    summarizeSets(ex.Sets),
+			})
+		}
+	}
+	return result, nil
+}
+
+func preloadWorkoutTree(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Exercises", func(db *gorm.DB) *gorm.DB { return db.Order("position, id") }).
+		Preload("Exercises.Exercise").
+		Preload("Exercises.Sets", func(db *gorm.DB) *gorm.DB { return db.Order("set_number, id") })
+}
+
+func workoutWithRecords(id uint) (entity.WorkoutLog, []entity.PersonalRecord, error) {
+	log, err := GetWorkoutLog(id)
+	if err != nil {
+		return log, nil, err
+	}
+	records := []entity.PersonalRecord{}
+	err = config.DB().Where("workout_log_id = ?", id).Order("exercise_key, kind").Find(&records).Error
+	return log, records, err
+}
+
+// prepareWorkoutLog ตรวจสอบข้อมูล เติมชื่อท่าจากคลัง ล้างรหัสเดิม และคำนวณ 1RM
+func prepareWorkoutLog(log *entity.WorkoutLog) error {
+	if log.UserID == 0 {
+		return fmt.Errorf("user_id ไม่ถูกต้อง")
+	}
+	if len(log.Exercises) == 0 {
+		return fmt.Errorf("กรุณาบันทึกอย่างน้อยหนึ่งท่า")
+	}
+	if log.Date.IsZero() {
+		log.Date = time.Now()
+	}
+	if log.DurationMinutes < 0 {
+		return fmt.Errorf("ระยะเวลาไม่ถูกต้อง")
+	}
+	if err := validateWorkoutLinks(*log); err != nil {
+		return err
+	}
+
+	for i := range log.Exercises {
+		ex := &log.Exercises[i]
+		ex.Model = gorm.Model{}
+		ex.WorkoutLogID = 0
+		if ex.ExerciseID != nil {
+			item, err := GetExercise(*ex.ExerciseID)
+			if err != nil {
+				return fmt.Errorf("ไม่พบท่าฝึกรหัส %d ในคลัง", *ex.ExerciseID)
+			}
+			if strings.TrimSpace(ex.ExerciseName) == "" {
+				ex.ExerciseName = exerciseDisplayName(item)
+			}
+		}
+		ex.Exercise = nil
+		if strings.TrimSpace(ex.ExerciseName) == "" {
+			return fmt.Errorf("กรุณาระบุชื่อท่าฝึก")
+		}
+		if ex.Position == 0 {
+			ex.Position = i + 1
+		}
+		for j := range ex.Sets {
+			s := &ex.Sets[j]
+			switch {
+			case s.Reps < 0:
+				return fmt.Errorf("ท่า %s: จำนวนครั้งไม่ถูกต้อง", ex.ExerciseName)
+			case s.WeightKg < 0:
+				return fmt.Errorf("ท่า %s: น้ำหนักไม่ถูกต้อง", ex.ExerciseName)
+			case s.RPE < 0 || s.RPE > 10:
+				return fmt.Errorf("ท่า %s: RPE ต้องอยู่ระหว่าง 0-10", ex.ExerciseName)
+			case s.RestSeconds < 0:
+				return fmt.Errorf("ท่า %s: เวลาพักไม่ถูกต้อง", ex.ExerciseName)
+			}
+			s.Model = gorm.Model{}
+			s.WorkoutExerciseID = 0
+			if s.SetNumber == 0 {
+				s.SetNumber = j + 1
+			}
+			s.Estimated1RM = EstimateOneRepMax(s.WeightKg, s.Reps)
+		}
+	}
+	return nil
+}
+
+// validateWorkoutLinks นัดเทรนและเซสชันที่อ้างถึงต้องเป็นของผู้ใช้คนเดียวกัน
+func validateWorkoutLinks(log entity.WorkoutLog) error {
+	if log.PersonalTrainID != nil {
+		var train entity.PersonalTrain
+		if err := config.DB().First(&train, *log.PersonalTrainID).Error; err != nil || train.UserID != log.UserID {
+			return fmt.Errorf("ไม่พบนัดเทรนส่วนตัวของผู้ใช้นี้")
+		}
+		// ถ้านัดเทรนผูกกับโปรแกรมแล้ว เซสชันที่ระบุต้องอยู่ในโปรแกรมนั้นหรือเวอร์ชันอื่นของโปรแกรมเดียวกัน
+		if log.ProgramSessionID != nil && train.ProgramID != nil {
+			program, err := GetProgramOfSession(*log.ProgramSessionID)
+			if err == nil {
+				var linked entity.TrainingProgram
+				if config.DB().First(&linked, *train.ProgramID).Error == nil &&
+					programRootID(linked) != programRootID(program) {
+					return fmt.Errorf("เซสชันไม่ได้อยู่ในโปรแกรมของนัดเทรนนี้")
+				}
+			}
+		}
+	}
+	if log.ProgramSessionID != nil {
+		program, err := GetProgramOfSession(*log.ProgramSessionID)
+		if err != nil || program.UserID == nil || *program.UserID != log.UserID {
+			return fmt.Errorf("ไม่พบเซสชันในโปรแกรมฝึกของผู้ใช้นี้")
+		}
+	}
+	return nil
+}
+
+// markSessionCompleted บันทึกว่าเซสชันในโปรแกรมทำแล้ว หากยังไม่เคยบันทึก
+func markSessionCompleted(tx *gorm.DB, log entity.WorkoutLog) error {
+	if log.ProgramSessionID == nil {
+		return nil
+	}
+	return tx.Model(&entity.ProgramSession{}).
+		Where("id = ? AND completed_at IS NULL", *log.ProgramSessionID).
+		Update("completed_at", log.Date).Error
+}
+
+type recordSetRow struct {
+	WorkoutLogID      uint
+	Date              time.Time
+	ExerciseID        *uint
+	ExerciseName      string
+	WorkoutExerciseID uint
+	SetID             uint
+	Reps              int
+	WeightKg          float64
+	Estimated1RM      float64 `gorm:"column:estimated_1rm"`
+}
+
+// rebuildPersonalRecords คำนวณประวัติสถิติส่วนตัวของท่าที่ระบุใหม่ทั้งหมดจากการฝึกที่ยังไม่ถูกลบ
+// สถิติจะถูกบันทึกเมื่อทำได้มากกว่าทุกครั้งก่อนหน้าตามลำดับวันที่
+func rebuildPersonalRecords(tx *gorm.DB, userID uint, keys []string) error {
+	done := map[string]bool{}
+	for _, key := range keys {
+		if done[key] {
+			continue
+		}
+		done[key] = true
+
+		query := tx.Table("workout_sets").
+			Select(`workout_logs.id AS workout_log_id, workout_logs.date,
+				workout_exercises.exercise_id, workout_exercises.exercise_name,
+				workout_sets.workout_exercise_id, workout_sets.id AS set_id,
+				workout_sets.reps, workout_sets.weight_kg, workout_sets.estimated_1rm`).
+			Joins("JOIN workout_exercises ON workout_exercises.id = workout_sets.workout_exercise_id").
+			Joins("JOIN workout_logs ON workout_logs.id = workout_exercises.workout_log_id").
+			Where("workout_logs.user_id = ?", userID).
+			Where("workout_sets.deleted_at IS NULL AND workout_exercises.deleted_at IS NULL AND workout_logs.deleted_at IS NULL").
+			Where("workout_sets.is_warmup = ? AND workout_sets.reps > 0", false)
+		if id, ok := strings.CutPrefix(key, "exercise:"); ok {
+			query = query.Where("workout_exercises.exercise_id = ?", id)
+		} else {
+			query = query.Where("workout_exercises.exercise_id IS NULL AND LOWER(workout_exercises.exercise_name) = ?",
+				strings.TrimPrefix(key, "name:"))
+		}
+		var rows []recordSetRow
+		if err := query.Scan(&rows).Error; err != nil {
+			return err
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			if !rows[i].Date.Equal(rows[j].Date) {
+				return rows[i].Date.Before(rows[j].Date)
+			}
+			if rows[i].WorkoutExerciseID != rows[j].WorkoutExerciseID {
+				return rows[i].WorkoutExerciseID < rows[j].WorkoutExerciseID
+			}
+			return rows[i].SetID < rows[j].SetID
+		})
+
+		var records []entity.PersonalRecord
+		best := map[string]float64{}
+		last := map[string]int{}
+		check := func(row recordSetRow, kind string, value float64, reps int, weight float64) {
+			if value <= best[kind] {
+				return
+			}
+			best[kind] = value
+			// หลายเซตในการฝึกครั้งเดียวกันทำลายสถิติ เก็บเฉพาะค่าที่ดีที่สุด
+			if i, ok := last[kind]; ok && records[i].WorkoutLogID == row.WorkoutLogID {
+				records = append(records[:i], records[i+1:]...)
+				for k, idx := range last {
+					if idx > i {
+						last[k] = idx - 1
+					}
+				}
+			}
+			last[kind] = len(records)
+			records = append(records, entity.PersonalRecord{
+				UserID:       userID,
+				ExerciseKey:  key,
+				ExerciseID:   row.ExerciseID,
+				ExerciseName: row.ExerciseName,
+				Kind:         kind,
+				Value:        value,
+				Reps:         reps,
+				WeightKg:     weight,
+				WorkoutLogID: row.WorkoutLogID,
+				AchievedAt:   row.Date,
+			})
+		}
+
+		for i := 0; i < len(rows); {
+			// ปริมาณรวมคิดต่อท่าในการฝึกหนึ่งครั้ง
+			j, volume := i, 0.0
+			for ; j < len(rows) && rows[j].WorkoutExerciseID == rows[i].WorkoutExerciseID; j++ {
+				row := rows[j]
+				volume += float64(row.Reps) * row.WeightKg
+				if row.WeightKg > 0 {
+					check(row, "estimated_1rm", row.Estimated1RM, row.Reps, row.WeightKg)
+					check(row, "max_weight", row.WeightKg, row.Reps, row.WeightKg)
+				} else {
+					check(row, "max_reps", float64(row.Reps), row.Reps, 0)
+				}
+			}
+			check(rows[i], "max_volume", volume, 0, 0)
+			i = j
+		}
+
+		if err := tx.Unscoped().Where("user_id = ? AND exercise_key = ?", userID, key).
+			Delete(&entity.PersonalRecord{}).Error; err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			if err := tx.Create(&records).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func workoutExerciseKeys(log entity.WorkoutLog) []string {
+	keys := make([]string, 0, len(log.Exercises))
+	for _, ex := range log.Exercises {
+		keys = append(keys, exerciseKey(ex.ExerciseID, ex.ExerciseName))
+	}
+	return keys
+}
+
+// exerciseKey ใช้รหัสในคลังท่าฝึกถ้ามี มิฉะนั้นใช้ชื่อท่าตัวพิมพ์เล็ก
+func exerciseKey(exerciseID *uint, name string) string {
+	if exerciseID != nil {
+		return fmt.Sprintf("exercise:%d", *exerciseID)
+	}
+	return "name:" + strings.ToLower(strings.TrimSpace(name))
+}
+
+func sameExercise(aID *uint, aName string, bID *uint, bName string) bool {
+	if aID != nil && bID != nil {
+		return *aID == *bID
+	}
+	return strings.EqualFold(strings.TrimSpace(aName), strings.TrimSpace(bName))
+}
+
+func summarizeSets(sets []entity.WorkoutSet) *PerformedSummary {
+	summary := &PerformedSummary{Reps: []int{}}
+	var rpeTotal float64
+	var rpeCount int
+	for _, s := range sets {
+		if s.IsWarmup {
+			continue
+		}
+		summary.Sets++
+		summary.Reps = append(summary.Reps, s.Reps)
+		summary.VolumeKg += float64(s.Reps) * s.WeightKg
+		if s.WeightKg > summary.TopWeightKg {
+			summary.TopWeightKg = s.WeightKg
+		}
+		if s.RPE > 0 {
+			rpeTotal += s.RPE
+			rpeCount++
+		}
+	}
+	if rpeCount > 0 {
+		summary.AverageRPE = math.Round(rpeTotal/float64(rpeCount)*10) / 10
+	}
+	return summary
+}
+
+// weekStart วันจันทร์ของสัปดาห์ตามเวลาไทย
+func weekStart(t time.Time) time.Time {
+	local := t.In(bangkok)
+	offset := (int(local.Weekday()) + 6) % 7
+	return time.Date(local.Year(), local.Month(), local.Day()-offset, 0, 0, 0, 0, bangkok)
+}